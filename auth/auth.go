@@ -0,0 +1,145 @@
+// Package auth authenticates the HTTP requests providers send: bearer
+// tokens, API key headers, OAuth2 client credentials, Azure Entra ID
+// tokens and HMAC request signing (AWS SigV4 and Volcano Engine).
+//
+// An Authenticator sets the credentials of one request. Transport and
+// NewClient apply it to every request an *http.Client sends, so providers
+// plug authentication in below their SDK or hand-written requests:
+//
+//	tokens := &auth.ClientCredentials{TokenURL: tokenURL, ClientID: id, ClientSecret: secret}
+//	httpClient = auth.NewClient(httpClient, auth.Bearer(tokens))
+//
+// Tokens are cached in a TokenCache, shared by default, which refreshes
+// them ahead of expiry and sends a single refresh at a time.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Authenticator sets the credentials of an outgoing request. It may read
+// the body, e.g. to sign it, but must leave it readable.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// CredentialProvider supplies a secret, such as an API key or an access
+// token. ClientCredentials and the cached sources of a TokenCache are
+// credential providers too.
+type CredentialProvider interface {
+	Credential(ctx context.Context) (string, error)
+}
+
+// StaticCredential is a CredentialProvider of a fixed secret
+type StaticCredential string
+
+// Credential returns s
+func (s StaticCredential) Credential(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// Bearer authenticates requests with "Authorization: Bearer <credential>"
+func Bearer(credentials CredentialProvider) Authenticator {
+	return Header("Authorization", "Bearer ", credentials)
+}
+
+// Header authenticates requests by setting a header to the credential,
+// with prefix before it, e.g. Header("x-api-key", "", key)
+func Header(name, prefix string, credentials CredentialProvider) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		credential, err := credentials.Credential(req.Context())
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, prefix+credential)
+		return nil
+	})
+}
+
+// QueryParam authenticates requests by setting a query parameter to the
+// credential, e.g. QueryParam("access_token", tokens)
+func QueryParam(name string, credentials CredentialProvider) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		credential, err := credentials.Credential(req.Context())
+		if err != nil {
+			return err
+		}
+		query := req.URL.Query()
+		query.Set(name, credential)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	})
+}
+
+// Error reports a failure to obtain credentials, e.g. a rejected token
+// request. StatusCode is the token endpoint's status, if it answered.
+type Error struct {
+	Op         string
+	StatusCode int
+	Err        error
+}
+
+// Error returns the failed operation and its cause
+func (e *Error) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("auth: %s: status %d: %v", e.Op, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("auth: %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Transport authenticates every request before sending it with Base
+type Transport struct {
+	Base          http.RoundTripper
+	Authenticator Authenticator
+}
+
+// RoundTrip sends an authenticated copy of req
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authed := req.Clone(req.Context())
+	if err := t.Authenticator.Authenticate(authed); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(authed)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped
+// transport
+func (t *Transport) CloseIdleConnections() {
+	if closer, ok := t.Base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// NewClient returns a copy of client, or of a zero client if nil, whose
+// requests are authenticated by a
+func NewClient(client *http.Client, a Authenticator) *http.Client {
+	authed := &http.Client{}
+	if client != nil {
+		*authed = *client
+	}
+	authed.Transport = &Transport{Base: authed.Transport, Authenticator: a}
+	return authed
+}
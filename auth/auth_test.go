@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoServer records the Authorization header and query of requests
+func echoServer(t *testing.T) (*httptest.Server, *http.Request) {
+	t.Helper()
+	got := &http.Request{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = *r.Clone(context.Background())
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+func TestAuthenticators(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		header, want  string
+		query         string
+	}{
+		{"bearer", Bearer(StaticCredential("sk")), "Authorization", "Bearer sk", ""},
+		{"header", Header("x-api-key", "", StaticCredential("sk")), "X-Api-Key", "sk", ""},
+		{"query", QueryParam("access_token", StaticCredential("tok")), "", "", "a=1&access_token=tok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, got := echoServer(t)
+			client := NewClient(nil, tt.authenticator)
+
+			req, _ := http.NewRequest("GET", server.URL+"/v1?a=1", nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if tt.header != "" && got.Header.Get(tt.header) != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, got.Header.Get(tt.header), tt.want)
+			}
+			if tt.query != "" && got.URL.RawQuery != tt.query {
+				t.Errorf("query = %q, want %q", got.URL.RawQuery, tt.query)
+			}
+			if req.Header.Get("Authorization") != "" || req.URL.RawQuery != "a=1" {
+				t.Error("the caller's request must not be modified")
+			}
+		})
+	}
+}
+
+func TestTransport_Error(t *testing.T) {
+	failing := Bearer(credentialFunc(func(ctx context.Context) (string, error) {
+		return "", &Error{Op: "token request", StatusCode: 401, Err: errors.New("invalid_client")}
+	}))
+	_, err := NewClient(nil, failing).Get("http://127.0.0.1:0/")
+
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.StatusCode != 401 {
+		t.Errorf("Get() error = %v, want the auth error", err)
+	}
+}
+
+// credentialFunc adapts a function to a CredentialProvider
+type credentialFunc func(ctx context.Context) (string, error)
+
+func (f credentialFunc) Credential(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+func TestClientCredentials(t *testing.T) {
+	tests := []struct {
+		name  string
+		style AuthStyle
+		check func(r *http.Request) bool
+	}{
+		{"body", AuthInBody, func(r *http.Request) bool {
+			return r.PostFormValue("client_id") == "id" && r.PostFormValue("client_secret") == "secret"
+		}},
+		{"header", AuthInHeader, func(r *http.Request) bool {
+			id, secret, ok := r.BasicAuth()
+			return ok && id == "id" && secret == "secret" && r.PostFormValue("client_secret") == ""
+		}},
+		{"query", AuthInQuery, func(r *http.Request) bool {
+			q := r.URL.Query()
+			return q.Get("client_id") == "id" && q.Get("client_secret") == "secret" && q.Get("grant_type") == "client_credentials"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if !tt.check(r) || r.FormValue("scope") != "read write" {
+					w.WriteHeader(http.StatusUnauthorized)
+					io.WriteString(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+					return
+				}
+				io.WriteString(w, `{"access_token":"tok-1","token_type":"Bearer","expires_in":3600}`)
+			}))
+			defer server.Close()
+
+			tokens := &ClientCredentials{
+				TokenURL:     server.URL + "/token",
+				ClientID:     "id",
+				ClientSecret: "secret",
+				Scopes:       []string{"read", "write"},
+				AuthStyle:    tt.style,
+				Cache:        NewTokenCache(time.Minute),
+			}
+			for i := 0; i < 3; i++ {
+				token, err := tokens.Token(context.Background())
+				if err != nil {
+					t.Fatalf("Token() error = %v", err)
+				}
+				if token.Value != "tok-1" || time.Until(token.Expiry) < 59*time.Minute {
+					t.Errorf("Token() = %+v", token)
+				}
+			}
+			if calls != 1 {
+				t.Errorf("token endpoint called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestClientCredentials_CacheKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The token names the audience and secret it was issued for
+		fmt.Fprintf(w, `{"access_token":"%s-%s","expires_in":3600}`, r.PostFormValue("audience"), r.PostFormValue("client_secret"))
+	}))
+	defer server.Close()
+
+	cache := NewTokenCache(time.Minute)
+	credentials := func(secret, audience string) *ClientCredentials {
+		return &ClientCredentials{
+			TokenURL:     server.URL,
+			ClientID:     "id",
+			ClientSecret: secret,
+			Params:       url.Values{"audience": {audience}},
+			Cache:        cache,
+		}
+	}
+
+	tests := []struct {
+		tokens *ClientCredentials
+		want   string
+	}{
+		{credentials("s1", "a"), "a-s1"},
+		{credentials("s1", "b"), "b-s1"},
+		{credentials("s2", "a"), "a-s2"},
+		{credentials("s1", "a"), "a-s1"},
+	}
+	for _, tt := range tests {
+		token, err := tt.tokens.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token.Value != tt.want {
+			t.Errorf("Token() = %q, want %q", token.Value, tt.want)
+		}
+	}
+}
+
+func TestClientCredentials_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"oauth error", 400, `{"error":"invalid_client","error_description":"unknown client"}`, "auth: token request: status 400: invalid_client: unknown client"},
+		{"error with 200", 200, `{"error":"invalid_client"}`, "auth: token request: status 200: invalid_client"},
+		{"not json", 502, "bad gateway", "auth: token request: status 502: bad gateway"},
+		{"no token", 200, `{"expires_in":"3600"}`, "auth: token response: status 200: empty access token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			tokens := &ClientCredentials{TokenURL: server.URL, ClientID: "id", Cache: NewTokenCache(0)}
+			_, err := tokens.Credential(context.Background())
+
+			var authErr *Error
+			if !errors.As(err, &authErr) || err.Error() != tt.want {
+				t.Errorf("Credential() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureEntraID(t *testing.T) {
+	tokens := AzureEntraID("contoso", "app", "secret")
+	if tokens.TokenURL != "https://login.microsoftonline.com/contoso/oauth2/v2.0/token" {
+		t.Errorf("TokenURL = %q", tokens.TokenURL)
+	}
+	if len(tokens.Scopes) != 1 || tokens.Scopes[0] != AzureCognitiveServicesScope {
+		t.Errorf("Scopes = %v", tokens.Scopes)
+	}
+}
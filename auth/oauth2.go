@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuthStyle is where ClientCredentials sends the client ID and secret
+type AuthStyle int
+
+const (
+	// AuthInBody sends them as form parameters of the request body
+	AuthInBody AuthStyle = iota
+	// AuthInHeader sends them as HTTP basic authentication
+	AuthInHeader
+	// AuthInQuery sends all parameters in the URL query, as Baidu expects
+	AuthInQuery
+)
+
+// ClientCredentials fetches access tokens with the OAuth2 client
+// credentials grant. It is a CredentialProvider of the current token, so
+// Bearer(tokens) authenticates requests with it.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Params are added to the token request
+	Params url.Values
+
+	AuthStyle AuthStyle
+
+	// HTTPClient sends the token requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+
+	// Cache holds the tokens, DefaultTokenCache if nil
+	Cache *TokenCache
+}
+
+// Credential returns the current access token
+func (c *ClientCredentials) Credential(ctx context.Context) (string, error) {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.Value, nil
+}
+
+// Token returns the current access token from the cache, requesting a new
+// one when needed
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	cache := c.Cache
+	if cache == nil {
+		cache = DefaultTokenCache
+	}
+	return cache.Token(ctx, c.cacheKey(), TokenSourceFunc(c.fetch))
+}
+
+// Invalidate drops the cached token, so that the next call requests a new
+// one
+func (c *ClientCredentials) Invalidate() {
+	cache := c.Cache
+	if cache == nil {
+		cache = DefaultTokenCache
+	}
+	cache.Invalidate(c.cacheKey())
+}
+
+// cacheKey identifies the tokens of these credentials. The secret and
+// extra parameters, such as an audience or resource, are part of it, so
+// that credentials differing only in those never share a token; they are
+// hashed to keep the secret out of the key.
+func (c *ClientCredentials) cacheKey() string {
+	sum := sha256.Sum256([]byte(c.ClientSecret + "\x00" + c.Params.Encode()))
+	return strings.Join([]string{"client_credentials", c.TokenURL, c.ClientID, strings.Join(c.Scopes, " "),
+		hex.EncodeToString(sum[:])}, "\x00")
+}
+
+// fetch requests a new access token
+func (c *ClientCredentials) fetch(ctx context.Context) (*Token, error) {
+	params := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		params.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	if c.AuthStyle != AuthInHeader {
+		params.Set("client_id", c.ClientID)
+		params.Set("client_secret", c.ClientSecret)
+	}
+
+	var req *http.Request
+	var err error
+	if c.AuthStyle == AuthInQuery {
+		tokenURL := c.TokenURL
+		if strings.Contains(tokenURL, "?") {
+			tokenURL += "&" + params.Encode()
+		} else {
+			tokenURL += "?" + params.Encode()
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return nil, &Error{Op: "token request", Err: err}
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.AuthStyle == AuthInHeader {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &Error{Op: "token request", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, &Error{Op: "token request", Err: err}
+	}
+	return parseTokenResponse(resp.StatusCode, body)
+}
+
+// parseTokenResponse reads an OAuth2 token response
+func parseTokenResponse(status int, body []byte) (*Token, error) {
+	var tokenResp struct {
+		AccessToken      string          `json:"access_token"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		if status < 200 || status >= 300 {
+			return nil, &Error{Op: "token request", StatusCode: status, Err: errors.New(strings.TrimSpace(string(body)))}
+		}
+		return nil, &Error{Op: "token response", StatusCode: status, Err: err}
+	}
+
+	if tokenResp.Error != "" {
+		message := tokenResp.Error
+		if tokenResp.ErrorDescription != "" {
+			message += ": " + tokenResp.ErrorDescription
+		}
+		return nil, &Error{Op: "token request", StatusCode: status, Err: errors.New(message)}
+	}
+	if status < 200 || status >= 300 {
+		return nil, &Error{Op: "token request", StatusCode: status, Err: errors.New(http.StatusText(status))}
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, &Error{Op: "token response", StatusCode: status, Err: errors.New("empty access token")}
+	}
+
+	token := &Token{Value: tokenResp.AccessToken}
+	// expires_in is a number, or a string of one for some servers
+	if expiresIn := strings.Trim(string(tokenResp.ExpiresIn), `"`); expiresIn != "" {
+		var seconds int64
+		if _, err := fmt.Sscan(expiresIn, &seconds); err == nil && seconds > 0 {
+			token.Expiry = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+	}
+	return token, nil
+}
+
+// AzureCognitiveServicesScope is the scope of Entra ID tokens for Azure
+// OpenAI
+const AzureCognitiveServicesScope = "https://cognitiveservices.azure.com/.default"
+
+// AzureEntraID returns the client credentials of an Entra ID (Azure AD)
+// application, whose tokens authenticate Azure OpenAI requests in place of
+// an api-key:
+//
+//	httpClient = auth.NewClient(httpClient, auth.Bearer(auth.AzureEntraID(tenant, id, secret)))
+func AzureEntraID(tenantID, clientID, clientSecret string) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     "https://login.microsoftonline.com/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       []string{AzureCognitiveServicesScope},
+	}
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signer signs requests with an HMAC-SHA256 scheme derived from AWS
+// Signature Version 4, which Volcano Engine uses too with other names. Use
+// SigV4 or Volcengine rather than filling it in.
+type Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Service         string
+
+	// Algorithm names the scheme, e.g. "AWS4-HMAC-SHA256"
+	Algorithm string
+	// KeyPrefix is prepended to the secret to derive the signing key
+	KeyPrefix string
+	// Terminator ends the credential scope, e.g. "aws4_request"
+	Terminator string
+	// DateHeader carries the signing time, e.g. "X-Amz-Date"
+	DateHeader string
+	// ContentHashHeader carries the body's hash, if set
+	ContentHashHeader string
+	// SessionTokenHeader carries SessionToken, if set
+	SessionTokenHeader string
+	// DoubleEscapePath escapes the path again in the canonical request, as
+	// AWS services other than S3 expect
+	DoubleEscapePath bool
+
+	// now returns the signing time, time.Now if nil
+	now func() time.Time
+}
+
+// SigV4 returns a signer for AWS Signature Version 4
+func SigV4(accessKeyID, secretAccessKey, sessionToken, region, service string) *Signer {
+	return &Signer{
+		AccessKeyID:        accessKeyID,
+		SecretAccessKey:    secretAccessKey,
+		SessionToken:       sessionToken,
+		Region:             region,
+		Service:            service,
+		Algorithm:          "AWS4-HMAC-SHA256",
+		KeyPrefix:          "AWS4",
+		Terminator:         "aws4_request",
+		DateHeader:         "X-Amz-Date",
+		SessionTokenHeader: "X-Amz-Security-Token",
+		DoubleEscapePath:   true,
+	}
+}
+
+// Volcengine returns a signer for the Volcano Engine (ByteDance) signature
+func Volcengine(accessKeyID, secretAccessKey, region, service string) *Signer {
+	return &Signer{
+		AccessKeyID:       accessKeyID,
+		SecretAccessKey:   secretAccessKey,
+		Region:            region,
+		Service:           service,
+		Algorithm:         "HMAC-SHA256",
+		Terminator:        "request",
+		DateHeader:        "X-Date",
+		ContentHashHeader: "X-Content-Sha256",
+	}
+}
+
+// Authenticate signs req, setting its date, content hash and Authorization
+// headers. The body is read and restored.
+func (s *Signer) Authenticate(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return &Error{Op: "sign", Err: err}
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	timestamp := now().UTC().Format("20060102T150405Z")
+	date := timestamp[:8]
+	payloadHash := hashHex(body)
+
+	req.Header.Set(s.DateHeader, timestamp)
+	if s.ContentHashHeader != "" {
+		req.Header.Set(s.ContentHashHeader, payloadHash)
+	}
+	if s.SessionTokenHeader != "" && s.SessionToken != "" {
+		req.Header.Set(s.SessionTokenHeader, s.SessionToken)
+	}
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, s.Service, s.Terminator}, "/")
+	stringToSign := strings.Join([]string{
+		s.Algorithm,
+		timestamp,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte(s.KeyPrefix+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, s.Service, s.Terminator} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.Algorithm, s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalHeaders returns the names of the signed headers, the host,
+// Content-Type and X- headers, and their canonical form
+func (s *Signer) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-") {
+			trimmed := make([]string, len(v))
+			for i := range v {
+				trimmed[i] = strings.Join(strings.Fields(v[i]), " ")
+			}
+			values[lower] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+// canonicalPath returns the escaped path, escaped again if the signer
+// double-escapes
+func (s *Signer) canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if !s.DoubleEscapePath {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query sorted by key and value, escaped
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape percent-encodes everything but unreserved characters
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// readBody returns the body of req, leaving it readable
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signingTime is the time of the AWS SigV4 test suite
+var signingTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSigV4(t *testing.T) {
+	// Vectors of the AWS Signature Version 4 test suite
+	tests := []struct {
+		name      string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := SigV4("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "", "us-east-1", "service")
+			signer.now = func() time.Time { return signingTime }
+
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if err := signer.Authenticate(req); err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization = %q, want %q", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+		})
+	}
+}
+
+func TestSigner_Body(t *testing.T) {
+	signer := Volcengine("ak", "sk", "cn-north-1", "ml_maas")
+	signer.now = func() time.Time { return signingTime }
+
+	// A body without GetBody is read and restored
+	req, _ := http.NewRequest("POST", "https://maas-api.ml-platform-cn-beijing.volces.com/api/v2/chat", nil)
+	req.Body = io.NopCloser(strings.NewReader(`{"stream":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	if err := signer.Authenticate(req); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if got := req.Header.Get("X-Content-Sha256"); got != hashHex([]byte(`{"stream":false}`)) {
+		t.Errorf("X-Content-Sha256 = %q, want the body's hash", got)
+	}
+	authorization := req.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "HMAC-SHA256 Credential=ak/20150830/cn-north-1/ml_maas/request, "+
+		"SignedHeaders=content-type;host;x-content-sha256;x-date, Signature=") {
+		t.Errorf("Authorization = %q", authorization)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"stream":false}` {
+		t.Errorf("body = %q, want it restored", body)
+	}
+}
+
+func TestSigner_SessionToken(t *testing.T) {
+	signer := SigV4("AKID", "secret", "session", "us-west-2", "bedrock")
+	req, _ := http.NewRequest("GET", "https://bedrock-runtime.us-west-2.amazonaws.com/model/a:b/converse", nil)
+	if err := signer.Authenticate(req); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if req.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Error("expected the session token header")
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %q, want the session token signed", req.Header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Token is an access token and the time it expires, zero if it does not
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// TokenSource fetches a new token on every call. Wrap it with a TokenCache
+// to reuse tokens until they expire.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// DefaultEarlyRefresh is how long before expiry DefaultTokenCache
+// refreshes a token
+const DefaultEarlyRefresh = 5 * time.Minute
+
+// DefaultTokenCache is the cache of ClientCredentials without their own,
+// shared so that clients with the same credentials share their token
+var DefaultTokenCache = NewTokenCache(DefaultEarlyRefresh)
+
+// TokenCache holds tokens by key. A token is refreshed in the background
+// once it is within EarlyRefresh of its expiry, while callers keep using
+// it; callers only wait for an expired or missing token. Concurrent
+// callers share a single refresh. Each fetch drops the keys whose token
+// expired, so keys that are no longer used don't accumulate.
+type TokenCache struct {
+	earlyRefresh time.Duration
+	now          func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*tokenEntry
+}
+
+// tokenEntry is a cached token and the refresh in flight, if any
+type tokenEntry struct {
+	token   *Token
+	refresh *tokenRefresh
+}
+
+// tokenRefresh is a token request shared by its callers
+type tokenRefresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// NewTokenCache creates a token cache refreshing tokens earlyRefresh
+// before they expire
+func NewTokenCache(earlyRefresh time.Duration) *TokenCache {
+	return &TokenCache{
+		earlyRefresh: earlyRefresh,
+		now:          time.Now,
+		entries:      make(map[string]*tokenEntry),
+	}
+}
+
+// Token returns the token cached under key, fetching it from source if it
+// is missing or expired
+func (c *TokenCache) Token(ctx context.Context, key string, source TokenSource) (*Token, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &tokenEntry{}
+		c.entries[key] = entry
+	}
+
+	now := c.now()
+	if entry.valid(now) {
+		token := entry.token
+		if !token.Expiry.IsZero() && !now.Before(token.Expiry.Add(-c.earlyRefresh)) && entry.refresh == nil {
+			c.startRefresh(ctx, key, entry, source)
+		}
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	refresh := entry.refresh
+	if refresh == nil {
+		refresh = c.startRefresh(ctx, key, entry, source)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startRefresh fetches a new token for entry in the background. The fetch
+// outlives the caller's cancellation, since other callers may wait for it.
+// c.mu must be held.
+func (c *TokenCache) startRefresh(ctx context.Context, key string, entry *tokenEntry, source TokenSource) *tokenRefresh {
+	refresh := &tokenRefresh{done: make(chan struct{})}
+	entry.refresh = refresh
+
+	go func() {
+		token, err := source.Token(context.WithoutCancel(ctx))
+		if err == nil && (token == nil || token.Value == "") {
+			err = &Error{Op: "token", Err: errors.New("empty token")}
+		}
+
+		c.mu.Lock()
+		if err == nil {
+			entry.token = token
+		}
+		entry.refresh = nil
+		c.sweep(c.now())
+		c.mu.Unlock()
+
+		refresh.token, refresh.err = token, err
+		close(refresh.done)
+	}()
+	return refresh
+}
+
+// sweep drops the entries without a valid token or a refresh in flight.
+// c.mu must be held.
+func (c *TokenCache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if entry.refresh == nil && !entry.valid(now) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// valid reports whether the entry holds a token that has not expired at
+// now
+func (e *tokenEntry) valid(now time.Time) bool {
+	return e.token != nil && (e.token.Expiry.IsZero() || now.Before(e.token.Expiry))
+}
+
+// Invalidate drops the token cached under key, e.g. after the server
+// rejected it
+func (c *TokenCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[key]; ok {
+		entry.token = nil
+	}
+}
+
+// Source returns a TokenSource, and CredentialProvider, of the tokens of
+// source cached under key
+func (c *TokenCache) Source(key string, source TokenSource) *CachedSource {
+	return &CachedSource{cache: c, key: key, source: source}
+}
+
+// CachedSource is a TokenSource served from a TokenCache
+type CachedSource struct {
+	cache  *TokenCache
+	key    string
+	source TokenSource
+}
+
+// Token returns the cached token
+func (s *CachedSource) Token(ctx context.Context) (*Token, error) {
+	return s.cache.Token(ctx, s.key, s.source)
+}
+
+// Credential returns the value of the cached token
+func (s *CachedSource) Credential(ctx context.Context) (string, error) {
+	token, err := s.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.Value, nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource returns numbered tokens valid for ttl, after an optional
+// delay
+type countingSource struct {
+	calls int32
+	ttl   time.Duration
+	delay time.Duration
+	fail  bool
+}
+
+func (s *countingSource) Token(ctx context.Context) (*Token, error) {
+	n := atomic.AddInt32(&s.calls, 1)
+	time.Sleep(s.delay)
+	if s.fail {
+		return nil, errors.New("unavailable")
+	}
+	return &Token{Value: string(rune('a' + n - 1)), Expiry: time.Now().Add(s.ttl)}, nil
+}
+
+func TestTokenCache_SingleFlight(t *testing.T) {
+	cache := NewTokenCache(time.Minute)
+	source := &countingSource{ttl: time.Hour, delay: 20 * time.Millisecond}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := cache.Token(context.Background(), "key", source)
+			if err != nil || token.Value != "a" {
+				t.Errorf("Token() = %v, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if source.calls != 1 {
+		t.Errorf("source called %d times, want 1", source.calls)
+	}
+}
+
+func TestTokenCache_EarlyRefresh(t *testing.T) {
+	cache := NewTokenCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	source := &countingSource{ttl: 90 * time.Second}
+
+	ctx := context.Background()
+	if token, _ := cache.Token(ctx, "key", source); token.Value != "a" {
+		t.Fatalf("Token() = %q, want a", token.Value)
+	}
+
+	// Within a minute of expiry, the current token is still returned while
+	// a new one is fetched in the background
+	now = now.Add(45 * time.Second)
+	if token, _ := cache.Token(ctx, "key", source); token.Value != "a" {
+		t.Errorf("Token() = %q, want the current token during the refresh", token.Value)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		token, _ := cache.Token(ctx, "key", source)
+		if token.Value == "b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("token was not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTokenCache_Expired(t *testing.T) {
+	cache := NewTokenCache(0)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	source := &countingSource{ttl: time.Minute}
+
+	ctx := context.Background()
+	cache.Token(ctx, "key", source)
+	now = now.Add(2 * time.Minute)
+	if token, _ := cache.Token(ctx, "key", source); token.Value != "b" {
+		t.Errorf("Token() = %q, want a new token after expiry", token.Value)
+	}
+
+	cache.Invalidate("key")
+	if token, _ := cache.Token(ctx, "key", source); token.Value != "c" {
+		t.Errorf("Token() = %q, want a new token after Invalidate", token.Value)
+	}
+}
+
+func TestTokenCache_Errors(t *testing.T) {
+	cache := NewTokenCache(time.Minute)
+	source := &countingSource{ttl: time.Hour, fail: true}
+
+	ctx := context.Background()
+	if _, err := cache.Token(ctx, "key", source); err == nil {
+		t.Fatal("expected the source's error")
+	}
+	source.fail = false
+	if token, err := cache.Token(ctx, "key", source); err != nil || token.Value != "b" {
+		t.Errorf("Token() = %v, %v, want failures not to be cached", token, err)
+	}
+
+	// A canceled caller stops waiting
+	slow := &countingSource{ttl: time.Hour, delay: time.Second}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := cache.Token(ctx, "slow", slow); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Token() error = %v, want the context's error", err)
+	}
+}
+
+func TestTokenCache_DropsExpiredKeys(t *testing.T) {
+	cache := NewTokenCache(0)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	source := &countingSource{ttl: time.Minute}
+
+	ctx := context.Background()
+	for _, key := range []string{"rotated-1", "rotated-2", "rotated-3"} {
+		cache.Token(ctx, key, source)
+	}
+	cache.Token(ctx, "failing", &countingSource{fail: true})
+
+	// The next fetch drops the keys whose token expired or never came
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.Token(ctx, "current", &countingSource{ttl: time.Hour}); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) != 1 || cache.entries["current"] == nil {
+		t.Errorf("cache holds %d keys, want only current", len(cache.entries))
+	}
+}
@@ -1,6 +1,7 @@
 package llmx
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"time"
@@ -110,6 +111,48 @@ func (b *ClientBuilder) HTTPClient(client *http.Client) *ClientBuilder {
 	return b
 }
 
+// Header adds a header to every request sent to the provider
+func (b *ClientBuilder) Header(key, value string) *ClientBuilder {
+	if b.err != nil {
+		return b
+	}
+	if key == "" {
+		b.err = fmt.Errorf("header name cannot be empty")
+		return b
+	}
+	if b.config.Headers == nil {
+		b.config.Headers = make(http.Header)
+	}
+	b.config.Headers.Add(key, value)
+	return b
+}
+
+// Proxy routes provider requests through the proxy at proxyURL
+func (b *ClientBuilder) Proxy(proxyURL string) *ClientBuilder {
+	if b.err != nil {
+		return b
+	}
+	if proxyURL == "" {
+		b.err = fmt.Errorf("proxy cannot be empty")
+		return b
+	}
+	b.config.ProxyURL = proxyURL
+	return b
+}
+
+// TLSConfig sets the TLS settings used to reach the provider
+func (b *ClientBuilder) TLSConfig(config *tls.Config) *ClientBuilder {
+	if b.err != nil {
+		return b
+	}
+	if config == nil {
+		b.err = fmt.Errorf("tls_config cannot be nil")
+		return b
+	}
+	b.config.TLSConfig = config
+	return b
+}
+
 // BaseURL sets the base URL for API requests (for OpenAI-compatible providers)
 func (b *ClientBuilder) BaseURL(url string) *ClientBuilder {
 	if b.err != nil {
@@ -127,6 +127,23 @@ func TestClientBuilder_Configuration(t *testing.T) {
 		}
 	})
 
+	t.Run("network settings", func(t *testing.T) {
+		builder := NewClientBuilder().
+			Provider("openai", "test-key").
+			Header("X-Team", "search").
+			Proxy("http://proxy.internal:3128")
+
+		if builder.config.Headers.Get("X-Team") != "search" {
+			t.Errorf("expected header X-Team, got %v", builder.config.Headers)
+		}
+		if builder.config.ProxyURL != "http://proxy.internal:3128" {
+			t.Errorf("expected proxy, got %q", builder.config.ProxyURL)
+		}
+		if _, err := NewClientBuilder().Provider("openai", "test-key").TLSConfig(nil).Build(); err == nil {
+			t.Error("expected error for nil TLS config")
+		}
+	})
+
 	t.Run("base url", func(t *testing.T) {
 		builder := NewClientBuilder().
 			Provider("compatible", "test-key").
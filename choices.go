@@ -0,0 +1,75 @@
+package llmx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// emulateChoices serves a request for several choices from a provider
+// without native support by sending one single-choice request per choice
+// in parallel. A request seed is offset by the choice index so that the
+// choices differ. The first failure cancels the other requests.
+func (c *Client) emulateChoices(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := *req.N
+	responses := make([]*ChatResponse, n)
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	for i := 0; i < n; i++ {
+		single := *req
+		single.N = nil
+		if req.Seed != nil {
+			seed := *req.Seed + i
+			single.Seed = &seed
+		}
+
+		wg.Add(1)
+		go func(i int, single *ChatRequest) {
+			defer wg.Done()
+			start := time.Now()
+			resp, err := c.sendChat(ctx, single)
+			if err != nil {
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			completeResponse(resp, c.provider.Name(), start)
+			responses[i] = resp
+		}(i, &single)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return mergeChoices(responses), nil
+}
+
+// mergeChoices combines single-choice responses into one response with
+// their choices and summed usage
+func mergeChoices(responses []*ChatResponse) *ChatResponse {
+	merged := *responses[0]
+	merged.Choices = nil
+	merged.Usage = Usage{}
+	merged.Metadata.Latency = 0
+	merged.Metadata.TimeToFirstToken = 0
+
+	for _, resp := range responses {
+		for _, choice := range resp.Choices {
+			choice.Index = len(merged.Choices)
+			merged.Choices = append(merged.Choices, choice)
+		}
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.CompletionTokens += resp.Usage.CompletionTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	return &merged
+}
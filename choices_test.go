@@ -0,0 +1,149 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/llmx-ai/llmx/provider"
+)
+
+// seededProvider answers with the request seed and fails for failSeed
+type seededProvider struct {
+	failSeed int
+}
+
+func init() {
+	provider.Register("seeded", func(opts map[string]interface{}) (provider.Provider, error) {
+		failSeed, _ := opts["fail_seed"].(int)
+		return &seededProvider{failSeed: failSeed}, nil
+	})
+}
+
+func (p *seededProvider) Name() string { return "seeded" }
+
+func (p *seededProvider) Chat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req := reqInterface.(*ChatRequest)
+	if req.N != nil {
+		return nil, fmt.Errorf("unexpected n=%d", *req.N)
+	}
+	if req.Seed != nil && *req.Seed == p.failSeed {
+		return nil, ErrOverloaded
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &ChatResponse{
+		Content: fmt.Sprintf("seed %d", *req.Seed),
+		Usage:   Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+	}, nil
+}
+
+func (p *seededProvider) StreamChat(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (p *seededProvider) SupportedFeatures() provider.Features {
+	return provider.Features{Seed: true}
+}
+
+func (p *seededProvider) SupportedModels() []provider.Model {
+	return nil
+}
+
+func choicesRequest(n, seed int) *ChatRequest {
+	return &ChatRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "hi"}}}},
+		N:        &n,
+		Seed:     &seed,
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	zero, two, tooMany := 0, 2, MaxTopLogprobs+1
+	tests := []struct {
+		name    string
+		req     *ChatRequest
+		wantErr bool
+	}{
+		{"empty", &ChatRequest{}, false},
+		{"n", &ChatRequest{N: &two}, false},
+		{"zero n", &ChatRequest{N: &zero}, true},
+		{"top logprobs", &ChatRequest{Logprobs: true, TopLogprobs: &two}, false},
+		{"top logprobs without logprobs", &ChatRequest{TopLogprobs: &two}, true},
+		{"too many top logprobs", &ChatRequest{Logprobs: true, TopLogprobs: &tooMany}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSampling(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSampling() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_ChoiceEmulation(t *testing.T) {
+	client, err := NewClient(WithProvider("seeded", map[string]interface{}{"api_key": "test", "fail_seed": -1}), WithChoiceEmulation(true))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	resp, err := client.Chat(context.Background(), choicesRequest(3, 7))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(resp.Choices) != 3 {
+		t.Fatalf("expected 3 choices, got %d", len(resp.Choices))
+	}
+	for i, choice := range resp.Choices {
+		if choice.Index != i || choice.Content != fmt.Sprintf("seed %d", 7+i) {
+			t.Errorf("choice %d = %+v", i, choice)
+		}
+		if choice.FinishReason != FinishReasonStop {
+			t.Errorf("choice %d finish reason = %q", i, choice.FinishReason)
+		}
+	}
+	if resp.Content != "seed 7" {
+		t.Errorf("expected top-level content of the first choice, got %q", resp.Content)
+	}
+	if resp.Usage.TotalTokens != 36 || resp.Usage.PromptTokens != 30 {
+		t.Errorf("expected summed usage, got %+v", resp.Usage)
+	}
+	if resp.Metadata.Provider != "seeded" || resp.Metadata.Latency <= 0 {
+		t.Errorf("unexpected metadata %+v", resp.Metadata)
+	}
+}
+
+func TestClient_ChoiceEmulationError(t *testing.T) {
+	client, err := NewClient(WithProvider("seeded", map[string]interface{}{"api_key": "test", "fail_seed": 8}), WithChoiceEmulation(true))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = client.Chat(context.Background(), choicesRequest(3, 7))
+	if !errors.Is(err, ErrOverloaded) {
+		t.Errorf("expected the failing choice's error, got %v", err)
+	}
+}
+
+func TestClient_MultipleChoicesUnsupported(t *testing.T) {
+	client, err := NewClient(WithProvider("seeded", map[string]interface{}{"api_key": "test"}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = client.Chat(context.Background(), choicesRequest(2, 0))
+	if _, ok := err.(*UnsupportedFeatureError); !ok {
+		t.Errorf("expected UnsupportedFeatureError without emulation, got %v", err)
+	}
+
+	req := choicesRequest(1, 0)
+	req.Logprobs = true
+	_, err = client.Chat(context.Background(), req)
+	if _, ok := err.(*UnsupportedFeatureError); !ok {
+		t.Errorf("expected UnsupportedFeatureError for logprobs, got %v", err)
+	}
+}
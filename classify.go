@@ -0,0 +1,204 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/llmx-ai/llmx/auth"
+)
+
+// ErrorInfo describes a failed provider call. Providers fill in what their
+// API reports and let ClassifyError pick the llmx error type, so that every
+// provider maps the same failure to the same error.
+type ErrorInfo struct {
+	Provider string
+
+	// StatusCode is the HTTP status of the response, or the closest one for
+	// non-HTTP APIs; 0 if unknown
+	StatusCode int
+
+	// Code is the provider's error code or type, such as
+	// "insufficient_quota" or "ThrottlingException"
+	Code string
+
+	Message    string
+	RequestID  string
+	RetryAfter time.Duration
+	Body       []byte
+	Cause      error
+}
+
+// Phrases identifying errors whose HTTP status is ambiguous, matched
+// against the lowercased code and message
+var (
+	contextLengthPhrases = []string{
+		"context_length_exceeded",
+		"maximum context length",
+		"context length",
+		"context window",
+		"prompt is too long",
+		"input is too long",
+		"too many tokens",
+		"exceeds the maximum number of tokens",
+		"reduce the length of the messages",
+	}
+	contentFilterPhrases = []string{
+		"content_filter",
+		"content_policy",
+		"content management policy",
+		"responsibleaipolicyviolation",
+		"blocked due to safety",
+		"safety reasons",
+		"prohibited content",
+	}
+	quotaPhrases = []string{
+		"insufficient_quota",
+		"exceeded your current quota",
+		"billing",
+		"credit balance",
+		"servicequotaexceeded",
+	}
+	overloadedPhrases = []string{
+		"overloaded",
+		"over capacity",
+		"serviceunavailable",
+		"modelnotready",
+	}
+)
+
+// ClassifyError returns the llmx error for a failed provider call
+func ClassifyError(info ErrorInfo) error {
+	if errors.Is(info.Cause, context.Canceled) {
+		return info.Cause
+	}
+
+	message := info.Message
+	if message == "" {
+		switch {
+		case info.Cause != nil:
+			message = info.Cause.Error()
+		case info.StatusCode > 0:
+			message = http.StatusText(info.StatusCode)
+		default:
+			message = "request failed"
+		}
+	}
+	if info.Provider != "" && !strings.HasPrefix(message, info.Provider+":") {
+		message = info.Provider + ": " + message
+	}
+	text := strings.ToLower(info.Code + " " + info.Message)
+
+	var err Error
+	switch {
+	case isTimeout(info.Cause) || info.StatusCode == http.StatusRequestTimeout || info.StatusCode == http.StatusGatewayTimeout:
+		err = NewTimeoutError(message, info.Cause)
+	case isAuthRejected(info.Cause):
+		err = NewAuthenticationError(message)
+	case containsAny(text, contextLengthPhrases):
+		err = NewContextLengthExceededError(message)
+	case containsAny(text, contentFilterPhrases):
+		err = NewContentFilteredError(message)
+	case containsAny(text, quotaPhrases):
+		err = NewQuotaExceededError(message)
+	case info.StatusCode == http.StatusUnauthorized || info.StatusCode == http.StatusForbidden:
+		err = NewAuthenticationError(message)
+	case info.StatusCode == http.StatusNotFound:
+		if strings.Contains(text, "model") || strings.Contains(text, "deployment") {
+			err = NewModelNotFoundError(message, "")
+		} else {
+			err = NewNotFoundError(message, "")
+		}
+	case info.StatusCode == http.StatusTooManyRequests:
+		err = NewRateLimitError(message, info.RetryAfter)
+	case info.StatusCode == http.StatusServiceUnavailable || info.StatusCode == 529 ||
+		(info.StatusCode >= 500 && containsAny(text, overloadedPhrases)):
+		err = NewOverloadedError(message, info.RetryAfter)
+	case info.StatusCode == http.StatusBadRequest || info.StatusCode == http.StatusRequestEntityTooLarge ||
+		info.StatusCode == http.StatusUnprocessableEntity:
+		err = NewInvalidRequestError(message, nil)
+	case info.StatusCode == 0:
+		err = NewInternalError(message, nil)
+	default:
+		err = NewProviderError(info.Provider, message, info.StatusCode, nil)
+	}
+
+	if b, ok := err.(interface{ base() *BaseError }); ok {
+		base := b.base()
+		if base.Underlying == nil {
+			base.Underlying = info.Cause
+		}
+		base.RequestID = info.RequestID
+		base.RawBody = info.Body
+	}
+	return err
+}
+
+// ParseRetryAfter reads how long to wait before retrying from the
+// Retry-After header, in seconds or as an HTTP date, or from the
+// retry-after-ms header some providers send
+func ParseRetryAfter(h http.Header) time.Duration {
+	if ms := h.Get("Retry-After-Ms"); ms != "" {
+		if v, err := strconv.ParseFloat(ms, 64); err == nil && v > 0 {
+			return time.Duration(v * float64(time.Millisecond))
+		}
+	}
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		if v <= 0 {
+			return 0
+		}
+		return time.Duration(v * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RequestIDFromHeader returns the request ID providers echo in response
+// headers
+func RequestIDFromHeader(h http.Header) string {
+	for _, name := range []string{"X-Request-Id", "Request-Id", "X-Amzn-Requestid", "X-Amz-Request-Id", "X-Goog-Request-Id", "Apim-Request-Id", "X-Bce-Request-Id", "X-Tt-Logid"} {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isAuthRejected reports whether err is a failure to obtain credentials
+// the auth server rejected, as opposed to one it could not answer
+func isAuthRejected(err error) bool {
+	var authErr *auth.Error
+	return errors.As(err, &authErr) && authErr.StatusCode > 0 && authErr.StatusCode < 500
+}
+
+func containsAny(s string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(s, phrase) {
+			return true
+		}
+	}
+	return false
+}
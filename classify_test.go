@@ -0,0 +1,147 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/auth"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		info      ErrorInfo
+		sentinel  error
+		retryable bool
+	}{
+		{"openai context length", ErrorInfo{StatusCode: 400, Code: "invalid_request_error context_length_exceeded",
+			Message: "This model's maximum context length is 8192 tokens. However, your messages resulted in 9000 tokens."},
+			ErrContextLengthExceeded, false},
+		{"anthropic prompt too long", ErrorInfo{StatusCode: 400, Code: "invalid_request_error",
+			Message: "prompt is too long: 210000 tokens > 200000 maximum"}, ErrContextLengthExceeded, false},
+		{"azure content filter", ErrorInfo{StatusCode: 400, Code: "content_filter ResponsibleAIPolicyViolation",
+			Message: "The response was filtered due to the prompt triggering Azure OpenAI's content management policy."},
+			ErrContentFiltered, false},
+		{"openai quota", ErrorInfo{StatusCode: 429, Code: "insufficient_quota",
+			Message: "You exceeded your current quota, please check your plan and billing details."}, ErrQuotaExceeded, false},
+		{"rate limit", ErrorInfo{StatusCode: 429, Message: "Rate limit reached for requests", RetryAfter: time.Second},
+			ErrRateLimit, true},
+		{"anthropic overloaded", ErrorInfo{StatusCode: 529, Code: "overloaded_error", Message: "Overloaded"}, ErrOverloaded, true},
+		{"unavailable", ErrorInfo{StatusCode: 503, Message: "Service Unavailable"}, ErrOverloaded, true},
+		{"model not found", ErrorInfo{StatusCode: 404, Code: "model_not_found",
+			Message: "The model `gpt-5o` does not exist or you do not have access to it."}, ErrModelNotFound, false},
+		{"not found", ErrorInfo{StatusCode: 404, Message: "No such file"}, ErrNotFound, false},
+		{"unauthorized", ErrorInfo{StatusCode: 401, Message: "Incorrect API key provided"}, ErrAuthentication, false},
+		{"forbidden", ErrorInfo{StatusCode: 403, Message: "Permission denied"}, ErrAuthentication, false},
+		{"bad request", ErrorInfo{StatusCode: 400, Message: "Invalid value for 'temperature'"}, ErrInvalidRequest, false},
+		{"gateway timeout", ErrorInfo{StatusCode: 504, Message: "Gateway Timeout"}, ErrTimeout, true},
+		{"deadline", ErrorInfo{Cause: fmt.Errorf("post: %w", context.DeadlineExceeded)}, ErrTimeout, true},
+		{"server error", ErrorInfo{StatusCode: 500, Message: "The server had an error"}, ErrProvider, true},
+		{"no status", ErrorInfo{Cause: errors.New("connection reset by peer")}, ErrInternal, true},
+		{"token rejected", ErrorInfo{Cause: &url.Error{Op: "Post", URL: "https://api.test", Err: &auth.Error{
+			Op: "token request", StatusCode: 401, Err: errors.New("invalid_client")}}}, ErrAuthentication, false},
+		{"token endpoint down", ErrorInfo{Cause: &auth.Error{Op: "token request", StatusCode: 503,
+			Err: errors.New("Service Unavailable")}}, ErrInternal, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.info.Provider = "test"
+			tt.info.RequestID = "req_123"
+			err := ClassifyError(tt.info)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var llmxErr Error
+			if !errors.As(err, &llmxErr) || llmxErr.Retryable() != tt.retryable {
+				t.Errorf("expected retryable %v, got %v", tt.retryable, err)
+			}
+			if b := err.(interface{ base() *BaseError }).base(); b.RequestID != "req_123" {
+				t.Errorf("expected request ID to be kept, got %q", b.RequestID)
+			}
+		})
+	}
+}
+
+func TestClassifyError_Canceled(t *testing.T) {
+	cause := fmt.Errorf("post: %w", context.Canceled)
+	if err := ClassifyError(ErrorInfo{Cause: cause}); err != cause {
+		t.Errorf("expected cancellation to pass through, got %v", err)
+	}
+}
+
+func TestErrorHierarchy(t *testing.T) {
+	var invalid *InvalidRequestError
+	if err := error(NewContextLengthExceededError("too long")); !errors.As(err, &invalid) || invalid.StatusCode() != 400 {
+		t.Errorf("context length error should be an InvalidRequestError, got %v", err)
+	}
+	if err := error(NewContentFilteredError("blocked")); !errors.As(err, &invalid) {
+		t.Errorf("content filtered error should be an InvalidRequestError, got %v", err)
+	}
+
+	var notFound *NotFoundError
+	err := error(NewModelNotFoundError("no such model", "gpt-5o"))
+	if !errors.As(err, &notFound) || notFound.Resource != "model" {
+		t.Errorf("model not found error should be a NotFoundError, got %v", err)
+	}
+	if errors.Is(err, ErrNotFound) || !errors.Is(err, ErrModelNotFound) {
+		t.Error("sentinels should match the exact kind")
+	}
+
+	wrapped := fmt.Errorf("chat: %w", NewRateLimitError("slow down", time.Second))
+	var rateErr *RateLimitError
+	if !errors.Is(wrapped, ErrRateLimit) || !errors.As(wrapped, &rateErr) || rateErr.RetryAfter != time.Second {
+		t.Errorf("wrapped rate limit error not matched: %v", wrapped)
+	}
+	if errors.Is(NewRateLimitError("a", 0), NewRateLimitError("b", 0)) {
+		t.Error("distinct errors should not match each other")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"seconds", http.Header{"Retry-After": {"20"}}, 20 * time.Second},
+		{"milliseconds", http.Header{"Retry-After-Ms": {"1500"}, "Retry-After": {"2"}}, 1500 * time.Millisecond},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+		{"missing", http.Header{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	date := http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+	if got := ParseRetryAfter(date); got < 58*time.Second || got > time.Minute {
+		t.Errorf("expected about a minute, got %v", got)
+	}
+}
+
+func TestBaseErrorAs(t *testing.T) {
+	classified := ClassifyError(ErrorInfo{StatusCode: 400, Message: "maximum context length is 8192 tokens", RequestID: "req_1"})
+	for _, err := range []error{
+		classified,
+		fmt.Errorf("chat: %w", NewRateLimitError("slow down", 0)),
+		NewModelNotFoundError("no such model", "gpt-5o"),
+	} {
+		var base *BaseError
+		if !errors.As(err, &base) || base.ErrorCode == "" {
+			t.Errorf("expected a *BaseError in %T", err)
+		}
+	}
+	var base *BaseError
+	errors.As(classified, &base)
+	if base.RequestID != "req_1" {
+		t.Errorf("expected request ID req_1, got %q", base.RequestID)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"runtime"
 	"sync"
+	"time"
 
+	"github.com/llmx-ai/llmx/core"
 	"github.com/llmx-ai/llmx/provider"
 )
 
@@ -15,6 +17,7 @@ import (
 type Client struct {
 	config      *Config
 	provider    provider.Provider
+	httpClient  *http.Client // Client the provider sends requests with
 	middlewares []Middleware // Store middlewares with correct type
 	handler     Handler      // Cached middleware chain handler
 	tools       []Tool       // Store tools
@@ -40,16 +43,30 @@ func NewClient(opts ...Option) (*Client, error) {
 		return nil, err
 	}
 
-	// Create provider
-	prov, err := provider.New(config.Provider, config.ProviderOptions)
+	// Build the middleware declared in config
+	mws, err := config.buildMiddleware()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create provider with the configured HTTP client
+	providerOpts, err := config.providerOptions()
+	if err != nil {
+		return nil, err
+	}
+	prov, err := provider.New(config.Provider, providerOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create provider: %w", err)
 	}
 
 	client := &Client{
-		config:   config,
-		provider: prov,
-		tools:    []Tool{},
+		config:     config,
+		provider:   prov,
+		httpClient: provider.HTTPClient(providerOpts),
+		tools:      []Tool{},
+	}
+	if len(mws) > 0 {
+		client.Use(mws...)
 	}
 
 	// Set finalizer to ensure resources are cleaned up
@@ -72,6 +89,10 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 		return nil, err
 	}
 
+	if err := c.checkFeatures(req); err != nil {
+		return nil, err
+	}
+
 	// Apply defaults
 	c.applyDefaults(req)
 
@@ -81,6 +102,31 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 	}
 
 	// Fallback to direct provider call
+	return c.callProvider(ctx, req)
+}
+
+// callProvider sends req to the provider and completes the response
+// metadata
+func (c *Client) callProvider(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	start := time.Now()
+
+	var resp *ChatResponse
+	var err error
+	if req.N != nil && *req.N > 1 && !c.provider.SupportedFeatures().MultipleChoices {
+		resp, err = c.emulateChoices(ctx, req)
+	} else {
+		resp, err = c.sendChat(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	completeResponse(resp, c.provider.Name(), start)
+	return resp, nil
+}
+
+// sendChat sends req to the provider
+func (c *Client) sendChat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	respInterface, err := c.provider.Chat(ctx, req)
 	if err != nil {
 		return nil, err
@@ -91,11 +137,11 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 	if !ok {
 		return nil, fmt.Errorf("llmx: invalid response type %T from provider %s", respInterface, c.provider.Name())
 	}
-
 	return resp, nil
 }
 
-// StreamChat sends a streaming chat request
+// StreamChat sends a streaming chat request. The client's middlewares run
+// around the whole stream and see its accumulated response once it ends.
 func (c *Client) StreamChat(ctx context.Context, req *ChatRequest) (*ChatStream, error) {
 	// Check if client is closed
 	if err := c.checkClosed(); err != nil {
@@ -107,10 +153,29 @@ func (c *Client) StreamChat(ctx context.Context, req *ChatRequest) (*ChatStream,
 		return nil, err
 	}
 
+	if err := c.checkFeatures(req); err != nil {
+		return nil, err
+	}
+
+	if req.N != nil && *req.N > 1 {
+		return nil, NewInvalidRequestError("multiple choices cannot be streamed", map[string]interface{}{"n": *req.N})
+	}
+
 	// Apply defaults
 	c.applyDefaults(req)
 
-	// Call provider
+	// Use middleware chain if available
+	if len(c.middlewares) > 0 {
+		return c.streamThroughMiddleware(ctx, req)
+	}
+
+	// Fallback to direct provider call
+	return c.openStream(ctx, req)
+}
+
+// openStream opens a stream of req with the provider
+func (c *Client) openStream(ctx context.Context, req *ChatRequest) (*ChatStream, error) {
+	start := time.Now()
 	streamInterface, err := c.provider.StreamChat(ctx, req)
 	if err != nil {
 		return nil, err
@@ -122,9 +187,91 @@ func (c *Client) StreamChat(ctx context.Context, req *ChatRequest) (*ChatStream,
 		return nil, fmt.Errorf("llmx: invalid stream type %T from provider %s", streamInterface, c.provider.Name())
 	}
 
+	stream.started(c.provider.Name(), start)
 	return stream, nil
 }
 
+// streamThroughMiddleware runs the middleware chain around a stream. The
+// chain's final handler opens the stream, hands it to the caller and
+// returns once it is closed, with the accumulated response or the stream's
+// error, so rate limits, circuit breakers and scheduler slots cover the
+// whole stream. A response the chain returns without opening a stream,
+// e.g. from a cache, is replayed as a stream.
+func (c *Client) streamThroughMiddleware(ctx context.Context, req *ChatRequest) (*ChatStream, error) {
+	opened := make(chan *ChatStream, 1)
+	var mu sync.Mutex
+	handedOut := false
+
+	handler := c.chain(func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if handedOut {
+			// A middleware such as Retry called again after the stream
+			// was handed out; it cannot be sent twice
+			return nil, &BaseError{Message: "stream already started", ErrorCode: "stream_started"}
+		}
+
+		stream, err := c.openStream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		handedOut = true
+		opened <- stream
+
+		select {
+		case <-stream.done:
+			return stream.result()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	type result struct {
+		resp *ChatResponse
+		err  error
+	}
+	finished := make(chan result, 1)
+	go func() {
+		resp, err := handler(ctx, req)
+		finished <- result{resp, err}
+	}()
+
+	select {
+	case stream := <-opened:
+		return stream, nil
+	case r := <-finished:
+		select {
+		case stream := <-opened:
+			return stream, nil
+		default:
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		return replayStream(ctx, r.resp), nil
+	}
+}
+
+// replayStream returns a stream sending resp as events
+func replayStream(ctx context.Context, resp *ChatResponse) *ChatStream {
+	stream := NewChatStream(ctx)
+	stream.accumulated.Metadata = resp.Metadata
+	go func() {
+		defer stream.Close()
+		if resp.Content != "" {
+			stream.SendEvent(core.StreamEvent{Type: core.EventTypeTextDelta, Data: resp.Content})
+		}
+		for _, call := range resp.ToolCalls {
+			stream.SendEvent(core.StreamEvent{Type: core.EventTypeToolCall, Data: map[string]interface{}{
+				"id": call.ID, "name": call.Name, "args": string(call.Arguments),
+			}})
+		}
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeUsage, Data: resp.Usage})
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeFinish, Data: resp.RawFinishReason})
+	}()
+	return stream
+}
+
 // validateRequest validates a chat request
 func (c *Client) validateRequest(req *ChatRequest) error {
 	if req == nil {
@@ -135,6 +282,48 @@ func (c *Client) validateRequest(req *ChatRequest) error {
 		return err
 	}
 
+	if err := ValidateToolChoice(req); err != nil {
+		return err
+	}
+
+	if err := ValidateSampling(req); err != nil {
+		return err
+	}
+
+	if err := ValidateProviderOptions(req.ProviderOptions); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkFeatures rejects request settings the provider cannot honor
+func (c *Client) checkFeatures(req *ChatRequest) error {
+	features := c.provider.SupportedFeatures()
+
+	if req.ToolChoice != nil && req.ToolChoice.Mode != ToolChoiceAuto && !features.ToolChoice {
+		return NewUnsupportedFeatureError(c.provider.Name(), "tool_choice",
+			fmt.Sprintf("tool choice %q is not supported", req.ToolChoice.Mode))
+	}
+
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls && !features.ParallelToolCalls {
+		return NewUnsupportedFeatureError(c.provider.Name(), "parallel_tool_calls",
+			"disabling parallel tool calls is not supported")
+	}
+
+	if req.N != nil && *req.N > 1 && !features.MultipleChoices && !c.config.EmulateChoices {
+		return NewUnsupportedFeatureError(c.provider.Name(), "n",
+			"multiple choices are not supported; WithChoiceEmulation sends one request per choice")
+	}
+
+	if req.Logprobs && !features.Logprobs {
+		return NewUnsupportedFeatureError(c.provider.Name(), "logprobs", "logprobs are not supported")
+	}
+
+	if req.Seed != nil && !features.Seed {
+		return NewUnsupportedFeatureError(c.provider.Name(), "seed", "seeded sampling is not supported")
+	}
+
 	return nil
 }
 
@@ -191,28 +380,16 @@ func (c *Client) Use(mws ...Middleware) *Client {
 // rebuildHandler rebuilds the middleware chain handler
 func (c *Client) rebuildHandler() {
 	// Base handler that calls the provider
-	baseHandler := func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
-		respInterface, err := c.provider.Chat(ctx, req)
-		if err != nil {
-			return nil, err
-		}
-
-		// Type assertion with detailed error handling
-		resp, ok := respInterface.(*ChatResponse)
-		if !ok {
-			return nil, fmt.Errorf("llmx: invalid response type %T from provider %s", respInterface, c.provider.Name())
-		}
-
-		return resp, nil
-	}
+	c.handler = c.chain(c.callProvider)
+}
 
+// chain wraps handler in the client's middlewares
+func (c *Client) chain(handler Handler) Handler {
 	// Apply all middlewares in reverse order (last added = outermost)
-	handler := baseHandler
 	for i := len(c.middlewares) - 1; i >= 0; i-- {
 		handler = c.middlewares[i](handler)
 	}
-
-	c.handler = handler
+	return handler
 }
 
 // GenerateObject generates a structured object from a prompt
@@ -223,7 +400,7 @@ func (c *Client) GenerateObject(ctx context.Context, prompt string, output inter
 		return err
 	}
 
-	// Create request with JSON mode
+	// Create request; JSON output is requested through the system prompt
 	req := &ChatRequest{
 		Messages: []Message{
 			{
@@ -239,11 +416,6 @@ func (c *Client) GenerateObject(ctx context.Context, prompt string, output inter
 				},
 			},
 		},
-		ProviderOptions: map[string]interface{}{
-			"response_format": map[string]string{
-				"type": "json_object",
-			},
-		},
 	}
 
 	// Apply defaults
@@ -269,9 +441,9 @@ func (c *Client) Close() error {
 
 		c.closed = true
 
-		// Close HTTP client connections if using default transport
-		if c.config.HTTPClient != nil {
-			if transport, ok := c.config.HTTPClient.Transport.(*http.Transport); ok {
+		// Close HTTP client connections unless using the default transport
+		if c.httpClient != nil {
+			if transport, ok := c.httpClient.Transport.(interface{ CloseIdleConnections() }); ok {
 				transport.CloseIdleConnections()
 			}
 		}
@@ -105,6 +105,56 @@ func TestClient_validateRequest(t *testing.T) {
 	}
 }
 
+func TestValidateToolChoice(t *testing.T) {
+	tools := []Tool{{Name: "search"}}
+	tests := []struct {
+		name    string
+		req     *ChatRequest
+		wantErr bool
+	}{
+		{"unset", &ChatRequest{}, false},
+		{"none without tools", &ChatRequest{ToolChoice: &ToolChoice{Mode: ToolChoiceNone}}, false},
+		{"required without tools", &ChatRequest{ToolChoice: &ToolChoice{Mode: ToolChoiceRequired}}, true},
+		{"known function", &ChatRequest{Tools: tools, ToolChoice: ForceTool("search")}, false},
+		{"unknown function", &ChatRequest{Tools: tools, ToolChoice: ForceTool("lookup")}, true},
+		{"unknown mode", &ChatRequest{Tools: tools, ToolChoice: &ToolChoice{Mode: "sometimes"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateToolChoice(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateToolChoice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_UnsupportedToolChoice(t *testing.T) {
+	client, err := NewClient(WithProvider("mock", map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	parallel := false
+	reqs := map[string]*ChatRequest{
+		"tool choice":         {ToolChoice: &ToolChoice{Mode: ToolChoiceRequired}},
+		"parallel tool calls": {ParallelToolCalls: &parallel},
+	}
+	for name, req := range reqs {
+		t.Run(name, func(t *testing.T) {
+			req.Model = "test-model"
+			req.Messages = []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "Hello"}}}}
+			req.Tools = []Tool{{Name: "search"}}
+
+			_, err := client.Chat(context.Background(), req)
+			if _, ok := err.(*UnsupportedFeatureError); !ok {
+				t.Errorf("expected UnsupportedFeatureError, got %v", err)
+			}
+		})
+	}
+}
+
 func TestClient_applyDefaults(t *testing.T) {
 	defaultTemp := 0.7
 	defaultTokens := 1000
@@ -174,6 +224,7 @@ func init() {
 	provider.Register("mock", func(opts map[string]interface{}) (provider.Provider, error) {
 		return &mockProvider{}, nil
 	})
+	provider.RegisterOptions("mock", provider.OptionSpec{AnyOptions: true})
 }
 
 func (m *mockProvider) Name() string {
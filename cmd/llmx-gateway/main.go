@@ -0,0 +1,136 @@
+// Command llmx-gateway serves an llmx client over HTTP in the OpenAI wire
+// format.
+//
+// The upstream provider is chosen with -provider and authenticated with the
+// LLMX_API_KEY environment variable. Virtual keys for gateway callers are
+// read from the JSON file named by -keys:
+//
+//	[
+//	  {"key": "sk-team-a", "name": "team-a", "models": ["gpt-4o*"]},
+//	  {"key": "sk-batch", "name": "batch"}
+//	]
+//
+// Usage:
+//
+//	LLMX_API_KEY=sk-... llmx-gateway -provider openai -model gpt-4o -keys keys.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/gateway"
+	"github.com/llmx-ai/llmx/middleware"
+
+	// Register providers
+	_ "github.com/llmx-ai/llmx/provider/anthropic"
+	_ "github.com/llmx-ai/llmx/provider/azure"
+	_ "github.com/llmx-ai/llmx/provider/bedrock"
+	_ "github.com/llmx-ai/llmx/provider/cohere"
+	_ "github.com/llmx-ai/llmx/provider/deepseek"
+	_ "github.com/llmx-ai/llmx/provider/doubao"
+	_ "github.com/llmx-ai/llmx/provider/google"
+	_ "github.com/llmx-ai/llmx/provider/groq"
+	_ "github.com/llmx-ai/llmx/provider/huggingface"
+	_ "github.com/llmx-ai/llmx/provider/lmstudio"
+	_ "github.com/llmx-ai/llmx/provider/localai"
+	_ "github.com/llmx-ai/llmx/provider/mistral"
+	_ "github.com/llmx-ai/llmx/provider/ollama"
+	_ "github.com/llmx-ai/llmx/provider/openai"
+	_ "github.com/llmx-ai/llmx/provider/tongyi"
+	_ "github.com/llmx-ai/llmx/provider/vllm"
+	_ "github.com/llmx-ai/llmx/provider/wenxin"
+	_ "github.com/llmx-ai/llmx/provider/zhipu"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	providerName := flag.String("provider", "openai", "upstream provider")
+	baseURL := flag.String("base-url", "", "upstream base URL, for compatible providers")
+	model := flag.String("model", "", "model used when a request names none")
+	keysFile := flag.String("keys", "", "JSON file of virtual API keys")
+	anonymous := flag.Bool("anonymous", false, "allow requests without an API key")
+	timeout := flag.Duration("timeout", 2*time.Minute, "per-request timeout")
+	retries := flag.Int("retries", 2, "retries of failed upstream requests")
+	cacheTTL := flag.Duration("cache", 0, "cache identical requests for this long (0 disables)")
+	flag.Parse()
+
+	opts := map[string]interface{}{"api_key": os.Getenv("LLMX_API_KEY")}
+	if *baseURL != "" {
+		opts["base_url"] = *baseURL
+	}
+
+	client, err := llmx.NewClient(
+		llmx.WithProvider(*providerName, opts),
+		llmx.WithDefaultModel(*model),
+	)
+	if err != nil {
+		log.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	client.Use(
+		middleware.Logging(nil),
+		middleware.Timeout(*timeout),
+		middleware.Retry(*retries, middleware.NewExponentialBackoff()),
+	)
+	if *cacheTTL > 0 {
+		client.Use(middleware.CacheMiddleware(nil, *cacheTTL))
+	}
+
+	var gatewayOpts []gateway.Option
+	if *keysFile != "" {
+		keys, err := loadKeys(*keysFile)
+		if err != nil {
+			log.Fatalf("failed to load keys: %v", err)
+		}
+		gatewayOpts = append(gatewayOpts, gateway.WithAPIKeys(keys...))
+	}
+	if *anonymous {
+		gatewayOpts = append(gatewayOpts, gateway.WithAnonymousAccess())
+	}
+	if *keysFile == "" && !*anonymous {
+		log.Fatal("either -keys or -anonymous is required")
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           gateway.New(client, gatewayOpts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("llmx gateway for %s listening on %s", *providerName, *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+func loadKeys(path string) ([]gateway.APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []gateway.APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
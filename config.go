@@ -1,8 +1,15 @@
 package llmx
 
 import (
+	"crypto/tls"
+	"errors"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/llmx-ai/llmx/auth"
+	"github.com/llmx-ai/llmx/provider"
 )
 
 // Config holds the client configuration
@@ -11,20 +18,53 @@ type Config struct {
 	Provider        string
 	ProviderOptions map[string]interface{}
 
+	// Credentials supplies the provider's credential, usually its API
+	// key, in place of the one in ProviderOptions, see CredentialProvider
+	Credentials CredentialProvider
+
+	// Authenticator authenticates every request sent to the provider in
+	// place of the provider's own scheme, e.g. with auth.Bearer over Entra
+	// ID tokens or auth.SigV4 behind a gateway
+	Authenticator auth.Authenticator
+
 	// Default model settings
 	DefaultModel string
 	Temperature  *float64
 	MaxTokens    *int
 	TopP         *float64
 
-	// HTTP client
+	// HTTP client, passed to the provider together with the settings
+	// below unless ProviderOptions already holds an "http_client"
 	HTTPClient *http.Client
 
 	// Timeout
 	Timeout time.Duration
 
+	// Headers are added to every request sent to the provider
+	Headers http.Header
+
+	// ProxyURL routes provider requests through an HTTP(S) or SOCKS5 proxy
+	ProxyURL string
+
+	// TLSConfig replaces the TLS settings of the HTTP client's transport,
+	// e.g. to add a private CA or a client certificate for mTLS
+	TLSConfig *tls.Config
+
+	// EmulateChoices serves requests for several choices (N > 1) from
+	// providers without native support by sending N requests in parallel
+	EmulateChoices bool
+
+	// Middleware declares middleware by name, built from the registry
+	// filled by RegisterMiddleware and applied in order, the first
+	// outermost
+	Middleware []MiddlewareConfig
+
 	// Debug mode
 	Debug bool
+
+	// sources maps the keys of values read by LoadConfig to the key path
+	// or environment variable they came from, for error messages
+	sources map[string]string
 }
 
 // DefaultConfig returns a default configuration
@@ -39,11 +79,13 @@ func DefaultConfig() *Config {
 	}
 }
 
-// Validate validates the configuration
+// Validate validates the configuration. Errors name the key path of the
+// offending value, e.g. "profiles.prod.temperature" for a config loaded
+// with LoadConfig, and carry it as the "key" detail.
 func (c *Config) Validate() error {
 	// Validate provider
 	if c.Provider == "" {
-		return NewInvalidRequestError("provider is required", nil)
+		return c.configError("provider", "is required", nil)
 	}
 
 	// Validate provider options
@@ -51,18 +93,15 @@ func (c *Config) Validate() error {
 		c.ProviderOptions = make(map[string]interface{})
 	}
 
-	// Check for API key (required for most providers)
-	apiKey, _ := c.ProviderOptions["api_key"].(string)
-	if apiKey == "" && c.Provider != "ollama" && c.Provider != "mock" { // ollama and mock don't need API key
-		return NewInvalidRequestError("api_key is required", map[string]interface{}{
-			"provider": c.Provider,
-		})
+	// Let the provider check its options
+	if err := c.validateProviderOptions(); err != nil {
+		return err
 	}
 
 	// Validate temperature
 	if c.Temperature != nil {
 		if *c.Temperature < 0 || *c.Temperature > 2 {
-			return NewInvalidRequestError("temperature must be between 0 and 2", map[string]interface{}{
+			return c.configError("temperature", "must be between 0 and 2", map[string]interface{}{
 				"temperature": *c.Temperature,
 			})
 		}
@@ -71,7 +110,7 @@ func (c *Config) Validate() error {
 	// Validate max_tokens
 	if c.MaxTokens != nil {
 		if *c.MaxTokens <= 0 {
-			return NewInvalidRequestError("max_tokens must be positive", map[string]interface{}{
+			return c.configError("max_tokens", "must be positive", map[string]interface{}{
 				"max_tokens": *c.MaxTokens,
 			})
 		}
@@ -85,7 +124,7 @@ func (c *Config) Validate() error {
 	// Validate top_p
 	if c.TopP != nil {
 		if *c.TopP < 0 || *c.TopP > 1 {
-			return NewInvalidRequestError("top_p must be between 0 and 1", map[string]interface{}{
+			return c.configError("top_p", "must be between 0 and 1", map[string]interface{}{
 				"top_p": *c.TopP,
 			})
 		}
@@ -106,29 +145,104 @@ func (c *Config) Validate() error {
 		c.HTTPClient.Timeout = 60 * time.Second
 	}
 
-	// Provider-specific validation
-	switch c.Provider {
-	case "azure":
-		// Azure requires additional fields
-		if _, ok := c.ProviderOptions["endpoint"].(string); !ok {
-			return NewInvalidRequestError("azure provider requires 'endpoint' in provider options", nil)
+	// Validate proxy
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Host == "" {
+			return c.configError("proxy", "is not a valid URL", map[string]interface{}{
+				"proxy": c.ProxyURL,
+			})
 		}
-		if _, ok := c.ProviderOptions["deployment"].(string); !ok {
-			return NewInvalidRequestError("azure provider requires 'deployment' in provider options", nil)
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return c.configError("proxy", "must use the http, https or socks5 scheme", map[string]interface{}{
+				"proxy": c.ProxyURL,
+			})
 		}
+	}
 
-	case "google":
-		// Google might have specific requirements
-		// Add validation as needed
-
-	case "anthropic":
-		// Anthropic might have specific requirements
-		// Add validation as needed
+	// Validate the declared middleware and their parameters; NewClient
+	// builds them
+	if err := c.validateMiddleware(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// defaultOptionSpec applies to providers without a registered
+// provider.OptionValidator, which are assumed to need an API key
+var defaultOptionSpec = provider.OptionSpec{
+	Required:   []string{"api_key"},
+	AnyOptions: true,
+	Credential: "api_key",
+}
+
+// authenticatorCredential fills the credential option of providers whose
+// requests an Authenticator authenticates, as their SDKs insist on one
+const authenticatorCredential = "llmx-authenticator"
+
+// optionValidator returns the option validator of the configured provider
+func (c *Config) optionValidator() provider.OptionValidator {
+	if validator, ok := provider.Validator(c.Provider); ok {
+		return validator
+	}
+	return defaultOptionSpec
+}
+
+// validateProviderOptions runs the provider's option validator. A
+// configured CredentialProvider or Authenticator stands in for the
+// credential option.
+func (c *Config) validateProviderOptions() error {
+	validator := c.optionValidator()
+	opts := c.ProviderOptions
+	key := validator.Options().Credential
+	if (c.Credentials != nil || c.Authenticator != nil) && key != "" && !provider.HasOption(opts, key) {
+		opts = make(map[string]interface{}, len(c.ProviderOptions)+1)
+		for k, v := range c.ProviderOptions {
+			opts[k] = v
+		}
+		opts[key] = authenticatorCredential
+	}
+
+	err := validator.ValidateOptions(opts)
+	if err == nil {
+		return nil
+	}
+	details := map[string]interface{}{"provider": c.Provider}
+	var optErr *provider.OptionError
+	if errors.As(err, &optErr) {
+		return c.configError("options."+optErr.Key, optErr.Message, details)
+	}
+	return NewInvalidRequestError(err.Error(), details)
+}
+
+// keyPath returns the key path or environment variable the value at key
+// was loaded from. Keys of configs built in code are reported as they are,
+// without the "options." prefix of provider options.
+func (c *Config) keyPath(key string) string {
+	if path, ok := c.sources[key]; ok {
+		return path
+	}
+	return strings.TrimPrefix(key, "options.")
+}
+
+// configError returns an invalid request error for the value at key
+func (c *Config) configError(key, message string, details map[string]interface{}) error {
+	err := keyError(c.keyPath(key), message)
+	for k, v := range details {
+		err.Details[k] = v
+	}
+	return err
+}
+
+// keyError returns an invalid request error for the value at a key path,
+// e.g. "profiles.prod.temperature must be between 0 and 2"
+func keyError(path, message string) *InvalidRequestError {
+	return NewInvalidRequestError(path+" "+message, map[string]interface{}{"key": path})
+}
+
 // Clone creates a copy of the config
 func (c *Config) Clone() *Config {
 	clone := *c
@@ -136,5 +250,7 @@ func (c *Config) Clone() *Config {
 	for k, v := range c.ProviderOptions {
 		clone.ProviderOptions[k] = v
 	}
+	clone.Headers = c.Headers.Clone()
+	clone.Middleware = append([]MiddlewareConfig(nil), c.Middleware...)
 	return &clone
 }
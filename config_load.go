@@ -0,0 +1,486 @@
+package llmx
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/llmx-ai/llmx/internal/configfile"
+)
+
+// LoadOption configures LoadConfig
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	profile   string
+	envPrefix string
+}
+
+// WithProfile selects a profile of the config file, taking precedence
+// over LLMX_PROFILE and the file's default_profile
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = name
+	}
+}
+
+// WithEnvPrefix changes the prefix of the environment variables LoadConfig
+// reads from "LLMX_". An empty prefix ignores the environment.
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(o *loadOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// LoadConfig loads a client configuration from a JSON, YAML or TOML file,
+// chosen by its extension, and LLMX_* environment variables, and validates
+// it. The result is used with NewClient(WithConfig(config)). An empty path
+// reads the environment only.
+//
+// A file holds top-level settings and named profiles that override them:
+//
+//	provider: openai
+//	api_key: ${OPENAI_API_KEY}
+//	model: gpt-4o-mini
+//	temperature: 0.7
+//	timeout: 30s
+//	middleware:
+//	  - name: retry
+//	    max_retries: 3
+//	  - name: circuit_breaker
+//	    max_failures: 5
+//	    timeout: 30s
+//	profiles:
+//	  claude:
+//	    provider: anthropic
+//	    api_key: ${file:/run/secrets/anthropic_key}
+//	    model: claude-sonnet-4-5
+//
+// Settings are provider, api_key, base_url, options (further provider
+// options), model, temperature, max_tokens, top_p, timeout, proxy, headers,
+// emulate_choices, debug and middleware. A profile that changes the
+// provider starts from empty provider options, and a profile's middleware
+// list replaces the top-level one. Middleware are built from the registry
+// filled by RegisterMiddleware; importing the middleware package registers
+// retry, rate_limit, token_rate_limit, cache, circuit_breaker, scheduler,
+// timeout and logging.
+//
+// The profile is chosen by WithProfile, LLMX_PROFILE or default_profile, in
+// that order. String values may reference environment variables as
+// ${NAME} or ${NAME:-default} and files as ${file:path}, resolved relative
+// to the config file, with trailing newlines removed; "$${" is a literal
+// "${". References are only resolved in the sections used.
+//
+// Environment variables override the file: LLMX_PROVIDER, LLMX_API_KEY,
+// LLMX_BASE_URL, LLMX_MODEL, LLMX_TEMPERATURE, LLMX_MAX_TOKENS, LLMX_TOP_P,
+// LLMX_TIMEOUT, LLMX_PROXY, LLMX_EMULATE_CHOICES, LLMX_DEBUG and
+// LLMX_OPTIONS_<NAME> for the provider option <name>.
+//
+// Errors name the key path or environment variable of the offending value,
+// e.g. "profiles.claude.temperature must be between 0 and 2".
+func LoadConfig(path string, opts ...LoadOption) (*Config, error) {
+	o := loadOptions{envPrefix: "LLMX_"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l := &configLoader{config: DefaultConfig()}
+	l.config.sources = make(map[string]string)
+
+	profile := o.profile
+	if profile == "" && o.envPrefix != "" {
+		profile = os.Getenv(o.envPrefix + "PROFILE")
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		root, err := configfile.Parse(path, data)
+		if err != nil {
+			return nil, NewInvalidRequestError(fmt.Sprintf("%s: %v", path, err), map[string]interface{}{"file": path})
+		}
+		l.dir = filepath.Dir(path)
+
+		if err := l.loadFile(root, profile); err != nil {
+			return nil, err
+		}
+	} else if profile != "" {
+		return nil, keyError("profiles."+profile, "is not defined: no config file was given")
+	}
+
+	if o.envPrefix != "" {
+		if err := l.loadEnv(o.envPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.config.Validate(); err != nil {
+		return nil, err
+	}
+	return l.config, nil
+}
+
+// configLoader applies config sections to a Config, recording where each
+// value came from
+type configLoader struct {
+	config *Config
+
+	// dir is the directory of the config file, for ${file:...} references
+	dir string
+}
+
+// loadFile applies the top-level settings of a parsed file and then the
+// selected profile
+func (l *configLoader) loadFile(root map[string]interface{}, profile string) error {
+	base := make(map[string]interface{}, len(root))
+	for k, v := range root {
+		if k != "profiles" && k != "default_profile" {
+			base[k] = v
+		}
+	}
+
+	if profile == "" {
+		if v, ok := root["default_profile"]; ok {
+			name, err := l.string("default_profile", v)
+			if err != nil {
+				return err
+			}
+			profile = name
+		}
+	}
+
+	profiles := map[string]interface{}{}
+	if v, ok := root["profiles"]; ok {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return keyError("profiles", "must be a mapping of profile names to settings")
+		}
+		profiles = m
+	}
+
+	if err := l.apply(base, prefixPaths(""), true); err != nil {
+		return err
+	}
+	if profile == "" {
+		return nil
+	}
+
+	prefix := "profiles." + profile
+	section, ok := profiles[profile]
+	if !ok {
+		return keyError(prefix, "is not defined")
+	}
+	settings, ok := section.(map[string]interface{})
+	if !ok {
+		return keyError(prefix, "must be a mapping of settings")
+	}
+	return l.apply(settings, prefixPaths(prefix+"."), true)
+}
+
+// envSettings maps environment variable suffixes to settings
+var envSettings = []struct {
+	suffix string
+	key    string
+}{
+	{"PROVIDER", "provider"},
+	{"API_KEY", "api_key"},
+	{"BASE_URL", "base_url"},
+	{"MODEL", "model"},
+	{"TEMPERATURE", "temperature"},
+	{"MAX_TOKENS", "max_tokens"},
+	{"TOP_P", "top_p"},
+	{"TIMEOUT", "timeout"},
+	{"PROXY", "proxy"},
+	{"EMULATE_CHOICES", "emulate_choices"},
+	{"DEBUG", "debug"},
+}
+
+// loadEnv applies settings from environment variables
+func (l *configLoader) loadEnv(prefix string) error {
+	section := make(map[string]interface{})
+	vars := make(map[string]string)
+	for _, s := range envSettings {
+		if v, ok := os.LookupEnv(prefix + s.suffix); ok {
+			section[s.key] = v
+			vars[s.key] = prefix + s.suffix
+		}
+	}
+
+	options := make(map[string]interface{})
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if option, ok := strings.CutPrefix(name, prefix+"OPTIONS_"); ok && option != "" {
+			key := strings.ToLower(option)
+			options[key] = value
+			vars["options."+key] = name
+		}
+	}
+	if len(options) > 0 {
+		section["options"] = options
+	}
+
+	return l.apply(section, func(key string) string { return vars[key] }, false)
+}
+
+// prefixPaths returns the key paths of a file section
+func prefixPaths(prefix string) func(string) string {
+	return func(key string) string { return prefix + key }
+}
+
+// apply applies one section of settings. path returns the key path, or
+// environment variable, of a setting; expand resolves ${...} references.
+func (l *configLoader) apply(section map[string]interface{}, path func(string) string, expand bool) error {
+	c := l.config
+
+	// Apply the provider first, as changing it resets the provider options
+	keys := make([]string, 0, len(section))
+	for k := range section {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "provider") != (keys[j] == "provider") {
+			return keys[i] == "provider"
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		p := path(key)
+		value := section[key]
+		if expand {
+			var err error
+			if value, err = l.expand(p, value); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch key {
+		case "provider":
+			var name string
+			if name, err = l.string(p, value); err == nil && name != c.Provider {
+				if c.Provider != "" {
+					c.ProviderOptions = make(map[string]interface{})
+					for k := range c.sources {
+						if strings.HasPrefix(k, "options.") {
+							delete(c.sources, k)
+						}
+					}
+				}
+				c.Provider = name
+			}
+
+		case "api_key", "base_url":
+			var s string
+			if s, err = l.string(p, value); err == nil {
+				c.ProviderOptions[key] = s
+				c.sources["options."+key] = p
+			}
+			continue
+
+		case "options":
+			options, ok := value.(map[string]interface{})
+			if !ok {
+				return keyError(p, "must be a mapping of provider options")
+			}
+			for k, v := range options {
+				c.ProviderOptions[k] = v
+				c.sources["options."+k] = path(key + "." + k)
+			}
+			continue
+
+		case "model":
+			c.DefaultModel, err = l.string(p, value)
+
+		case "temperature", "top_p":
+			f, ok := floatValue(value)
+			if !ok {
+				return keyError(p, "must be a number")
+			}
+			if key == "temperature" {
+				c.Temperature = &f
+			} else {
+				c.TopP = &f
+			}
+
+		case "max_tokens":
+			n, ok := intValue(value)
+			if !ok {
+				return keyError(p, "must be an integer")
+			}
+			c.MaxTokens = &n
+
+		case "timeout":
+			d, ok := durationValue(value)
+			if !ok {
+				return keyError(p, `must be a duration such as "30s"`)
+			}
+			c.Timeout = d
+
+		case "proxy":
+			c.ProxyURL, err = l.string(p, value)
+
+		case "headers":
+			headers, ok := value.(map[string]interface{})
+			if !ok {
+				return keyError(p, "must be a mapping of header names to values")
+			}
+			if c.Headers == nil {
+				c.Headers = make(http.Header)
+			}
+			for name, v := range headers {
+				s, err := l.string(p+"."+name, v)
+				if err != nil {
+					return err
+				}
+				c.Headers.Set(name, s)
+			}
+
+		case "emulate_choices", "debug":
+			b, ok := boolValue(value)
+			if !ok {
+				return keyError(p, "must be a boolean")
+			}
+			if key == "debug" {
+				c.Debug = b
+			} else {
+				c.EmulateChoices = b
+			}
+
+		case "middleware":
+			c.Middleware, err = l.middleware(p, value)
+
+		default:
+			return keyError(p, "is not a known setting")
+		}
+		if err != nil {
+			return err
+		}
+		c.sources[key] = p
+	}
+	return nil
+}
+
+// middleware decodes a middleware list. Entries are mappings with a name
+// and parameters, or bare names.
+func (l *configLoader) middleware(path string, value interface{}) ([]MiddlewareConfig, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, keyError(path, "must be a list of middleware")
+	}
+
+	mws := make([]MiddlewareConfig, 0, len(items))
+	for i, item := range items {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch item := item.(type) {
+		case string:
+			mws = append(mws, MiddlewareConfig{Name: item, Params: MiddlewareParams{}})
+		case map[string]interface{}:
+			name, ok := item["name"].(string)
+			if !ok || name == "" {
+				return nil, keyError(p+".name", "is required")
+			}
+			params := make(MiddlewareParams, len(item))
+			for k, v := range item {
+				if k != "name" {
+					params[k] = v
+				}
+			}
+			mws = append(mws, MiddlewareConfig{Name: name, Params: params})
+		default:
+			return nil, keyError(p, "must be a middleware name or a mapping with a name")
+		}
+	}
+	return mws, nil
+}
+
+func (l *configLoader) string(path string, value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", keyError(path, "must be a string")
+	}
+	return s, nil
+}
+
+// expand resolves ${...} references in the strings of value
+func (l *configLoader) expand(path string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return l.expandString(path, v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			expanded, err := l.expand(path+"."+k, item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = expanded
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded, err := l.expand(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		return out, nil
+	}
+	return value, nil
+}
+
+// expandString resolves ${NAME}, ${NAME:-default} and ${file:path}
+func (l *configLoader) expandString(path, s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", keyError(path, "has an unterminated ${ reference")
+		}
+		ref := s[i+2 : i+end]
+		s = s[i+end+1:]
+
+		if file, ok := strings.CutPrefix(ref, "file:"); ok {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(l.dir, file)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return "", keyError(path, fmt.Sprintf("references a file that cannot be read: %v", err))
+			}
+			b.WriteString(strings.TrimRight(string(data), "\r\n"))
+			continue
+		}
+
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		value, ok := os.LookupEnv(name)
+		switch {
+		case ok && value != "":
+			b.WriteString(value)
+		case hasDefault:
+			b.WriteString(def)
+		case ok:
+		default:
+			return "", keyError(path, fmt.Sprintf("references unset environment variable %s", name))
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file into a temporary directory
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// builds counts the tagged middleware built
+var builds atomic.Int32
+
+func init() {
+	// tagged marks responses so tests can see the middleware ran
+	RegisterMiddleware("tagged", func(params MiddlewareParams) (string, error) {
+		if err := params.Only("tag"); err != nil {
+			return "", err
+		}
+		return params.String("tag", "")
+	}, func(tag string) Middleware {
+		builds.Add(1)
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+				resp, err := next(ctx, req)
+				if err == nil {
+					resp.Content = tag + "(" + resp.Content + ")"
+				}
+				return resp, err
+			}
+		}
+	})
+}
+
+const yamlConfig = `
+provider: openai
+api_key: ${LLMX_TEST_KEY}
+model: gpt-4o-mini
+temperature: 0.7
+timeout: 45s
+headers:
+  X-Team: search
+middleware:
+  - name: tagged
+    tag: outer
+  - {name: tagged, tag: inner}
+profiles:
+  local:
+    provider: mock
+    model: llama3
+    max_tokens: 512
+  claude:
+    provider: anthropic
+    api_key: ${file:anthropic_key}
+    temperature: 3
+`
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("LLMX_TEST_KEY", "sk-test")
+	path := writeConfig(t, "llmx.yaml", yamlConfig)
+
+	config, err := LoadConfig(path, WithEnvPrefix(""))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.Provider != "openai" || config.ProviderOptions["api_key"] != "sk-test" {
+		t.Errorf("provider = %q, options = %v", config.Provider, config.ProviderOptions)
+	}
+	if config.DefaultModel != "gpt-4o-mini" || config.Temperature == nil || *config.Temperature != 0.7 {
+		t.Errorf("model = %q, temperature = %v", config.DefaultModel, config.Temperature)
+	}
+	if config.Timeout != 45*time.Second || config.HTTPClient.Timeout != 45*time.Second {
+		t.Errorf("timeout = %v, HTTP client timeout = %v", config.Timeout, config.HTTPClient.Timeout)
+	}
+	if config.Headers.Get("X-Team") != "search" {
+		t.Errorf("headers = %v", config.Headers)
+	}
+	if len(config.Middleware) != 2 || config.Middleware[1].Params["tag"] != "inner" {
+		t.Errorf("middleware = %+v", config.Middleware)
+	}
+}
+
+func TestLoadConfig_Profile(t *testing.T) {
+	path := writeConfig(t, "llmx.yaml", yamlConfig)
+
+	// The claude profile is not used, so its missing file reference is
+	// never read
+	t.Setenv("LLMX_TEST_KEY", "sk-test")
+	before := builds.Load()
+	config, err := LoadConfig(path, WithProfile("local"), WithEnvPrefix(""))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if builds.Load() != before {
+		t.Error("validating the config built its middleware")
+	}
+	if config.Provider != "mock" || config.DefaultModel != "llama3" || *config.MaxTokens != 512 {
+		t.Errorf("provider = %q, model = %q, max_tokens = %v", config.Provider, config.DefaultModel, *config.MaxTokens)
+	}
+	if _, ok := config.ProviderOptions["api_key"]; ok {
+		t.Error("changing the provider should drop the provider options")
+	}
+	if *config.Temperature != 0.7 {
+		t.Errorf("temperature = %v, want the top-level 0.7", *config.Temperature)
+	}
+
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer client.Close()
+	if built := builds.Load() - before; built != 2 {
+		t.Errorf("built %d middleware, want each of the 2 built once", built)
+	}
+
+	resp, err := client.Chat(context.Background(), &ChatRequest{Messages: []Message{{
+		Role:    RoleUser,
+		Content: []ContentPart{TextPart{Text: "hi"}},
+	}}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Content != "outer(inner(mock response))" {
+		t.Errorf("Content = %q, want the middleware applied in order", resp.Content)
+	}
+}
+
+func TestLoadConfig_ProfileFromEnv(t *testing.T) {
+	path := writeConfig(t, "llmx.yaml", yamlConfig)
+	t.Setenv("LLMX_TEST_KEY", "sk-test")
+	t.Setenv("LLMX_PROFILE", "local")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.Provider != "mock" {
+		t.Errorf("provider = %q, want mock", config.Provider)
+	}
+}
+
+func TestLoadConfig_Env(t *testing.T) {
+	path := writeConfig(t, "llmx.toml", `
+provider = "openai"
+api_key = "sk-file"
+model = "gpt-4o"
+`)
+	t.Setenv("LLMX_API_KEY", "sk-env")
+	t.Setenv("LLMX_MAX_TOKENS", "100")
+	t.Setenv("LLMX_DEBUG", "true")
+	t.Setenv("LLMX_OPTIONS_ORGANIZATION", "org-1")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.ProviderOptions["api_key"] != "sk-env" || config.ProviderOptions["organization"] != "org-1" {
+		t.Errorf("options = %v", config.ProviderOptions)
+	}
+	if *config.MaxTokens != 100 || !config.Debug || config.DefaultModel != "gpt-4o" {
+		t.Errorf("max_tokens = %d, debug = %v, model = %q", *config.MaxTokens, config.Debug, config.DefaultModel)
+	}
+
+	// Without a file, the environment alone configures the client
+	t.Setenv("LLMX_PROVIDER", "mock")
+	config, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig(\"\") error = %v", err)
+	}
+	if config.Provider != "mock" {
+		t.Errorf("provider = %q, want mock", config.Provider)
+	}
+}
+
+func TestLoadConfig_Interpolation(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key.txt"), []byte("sk-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "llmx.json")
+	err := os.WriteFile(path, []byte(`{
+		"provider": "openai",
+		"api_key": "${file:key.txt}",
+		"base_url": "https://${LLMX_TEST_HOST:-api.example.com}/v1",
+		"options": {"organization": "$${literal}"}
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path, WithEnvPrefix(""))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"api_key":      "sk-from-file",
+		"base_url":     "https://api.example.com/v1",
+		"organization": "${literal}",
+	}
+	for k, v := range want {
+		if config.ProviderOptions[k] != v {
+			t.Errorf("options[%s] = %v, want %v", k, config.ProviderOptions[k], v)
+		}
+	}
+}
+
+func TestLoadConfig_ErrorPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		profile string
+		want    string
+	}{
+		{
+			name:    "unreadable file reference",
+			file:    yamlConfig,
+			env:     map[string]string{"LLMX_TEST_KEY": "sk"},
+			profile: "claude",
+			want:    "profiles.claude.api_key",
+		},
+		{
+			name: "unset variable",
+			file: "provider: openai\napi_key: ${LLMX_TEST_UNSET}\n",
+			want: "api_key",
+		},
+		{
+			name: "unknown setting",
+			file: "provider: mock\nprofiles:\n  dev:\n    modle: x\n",
+			env:  map[string]string{"LLMX_PROFILE": "dev"},
+			want: "profiles.dev.modle",
+		},
+		{
+			name:    "missing profile",
+			file:    "provider: mock\n",
+			profile: "prod",
+			want:    "profiles.prod",
+		},
+		{
+			name: "wrong type",
+			file: "provider: mock\ntemperature: hot\n",
+			want: "temperature",
+		},
+		{
+			name: "validation of a file value",
+			file: "provider: mock\nprofiles:\n  dev:\n    top_p: 5\n",
+			env:  map[string]string{"LLMX_PROFILE": "dev"},
+			want: "profiles.dev.top_p",
+		},
+		{
+			name: "validation of an environment value",
+			file: "provider: mock\n",
+			env:  map[string]string{"LLMX_TEMPERATURE": "9"},
+			want: "LLMX_TEMPERATURE",
+		},
+		{
+			name: "missing api key",
+			file: "provider: openai\nprofiles:\n  prod:\n    model: gpt-4o\n",
+			want: "api_key",
+		},
+		{
+			name: "unknown middleware",
+			file: "provider: mock\nmiddleware:\n  - name: nope\n",
+			want: "middleware[0].name",
+		},
+		{
+			name: "invalid middleware parameter",
+			file: "provider: mock\nprofiles:\n  dev:\n    middleware:\n      - tagged\n      - name: tagged\n        tga: x\n",
+			env:  map[string]string{"LLMX_PROFILE": "dev"},
+			want: "profiles.dev.middleware[1].tga",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := writeConfig(t, "llmx.yaml", tt.file)
+
+			var opts []LoadOption
+			if tt.profile != "" {
+				opts = append(opts, WithProfile(tt.profile))
+			}
+			_, err := LoadConfig(path, opts...)
+
+			var invalid *InvalidRequestError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("LoadConfig() error = %v, want an invalid request error", err)
+			}
+			if invalid.Details["key"] != tt.want {
+				t.Errorf("key = %v, want %q (error: %v)", invalid.Details["key"], tt.want, err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateKeyPaths(t *testing.T) {
+	temp := 3.0
+	config := &Config{Provider: "mock", Temperature: &temp}
+
+	err := config.Validate()
+	var invalid *InvalidRequestError
+	if !errors.As(err, &invalid) || invalid.Details["key"] != "temperature" {
+		t.Fatalf("Validate() error = %v, want one for key temperature", err)
+	}
+	if err.Error() != "temperature must be between 0 and 2" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
@@ -1,9 +1,12 @@
 package llmx
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/llmx-ai/llmx/provider"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -165,42 +168,70 @@ func TestConfig_Validate(t *testing.T) {
 		}
 	})
 
-	t.Run("azure requires endpoint and deployment", func(t *testing.T) {
+	t.Run("provider options are checked by the provider", func(t *testing.T) {
 		config := &Config{
-			Provider: "azure",
+			Provider: "options-probe",
 			ProviderOptions: map[string]interface{}{
 				"api_key": "test-key",
 			},
 		}
 
 		err := config.Validate()
-		if err == nil {
-			t.Error("expected error for missing azure endpoint")
+		var invalid *InvalidRequestError
+		if !errors.As(err, &invalid) || invalid.Details["key"] != "endpoint" {
+			t.Errorf("expected error for missing endpoint, got %v", err)
 		}
 
-		config.ProviderOptions["endpoint"] = "https://example.openai.azure.com"
+		config.ProviderOptions["endpoint"] = "https://example.test"
 		err = config.Validate()
-		if err == nil {
-			t.Error("expected error for missing azure deployment")
+		if !errors.As(err, &invalid) || invalid.Details["key"] != "deployment" {
+			t.Errorf("expected error for deployment without region, got %v", err)
 		}
 
-		config.ProviderOptions["deployment"] = "gpt-4"
+		config.ProviderOptions["region"] = "eu"
 		err = config.Validate()
 		if err != nil {
-			t.Errorf("expected no error with complete azure config, got %v", err)
+			t.Errorf("expected no error with complete options, got %v", err)
 		}
 	})
 
-	t.Run("ollama doesn't require api key", func(t *testing.T) {
+	t.Run("providers without required options need no api key", func(t *testing.T) {
 		config := &Config{
-			Provider:        "ollama",
+			Provider:        "mock",
 			ProviderOptions: map[string]interface{}{},
 		}
 
 		err := config.Validate()
 		if err != nil {
-			t.Errorf("expected no error for ollama without api_key, got %v", err)
+			t.Errorf("expected no error for mock without api_key, got %v", err)
+		}
+	})
+
+	t.Run("credentials stand in for the api key", func(t *testing.T) {
+		config := &Config{
+			Provider:        "openai",
+			ProviderOptions: map[string]interface{}{},
+			Credentials:     EnvCredential("LLMX_TEST_KEY"),
 		}
+
+		err := config.Validate()
+		if err != nil {
+			t.Errorf("expected no error with credentials, got %v", err)
+		}
+	})
+}
+
+func init() {
+	// options-probe requires an endpoint, and a region with a deployment
+	provider.RegisterOptions("options-probe", provider.OptionSpec{
+		Required: []string{"api_key", "endpoint"},
+		Optional: []string{"deployment", "region"},
+		Check: func(opts map[string]interface{}) error {
+			if !provider.HasOption(opts, "region") {
+				return &provider.OptionError{Key: "deployment", Message: "needs a region"}
+			}
+			return nil
+		},
 	})
 }
 
@@ -10,6 +10,8 @@ const (
 	EventTypeToolCallDelta  EventType = "tool-call-delta"
 	EventTypeReasoning      EventType = "reasoning"
 	EventTypeReasoningDelta EventType = "reasoning-delta"
+	EventTypeCitation       EventType = "citation"
+	EventTypeUsage          EventType = "usage"
 	EventTypeFinish         EventType = "finish"
 	EventTypeError          EventType = "error"
 )
@@ -0,0 +1,142 @@
+package llmx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx/auth"
+)
+
+// CredentialProvider supplies the secret a provider authenticates with,
+// usually its API key. It is asked for the credential on every request to
+// providers that send it in a header, so a rotated key is picked up without
+// rebuilding the client; other providers receive it once, when created.
+type CredentialProvider = auth.CredentialProvider
+
+// CredentialFunc adapts a function to a CredentialProvider, e.g. one
+// fetching the current key from a secret manager
+type CredentialFunc func(ctx context.Context) (string, error)
+
+// Credential calls f
+func (f CredentialFunc) Credential(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// EnvCredential reads the credential from the environment variable name
+// on every request
+func EnvCredential(name string) CredentialProvider {
+	return CredentialFunc(func(ctx context.Context) (string, error) {
+		value := os.Getenv(name)
+		if value == "" {
+			return "", fmt.Errorf("credential: environment variable %s is not set", name)
+		}
+		return value, nil
+	})
+}
+
+// FileCredential reads the credential from the file at path, re-reading it
+// whenever it is modified. Surrounding whitespace is trimmed.
+func FileCredential(path string) CredentialProvider {
+	return &fileCredential{path: path}
+}
+
+// fileCredential caches the content of a file until its modification time
+// changes
+type fileCredential struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	value   string
+}
+
+// Credential returns the content of the file
+func (f *fileCredential) Credential(ctx context.Context) (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("credential: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.value != "" && info.ModTime().Equal(f.modTime) {
+		return f.value, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("credential: %w", err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("credential: %s is empty", f.path)
+	}
+	f.value, f.modTime = value, info.ModTime()
+	return value, nil
+}
+
+// CommandCredential runs a command and uses its trimmed output as the
+// credential, e.g. CommandCredential(time.Hour, "op", "read",
+// "op://dev/openai/key"). The output is reused for ttl before the command
+// runs again; a ttl of zero runs it for every request.
+func CommandCredential(ttl time.Duration, name string, args ...string) CredentialProvider {
+	return CacheCredential(CredentialFunc(func(ctx context.Context) (string, error) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("credential: %s: %w: %s", name, err, msg)
+			}
+			return "", fmt.Errorf("credential: %s: %w", name, err)
+		}
+		value := strings.TrimSpace(string(out))
+		if value == "" {
+			return "", fmt.Errorf("credential: %s printed nothing", name)
+		}
+		return value, nil
+	}), ttl)
+}
+
+// CacheCredential reuses the credential of p for ttl after fetching it.
+// Failures are not cached, and a ttl of zero disables caching.
+func CacheCredential(p CredentialProvider, ttl time.Duration) CredentialProvider {
+	if ttl <= 0 {
+		return p
+	}
+	return &cachedCredential{provider: p, ttl: ttl}
+}
+
+// cachedCredential holds a credential until it expires
+type cachedCredential struct {
+	provider CredentialProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	value   string
+	expires time.Time
+}
+
+// Credential returns the cached credential, fetching a new one once it
+// expired
+func (c *cachedCredential) Credential(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value != "" && time.Now().Before(c.expires) {
+		return c.value, nil
+	}
+
+	value, err := c.provider.Credential(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.value, c.expires = value, time.Now().Add(c.ttl)
+	return value, nil
+}
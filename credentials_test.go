@@ -0,0 +1,203 @@
+package llmx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/auth"
+	"github.com/llmx-ai/llmx/provider"
+)
+
+func init() {
+	provider.Register("credential-probe", func(opts map[string]interface{}) (provider.Provider, error) {
+		return &probeProvider{opts: opts}, nil
+	})
+	provider.RegisterOptions("credential-probe", provider.OptionSpec{
+		Required:         []string{"api_key"},
+		Credential:       "api_key",
+		CredentialHeader: "Authorization",
+		CredentialPrefix: "Bearer ",
+	})
+}
+
+func TestEnvCredential(t *testing.T) {
+	ctx := context.Background()
+	p := EnvCredential("LLMX_TEST_CREDENTIAL")
+
+	t.Setenv("LLMX_TEST_CREDENTIAL", "")
+	if _, err := p.Credential(ctx); err == nil {
+		t.Error("expected an error for an unset variable")
+	}
+
+	t.Setenv("LLMX_TEST_CREDENTIAL", "sk-env")
+	if got, err := p.Credential(ctx); err != nil || got != "sk-env" {
+		t.Errorf("Credential() = %q, %v", got, err)
+	}
+}
+
+func TestFileCredential(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("sk-one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileCredential(path)
+
+	if got, err := p.Credential(ctx); err != nil || got != "sk-one" {
+		t.Fatalf("Credential() = %q, %v", got, err)
+	}
+
+	// A rotated key is read once the file changes
+	if err := os.WriteFile(path, []byte("sk-two"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.Credential(ctx); err != nil || got != "sk-two" {
+		t.Errorf("Credential() after rotation = %q, %v", got, err)
+	}
+
+	if _, err := FileCredential(filepath.Join(t.TempDir(), "missing")).Credential(ctx); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestCommandCredential(t *testing.T) {
+	ctx := context.Background()
+
+	got, err := CommandCredential(0, "sh", "-c", "echo sk-command").Credential(ctx)
+	if err != nil || got != "sk-command" {
+		t.Errorf("Credential() = %q, %v", got, err)
+	}
+
+	_, err = CommandCredential(0, "sh", "-c", "echo denied >&2; exit 1").Credential(ctx)
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("expected the command's error output, got %v", err)
+	}
+}
+
+func TestCacheCredential(t *testing.T) {
+	var calls int32
+	p := CacheCredential(CredentialFunc(func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return "", errors.New("unavailable")
+		}
+		return "sk-cached", nil
+	}), time.Hour)
+
+	ctx := context.Background()
+	if _, err := p.Credential(ctx); err == nil {
+		t.Fatal("expected the first call to fail")
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := p.Credential(ctx); err != nil || got != "sk-cached" {
+			t.Fatalf("Credential() = %q, %v", got, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2: failures are not cached", calls)
+	}
+}
+
+func TestNewClient_Credentials(t *testing.T) {
+	var key atomic.Value
+	key.Store("sk-one")
+	credentials := CredentialFunc(func(ctx context.Context) (string, error) {
+		return key.Load().(string), nil
+	})
+
+	client, err := NewClient(
+		WithProvider("credential-probe", map[string]interface{}{}),
+		WithCredentials(credentials),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	opts := client.provider.(*probeProvider).opts
+	if opts["api_key"] != "sk-one" {
+		t.Errorf("api_key = %v, want the credential at creation", opts["api_key"])
+	}
+
+	var gotAuth string
+	httpClient := *provider.HTTPClient(opts)
+	httpClient.Transport.(*auth.Transport).Base = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotAuth = req.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	// The provider's own header is replaced by the current credential
+	key.Store("sk-two")
+	req, _ := http.NewRequest("GET", "http://api.provider.test/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer sk-one")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if gotAuth != "Bearer sk-two" {
+		t.Errorf("Authorization = %q, want the rotated key", gotAuth)
+	}
+}
+
+func TestNewClient_CredentialError(t *testing.T) {
+	_, err := NewClient(
+		WithProvider("credential-probe", map[string]interface{}{}),
+		WithCredentials(CredentialFunc(func(ctx context.Context) (string, error) {
+			return "", errors.New("vault sealed")
+		})),
+	)
+	if !errors.Is(err, ErrAuthentication) {
+		t.Errorf("NewClient() error = %v, want an authentication error", err)
+	}
+}
+
+func TestNewClient_Authenticator(t *testing.T) {
+	authenticator := auth.Bearer(auth.StaticCredential("gateway-token"))
+
+	// The authenticator takes precedence over the credential header
+	httpClient := providerHTTPClient(t,
+		WithProvider("credential-probe", map[string]interface{}{}),
+		WithAuthenticator(authenticator),
+		WithCredentials(auth.StaticCredential("sk-unused")),
+	)
+	transport, ok := httpClient.Transport.(*auth.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *auth.Transport", httpClient.Transport)
+	}
+
+	var gotAuth string
+	transport.Base = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		gotAuth = req.Header.Get("Authorization")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	resp, err := httpClient.Get("http://api.provider.test/v1/models")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if gotAuth != "Bearer gateway-token" {
+		t.Errorf("Authorization = %q, want the authenticator's", gotAuth)
+	}
+}
+
+func TestNewClient_AuthenticatorWithoutKey(t *testing.T) {
+	client, err := NewClient(
+		WithProvider("credential-probe", map[string]interface{}{}),
+		WithAuthenticator(auth.Bearer(auth.StaticCredential("gateway-token"))),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v, want the authenticator to stand in for the api key", err)
+	}
+	if client.provider.(*probeProvider).opts["api_key"] == "" {
+		t.Error("expected a placeholder api key for the provider")
+	}
+}
@@ -0,0 +1,281 @@
+package llmx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// TranscriptVersion is the version of the message encoding written by
+// MarshalTranscript. Version 1 tags every content part with its type;
+// version 0 is the untagged encoding produced before parts were tagged.
+const TranscriptVersion = 1
+
+// ContentPartDecoder decodes the JSON object of a content part, including
+// its "type" field
+type ContentPartDecoder func(data []byte) (ContentPart, error)
+
+var contentParts = struct {
+	sync.RWMutex
+	decoders map[ContentType]ContentPartDecoder
+}{decoders: make(map[ContentType]ContentPartDecoder)}
+
+func init() {
+	RegisterContentPart[TextPart](ContentTypeText)
+	RegisterContentPart[ImagePart](ContentTypeImage)
+	RegisterContentPart[ToolCall](ContentTypeToolCall)
+	RegisterContentPart[ToolResultPart](ContentTypeToolResult)
+}
+
+// RegisterContentPart registers T as the Go type of content parts tagged
+// typ, so that messages holding custom parts can be unmarshalled. T is
+// decoded with encoding/json; a later registration for the same type
+// replaces the earlier one.
+func RegisterContentPart[T ContentPart](typ ContentType) {
+	RegisterContentPartDecoder(typ, func(data []byte) (ContentPart, error) {
+		var part T
+		if err := json.Unmarshal(data, &part); err != nil {
+			return nil, err
+		}
+		return part, nil
+	})
+}
+
+// RegisterContentPartDecoder registers a decoder for content parts tagged typ
+func RegisterContentPartDecoder(typ ContentType, decode ContentPartDecoder) {
+	contentParts.Lock()
+	defer contentParts.Unlock()
+	contentParts.decoders[typ] = decode
+}
+
+// MarshalContentPart encodes a content part as a JSON object whose "type"
+// field holds part.Type(), followed by the part's own fields
+func MarshalContentPart(part ContentPart) ([]byte, error) {
+	if part == nil {
+		return nil, fmt.Errorf("llmx: cannot marshal nil content part")
+	}
+	data, err := json.Marshal(part)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' {
+		return nil, fmt.Errorf("llmx: content part %q must encode as a JSON object", part.Type())
+	}
+
+	// Parts that already write their own tag are left alone
+	var tagged struct {
+		Type *ContentType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &tagged); err == nil && tagged.Type != nil {
+		if *tagged.Type != part.Type() {
+			return nil, fmt.Errorf("llmx: content part %q encodes type %q", part.Type(), *tagged.Type)
+		}
+		return data, nil
+	}
+
+	typ, err := json.Marshal(part.Type())
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":`)
+	buf.Write(typ)
+	if rest := bytes.TrimSpace(data[1:]); len(rest) > 0 && rest[0] != '}' {
+		buf.WriteByte(',')
+	}
+	buf.Write(data[1:])
+	return buf.Bytes(), nil
+}
+
+// UnmarshalContentPart decodes a content part written by MarshalContentPart.
+// Untagged parts written before parts carried a type are recognised by
+// their fields, and a JSON string decodes as a TextPart.
+func UnmarshalContentPart(data []byte) (ContentPart, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return TextPart{Text: text}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("llmx: invalid content part: %w", err)
+	}
+
+	var typ ContentType
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &typ); err != nil {
+			return nil, fmt.Errorf("llmx: invalid content part type: %w", err)
+		}
+	} else {
+		typ = legacyContentType(fields)
+	}
+
+	contentParts.RLock()
+	decode, ok := contentParts.decoders[typ]
+	contentParts.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llmx: unknown content part type %q", typ)
+	}
+
+	part, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("llmx: invalid %s content part: %w", typ, err)
+	}
+	return part, nil
+}
+
+// legacyContentType infers the type of an untagged (version 0) part
+func legacyContentType(fields map[string]json.RawMessage) ContentType {
+	has := func(name string) bool {
+		_, ok := fields[name]
+		return ok
+	}
+	switch {
+	case has("tool_call_id"):
+		return ContentTypeToolResult
+	case has("arguments") || (has("id") && has("name")):
+		return ContentTypeToolCall
+	case has("url") || has("base64"):
+		return ContentTypeImage
+	case has("text"):
+		return ContentTypeText
+	}
+	return ""
+}
+
+// message is Message with its content parts left encoded
+type message struct {
+	Role      MessageRole       `json:"role"`
+	Content   []json.RawMessage `json:"content"`
+	ToolCalls []ToolCall        `json:"tool_calls,omitempty"`
+}
+
+// MarshalJSON encodes the message with every content part tagged by type
+func (m Message) MarshalJSON() ([]byte, error) {
+	encoded := message{Role: m.Role, ToolCalls: m.ToolCalls}
+	if m.Content != nil {
+		encoded.Content = make([]json.RawMessage, 0, len(m.Content))
+	}
+	for i, part := range m.Content {
+		data, err := MarshalContentPart(part)
+		if err != nil {
+			return nil, fmt.Errorf("content[%d]: %w", i, err)
+		}
+		encoded.Content = append(encoded.Content, data)
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a message. Content may also be a plain string,
+// which becomes a single TextPart.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		Role      MessageRole     `json:"role"`
+		Content   json.RawMessage `json:"content"`
+		ToolCalls []ToolCall      `json:"tool_calls,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var content []ContentPart
+	switch raw := bytes.TrimSpace(decoded.Content); {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+	case raw[0] == '"':
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return err
+		}
+		content = []ContentPart{TextPart{Text: text}}
+	default:
+		var parts []json.RawMessage
+		if err := json.Unmarshal(raw, &parts); err != nil {
+			return fmt.Errorf("llmx: invalid message content: %w", err)
+		}
+		content = make([]ContentPart, 0, len(parts))
+		for i, data := range parts {
+			part, err := UnmarshalContentPart(data)
+			if err != nil {
+				return fmt.Errorf("content[%d]: %w", i, err)
+			}
+			content = append(content, part)
+		}
+	}
+
+	*m = Message{Role: decoded.Role, Content: content, ToolCalls: decoded.ToolCalls}
+	return nil
+}
+
+// UnmarshalJSON decodes a request, decoding the options of each provider
+// with the type registered with RegisterProviderOptions
+func (r *ChatRequest) UnmarshalJSON(data []byte) error {
+	type plain ChatRequest
+	decoded := struct {
+		*plain
+		ProviderOptions map[string]json.RawMessage `json:"provider_options,omitempty"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	r.ProviderOptions = nil
+	if decoded.ProviderOptions != nil {
+		options, err := unmarshalProviderOptions(decoded.ProviderOptions)
+		if err != nil {
+			return err
+		}
+		r.ProviderOptions = options
+	}
+	return nil
+}
+
+// transcript is the versioned envelope written by MarshalTranscript
+type transcript struct {
+	Version  int       `json:"version"`
+	Messages []Message `json:"messages"`
+}
+
+// MarshalTranscript encodes a conversation for storage, stamped with
+// TranscriptVersion
+func MarshalTranscript(messages []Message) ([]byte, error) {
+	return json.Marshal(transcript{Version: TranscriptVersion, Messages: messages})
+}
+
+// UnmarshalTranscript decodes a conversation written by MarshalTranscript
+// or by any earlier version, including a bare JSON array of messages.
+// Transcripts from a newer version are rejected.
+func UnmarshalTranscript(data []byte) ([]Message, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var messages []Message
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, err
+		}
+		return messages, nil
+	}
+
+	var decoded struct {
+		Version  int             `json:"version"`
+		Messages json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded.Version > TranscriptVersion {
+		return nil, fmt.Errorf("llmx: transcript version %d is newer than supported version %d", decoded.Version, TranscriptVersion)
+	}
+
+	var messages []Message
+	if len(decoded.Messages) > 0 {
+		if err := json.Unmarshal(decoded.Messages, &messages); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,226 @@
+package llmx
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// citationPart is a custom content part used to exercise the registry
+type citationPart struct {
+	Source string `json:"source"`
+}
+
+func (c citationPart) Type() ContentType { return "test_citation" }
+
+func init() {
+	RegisterContentPart[citationPart]("test_citation")
+	RegisterProviderOptions[testProviderOptions]()
+}
+
+func TestMessage_JSONRoundTrip(t *testing.T) {
+	temp := 0.5
+	req := &ChatRequest{
+		Model:       "test-model",
+		Temperature: &temp,
+		Messages: []Message{
+			{Role: RoleSystem, Content: []ContentPart{TextPart{Text: "be brief"}}},
+			{Role: RoleUser, Content: []ContentPart{
+				TextPart{Text: "what is this?"},
+				ImagePart{URL: "https://example.com/cat.png", Detail: "low"},
+				citationPart{Source: "doc-1"},
+			}},
+			{
+				Role:      RoleAssistant,
+				Content:   []ContentPart{ToolCall{ID: "call_1", Name: "search", Arguments: json.RawMessage(`{"q":"cat"}`)}},
+				ToolCalls: []ToolCall{{ID: "call_1", Name: "search", Arguments: json.RawMessage(`{"q":"cat"}`)}},
+			},
+			{Role: RoleTool, Content: []ContentPart{ToolResultPart{ToolCallID: "call_1", Result: "a cat", IsError: true}}},
+		},
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded ChatRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(req, &decoded) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", decoded, *req)
+	}
+
+	again, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("encoding is not stable:\n%s\n%s", data, again)
+	}
+}
+
+func TestChatRequest_JSONRoundTripToolsAndOptions(t *testing.T) {
+	req := &ChatRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "weather?"}}}},
+		Tools: []Tool{{
+			Name:        "weather",
+			Description: "Gets the weather",
+			Parameters: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+			Execute: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+				return &ToolResult{}, nil
+			},
+		}},
+		ToolChoice: ForceTool("weather"),
+	}
+	req.SetProviderOptions(testProviderOptions{Flag: "on"})
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "Execute") {
+		t.Errorf("tool executor was encoded: %s", data)
+	}
+
+	var decoded ChatRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := ValidateProviderOptions(decoded.ProviderOptions); err != nil {
+		t.Fatalf("ValidateProviderOptions() error = %v", err)
+	}
+	if err := ValidateToolChoice(&decoded); err != nil {
+		t.Fatalf("ValidateToolChoice() error = %v", err)
+	}
+	if opts, ok := ProviderOptionsFor[testProviderOptions](&decoded); !ok || opts.Flag != "on" {
+		t.Errorf("ProviderOptionsFor() = %+v, %v, want flag on", opts, ok)
+	}
+
+	want := *req
+	want.Tools = []Tool{req.Tools[0]}
+	want.Tools[0].Execute = nil
+	if !reflect.DeepEqual(&want, &decoded) {
+		t.Errorf("round trip mismatch:\n got %#v\nwant %#v", decoded, want)
+	}
+
+	bad := strings.Replace(string(data), `"Flag":"on"`, `"Flag":"bad"`, 1)
+	if err := json.Unmarshal([]byte(bad), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := ValidateProviderOptions(decoded.ProviderOptions); err == nil {
+		t.Error("expected the decoded options to be validated")
+	}
+
+	unknown := `{"model":"m","messages":[],"provider_options":{"nope":{}}}`
+	if err := json.Unmarshal([]byte(unknown), &decoded); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("Unmarshal() error = %v, want unknown provider options", err)
+	}
+}
+
+func TestMarshalContentPart(t *testing.T) {
+	tests := []struct {
+		part ContentPart
+		want string
+	}{
+		{TextPart{Text: "hi"}, `{"type":"text","text":"hi"}`},
+		{ImagePart{Base64: "aGk="}, `{"type":"image","base64":"aGk="}`},
+		{ToolCall{ID: "c", Name: "f", Arguments: json.RawMessage(`{}`)}, `{"type":"tool_call","id":"c","name":"f","arguments":{}}`},
+		{ToolResultPart{ToolCallID: "c", Result: "ok"}, `{"type":"tool_result","tool_call_id":"c","result":"ok"}`},
+		{citationPart{}, `{"type":"test_citation","source":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.part.Type()), func(t *testing.T) {
+			got, err := MarshalContentPart(tt.part)
+			if err != nil {
+				t.Fatalf("MarshalContentPart() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalContentPart() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalContentPart(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    ContentPart
+		wantErr string
+	}{
+		{"string", `"hi"`, TextPart{Text: "hi"}, ""},
+		{"legacy text", `{"text":"hi"}`, TextPart{Text: "hi"}, ""},
+		{"legacy image", `{"url":"https://example.com/a.png"}`, ImagePart{URL: "https://example.com/a.png"}, ""},
+		{"legacy tool call", `{"id":"c","name":"f","arguments":{}}`, ToolCall{ID: "c", Name: "f", Arguments: json.RawMessage(`{}`)}, ""},
+		{"legacy tool result", `{"tool_call_id":"c","result":"ok"}`, ToolResultPart{ToolCallID: "c", Result: "ok"}, ""},
+		{"unknown type", `{"type":"hologram"}`, nil, `unknown content part type "hologram"`},
+		{"unrecognised legacy part", `{"foo":1}`, nil, `unknown content part type ""`},
+		{"not an object", `42`, nil, "invalid content part"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalContentPart([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UnmarshalContentPart() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalContentPart() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalContentPart() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_UnmarshalStringContent(t *testing.T) {
+	var m Message
+	if err := json.Unmarshal([]byte(`{"role":"user","content":"hello"}`), &m); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(m.Content) != 1 || m.Content[0] != (TextPart{Text: "hello"}) {
+		t.Errorf("expected a single text part, got %#v", m.Content)
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	messages := []Message{
+		{Role: RoleUser, Content: []ContentPart{TextPart{Text: "hi"}}},
+		{Role: RoleAssistant, Content: []ContentPart{TextPart{Text: "hello"}}},
+	}
+	data, err := MarshalTranscript(messages)
+	if err != nil {
+		t.Fatalf("MarshalTranscript() error = %v", err)
+	}
+	want := `{"version":1,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]},{"role":"assistant","content":[{"type":"text","text":"hello"}]}]}`
+	if string(data) != want {
+		t.Errorf("MarshalTranscript() = %s, want %s", data, want)
+	}
+
+	decoded, err := UnmarshalTranscript(data)
+	if err != nil || !reflect.DeepEqual(decoded, messages) {
+		t.Errorf("UnmarshalTranscript() = %#v, %v", decoded, err)
+	}
+
+	// A bare array of untagged messages is the version 0 encoding
+	legacy := `[{"role":"user","content":[{"text":"hi"}]},{"role":"assistant","content":[{"text":"hello"}]}]`
+	decoded, err = UnmarshalTranscript([]byte(legacy))
+	if err != nil || !reflect.DeepEqual(decoded, messages) {
+		t.Errorf("UnmarshalTranscript(legacy) = %#v, %v", decoded, err)
+	}
+
+	if _, err := UnmarshalTranscript([]byte(`{"version":2,"messages":[]}`)); err == nil {
+		t.Error("expected a newer transcript version to be rejected")
+	}
+}
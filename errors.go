@@ -20,8 +20,34 @@ type BaseError struct {
 	ErrorCode  string
 	IsRetry    bool
 	Underlying error
+
+	// RequestID is the provider's ID for the failed request, if known
+	RequestID string
+	// RawBody is the provider's error response body, if any
+	RawBody []byte
 }
 
+// Sentinel errors matching every error of a kind with errors.Is, e.g.
+// errors.Is(err, llmx.ErrRateLimit). Use errors.As with the error types to
+// read their fields.
+var (
+	ErrInvalidRequest        = &BaseError{Message: "invalid request", ErrorCode: "invalid_request"}
+	ErrRateLimit             = &BaseError{Message: "rate limit exceeded", ErrorCode: "rate_limit"}
+	ErrAuthentication        = &BaseError{Message: "authentication failed", ErrorCode: "authentication"}
+	ErrNotFound              = &BaseError{Message: "not found", ErrorCode: "not_found"}
+	ErrInternal              = &BaseError{Message: "internal error", ErrorCode: "internal"}
+	ErrProvider              = &BaseError{Message: "provider error", ErrorCode: "provider_error"}
+	ErrUnsupportedFeature    = &BaseError{Message: "unsupported feature", ErrorCode: "unsupported_feature"}
+	ErrContextLengthExceeded = &BaseError{Message: "context length exceeded", ErrorCode: "context_length_exceeded"}
+	ErrContentFiltered       = &BaseError{Message: "content filtered", ErrorCode: "content_filtered"}
+	ErrQuotaExceeded         = &BaseError{Message: "quota exceeded", ErrorCode: "quota_exceeded"}
+	ErrOverloaded            = &BaseError{Message: "provider overloaded", ErrorCode: "overloaded"}
+	ErrModelNotFound         = &BaseError{Message: "model not found", ErrorCode: "model_not_found"}
+	ErrTimeout               = &BaseError{Message: "request timed out", ErrorCode: "timeout"}
+	ErrCircuitOpen           = &BaseError{Message: "circuit breaker open", ErrorCode: "circuit_open"}
+	ErrQueueFull             = &BaseError{Message: "queue full", ErrorCode: "queue_full"}
+)
+
 func (e *BaseError) Error() string {
 	if e.Underlying != nil {
 		return fmt.Sprintf("%s: %v", e.Message, e.Underlying)
@@ -45,6 +71,27 @@ func (e *BaseError) Unwrap() error {
 	return e.Underlying
 }
 
+// Is reports whether target is the sentinel error of e's kind
+func (e *BaseError) Is(target error) bool {
+	t, ok := target.(*BaseError)
+	return ok && t.ErrorCode != "" && t.ErrorCode == e.ErrorCode
+}
+
+// As lets errors.As find the *BaseError of any llmx error type, which
+// carries the request ID and raw body
+func (e *BaseError) As(target interface{}) bool {
+	if t, ok := target.(**BaseError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
+
+// base returns the BaseError of any llmx error type
+func (e *BaseError) base() *BaseError {
+	return e
+}
+
 // InvalidRequestError represents an invalid request
 type InvalidRequestError struct {
 	*BaseError
@@ -156,3 +203,192 @@ func NewProviderError(provider string, message string, statusCode int, cause err
 		Provider: provider,
 	}
 }
+
+// UnsupportedFeatureError reports a request setting the provider or model
+// cannot honor
+type UnsupportedFeatureError struct {
+	*BaseError
+	Provider string
+	Feature  string
+}
+
+// NewUnsupportedFeatureError creates a new unsupported feature error
+func NewUnsupportedFeatureError(provider string, feature string, message string) *UnsupportedFeatureError {
+	return &UnsupportedFeatureError{
+		BaseError: &BaseError{
+			Message:   fmt.Sprintf("%s: %s", provider, message),
+			StatusCd:  400,
+			ErrorCode: "unsupported_feature",
+			IsRetry:   false,
+		},
+		Provider: provider,
+		Feature:  feature,
+	}
+}
+
+// ContextLengthExceededError reports a request too long for the model's
+// context window. It is also an *InvalidRequestError for errors.As.
+type ContextLengthExceededError struct {
+	*InvalidRequestError
+}
+
+// NewContextLengthExceededError creates a new context length exceeded error
+func NewContextLengthExceededError(message string) *ContextLengthExceededError {
+	err := NewInvalidRequestError(message, nil)
+	err.ErrorCode = "context_length_exceeded"
+	return &ContextLengthExceededError{InvalidRequestError: err}
+}
+
+// As lets errors.As find the embedded *InvalidRequestError
+func (e *ContextLengthExceededError) As(target interface{}) bool {
+	if t, ok := target.(**InvalidRequestError); ok {
+		*t = e.InvalidRequestError
+		return true
+	}
+	return e.BaseError.As(target)
+}
+
+// ContentFilteredError reports a prompt or completion blocked by the
+// provider's safety filters. It is also an *InvalidRequestError for
+// errors.As.
+type ContentFilteredError struct {
+	*InvalidRequestError
+}
+
+// NewContentFilteredError creates a new content filtered error
+func NewContentFilteredError(message string) *ContentFilteredError {
+	err := NewInvalidRequestError(message, nil)
+	err.ErrorCode = "content_filtered"
+	return &ContentFilteredError{InvalidRequestError: err}
+}
+
+// As lets errors.As find the embedded *InvalidRequestError
+func (e *ContentFilteredError) As(target interface{}) bool {
+	if t, ok := target.(**InvalidRequestError); ok {
+		*t = e.InvalidRequestError
+		return true
+	}
+	return e.BaseError.As(target)
+}
+
+// QuotaExceededError reports an exhausted account quota or billing limit.
+// Unlike a rate limit it does not clear by waiting, so it is not retryable.
+type QuotaExceededError struct {
+	*BaseError
+}
+
+// NewQuotaExceededError creates a new quota exceeded error
+func NewQuotaExceededError(message string) *QuotaExceededError {
+	return &QuotaExceededError{
+		BaseError: &BaseError{
+			Message:   message,
+			StatusCd:  429,
+			ErrorCode: "quota_exceeded",
+			IsRetry:   false,
+		},
+	}
+}
+
+// OverloadedError reports a provider temporarily out of capacity
+type OverloadedError struct {
+	*BaseError
+	RetryAfter time.Duration
+}
+
+// NewOverloadedError creates a new overloaded error
+func NewOverloadedError(message string, retryAfter time.Duration) *OverloadedError {
+	return &OverloadedError{
+		BaseError: &BaseError{
+			Message:   message,
+			StatusCd:  503,
+			ErrorCode: "overloaded",
+			IsRetry:   true,
+		},
+		RetryAfter: retryAfter,
+	}
+}
+
+// ModelNotFoundError reports an unknown model or deployment. It is also a
+// *NotFoundError for errors.As.
+type ModelNotFoundError struct {
+	*NotFoundError
+	Model string
+}
+
+// NewModelNotFoundError creates a new model not found error
+func NewModelNotFoundError(message string, model string) *ModelNotFoundError {
+	err := NewNotFoundError(message, "model")
+	err.ErrorCode = "model_not_found"
+	return &ModelNotFoundError{NotFoundError: err, Model: model}
+}
+
+// As lets errors.As find the embedded *NotFoundError
+func (e *ModelNotFoundError) As(target interface{}) bool {
+	if t, ok := target.(**NotFoundError); ok {
+		*t = e.NotFoundError
+		return true
+	}
+	return e.BaseError.As(target)
+}
+
+// TimeoutError reports a request that did not complete in time, either
+// locally or at the provider
+type TimeoutError struct {
+	*BaseError
+}
+
+// NewTimeoutError creates a new timeout error
+func NewTimeoutError(message string, cause error) *TimeoutError {
+	return &TimeoutError{
+		BaseError: &BaseError{
+			Message:    message,
+			StatusCd:   504,
+			ErrorCode:  "timeout",
+			IsRetry:    true,
+			Underlying: cause,
+		},
+	}
+}
+
+// CircuitOpenError reports a request refused by an open circuit breaker
+// without reaching the provider. It is not retryable: the breaker is open
+// so that requests fail fast until RetryAfter, when it lets a few through
+// again.
+type CircuitOpenError struct {
+	*BaseError
+	RetryAfter time.Duration
+}
+
+// NewCircuitOpenError creates a new circuit open error
+func NewCircuitOpenError(message string, retryAfter time.Duration) *CircuitOpenError {
+	return &CircuitOpenError{
+		BaseError: &BaseError{
+			Message:   message,
+			StatusCd:  503,
+			ErrorCode: "circuit_open",
+			IsRetry:   false,
+		},
+		RetryAfter: retryAfter,
+	}
+}
+
+// QueueFullError reports a request refused by a scheduler whose queue, or
+// the share of it a tenant may take, is full. It is retryable: the queue
+// drains as requests complete.
+type QueueFullError struct {
+	*BaseError
+	Tenant string
+}
+
+// NewQueueFullError creates a new queue full error
+func NewQueueFullError(message, tenant string) *QueueFullError {
+	return &QueueFullError{
+		BaseError: &BaseError{
+			Message:   message,
+			StatusCd:  503,
+			ErrorCode: "queue_full",
+			IsRetry:   true,
+		},
+		Tenant: tenant,
+	}
+}
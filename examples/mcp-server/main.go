@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/llmx-ai/llmx/tools"
+	"github.com/llmx-ai/llmx/tools/builtin"
+	"github.com/llmx-ai/llmx/tools/mcp"
+)
+
+// Serves the builtin tools to MCP clients, on stdio by default or over
+// streamable HTTP with -http :8080
+func main() {
+	addr := flag.String("http", "", "serve streamable HTTP on this address instead of stdio")
+	flag.Parse()
+
+	registry := tools.NewRegistry()
+	if err := registry.Register(builtin.CalculatorTool()); err != nil {
+		log.Fatal(err)
+	}
+	if err := registry.Register(builtin.DateTimeTool()); err != nil {
+		log.Fatal(err)
+	}
+
+	server := mcp.NewServer(registry, mcp.WithServerInfo("llmx-builtin", "1.0.0"))
+
+	if *addr != "" {
+		http.Handle("/mcp", server)
+		log.Printf("MCP server listening on %s/mcp", *addr)
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}
+
+	// Logs go to stderr; stdout carries the protocol
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := server.ServeStdio(ctx); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
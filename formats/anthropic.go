@@ -0,0 +1,495 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// AnthropicRequest is the body of a Messages API request. System is a
+// string or an array of text blocks.
+type AnthropicRequest struct {
+	Model         string               `json:"model"`
+	MaxTokens     *int                 `json:"max_tokens,omitempty"`
+	System        json.RawMessage      `json:"system,omitempty"`
+	Messages      []AnthropicMessage   `json:"messages"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	TopK          *int                 `json:"top_k,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Tools         []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *AnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// AnthropicMessage is a request message. Content is a string or an array
+// of AnthropicContentBlock.
+type AnthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// AnthropicContentBlock is a content block of a request message
+type AnthropicContentBlock struct {
+	Type      string                `json:"type"`
+	Text      string                `json:"text,omitempty"`
+	Source    *AnthropicImageSource `json:"source,omitempty"`
+	ID        string                `json:"id,omitempty"`
+	Name      string                `json:"name,omitempty"`
+	Input     json.RawMessage       `json:"input,omitempty"`
+	ToolUseID string                `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage       `json:"content,omitempty"`
+	IsError   bool                  `json:"is_error,omitempty"`
+}
+
+// AnthropicImageSource holds an inline ("base64") or "url" image
+type AnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// AnthropicTool declares a tool the model may use
+type AnthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+}
+
+// AnthropicToolChoice is the tool_choice parameter
+type AnthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// AnthropicResponse is a Messages API response, also sent in the
+// message_start event of a stream
+type AnthropicResponse struct {
+	ID           string                   `json:"id"`
+	Type         string                   `json:"type"`
+	Role         string                   `json:"role"`
+	Model        string                   `json:"model"`
+	Content      []AnthropicResponseBlock `json:"content"`
+	StopReason   *string                  `json:"stop_reason"`
+	StopSequence *string                  `json:"stop_sequence"`
+	Usage        AnthropicUsage           `json:"usage"`
+}
+
+// AnthropicResponseBlock is a content block of a response
+type AnthropicResponseBlock struct {
+	Type  string          `json:"type"`
+	Text  *string         `json:"text,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// AnthropicUsage reports token counts
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// AnthropicTextBlock returns a text response block
+func AnthropicTextBlock(text string) AnthropicResponseBlock {
+	return AnthropicResponseBlock{Type: "text", Text: &text}
+}
+
+// AnthropicToolUseBlock returns a tool_use response block for call
+func AnthropicToolUseBlock(call llmx.ToolCall) AnthropicResponseBlock {
+	return AnthropicResponseBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: arguments(call.Arguments)}
+}
+
+// ToAnthropicRequest converts a request to the Messages format. System
+// messages are joined into the system prompt, and tool results are sent
+// as tool_result blocks at the start of the following user message.
+func ToAnthropicRequest(req *llmx.ChatRequest) (*AnthropicRequest, error) {
+	wire := &AnthropicRequest{
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		Messages:      []AnthropicMessage{},
+		StopSequences: req.Stop,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
+		ToolChoice:    AnthropicToolChoiceFrom(req),
+	}
+
+	var system []string
+	var pending []AnthropicContentBlock
+	var pendingRole string
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		content, err := encodeAnthropicContent(pending)
+		if err != nil {
+			return err
+		}
+		wire.Messages = append(wire.Messages, AnthropicMessage{Role: pendingRole, Content: content})
+		pending = nil
+		return nil
+	}
+
+	for i, msg := range req.Messages {
+		if msg.Role == llmx.RoleSystem {
+			system = append(system, llmx.ExtractText(msg))
+			continue
+		}
+
+		role := "user"
+		if msg.Role == llmx.RoleAssistant {
+			role = "assistant"
+		}
+		blocks, err := toAnthropicBlocks(msg)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("messages[%d]: %v", i, err), "messages")
+		}
+
+		// Tool results join the user message that follows them
+		if pendingRole != role || msg.Role == llmx.RoleUser && !pendingToolResults(pending) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		pendingRole = role
+		pending = append(pending, blocks...)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(system) > 0 {
+		encoded, err := json.Marshal(strings.Join(system, "\n"))
+		if err != nil {
+			return nil, err
+		}
+		wire.System = encoded
+	}
+
+	for _, t := range req.Tools {
+		schema, err := encodeSchema(t.Parameters)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("tool %s: invalid parameters: %v", t.Name, err), "tools")
+		}
+		wire.Tools = append(wire.Tools, AnthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+
+	return wire, nil
+}
+
+// pendingToolResults reports whether blocks consist of tool results only
+func pendingToolResults(blocks []AnthropicContentBlock) bool {
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
+
+// encodeAnthropicContent encodes a lone text block as a string
+func encodeAnthropicContent(blocks []AnthropicContentBlock) (json.RawMessage, error) {
+	if len(blocks) == 1 && blocks[0].Type == "text" {
+		return json.Marshal(blocks[0].Text)
+	}
+	return json.Marshal(blocks)
+}
+
+func toAnthropicBlocks(msg llmx.Message) ([]AnthropicContentBlock, error) {
+	var blocks []AnthropicContentBlock
+	for _, part := range msg.Content {
+		switch p := part.(type) {
+		case llmx.TextPart:
+			blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: p.Text})
+		case llmx.ImagePart:
+			source := &AnthropicImageSource{Type: "url", URL: p.URL}
+			if p.Base64 != "" {
+				mediaType, data := splitImage(p)
+				source = &AnthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+			}
+			blocks = append(blocks, AnthropicContentBlock{Type: "image", Source: source})
+		case llmx.ToolResultPart:
+			content, err := json.Marshal(p.Result)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, AnthropicContentBlock{Type: "tool_result", ToolUseID: p.ToolCallID, Content: content, IsError: p.IsError})
+		case llmx.ToolCall:
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type())
+		}
+	}
+	for _, call := range messageToolCalls(msg) {
+		blocks = append(blocks, AnthropicContentBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: arguments(call.Arguments)})
+	}
+	return blocks, nil
+}
+
+// AnthropicToolChoiceFrom converts the request's tool choice and parallel
+// tool call setting to Anthropic's tool_choice. Anthropic has no separate
+// parallel flag, so disabling parallel calls alone yields an "auto" choice.
+func AnthropicToolChoiceFrom(req *llmx.ChatRequest) *AnthropicToolChoice {
+	disableParallel := req.ParallelToolCalls != nil && !*req.ParallelToolCalls
+	if req.ToolChoice == nil && !disableParallel {
+		return nil
+	}
+
+	choice := &AnthropicToolChoice{Type: "auto"}
+	if req.ToolChoice != nil {
+		switch req.ToolChoice.Mode {
+		case llmx.ToolChoiceNone:
+			choice.Type = "none"
+		case llmx.ToolChoiceRequired:
+			choice.Type = "any"
+		case llmx.ToolChoiceFunction:
+			choice.Type = "tool"
+			choice.Name = req.ToolChoice.Name
+		}
+	}
+
+	// disable_parallel_tool_use is rejected with "none"
+	if choice.Type != "none" {
+		choice.DisableParallelToolUse = disableParallel
+	}
+	return choice
+}
+
+// FromAnthropicRequest converts a Messages API request
+func FromAnthropicRequest(r *AnthropicRequest) (*llmx.ChatRequest, error) {
+	req := &llmx.ChatRequest{
+		Model:       r.Model,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+		TopP:        r.TopP,
+		TopK:        r.TopK,
+		Stop:        r.StopSequences,
+	}
+
+	system, err := anthropicText(r.System)
+	if err != nil {
+		return nil, invalidParam("system: "+err.Error(), "system")
+	}
+	if system != "" {
+		req.Messages = append(req.Messages, llmx.Message{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: system}}})
+	}
+
+	for i, m := range r.Messages {
+		msgs, err := m.toMessages()
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("messages[%d]: %v", i, err), "messages")
+		}
+		req.Messages = append(req.Messages, msgs...)
+	}
+
+	for _, t := range r.Tools {
+		schema, err := decodeSchema(t.InputSchema)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("tool %s: invalid input_schema: %v", t.Name, err), "tools")
+		}
+		req.Tools = append(req.Tools, llmx.Tool{Name: t.Name, Description: t.Description, Parameters: schema})
+	}
+
+	if c := r.ToolChoice; c != nil {
+		switch c.Type {
+		case "auto":
+			if !c.DisableParallelToolUse {
+				req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceAuto}
+			}
+		case "any":
+			req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}
+		case "none":
+			req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}
+		case "tool":
+			req.ToolChoice = llmx.ForceTool(c.Name)
+		default:
+			return nil, invalidParam("invalid tool_choice type: "+c.Type, "tool_choice")
+		}
+		if c.DisableParallelToolUse {
+			parallel := false
+			req.ParallelToolCalls = &parallel
+		}
+	}
+
+	return req, nil
+}
+
+// toMessages converts one message. Tool results, which Anthropic sends
+// as blocks of a user message, become separate tool messages ahead of
+// the rest of the user's content.
+func (m *AnthropicMessage) toMessages() ([]llmx.Message, error) {
+	blocks, err := anthropicBlocks(m.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var role llmx.MessageRole
+	switch m.Role {
+	case "user":
+		role = llmx.RoleUser
+	case "assistant":
+		role = llmx.RoleAssistant
+	default:
+		return nil, fmt.Errorf("unsupported role %q", m.Role)
+	}
+
+	var msgs []llmx.Message
+	msg := llmx.Message{Role: role}
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			msg.Content = append(msg.Content, llmx.TextPart{Text: b.Text})
+
+		case "image":
+			if b.Source == nil {
+				return nil, fmt.Errorf("image block without source")
+			}
+			if b.Source.Type == "url" {
+				msg.Content = append(msg.Content, llmx.ImagePart{URL: b.Source.URL})
+			} else {
+				msg.Content = append(msg.Content, llmx.ImagePart{Base64: dataURL(b.Source.MediaType, b.Source.Data)})
+			}
+
+		case "tool_use":
+			if role != llmx.RoleAssistant {
+				return nil, fmt.Errorf("tool_use blocks must be in assistant messages")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, llmx.ToolCall{ID: b.ID, Name: b.Name, Arguments: arguments(b.Input)})
+
+		case "tool_result":
+			if role != llmx.RoleUser {
+				return nil, fmt.Errorf("tool_result blocks must be in user messages")
+			}
+			result, err := anthropicText(b.Content)
+			if err != nil {
+				return nil, fmt.Errorf("tool_result %s: %w", b.ToolUseID, err)
+			}
+			msgs = append(msgs, llmx.Message{Role: llmx.RoleTool, Content: []llmx.ContentPart{
+				llmx.ToolResultPart{ToolCallID: b.ToolUseID, Result: result, IsError: b.IsError},
+			}})
+
+		case "thinking", "redacted_thinking":
+			// Reasoning from earlier turns is not forwarded
+
+		default:
+			return nil, fmt.Errorf("unsupported content block type %q", b.Type)
+		}
+	}
+
+	if len(msg.Content) > 0 || len(msg.ToolCalls) > 0 {
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// anthropicBlocks decodes string or block array content
+func anthropicBlocks(raw json.RawMessage) ([]AnthropicContentBlock, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []AnthropicContentBlock{{Type: "text", Text: text}}, nil
+	}
+
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of content blocks")
+	}
+	return blocks, nil
+}
+
+// anthropicText joins the text of string or block array content
+func anthropicText(raw json.RawMessage) (string, error) {
+	blocks, err := anthropicBlocks(raw)
+	if err != nil {
+		return "", err
+	}
+
+	texts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Type != "text" {
+			return "", fmt.Errorf("only text content is supported here, got %q", b.Type)
+		}
+		texts = append(texts, b.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// ToAnthropicResponse converts the first choice of a response to a
+// Messages API response
+func ToAnthropicResponse(resp *llmx.ChatResponse) *AnthropicResponse {
+	choice := choices(resp)[0]
+	content := []AnthropicResponseBlock{}
+	if choice.Content != "" {
+		content = append(content, AnthropicTextBlock(choice.Content))
+	}
+	for _, call := range choice.ToolCalls {
+		content = append(content, AnthropicToolUseBlock(call))
+	}
+
+	stop := AnthropicStopReason(choice.FinishReason, len(choice.ToolCalls) > 0)
+	return &AnthropicResponse{
+		ID:         resp.ID,
+		Type:       "message",
+		Role:       "assistant",
+		Model:      resp.Model,
+		Content:    content,
+		StopReason: &stop,
+		Usage: AnthropicUsage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		},
+	}
+}
+
+// AnthropicStopReason maps a finish reason to an Anthropic stop reason
+func AnthropicStopReason(reason llmx.FinishReason, hasToolCalls bool) string {
+	switch OpenAIFinishReason(reason, hasToolCalls) {
+	case "length":
+		return "max_tokens"
+	case "tool_calls":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+// FromAnthropicResponse converts a Messages API response. Text blocks are
+// concatenated; thinking blocks are dropped.
+func FromAnthropicResponse(r *AnthropicResponse) (*llmx.ChatResponse, error) {
+	resp := &llmx.ChatResponse{
+		ID:    r.ID,
+		Model: r.Model,
+		Usage: llmx.Usage{
+			PromptTokens:     r.Usage.InputTokens,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      r.Usage.InputTokens + r.Usage.OutputTokens,
+		},
+	}
+
+	var choice llmx.Choice
+	for _, b := range r.Content {
+		switch b.Type {
+		case "text":
+			if b.Text != nil {
+				choice.Content += *b.Text
+			}
+		case "tool_use":
+			choice.ToolCalls = append(choice.ToolCalls, llmx.ToolCall{ID: b.ID, Name: b.Name, Arguments: arguments(b.Input)})
+		}
+	}
+	if r.StopReason != nil {
+		choice.RawFinishReason = *r.StopReason
+		choice.FinishReason = llmx.NormalizeFinishReason(*r.StopReason)
+	}
+	return withChoices(resp, []llmx.Choice{choice}), nil
+}
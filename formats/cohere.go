@@ -0,0 +1,524 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// CohereRequest is the body of a Cohere Chat (v1) request
+type CohereRequest struct {
+	Message       string             `json:"message"`
+	Model         string             `json:"model,omitempty"`
+	Preamble      string             `json:"preamble,omitempty"`
+	ChatHistory   []CohereMessage    `json:"chat_history,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	MaxTokens     *int               `json:"max_tokens,omitempty"`
+	P             *float64           `json:"p,omitempty"`
+	K             *int               `json:"k,omitempty"`
+	Seed          *int               `json:"seed,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Tools         []CohereTool       `json:"tools,omitempty"`
+	ToolResults   []CohereToolResult `json:"tool_results,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+// CohereMessage is a chat history entry. Role is USER, CHATBOT, SYSTEM or
+// TOOL; TOOL entries carry ToolResults instead of a message.
+type CohereMessage struct {
+	Role        string             `json:"role"`
+	Message     string             `json:"message,omitempty"`
+	ToolCalls   []CohereToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []CohereToolResult `json:"tool_results,omitempty"`
+}
+
+// CohereToolCall is a call the model made. Cohere calls have no IDs.
+type CohereToolCall struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+// CohereToolResult pairs a call with its outputs, which are objects
+type CohereToolResult struct {
+	Call    CohereToolCall           `json:"call"`
+	Outputs []map[string]interface{} `json:"outputs"`
+}
+
+// CohereTool declares a tool by its parameter definitions
+type CohereTool struct {
+	Name                 string                               `json:"name"`
+	Description          string                               `json:"description"`
+	ParameterDefinitions map[string]CohereParameterDefinition `json:"parameter_definitions,omitempty"`
+}
+
+// CohereParameterDefinition describes a parameter with a Python-style
+// type name such as "str" or "List[int]"
+type CohereParameterDefinition struct {
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+}
+
+// CohereResponse is a non-streamed Chat response
+type CohereResponse struct {
+	Text         string           `json:"text"`
+	GenerationID string           `json:"generation_id,omitempty"`
+	Citations    []CohereCitation `json:"citations,omitempty"`
+	FinishReason string           `json:"finish_reason,omitempty"`
+	ToolCalls    []CohereToolCall `json:"tool_calls,omitempty"`
+	Meta         *CohereMeta      `json:"meta,omitempty"`
+}
+
+// CohereCitation ties a span of the text to the documents supporting it
+type CohereCitation struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// CohereMeta holds the token counts of a response
+type CohereMeta struct {
+	BilledUnits *CohereTokens `json:"billed_units,omitempty"`
+	Tokens      *CohereTokens `json:"tokens,omitempty"`
+}
+
+// CohereTokens counts input and output tokens
+type CohereTokens struct {
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+// ToCohereRequest converts a request to the Chat format.
+//
+// System messages become the preamble, earlier turns become chat history and
+// the final turn becomes either the message or, when the conversation ends
+// with tool results, the request's tool_results. Cohere has no tool choice,
+// so ToolChoice and ParallelToolCalls are dropped.
+func ToCohereRequest(req *llmx.ChatRequest) (*CohereRequest, error) {
+	wire := &CohereRequest{
+		Model:         req.Model,
+		Temperature:   req.Temperature,
+		MaxTokens:     req.MaxTokens,
+		P:             req.TopP,
+		K:             req.TopK,
+		Seed:          req.Seed,
+		StopSequences: req.Stop,
+	}
+
+	var preamble []string
+	var turns []llmx.Message
+	for _, msg := range req.Messages {
+		if msg.Role == llmx.RoleSystem {
+			preamble = append(preamble, llmx.ExtractText(msg))
+			continue
+		}
+		turns = append(turns, msg)
+	}
+	if len(turns) == 0 {
+		return nil, invalidParam("at least one non-system message is required", "messages")
+	}
+	wire.Preamble = strings.Join(preamble, "\n")
+
+	// Trailing tool results are sent as tool_results rather than history
+	last := len(turns)
+	for last > 0 && isToolResultMessage(turns[last-1]) {
+		last--
+	}
+	history := turns[:last]
+	if last == len(turns) {
+		current := turns[len(turns)-1]
+		if current.Role != llmx.RoleUser {
+			return nil, invalidParam(fmt.Sprintf("last message must be from the user or a tool, got %q", current.Role), "messages")
+		}
+		history = turns[:len(turns)-1]
+		wire.Message = llmx.ExtractText(current)
+	}
+
+	calls := make(map[string]llmx.ToolCall)
+	for _, msg := range history {
+		for _, call := range messageToolCalls(msg) {
+			calls[call.ID] = call
+		}
+	}
+
+	for _, msg := range history {
+		converted, err := toCohereMessage(msg, calls)
+		if err != nil {
+			return nil, err
+		}
+		wire.ChatHistory = append(wire.ChatHistory, converted)
+	}
+	for _, msg := range turns[last:] {
+		results, err := toCohereToolResults(msg, calls)
+		if err != nil {
+			return nil, err
+		}
+		wire.ToolResults = append(wire.ToolResults, results...)
+	}
+
+	for _, tool := range req.Tools {
+		converted := CohereTool{Name: tool.Name, Description: tool.Description}
+		if tool.Parameters != nil && len(tool.Parameters.Properties) > 0 {
+			converted.ParameterDefinitions = cohereParameters(tool.Parameters)
+		}
+		wire.Tools = append(wire.Tools, converted)
+	}
+
+	return wire, nil
+}
+
+// toCohereMessage converts a history message
+func toCohereMessage(msg llmx.Message, calls map[string]llmx.ToolCall) (CohereMessage, error) {
+	if isToolResultMessage(msg) {
+		results, err := toCohereToolResults(msg, calls)
+		if err != nil {
+			return CohereMessage{}, err
+		}
+		return CohereMessage{Role: "TOOL", ToolResults: results}, nil
+	}
+
+	switch msg.Role {
+	case llmx.RoleUser:
+		return CohereMessage{Role: "USER", Message: llmx.ExtractText(msg)}, nil
+	case llmx.RoleAssistant:
+		chatbot := CohereMessage{Role: "CHATBOT", Message: llmx.ExtractText(msg)}
+		for _, call := range messageToolCalls(msg) {
+			converted, err := toCohereToolCall(call)
+			if err != nil {
+				return CohereMessage{}, err
+			}
+			chatbot.ToolCalls = append(chatbot.ToolCalls, converted)
+		}
+		return chatbot, nil
+	}
+	return CohereMessage{}, invalidParam(fmt.Sprintf("unsupported message role %q", msg.Role), "messages")
+}
+
+// toCohereToolResults converts the tool results in a message, pairing each
+// with the call that produced it
+func toCohereToolResults(msg llmx.Message, calls map[string]llmx.ToolCall) ([]CohereToolResult, error) {
+	var results []CohereToolResult
+	for _, result := range toolResults(msg) {
+		call, ok := calls[result.ToolCallID]
+		if !ok {
+			return nil, invalidParam(fmt.Sprintf("tool result references unknown tool call %q", result.ToolCallID), "messages")
+		}
+		converted, err := toCohereToolCall(call)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, CohereToolResult{Call: converted, Outputs: []map[string]interface{}{CohereToolOutput(result)}})
+	}
+	return results, nil
+}
+
+// CohereToolOutput converts a tool result into an output object. Cohere
+// requires objects, so non-object results are wrapped as {"result": ...},
+// and errors as {"error": ...}.
+func CohereToolOutput(result llmx.ToolResultPart) map[string]interface{} {
+	if result.IsError {
+		return map[string]interface{}{"error": result.Result}
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Result), &output); err == nil && output != nil {
+		return output
+	}
+	return map[string]interface{}{"result": result.Result}
+}
+
+func toCohereToolCall(call llmx.ToolCall) (CohereToolCall, error) {
+	params := make(map[string]interface{})
+	if len(call.Arguments) > 0 {
+		if err := json.Unmarshal(call.Arguments, &params); err != nil {
+			return CohereToolCall{}, invalidParam(fmt.Sprintf("invalid arguments for tool call %q: %v", call.Name, err), "messages")
+		}
+	}
+	return CohereToolCall{Name: call.Name, Parameters: params}, nil
+}
+
+// isToolResultMessage reports whether a message carries tool results
+func isToolResultMessage(msg llmx.Message) bool {
+	return msg.Role == llmx.RoleTool || len(toolResults(msg)) > 0
+}
+
+// cohereParameters converts an object schema to parameter definitions
+func cohereParameters(schema *llmx.Schema) map[string]CohereParameterDefinition {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	definitions := make(map[string]CohereParameterDefinition, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		if prop == nil {
+			continue
+		}
+		definitions[name] = CohereParameterDefinition{
+			Description: cohereParameterDescription(prop),
+			Type:        cohereParameterType(prop),
+			Required:    required[name],
+		}
+	}
+	return definitions
+}
+
+// cohereParameterType maps a JSON Schema type to the Python-style type
+// names Cohere uses in parameter definitions
+func cohereParameterType(schema *llmx.Schema) string {
+	switch schema.Type {
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		if schema.Items != nil && schema.Items.Type != "" {
+			return "List[" + cohereParameterType(schema.Items) + "]"
+		}
+		return "List"
+	case "object":
+		return "Dict"
+	default:
+		return "str"
+	}
+}
+
+// cohereParameterDescription returns the schema description, listing enum
+// values since parameter definitions have no enum field
+func cohereParameterDescription(schema *llmx.Schema) string {
+	if len(schema.Enum) == 0 {
+		return schema.Description
+	}
+
+	values := make([]string, len(schema.Enum))
+	for i, v := range schema.Enum {
+		values[i] = fmt.Sprint(v)
+	}
+	enum := "One of: " + strings.Join(values, ", ")
+	if schema.Description == "" {
+		return enum
+	}
+	return schema.Description + ". " + enum
+}
+
+// FromCohereRequest converts a Chat request. Cohere calls have no IDs, so
+// they are derived from the name and position, and results are paired
+// with the earliest unanswered call of the same name.
+func FromCohereRequest(r *CohereRequest) (*llmx.ChatRequest, error) {
+	req := &llmx.ChatRequest{
+		Model:       r.Model,
+		Temperature: r.Temperature,
+		MaxTokens:   r.MaxTokens,
+		TopP:        r.P,
+		TopK:        r.K,
+		Seed:        r.Seed,
+		Stop:        r.StopSequences,
+	}
+
+	if r.Preamble != "" {
+		req.Messages = append(req.Messages, textMessage(llmx.RoleSystem, r.Preamble))
+	}
+
+	calls := newCallIDs()
+	for i, m := range r.ChatHistory {
+		switch m.Role {
+		case "USER":
+			req.Messages = append(req.Messages, textMessage(llmx.RoleUser, m.Message))
+		case "SYSTEM":
+			req.Messages = append(req.Messages, textMessage(llmx.RoleSystem, m.Message))
+		case "CHATBOT":
+			msg := llmx.Message{Role: llmx.RoleAssistant}
+			if m.Message != "" {
+				msg.Content = []llmx.ContentPart{llmx.TextPart{Text: m.Message}}
+			}
+			for _, call := range m.ToolCalls {
+				converted, err := fromCohereToolCall(call, calls.call("", call.Name))
+				if err != nil {
+					return nil, err
+				}
+				msg.ToolCalls = append(msg.ToolCalls, converted)
+			}
+			req.Messages = append(req.Messages, msg)
+		case "TOOL":
+			req.Messages = append(req.Messages, fromCohereToolResults(m.ToolResults, calls)...)
+		default:
+			return nil, invalidParam(fmt.Sprintf("chat_history[%d]: unsupported role %q", i, m.Role), "chat_history")
+		}
+	}
+
+	req.Messages = append(req.Messages, fromCohereToolResults(r.ToolResults, calls)...)
+	if r.Message != "" {
+		req.Messages = append(req.Messages, textMessage(llmx.RoleUser, r.Message))
+	}
+
+	for _, t := range r.Tools {
+		tool := llmx.Tool{Name: t.Name, Description: t.Description}
+		if len(t.ParameterDefinitions) > 0 {
+			tool.Parameters = fromCohereParameters(t.ParameterDefinitions)
+		}
+		req.Tools = append(req.Tools, tool)
+	}
+
+	return req, nil
+}
+
+func textMessage(role llmx.MessageRole, text string) llmx.Message {
+	return llmx.Message{Role: role, Content: []llmx.ContentPart{llmx.TextPart{Text: text}}}
+}
+
+func fromCohereToolCall(call CohereToolCall, id string) (llmx.ToolCall, error) {
+	args := json.RawMessage("{}")
+	if len(call.Parameters) > 0 {
+		encoded, err := json.Marshal(call.Parameters)
+		if err != nil {
+			return llmx.ToolCall{}, err
+		}
+		args = encoded
+	}
+	return llmx.ToolCall{ID: id, Name: call.Name, Arguments: args}, nil
+}
+
+// fromCohereToolResults converts tool results to one tool message each,
+// unwrapping outputs written by CohereToolOutput
+func fromCohereToolResults(results []CohereToolResult, calls *callIDs) []llmx.Message {
+	var msgs []llmx.Message
+	for _, r := range results {
+		part := llmx.ToolResultPart{ToolCallID: calls.response("", r.Call.Name)}
+		if len(r.Outputs) == 1 && len(r.Outputs[0]) == 1 {
+			if text, ok := r.Outputs[0]["result"].(string); ok {
+				part.Result = text
+			} else if text, ok := r.Outputs[0]["error"].(string); ok {
+				part.Result, part.IsError = text, true
+			}
+		}
+		if part.Result == "" && !part.IsError {
+			var output interface{} = r.Outputs
+			if len(r.Outputs) == 1 {
+				output = r.Outputs[0]
+			}
+			encoded, _ := json.Marshal(output)
+			part.Result = string(encoded)
+		}
+		msgs = append(msgs, llmx.Message{Role: llmx.RoleTool, Content: []llmx.ContentPart{part}})
+	}
+	return msgs
+}
+
+// fromCohereParameters converts parameter definitions to an object schema.
+// Python-style types are mapped back to JSON Schema where possible.
+func fromCohereParameters(definitions map[string]CohereParameterDefinition) *llmx.Schema {
+	schema := &llmx.Schema{Type: "object", Properties: make(map[string]*llmx.Schema, len(definitions))}
+	for name, def := range definitions {
+		schema.Properties[name] = schemaFromCohereType(def.Type)
+		schema.Properties[name].Description = def.Description
+		if def.Required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func schemaFromCohereType(typ string) *llmx.Schema {
+	switch {
+	case typ == "int":
+		return &llmx.Schema{Type: "integer"}
+	case typ == "float":
+		return &llmx.Schema{Type: "number"}
+	case typ == "bool":
+		return &llmx.Schema{Type: "boolean"}
+	case typ == "Dict":
+		return &llmx.Schema{Type: "object"}
+	case typ == "List":
+		return &llmx.Schema{Type: "array"}
+	case strings.HasPrefix(typ, "List[") && strings.HasSuffix(typ, "]"):
+		return &llmx.Schema{Type: "array", Items: schemaFromCohereType(typ[len("List[") : len(typ)-1])}
+	default:
+		return &llmx.Schema{Type: "string"}
+	}
+}
+
+// ToCohereResponse converts the first choice of a response
+func ToCohereResponse(resp *llmx.ChatResponse) *CohereResponse {
+	choice := choices(resp)[0]
+	wire := &CohereResponse{
+		Text:         choice.Content,
+		GenerationID: resp.ID,
+		FinishReason: CohereFinishReason(choice.FinishReason),
+		Meta: &CohereMeta{Tokens: &CohereTokens{
+			InputTokens:  float64(resp.Usage.PromptTokens),
+			OutputTokens: float64(resp.Usage.CompletionTokens),
+		}},
+	}
+	for _, call := range choice.ToolCalls {
+		params := make(map[string]interface{})
+		_ = json.Unmarshal(arguments(call.Arguments), &params)
+		wire.ToolCalls = append(wire.ToolCalls, CohereToolCall{Name: call.Name, Parameters: params})
+	}
+	for _, c := range resp.Citations {
+		wire.Citations = append(wire.Citations, CohereCitation{Start: c.Start, End: c.End, Text: c.Text, DocumentIDs: c.DocumentIDs})
+	}
+	return wire
+}
+
+// CohereFinishReason maps a finish reason to a Cohere one
+func CohereFinishReason(reason llmx.FinishReason) string {
+	switch reason {
+	case llmx.FinishReasonLength:
+		return "MAX_TOKENS"
+	case llmx.FinishReasonContentFilter:
+		return "ERROR_TOXIC"
+	case llmx.FinishReasonError:
+		return "ERROR"
+	default:
+		return "COMPLETE"
+	}
+}
+
+// FromCohereResponse converts a Chat response. Call IDs are derived from
+// the tool name, a random prefix per response and the position, so that they
+// stay unique across the turns of a conversation.
+func FromCohereResponse(r *CohereResponse) (*llmx.ChatResponse, error) {
+	resp := &llmx.ChatResponse{ID: r.GenerationID}
+
+	choice := llmx.Choice{Content: r.Text, RawFinishReason: r.FinishReason, FinishReason: llmx.FinishReasonStop}
+	if r.FinishReason != "" {
+		choice.FinishReason = llmx.NormalizeFinishReason(r.FinishReason)
+		if choice.FinishReason == "" {
+			choice.FinishReason = llmx.FinishReasonError
+		}
+	}
+	prefix := callIDPrefix()
+	for i, call := range r.ToolCalls {
+		converted, err := fromCohereToolCall(call, fmt.Sprintf("%s_%s_%d", call.Name, prefix, i))
+		if err != nil {
+			return nil, err
+		}
+		choice.ToolCalls = append(choice.ToolCalls, converted)
+	}
+	if choice.FinishReason == llmx.FinishReasonStop && len(choice.ToolCalls) > 0 {
+		choice.FinishReason = llmx.FinishReasonToolCalls
+	}
+
+	for _, c := range r.Citations {
+		resp.Citations = append(resp.Citations, llmx.Citation{Start: c.Start, End: c.End, Text: c.Text, DocumentIDs: c.DocumentIDs})
+	}
+
+	if r.Meta != nil {
+		tokens := r.Meta.Tokens
+		if tokens == nil {
+			tokens = r.Meta.BilledUnits
+		}
+		if tokens != nil {
+			resp.Usage = llmx.Usage{PromptTokens: int(tokens.InputTokens), CompletionTokens: int(tokens.OutputTokens)}
+			resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+		}
+	}
+	return withChoices(resp, []llmx.Choice{choice}), nil
+}
@@ -0,0 +1,284 @@
+// Package formats converts llmx requests and responses to and from the
+// JSON wire formats of vendor APIs: OpenAI Chat Completions, Anthropic
+// Messages, Gemini generateContent and Cohere Chat.
+//
+// Each format has wire types mirroring the vendor's JSON and four
+// converters, To<Format>Request, From<Format>Request, To<Format>Response
+// and From<Format>Response. The Format values wrap them to work on encoded
+// JSON, e.g. to import or export a conversation:
+//
+//	data, err := formats.Anthropic.MarshalRequest(&llmx.ChatRequest{Messages: transcript})
+//
+// Conversions keep everything both sides can express. Vendor fields llmx
+// has no equivalent for are dropped when decoding, and llmx fields a
+// vendor cannot express are dropped when encoding.
+package formats
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Format converts between llmx values and one vendor's JSON encoding
+type Format interface {
+	// Name is the format's name, e.g. "openai"
+	Name() string
+
+	MarshalRequest(req *llmx.ChatRequest) ([]byte, error)
+	UnmarshalRequest(data []byte) (*llmx.ChatRequest, error)
+	MarshalResponse(resp *llmx.ChatResponse) ([]byte, error)
+	UnmarshalResponse(data []byte) (*llmx.ChatResponse, error)
+}
+
+var (
+	// OpenAI is the OpenAI Chat Completions format
+	OpenAI Format = &format[OpenAIRequest, OpenAIResponse]{
+		name:         "openai",
+		toRequest:    ToOpenAIRequest,
+		fromRequest:  FromOpenAIRequest,
+		toResponse:   ToOpenAIResponse,
+		fromResponse: FromOpenAIResponse,
+	}
+
+	// Anthropic is the Anthropic Messages format
+	Anthropic Format = &format[AnthropicRequest, AnthropicResponse]{
+		name:         "anthropic",
+		toRequest:    ToAnthropicRequest,
+		fromRequest:  FromAnthropicRequest,
+		toResponse:   ToAnthropicResponse,
+		fromResponse: FromAnthropicResponse,
+	}
+
+	// Gemini is the Gemini generateContent format. Gemini requests name
+	// their model in the URL, so UnmarshalRequest leaves Model empty.
+	Gemini Format = &format[GeminiRequest, GeminiResponse]{
+		name:      "gemini",
+		toRequest: ToGeminiRequest,
+		fromRequest: func(r *GeminiRequest) (*llmx.ChatRequest, error) {
+			return FromGeminiRequest(r, "")
+		},
+		toResponse:   ToGeminiResponse,
+		fromResponse: FromGeminiResponse,
+	}
+
+	// Cohere is the Cohere Chat (v1) format
+	Cohere Format = &format[CohereRequest, CohereResponse]{
+		name:         "cohere",
+		toRequest:    ToCohereRequest,
+		fromRequest:  FromCohereRequest,
+		toResponse:   ToCohereResponse,
+		fromResponse: FromCohereResponse,
+	}
+)
+
+// Lookup returns the format with the given name
+func Lookup(name string) (Format, bool) {
+	for _, f := range []Format{OpenAI, Anthropic, Gemini, Cohere} {
+		if f.Name() == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// format implements Format with the typed converters of one vendor
+type format[Req, Resp any] struct {
+	name         string
+	toRequest    func(*llmx.ChatRequest) (*Req, error)
+	fromRequest  func(*Req) (*llmx.ChatRequest, error)
+	toResponse   func(*llmx.ChatResponse) *Resp
+	fromResponse func(*Resp) (*llmx.ChatResponse, error)
+}
+
+func (f *format[Req, Resp]) Name() string {
+	return f.name
+}
+
+func (f *format[Req, Resp]) MarshalRequest(req *llmx.ChatRequest) ([]byte, error) {
+	wire, err := f.toRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(wire)
+}
+
+func (f *format[Req, Resp]) UnmarshalRequest(data []byte) (*llmx.ChatRequest, error) {
+	var wire Req
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, llmx.NewInvalidRequestError("invalid request body: "+err.Error(), nil)
+	}
+	return f.fromRequest(&wire)
+}
+
+func (f *format[Req, Resp]) MarshalResponse(resp *llmx.ChatResponse) ([]byte, error) {
+	return json.Marshal(f.toResponse(resp))
+}
+
+func (f *format[Req, Resp]) UnmarshalResponse(data []byte) (*llmx.ChatResponse, error) {
+	var wire Resp
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, err
+	}
+	return f.fromResponse(&wire)
+}
+
+// invalidParam returns an invalid request error naming the offending
+// request parameter
+func invalidParam(message, param string) error {
+	return llmx.NewInvalidRequestError(message, map[string]interface{}{"param": param})
+}
+
+// arguments returns tool call arguments, "{}" when there are none
+func arguments(args json.RawMessage) json.RawMessage {
+	if len(args) == 0 {
+		return json.RawMessage("{}")
+	}
+	return args
+}
+
+// decodeSchema decodes a JSON Schema, returning nil for an empty one
+func decodeSchema(raw json.RawMessage) (*llmx.Schema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	schema := &llmx.Schema{}
+	if err := json.Unmarshal(raw, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// encodeSchema encodes a JSON Schema, omitting a nil one
+func encodeSchema(schema *llmx.Schema) (json.RawMessage, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	return json.Marshal(schema)
+}
+
+// dataURL builds the data URL llmx uses for inline images
+func dataURL(mediaType, data string) string {
+	return "data:" + mediaType + ";base64," + data
+}
+
+// splitImage returns the media type and base64 payload of an inline
+// image. Bare base64 payloads are assumed to be PNG.
+func splitImage(image llmx.ImagePart) (mediaType, data string) {
+	header, payload, ok := strings.Cut(image.Base64, ",")
+	if !ok || !strings.HasPrefix(header, "data:") {
+		return "image/png", image.Base64
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64"), payload
+}
+
+// imageFromURL returns an image part for url, which may be a data URL
+func imageFromURL(url, detail string) llmx.ImagePart {
+	if strings.HasPrefix(url, "data:") {
+		return llmx.ImagePart{Base64: url, Detail: detail}
+	}
+	return llmx.ImagePart{URL: url, Detail: detail}
+}
+
+// messageToolCalls returns the tool calls of a message, whether held in
+// ToolCalls or as content parts
+func messageToolCalls(msg llmx.Message) []llmx.ToolCall {
+	calls := append([]llmx.ToolCall(nil), msg.ToolCalls...)
+	for _, part := range msg.Content {
+		if call, ok := part.(llmx.ToolCall); ok {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// toolResults returns the tool result parts of a message
+func toolResults(msg llmx.Message) []llmx.ToolResultPart {
+	var results []llmx.ToolResultPart
+	for _, part := range msg.Content {
+		if result, ok := part.(llmx.ToolResultPart); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// callIDPrefix returns a random prefix for the call IDs of a response, in
+// formats without call IDs
+func callIDPrefix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// callIDs assigns IDs to tool calls in formats where they are optional or
+// missing, and pairs results with the earliest unanswered call of the same
+// name
+type callIDs struct {
+	n       int
+	pending map[string][]string
+}
+
+func newCallIDs() *callIDs {
+	return &callIDs{pending: make(map[string][]string)}
+}
+
+// call returns the ID of a call, deriving one from the name and position
+// when id is empty
+func (c *callIDs) call(id, name string) string {
+	if id == "" {
+		id = fmt.Sprintf("%s_%d", name, c.n)
+	}
+	c.n++
+	c.pending[name] = append(c.pending[name], id)
+	return id
+}
+
+// response returns the ID of the call a result answers
+func (c *callIDs) response(id, name string) string {
+	queue := c.pending[name]
+	if id != "" {
+		for i, pending := range queue {
+			if pending == id {
+				c.pending[name] = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+		return id
+	}
+	if len(queue) == 0 {
+		return name
+	}
+	c.pending[name] = queue[1:]
+	return queue[0]
+}
+
+// choices returns the choices of a response, or a single choice built
+// from its top-level fields for responses without any
+func choices(resp *llmx.ChatResponse) []llmx.Choice {
+	if len(resp.Choices) > 0 {
+		return resp.Choices
+	}
+	return []llmx.Choice{{
+		Content:         resp.Content,
+		ToolCalls:       resp.ToolCalls,
+		FinishReason:    resp.FinishReason,
+		RawFinishReason: resp.RawFinishReason,
+	}}
+}
+
+// withChoices fills in a response's top-level fields from its first choice
+func withChoices(resp *llmx.ChatResponse, choices []llmx.Choice) *llmx.ChatResponse {
+	resp.Choices = choices
+	if len(choices) > 0 {
+		resp.Content = choices[0].Content
+		resp.ToolCalls = choices[0].ToolCalls
+		resp.FinishReason = choices[0].FinishReason
+		resp.RawFinishReason = choices[0].RawFinishReason
+	}
+	return resp
+}
@@ -0,0 +1,314 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var allFormats = []Format{OpenAI, Anthropic, Gemini, Cohere}
+
+func float(v float64) *float64 { return &v }
+func integer(v int) *int       { return &v }
+
+var requestCases = map[string]*llmx.ChatRequest{
+	"chat": {
+		Model: "model-1",
+		Messages: []llmx.Message{
+			textMessage(llmx.RoleSystem, "You are terse."),
+			textMessage(llmx.RoleUser, "Hello"),
+			textMessage(llmx.RoleAssistant, "Hi! How can I help?"),
+			textMessage(llmx.RoleUser, "Tell me a joke"),
+		},
+		Temperature: float(0.5),
+		MaxTokens:   integer(256),
+		Stop:        []string{"\n\n"},
+	},
+	"tools": {
+		Model: "model-1",
+		Messages: []llmx.Message{
+			textMessage(llmx.RoleUser, "What's the weather in Paris?"),
+			{
+				Role: llmx.RoleAssistant,
+				ToolCalls: []llmx.ToolCall{{
+					ID:        "call_1",
+					Name:      "get_weather",
+					Arguments: json.RawMessage(`{"city":"Paris"}`),
+				}},
+			},
+			{
+				Role: llmx.RoleTool,
+				Content: []llmx.ContentPart{
+					llmx.ToolResultPart{ToolCallID: "call_1", Result: `{"temperature":18}`},
+				},
+			},
+			textMessage(llmx.RoleUser, "And in Celsius?"),
+		},
+		Tools: []llmx.Tool{{
+			Name:        "get_weather",
+			Description: "Get the current weather",
+			Parameters: &llmx.Schema{
+				Type: "object",
+				Properties: map[string]*llmx.Schema{
+					"city": {Type: "string", Description: "City name"},
+				},
+				Required: []string{"city"},
+			},
+		}},
+	},
+}
+
+var responseCases = map[string]*llmx.ChatResponse{
+	"text": {
+		ID:           "resp_1",
+		Model:        "model-1",
+		CreatedAt:    time.Unix(1700000000, 0),
+		Content:      "Why did the gopher cross the road?",
+		FinishReason: llmx.FinishReasonStop,
+		Usage:        llmx.Usage{PromptTokens: 12, CompletionTokens: 9, TotalTokens: 21},
+	},
+	"tool_calls": {
+		ID:        "resp_2",
+		Model:     "model-1",
+		CreatedAt: time.Unix(1700000000, 0),
+		ToolCalls: []llmx.ToolCall{{
+			ID:        "get_weather_0",
+			Name:      "get_weather",
+			Arguments: json.RawMessage(`{"city":"Paris"}`),
+		}},
+		FinishReason: llmx.FinishReasonToolCalls,
+		Usage:        llmx.Usage{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25},
+	},
+}
+
+// golden compares data with a golden file, rewriting it with -update
+func golden(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	indented.WriteByte('\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, indented.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(indented.Bytes(), want) {
+		t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, indented.Bytes(), want)
+	}
+}
+
+// compact strips the whitespace of a golden file
+func compact(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := json.Compact(&out, data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestFormats_Requests(t *testing.T) {
+	for _, f := range allFormats {
+		for name, req := range requestCases {
+			t.Run(f.Name()+"/"+name, func(t *testing.T) {
+				path := filepath.Join("testdata", f.Name(), name+"_request.json")
+
+				data, err := f.MarshalRequest(req)
+				if err != nil {
+					t.Fatalf("MarshalRequest() error = %v", err)
+				}
+				golden(t, path, data)
+
+				// Decoding the golden file and encoding it again must
+				// reproduce it
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := f.UnmarshalRequest(want)
+				if err != nil {
+					t.Fatalf("UnmarshalRequest() error = %v", err)
+				}
+				again, err := f.MarshalRequest(decoded)
+				if err != nil {
+					t.Fatalf("MarshalRequest() of decoded request error = %v", err)
+				}
+				if !bytes.Equal(again, compact(t, want)) {
+					t.Errorf("round trip changed the request\ngot:  %s\nwant: %s", again, compact(t, want))
+				}
+				if len(decoded.Messages) != len(req.Messages) {
+					t.Errorf("decoded %d messages, want %d", len(decoded.Messages), len(req.Messages))
+				}
+			})
+		}
+	}
+}
+
+func TestFormats_Responses(t *testing.T) {
+	for _, f := range allFormats {
+		for name, resp := range responseCases {
+			t.Run(f.Name()+"/"+name, func(t *testing.T) {
+				path := filepath.Join("testdata", f.Name(), name+"_response.json")
+
+				data, err := f.MarshalResponse(resp)
+				if err != nil {
+					t.Fatalf("MarshalResponse() error = %v", err)
+				}
+				golden(t, path, data)
+
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := f.UnmarshalResponse(want)
+				if err != nil {
+					t.Fatalf("UnmarshalResponse() error = %v", err)
+				}
+				if decoded.Content != resp.Content {
+					t.Errorf("Content = %q, want %q", decoded.Content, resp.Content)
+				}
+				if decoded.FinishReason != resp.FinishReason {
+					t.Errorf("FinishReason = %q, want %q", decoded.FinishReason, resp.FinishReason)
+				}
+				if len(decoded.ToolCalls) != len(resp.ToolCalls) {
+					t.Fatalf("decoded %d tool calls, want %d", len(decoded.ToolCalls), len(resp.ToolCalls))
+				}
+				for i, call := range decoded.ToolCalls {
+					if call.Name != resp.ToolCalls[i].Name || !bytes.Equal(compact(t, call.Arguments), resp.ToolCalls[i].Arguments) {
+						t.Errorf("ToolCalls[%d] = %+v, want %+v", i, call, resp.ToolCalls[i])
+					}
+				}
+				if decoded.Usage.PromptTokens != resp.Usage.PromptTokens || decoded.Usage.CompletionTokens != resp.Usage.CompletionTokens {
+					t.Errorf("Usage = %+v, want %+v", decoded.Usage, resp.Usage)
+				}
+			})
+		}
+	}
+}
+
+func TestFormats_ToolCallIDs(t *testing.T) {
+	// Gemini and Cohere calls have no IDs; results must still be paired
+	// with the call they answer
+	for _, f := range []Format{Gemini, Cohere} {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := f.MarshalRequest(requestCases["tools"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := f.UnmarshalRequest(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			call := req.Messages[1].ToolCalls[0]
+			result := toolResults(req.Messages[2])[0]
+			if call.ID == "" || result.ToolCallID != call.ID {
+				t.Errorf("result for %q, want call ID %q", result.ToolCallID, call.ID)
+			}
+			if result.Result != `{"temperature":18}` {
+				t.Errorf("Result = %q", result.Result)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, f := range allFormats {
+		got, ok := Lookup(f.Name())
+		if !ok || got != f {
+			t.Errorf("Lookup(%q) = %v, %v", f.Name(), got, ok)
+		}
+	}
+	if _, ok := Lookup("unknown"); ok {
+		t.Error("Lookup(\"unknown\") succeeded")
+	}
+}
+
+func TestUnmarshalRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		format Format
+		body   string
+	}{
+		{OpenAI, `{"messages": [{"role": "robot", "content": "hi"}]}`},
+		{OpenAI, `{"messages": [], "stop": 1}`},
+		{Anthropic, `{"messages": [{"role": "system", "content": "hi"}]}`},
+		{Gemini, `{"contents": [{"role": "robot", "parts": [{"text": "hi"}]}]}`},
+		{Cohere, `{"message": "hi", "chat_history": [{"role": "ROBOT"}]}`},
+		{Cohere, `not json`},
+	}
+	for _, tt := range tests {
+		_, err := tt.format.UnmarshalRequest([]byte(tt.body))
+		if !errors.Is(err, llmx.ErrInvalidRequest) {
+			t.Errorf("%s: UnmarshalRequest(%s) error = %v, want an invalid request error", tt.format.Name(), tt.body, err)
+		}
+	}
+}
+
+func TestAnthropicToolChoiceFrom(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name string
+		req  *llmx.ChatRequest
+		want *AnthropicToolChoice
+	}{
+		{
+			name: "unset",
+			req:  &llmx.ChatRequest{},
+			want: nil,
+		},
+		{
+			name: "required",
+			req:  &llmx.ChatRequest{ToolChoice: &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}},
+			want: &AnthropicToolChoice{Type: "any"},
+		},
+		{
+			name: "named tool without parallel calls",
+			req:  &llmx.ChatRequest{ToolChoice: llmx.ForceTool("search"), ParallelToolCalls: &disabled},
+			want: &AnthropicToolChoice{Type: "tool", Name: "search", DisableParallelToolUse: true},
+		},
+		{
+			name: "parallel calls disabled only",
+			req:  &llmx.ChatRequest{ParallelToolCalls: &disabled},
+			want: &AnthropicToolChoice{Type: "auto", DisableParallelToolUse: true},
+		},
+		{
+			name: "none",
+			req:  &llmx.ChatRequest{ToolChoice: &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}, ParallelToolCalls: &disabled},
+			want: &AnthropicToolChoice{Type: "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnthropicToolChoiceFrom(tt.req)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("AnthropicToolChoiceFrom() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && *got != *tt.want {
+				t.Errorf("AnthropicToolChoiceFrom() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
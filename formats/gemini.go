@@ -0,0 +1,489 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// GeminiRequest is the body of a generateContent or streamGenerateContent
+// request; the model is named in the URL
+type GeminiRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig        *GeminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiContent is a turn of the conversation
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart is one part of a content; exactly one field is set
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *GeminiBlob             `json:"inlineData,omitempty"`
+	FileData         *GeminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiBlob is inline base64 data
+type GeminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// GeminiFileData references data by URI
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// GeminiFunctionCall is a call the model made
+type GeminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse is the result of a function call
+type GeminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// GeminiTool holds function declarations
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+// GeminiFunctionDeclaration declares a function the model may call.
+// Parameters use Gemini's OpenAPI subset; ParametersJSONSchema takes
+// plain JSON Schema.
+type GeminiFunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	Parameters           json.RawMessage `json:"parameters,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+// GeminiToolConfig configures function calling
+type GeminiToolConfig struct {
+	FunctionCallingConfig *GeminiFunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// GeminiFunctionCallingConfig selects the function calling mode
+type GeminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GeminiGenerationConfig holds the sampling parameters
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+	CandidateCount  *int     `json:"candidateCount,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
+}
+
+// GeminiResponse is a generateContent response, or one chunk of a stream
+type GeminiResponse struct {
+	Candidates    []GeminiCandidate `json:"candidates"`
+	UsageMetadata *GeminiUsage      `json:"usageMetadata,omitempty"`
+	ModelVersion  string            `json:"modelVersion,omitempty"`
+	ResponseID    string            `json:"responseId,omitempty"`
+}
+
+// GeminiCandidate is one completion
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
+	Index        int           `json:"index"`
+}
+
+// GeminiUsage reports token counts
+type GeminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// ToGeminiRequest converts a request to the generateContent format. Tool
+// results are sent as function responses of a user turn, wrapped as
+// {"output": ...} or, for errors, {"error": ...}.
+func ToGeminiRequest(req *llmx.ChatRequest) (*GeminiRequest, error) {
+	wire := &GeminiRequest{Contents: []GeminiContent{}}
+
+	if req.Temperature != nil || req.TopP != nil || req.TopK != nil || req.MaxTokens != nil ||
+		len(req.Stop) > 0 || req.N != nil || req.Seed != nil {
+		wire.GenerationConfig = &GeminiGenerationConfig{
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+			TopK:            req.TopK,
+			MaxOutputTokens: req.MaxTokens,
+			StopSequences:   req.Stop,
+			CandidateCount:  req.N,
+			Seed:            req.Seed,
+		}
+	}
+
+	toolNames := make(map[string]string)
+	for i, msg := range req.Messages {
+		if msg.Role == llmx.RoleSystem {
+			if wire.SystemInstruction == nil {
+				wire.SystemInstruction = &GeminiContent{}
+			}
+			wire.SystemInstruction.Parts = append(wire.SystemInstruction.Parts, GeminiPart{Text: llmx.ExtractText(msg)})
+			continue
+		}
+
+		content, err := toGeminiContent(msg, toolNames)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("messages[%d]: %v", i, err), "contents")
+		}
+		wire.Contents = append(wire.Contents, content)
+	}
+
+	var declarations []GeminiFunctionDeclaration
+	for _, t := range req.Tools {
+		params, err := encodeSchema(uppercaseTypes(t.Parameters))
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("function %s: invalid parameters: %v", t.Name, err), "tools")
+		}
+		declarations = append(declarations, GeminiFunctionDeclaration{Name: t.Name, Description: t.Description, Parameters: params})
+	}
+	if len(declarations) > 0 {
+		wire.Tools = []GeminiTool{{FunctionDeclarations: declarations}}
+	}
+
+	if c := req.ToolChoice; c != nil {
+		config := &GeminiFunctionCallingConfig{Mode: "AUTO"}
+		switch c.Mode {
+		case llmx.ToolChoiceNone:
+			config.Mode = "NONE"
+		case llmx.ToolChoiceRequired:
+			config.Mode = "ANY"
+		case llmx.ToolChoiceFunction:
+			config.Mode = "ANY"
+			config.AllowedFunctionNames = []string{c.Name}
+		}
+		wire.ToolConfig = &GeminiToolConfig{FunctionCallingConfig: config}
+	}
+
+	return wire, nil
+}
+
+func toGeminiContent(msg llmx.Message, toolNames map[string]string) (GeminiContent, error) {
+	content := GeminiContent{Role: "user", Parts: []GeminiPart{}}
+	if msg.Role == llmx.RoleAssistant {
+		content.Role = "model"
+	}
+
+	for _, part := range msg.Content {
+		switch p := part.(type) {
+		case llmx.TextPart:
+			content.Parts = append(content.Parts, GeminiPart{Text: p.Text})
+		case llmx.ImagePart:
+			if p.Base64 != "" {
+				mediaType, data := splitImage(p)
+				content.Parts = append(content.Parts, GeminiPart{InlineData: &GeminiBlob{MimeType: mediaType, Data: data}})
+			} else {
+				content.Parts = append(content.Parts, GeminiPart{FileData: &GeminiFileData{FileURI: p.URL}})
+			}
+		case llmx.ToolResultPart:
+			name, ok := toolNames[p.ToolCallID]
+			if !ok {
+				name = p.ToolCallID
+			}
+			response, err := geminiFunctionResponse(p)
+			if err != nil {
+				return GeminiContent{}, err
+			}
+			content.Parts = append(content.Parts, GeminiPart{FunctionResponse: &GeminiFunctionResponse{ID: p.ToolCallID, Name: name, Response: response}})
+		case llmx.ToolCall:
+		default:
+			return GeminiContent{}, fmt.Errorf("unsupported content part type %q", part.Type())
+		}
+	}
+
+	for _, call := range messageToolCalls(msg) {
+		toolNames[call.ID] = call.Name
+		content.Parts = append(content.Parts, GeminiPart{FunctionCall: GeminiFunctionCallFrom(call)})
+	}
+	return content, nil
+}
+
+// geminiFunctionResponse wraps a tool result, embedding results that are
+// JSON objects
+func geminiFunctionResponse(result llmx.ToolResultPart) (json.RawMessage, error) {
+	key := "output"
+	if result.IsError {
+		key = "error"
+	}
+	var value interface{} = result.Result
+	if trimmed := strings.TrimSpace(result.Result); strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		value = json.RawMessage(trimmed)
+	}
+	return json.Marshal(map[string]interface{}{key: value})
+}
+
+// GeminiFunctionCallFrom converts a tool call to a function call
+func GeminiFunctionCallFrom(call llmx.ToolCall) *GeminiFunctionCall {
+	return &GeminiFunctionCall{ID: call.ID, Name: call.Name, Args: arguments(call.Arguments)}
+}
+
+// uppercaseTypes returns a copy of s with Gemini's upper-case type names
+func uppercaseTypes(s *llmx.Schema) *llmx.Schema {
+	if s == nil {
+		return nil
+	}
+	converted := *s
+	converted.Type = strings.ToUpper(s.Type)
+	if s.Properties != nil {
+		converted.Properties = make(map[string]*llmx.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			converted.Properties[name] = uppercaseTypes(prop)
+		}
+	}
+	converted.Items = uppercaseTypes(s.Items)
+	return &converted
+}
+
+// FromGeminiRequest converts a generateContent request for model
+func FromGeminiRequest(r *GeminiRequest, model string) (*llmx.ChatRequest, error) {
+	req := &llmx.ChatRequest{Model: model}
+
+	if c := r.GenerationConfig; c != nil {
+		req.Temperature = c.Temperature
+		req.TopP = c.TopP
+		req.TopK = c.TopK
+		req.MaxTokens = c.MaxOutputTokens
+		req.Stop = c.StopSequences
+		req.N = c.CandidateCount
+		req.Seed = c.Seed
+	}
+
+	if r.SystemInstruction != nil {
+		var texts []string
+		for _, p := range r.SystemInstruction.Parts {
+			texts = append(texts, p.Text)
+		}
+		req.Messages = append(req.Messages, llmx.Message{
+			Role:    llmx.RoleSystem,
+			Content: []llmx.ContentPart{llmx.TextPart{Text: strings.Join(texts, "\n")}},
+		})
+	}
+
+	calls := newCallIDs()
+	for i, c := range r.Contents {
+		msgs, err := c.toMessages(calls)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("contents[%d]: %v", i, err), "contents")
+		}
+		req.Messages = append(req.Messages, msgs...)
+	}
+
+	for _, t := range r.Tools {
+		for _, fn := range t.FunctionDeclarations {
+			schema := fn.Parameters
+			if len(fn.ParametersJSONSchema) > 0 {
+				schema = fn.ParametersJSONSchema
+			}
+			params, err := decodeSchema(schema)
+			if err != nil {
+				return nil, invalidParam(fmt.Sprintf("function %s: invalid parameters: %v", fn.Name, err), "tools")
+			}
+			lowercaseTypes(params)
+			req.Tools = append(req.Tools, llmx.Tool{Name: fn.Name, Description: fn.Description, Parameters: params})
+		}
+	}
+
+	if r.ToolConfig != nil && r.ToolConfig.FunctionCallingConfig != nil {
+		config := r.ToolConfig.FunctionCallingConfig
+		switch config.Mode {
+		case "", "MODE_UNSPECIFIED":
+		case "AUTO":
+			req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceAuto}
+		case "NONE":
+			req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}
+		case "ANY", "VALIDATED":
+			if len(config.AllowedFunctionNames) == 1 {
+				req.ToolChoice = llmx.ForceTool(config.AllowedFunctionNames[0])
+			} else {
+				req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}
+			}
+		default:
+			return nil, invalidParam("invalid function calling mode: "+config.Mode, "toolConfig")
+		}
+	}
+
+	return req, nil
+}
+
+// lowercaseTypes rewrites Gemini's upper-case schema types ("OBJECT") to
+// JSON Schema ones
+func lowercaseTypes(s *llmx.Schema) {
+	if s == nil {
+		return
+	}
+	s.Type = strings.ToLower(s.Type)
+	for _, prop := range s.Properties {
+		lowercaseTypes(prop)
+	}
+	lowercaseTypes(s.Items)
+}
+
+// toMessages converts one content. Function responses become tool
+// messages ahead of the rest of the content.
+func (c *GeminiContent) toMessages(calls *callIDs) ([]llmx.Message, error) {
+	var role llmx.MessageRole
+	switch c.Role {
+	case "", "user", "function":
+		role = llmx.RoleUser
+	case "model":
+		role = llmx.RoleAssistant
+	default:
+		return nil, fmt.Errorf("unsupported role %q", c.Role)
+	}
+
+	var msgs []llmx.Message
+	msg := llmx.Message{Role: role}
+	for _, p := range c.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			msg.ToolCalls = append(msg.ToolCalls, llmx.ToolCall{
+				ID:        calls.call(p.FunctionCall.ID, p.FunctionCall.Name),
+				Name:      p.FunctionCall.Name,
+				Arguments: arguments(p.FunctionCall.Args),
+			})
+
+		case p.FunctionResponse != nil:
+			result := toolResult(p.FunctionResponse.Response)
+			result.ToolCallID = calls.response(p.FunctionResponse.ID, p.FunctionResponse.Name)
+			msgs = append(msgs, llmx.Message{Role: llmx.RoleTool, Content: []llmx.ContentPart{result}})
+
+		case p.InlineData != nil:
+			msg.Content = append(msg.Content, llmx.ImagePart{Base64: dataURL(p.InlineData.MimeType, p.InlineData.Data)})
+
+		case p.FileData != nil:
+			msg.Content = append(msg.Content, llmx.ImagePart{URL: p.FileData.FileURI})
+
+		default:
+			msg.Content = append(msg.Content, llmx.TextPart{Text: p.Text})
+		}
+	}
+
+	if len(msg.Content) > 0 || len(msg.ToolCalls) > 0 {
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// toolResult unwraps a function response written by ToGeminiRequest.
+// Other responses are kept as raw JSON.
+func toolResult(response json.RawMessage) llmx.ToolResultPart {
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(response, &wrapped); err == nil && len(wrapped) == 1 {
+		for key, value := range wrapped {
+			if key != "output" && key != "error" {
+				break
+			}
+			result := llmx.ToolResultPart{Result: string(value), IsError: key == "error"}
+			var text string
+			if err := json.Unmarshal(value, &text); err == nil {
+				result.Result = text
+			}
+			return result
+		}
+	}
+	return llmx.ToolResultPart{Result: string(bytes.TrimSpace(response))}
+}
+
+// ToGeminiResponse converts a response with a candidate per choice
+func ToGeminiResponse(resp *llmx.ChatResponse) *GeminiResponse {
+	wire := &GeminiResponse{
+		UsageMetadata: &GeminiUsage{
+			PromptTokenCount:     resp.Usage.PromptTokens,
+			CandidatesTokenCount: resp.Usage.CompletionTokens,
+			TotalTokenCount:      resp.Usage.TotalTokens,
+		},
+		ModelVersion: resp.Model,
+		ResponseID:   resp.ID,
+	}
+	for i, c := range choices(resp) {
+		parts := []GeminiPart{}
+		if c.Content != "" {
+			parts = append(parts, GeminiPart{Text: c.Content})
+		}
+		for _, call := range c.ToolCalls {
+			parts = append(parts, GeminiPart{FunctionCall: GeminiFunctionCallFrom(call)})
+		}
+		wire.Candidates = append(wire.Candidates, GeminiCandidate{
+			Content:      GeminiContent{Role: "model", Parts: parts},
+			FinishReason: GeminiFinishReason(c.FinishReason, len(c.ToolCalls) > 0),
+			Index:        i,
+		})
+	}
+	return wire
+}
+
+// GeminiFinishReason maps a finish reason to a Gemini one
+func GeminiFinishReason(reason llmx.FinishReason, hasToolCalls bool) string {
+	switch OpenAIFinishReason(reason, hasToolCalls) {
+	case "length":
+		return "MAX_TOKENS"
+	case "content_filter":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// FromGeminiResponse converts a generateContent response. Function calls
+// without an ID get one derived from the name and position.
+func FromGeminiResponse(r *GeminiResponse) (*llmx.ChatResponse, error) {
+	resp := &llmx.ChatResponse{ID: r.ResponseID, Model: r.ModelVersion}
+	if u := r.UsageMetadata; u != nil {
+		resp.Usage = llmx.Usage{
+			PromptTokens:     u.PromptTokenCount,
+			CompletionTokens: u.CandidatesTokenCount,
+			TotalTokens:      u.TotalTokenCount,
+		}
+	}
+
+	converted := make([]llmx.Choice, 0, len(r.Candidates))
+	for _, c := range r.Candidates {
+		choice := llmx.Choice{
+			Index:           c.Index,
+			RawFinishReason: c.FinishReason,
+			FinishReason:    llmx.NormalizeFinishReason(c.FinishReason),
+		}
+		for _, p := range c.Content.Parts {
+			if call := p.FunctionCall; call != nil {
+				id := call.ID
+				if id == "" {
+					id = fmt.Sprintf("%s_%d", call.Name, len(choice.ToolCalls))
+				}
+				choice.ToolCalls = append(choice.ToolCalls, llmx.ToolCall{ID: id, Name: call.Name, Arguments: arguments(call.Args)})
+				continue
+			}
+			choice.Content += p.Text
+		}
+		if choice.FinishReason == llmx.FinishReasonStop && len(choice.ToolCalls) > 0 {
+			choice.FinishReason = llmx.FinishReasonToolCalls
+		}
+		converted = append(converted, choice)
+	}
+	return withChoices(resp, converted), nil
+}
@@ -0,0 +1,593 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// OpenAIRequest is the body of a Chat Completions request
+type OpenAIRequest struct {
+	Model               string               `json:"model"`
+	Messages            []OpenAIMessage      `json:"messages"`
+	Temperature         *float64             `json:"temperature,omitempty"`
+	TopP                *float64             `json:"top_p,omitempty"`
+	MaxTokens           *int                 `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int                 `json:"max_completion_tokens,omitempty"`
+	Stop                json.RawMessage      `json:"stop,omitempty"`
+	N                   *int                 `json:"n,omitempty"`
+	Seed                *int                 `json:"seed,omitempty"`
+	Logprobs            bool                 `json:"logprobs,omitempty"`
+	TopLogprobs         *int                 `json:"top_logprobs,omitempty"`
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *OpenAIStreamOptions `json:"stream_options,omitempty"`
+	Tools               []OpenAITool         `json:"tools,omitempty"`
+	ToolChoice          json.RawMessage      `json:"tool_choice,omitempty"`
+	ParallelToolCalls   *bool                `json:"parallel_tool_calls,omitempty"`
+}
+
+// OpenAIStreamOptions configures a streamed completion
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// OpenAIMessage is a request message. Content is a string or an array of
+// OpenAIContentPart.
+type OpenAIMessage struct {
+	Role       string           `json:"role"`
+	Content    json.RawMessage  `json:"content,omitempty"`
+	Name       string           `json:"name,omitempty"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIContentPart is a part of array message content
+type OpenAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+}
+
+// OpenAIImageURL references an image by URL or data URL
+type OpenAIImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OpenAITool is a function tool
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction declares a function the model may call
+type OpenAIFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// OpenAIToolCall is a function call; stream deltas carry an index
+type OpenAIToolCall struct {
+	Index    *int               `json:"index,omitempty"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function OpenAIFunctionCall `json:"function"`
+}
+
+// OpenAIFunctionCall names the called function and its JSON arguments
+type OpenAIFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// OpenAIResponse is a chat.completion response, or a
+// chat.completion.chunk of a stream
+type OpenAIResponse struct {
+	ID                string         `json:"id"`
+	Object            string         `json:"object"`
+	Created           int64          `json:"created"`
+	Model             string         `json:"model"`
+	SystemFingerprint string         `json:"system_fingerprint,omitempty"`
+	Choices           []OpenAIChoice `json:"choices"`
+	Usage             *OpenAIUsage   `json:"usage,omitempty"`
+}
+
+// OpenAIChoice is one completion; Delta replaces Message in stream chunks
+type OpenAIChoice struct {
+	Index        int                    `json:"index"`
+	Message      *OpenAIResponseMessage `json:"message,omitempty"`
+	Delta        *OpenAIResponseMessage `json:"delta,omitempty"`
+	Logprobs     *OpenAILogprobs        `json:"logprobs,omitempty"`
+	FinishReason *string                `json:"finish_reason"`
+}
+
+// OpenAIResponseMessage is the message of a choice
+type OpenAIResponseMessage struct {
+	Role      string           `json:"role,omitempty"`
+	Content   *string          `json:"content,omitempty"`
+	ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
+}
+
+// OpenAILogprobs holds the log probabilities of a choice's tokens
+type OpenAILogprobs struct {
+	Content []OpenAITokenLogprob `json:"content"`
+}
+
+// OpenAITokenLogprob is the log probability of a sampled token and of its
+// most likely alternatives
+type OpenAITokenLogprob struct {
+	Token       string             `json:"token"`
+	Logprob     float64            `json:"logprob"`
+	Bytes       OpenAIBytes        `json:"bytes"`
+	TopLogprobs []OpenAITopLogprob `json:"top_logprobs"`
+}
+
+// OpenAITopLogprob is the log probability of an alternative token
+type OpenAITopLogprob struct {
+	Token   string      `json:"token"`
+	Logprob float64     `json:"logprob"`
+	Bytes   OpenAIBytes `json:"bytes"`
+}
+
+// OpenAIUsage reports token counts
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ToOpenAIRequest converts a request to the Chat Completions format. Each
+// tool result becomes a message of its own.
+func ToOpenAIRequest(req *llmx.ChatRequest) (*OpenAIRequest, error) {
+	wire := &OpenAIRequest{
+		Model:             req.Model,
+		Messages:          []OpenAIMessage{},
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		MaxTokens:         req.MaxTokens,
+		N:                 req.N,
+		Seed:              req.Seed,
+		Logprobs:          req.Logprobs,
+		TopLogprobs:       req.TopLogprobs,
+		ParallelToolCalls: req.ParallelToolCalls,
+	}
+	if len(req.Stop) > 0 {
+		stop, err := json.Marshal(req.Stop)
+		if err != nil {
+			return nil, err
+		}
+		wire.Stop = stop
+	}
+
+	for i, msg := range req.Messages {
+		msgs, err := toOpenAIMessages(msg)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("messages[%d]: %v", i, err), "messages")
+		}
+		wire.Messages = append(wire.Messages, msgs...)
+	}
+
+	for _, t := range req.Tools {
+		params, err := encodeSchema(t.Parameters)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("tool %s: invalid parameters: %v", t.Name, err), "tools")
+		}
+		wire.Tools = append(wire.Tools, OpenAITool{
+			Type:     "function",
+			Function: OpenAIFunction{Name: t.Name, Description: t.Description, Parameters: params},
+		})
+	}
+
+	if c := req.ToolChoice; c != nil {
+		var choice interface{} = string(c.Mode)
+		if c.Mode == llmx.ToolChoiceFunction {
+			choice = map[string]interface{}{"type": "function", "function": map[string]string{"name": c.Name}}
+		}
+		encoded, err := json.Marshal(choice)
+		if err != nil {
+			return nil, err
+		}
+		wire.ToolChoice = encoded
+	}
+
+	return wire, nil
+}
+
+func toOpenAIMessages(msg llmx.Message) ([]OpenAIMessage, error) {
+	if results := toolResults(msg); len(results) > 0 {
+		msgs := make([]OpenAIMessage, 0, len(results))
+		for _, result := range results {
+			content, _ := json.Marshal(result.Result)
+			msgs = append(msgs, OpenAIMessage{Role: "tool", Content: content, ToolCallID: result.ToolCallID})
+		}
+		return msgs, nil
+	}
+
+	wire := OpenAIMessage{Role: string(msg.Role)}
+	var parts []OpenAIContentPart
+	for _, part := range msg.Content {
+		switch p := part.(type) {
+		case llmx.TextPart:
+			parts = append(parts, OpenAIContentPart{Type: "text", Text: p.Text})
+		case llmx.ImagePart:
+			url := p.URL
+			if p.Base64 != "" {
+				mediaType, data := splitImage(p)
+				url = dataURL(mediaType, data)
+			}
+			parts = append(parts, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: url, Detail: p.Detail}})
+		case llmx.ToolCall:
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", part.Type())
+		}
+	}
+
+	var content interface{} = parts
+	if len(parts) == 1 && parts[0].Type == "text" {
+		content = parts[0].Text
+	}
+	if len(parts) > 0 {
+		encoded, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		wire.Content = encoded
+	}
+
+	for i, call := range messageToolCalls(msg) {
+		wire.ToolCalls = append(wire.ToolCalls, OpenAIToolCallFrom(i, call, false))
+	}
+	return []OpenAIMessage{wire}, nil
+}
+
+// OpenAIToolCallFrom converts a tool call; withIndex sets the index stream
+// deltas carry
+func OpenAIToolCallFrom(index int, call llmx.ToolCall, withIndex bool) OpenAIToolCall {
+	converted := OpenAIToolCall{
+		ID:       call.ID,
+		Type:     "function",
+		Function: OpenAIFunctionCall{Name: call.Name, Arguments: string(arguments(call.Arguments))},
+	}
+	if withIndex {
+		converted.Index = &index
+	}
+	return converted
+}
+
+// FromOpenAIRequest converts a Chat Completions request. Fields llmx
+// cannot forward are ignored, as OpenAI clients send many defaults.
+func FromOpenAIRequest(r *OpenAIRequest) (*llmx.ChatRequest, error) {
+	req := &llmx.ChatRequest{
+		Model:             r.Model,
+		Temperature:       r.Temperature,
+		TopP:              r.TopP,
+		MaxTokens:         r.MaxTokens,
+		N:                 r.N,
+		Seed:              r.Seed,
+		Logprobs:          r.Logprobs,
+		TopLogprobs:       r.TopLogprobs,
+		ParallelToolCalls: r.ParallelToolCalls,
+	}
+	if r.MaxCompletionTokens != nil {
+		req.MaxTokens = r.MaxCompletionTokens
+	}
+
+	stop, err := decodeStop(r.Stop)
+	if err != nil {
+		return nil, err
+	}
+	req.Stop = stop
+
+	for i, m := range r.Messages {
+		msg, err := m.toMessage()
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("messages[%d]: %v", i, err), "messages")
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+
+	for _, t := range r.Tools {
+		if t.Type != "function" {
+			return nil, invalidParam("unsupported tool type: "+t.Type, "tools")
+		}
+		params, err := decodeSchema(t.Function.Parameters)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("tool %s: invalid parameters: %v", t.Function.Name, err), "tools")
+		}
+		req.Tools = append(req.Tools, llmx.Tool{Name: t.Function.Name, Description: t.Function.Description, Parameters: params})
+	}
+
+	choice, err := decodeToolChoice(r.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	req.ToolChoice = choice
+
+	return req, nil
+}
+
+// decodeStop accepts a single stop sequence or an array of them
+func decodeStop(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, invalidParam("stop must be a string or an array of strings", "stop")
+	}
+	return many, nil
+}
+
+// decodeToolChoice accepts "auto", "none", "required" or a named function
+func decodeToolChoice(raw json.RawMessage) (*llmx.ToolChoice, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch llmx.ToolChoiceMode(mode) {
+		case llmx.ToolChoiceAuto, llmx.ToolChoiceNone, llmx.ToolChoiceRequired:
+			return &llmx.ToolChoice{Mode: llmx.ToolChoiceMode(mode)}, nil
+		}
+		return nil, invalidParam("invalid tool_choice: "+mode, "tool_choice")
+	}
+
+	var named struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &named); err != nil || named.Type != "function" {
+		return nil, invalidParam("invalid tool_choice", "tool_choice")
+	}
+	return llmx.ForceTool(named.Function.Name), nil
+}
+
+func (m *OpenAIMessage) toMessage() (llmx.Message, error) {
+	switch m.Role {
+	case "system", "developer":
+		text, err := m.text()
+		if err != nil {
+			return llmx.Message{}, err
+		}
+		return llmx.Message{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: text}}}, nil
+
+	case "user":
+		parts, err := m.parts()
+		if err != nil {
+			return llmx.Message{}, err
+		}
+		return llmx.Message{Role: llmx.RoleUser, Content: parts}, nil
+
+	case "assistant":
+		parts, err := m.parts()
+		if err != nil {
+			return llmx.Message{}, err
+		}
+		msg := llmx.Message{Role: llmx.RoleAssistant, Content: parts}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, llmx.ToolCall{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: arguments(json.RawMessage(call.Function.Arguments)),
+			})
+		}
+		return msg, nil
+
+	case "tool":
+		text, err := m.text()
+		if err != nil {
+			return llmx.Message{}, err
+		}
+		return llmx.Message{Role: llmx.RoleTool, Content: []llmx.ContentPart{
+			llmx.ToolResultPart{ToolCallID: m.ToolCallID, Result: text},
+		}}, nil
+
+	default:
+		return llmx.Message{}, fmt.Errorf("unsupported role %q", m.Role)
+	}
+}
+
+// parts decodes string or array content
+func (m *OpenAIMessage) parts() ([]llmx.ContentPart, error) {
+	if len(m.Content) == 0 || bytes.Equal(m.Content, []byte("null")) {
+		return nil, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return []llmx.ContentPart{llmx.TextPart{Text: text}}, nil
+	}
+
+	var raw []OpenAIContentPart
+	if err := json.Unmarshal(m.Content, &raw); err != nil {
+		return nil, fmt.Errorf("content must be a string or an array of parts")
+	}
+
+	parts := make([]llmx.ContentPart, 0, len(raw))
+	for _, p := range raw {
+		switch p.Type {
+		case "text":
+			parts = append(parts, llmx.TextPart{Text: p.Text})
+		case "image_url":
+			if p.ImageURL == nil {
+				return nil, fmt.Errorf("image_url part without image_url")
+			}
+			parts = append(parts, imageFromURL(p.ImageURL.URL, p.ImageURL.Detail))
+		default:
+			return nil, fmt.Errorf("unsupported content part type %q", p.Type)
+		}
+	}
+	return parts, nil
+}
+
+// text joins the text of string or array content
+func (m *OpenAIMessage) text() (string, error) {
+	parts, err := m.parts()
+	if err != nil {
+		return "", err
+	}
+	var text string
+	for _, p := range parts {
+		t, ok := p.(llmx.TextPart)
+		if !ok {
+			return "", fmt.Errorf("%s messages may only contain text", m.Role)
+		}
+		text += t.Text
+	}
+	return text, nil
+}
+
+// ToOpenAIResponse converts a response to a chat.completion
+func ToOpenAIResponse(resp *llmx.ChatResponse) *OpenAIResponse {
+	wire := &OpenAIResponse{
+		ID:                resp.ID,
+		Object:            "chat.completion",
+		Model:             resp.Model,
+		SystemFingerprint: resp.Metadata.SystemFingerprint,
+		Usage: &OpenAIUsage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+	if !resp.CreatedAt.IsZero() {
+		wire.Created = resp.CreatedAt.Unix()
+	}
+
+	for i, c := range choices(resp) {
+		content := c.Content
+		msg := &OpenAIResponseMessage{Role: "assistant", Content: &content}
+		for j, call := range c.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, OpenAIToolCallFrom(j, call, false))
+		}
+		finish := OpenAIFinishReason(c.FinishReason, len(c.ToolCalls) > 0)
+		wire.Choices = append(wire.Choices, OpenAIChoice{
+			Index:        i,
+			Message:      msg,
+			Logprobs:     toOpenAILogprobs(c.Logprobs),
+			FinishReason: &finish,
+		})
+	}
+	return wire
+}
+
+func toOpenAILogprobs(logprobs []llmx.TokenLogprob) *OpenAILogprobs {
+	if len(logprobs) == 0 {
+		return nil
+	}
+	wire := &OpenAILogprobs{Content: make([]OpenAITokenLogprob, 0, len(logprobs))}
+	for _, lp := range logprobs {
+		token := OpenAITokenLogprob{
+			Token:       lp.Token,
+			Logprob:     lp.Logprob,
+			Bytes:       OpenAIBytes(lp.Bytes),
+			TopLogprobs: []OpenAITopLogprob{},
+		}
+		for _, top := range lp.TopLogprobs {
+			token.TopLogprobs = append(token.TopLogprobs, OpenAITopLogprob{Token: top.Token, Logprob: top.Logprob, Bytes: OpenAIBytes(top.Bytes)})
+		}
+		wire.Content = append(wire.Content, token)
+	}
+	return wire
+}
+
+// OpenAIFinishReason maps a finish reason to the OpenAI vocabulary
+func OpenAIFinishReason(reason llmx.FinishReason, hasToolCalls bool) string {
+	switch reason {
+	case llmx.FinishReasonStop, llmx.FinishReasonLength, llmx.FinishReasonToolCalls, llmx.FinishReasonContentFilter:
+		return string(reason)
+	}
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+// FromOpenAIResponse converts a chat.completion
+func FromOpenAIResponse(r *OpenAIResponse) (*llmx.ChatResponse, error) {
+	resp := &llmx.ChatResponse{
+		ID:       r.ID,
+		Model:    r.Model,
+		Metadata: llmx.ResponseMetadata{SystemFingerprint: r.SystemFingerprint},
+	}
+	if r.Created != 0 {
+		resp.CreatedAt = time.Unix(r.Created, 0)
+	}
+	if r.Usage != nil {
+		resp.Usage = llmx.Usage{
+			PromptTokens:     r.Usage.PromptTokens,
+			CompletionTokens: r.Usage.CompletionTokens,
+			TotalTokens:      r.Usage.TotalTokens,
+		}
+	}
+
+	converted := make([]llmx.Choice, 0, len(r.Choices))
+	for _, c := range r.Choices {
+		choice := llmx.Choice{Index: c.Index}
+		if msg := c.Message; msg != nil {
+			if msg.Content != nil {
+				choice.Content = *msg.Content
+			}
+			for _, call := range msg.ToolCalls {
+				choice.ToolCalls = append(choice.ToolCalls, llmx.ToolCall{
+					ID:        call.ID,
+					Name:      call.Function.Name,
+					Arguments: arguments(json.RawMessage(call.Function.Arguments)),
+				})
+			}
+		}
+		if c.FinishReason != nil {
+			choice.RawFinishReason = *c.FinishReason
+			choice.FinishReason = llmx.NormalizeFinishReason(*c.FinishReason)
+		}
+		if c.Logprobs != nil {
+			for _, lp := range c.Logprobs.Content {
+				token := llmx.TokenLogprob{Token: lp.Token, Logprob: lp.Logprob, Bytes: []byte(lp.Bytes)}
+				for _, top := range lp.TopLogprobs {
+					token.TopLogprobs = append(token.TopLogprobs, llmx.TopLogprob{Token: top.Token, Logprob: top.Logprob, Bytes: []byte(top.Bytes)})
+				}
+				choice.Logprobs = append(choice.Logprobs, token)
+			}
+		}
+		converted = append(converted, choice)
+	}
+	return withChoices(resp, converted), nil
+}
+
+// OpenAIBytes are the UTF-8 bytes of a token, encoded as the integer array
+// OpenAI uses. Decoding also accepts the base64 string encoding/json
+// writes for a []byte, as SDK types such as go-openai's do.
+type OpenAIBytes []byte
+
+// MarshalJSON encodes the bytes as an array of integers
+func (b OpenAIBytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+	ints := make([]int, len(b))
+	for i, v := range b {
+		ints[i] = int(v)
+	}
+	return json.Marshal(ints)
+}
+
+// UnmarshalJSON decodes an array of integers or a base64 string
+func (b *OpenAIBytes) UnmarshalJSON(data []byte) error {
+	var decoded []byte
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
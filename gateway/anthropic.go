@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// fromChatResponseAnthropic converts a response to a Messages API
+// response, filling in the fields providers may leave empty
+func fromChatResponseAnthropic(resp *llmx.ChatResponse, requestModel string) *formats.AnthropicResponse {
+	out := *resp
+	out.ID = messageID(resp.ID)
+	out.Model = responseModel(resp.Model, requestModel)
+	return formats.ToAnthropicResponse(&out)
+}
+
+func messageID(id string) string {
+	if id == "" {
+		return fmt.Sprintf("msg_%d", time.Now().UnixNano())
+	}
+	return id
+}
+
+func (g *Gateway) handleMessages(w http.ResponseWriter, r *http.Request) {
+	var body formats.AnthropicRequest
+	if err := readBody(w, r, &body); err != nil {
+		writeAnthropicError(w, err)
+		return
+	}
+
+	req, err := formats.FromAnthropicRequest(&body)
+	if err == nil {
+		err = g.authorize(r.Context(), req)
+	}
+	if err != nil {
+		writeAnthropicError(w, err)
+		return
+	}
+
+	if body.Stream {
+		stream, err := g.client.StreamChat(r.Context(), req)
+		if err != nil {
+			writeAnthropicError(w, err)
+			return
+		}
+		out := &anthropicEventWriter{events: eventWriter{w: w}, index: -1}
+		err = out.events.sendJSON("message_start", map[string]interface{}{
+			"type": "message_start",
+			"message": &formats.AnthropicResponse{
+				ID:      messageID(""),
+				Type:    "message",
+				Role:    "assistant",
+				Model:   req.Model,
+				Content: []formats.AnthropicResponseBlock{},
+			},
+		})
+		if err != nil {
+			stream.Close()
+			return
+		}
+		forwardStream(r.Context(), stream, out)
+		return
+	}
+
+	resp, err := g.client.Chat(r.Context(), req)
+	if err != nil {
+		writeAnthropicError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, fromChatResponseAnthropic(resp, req.Model))
+}
+
+// anthropicEventWriter writes a completion as Messages API stream events
+type anthropicEventWriter struct {
+	events eventWriter
+
+	// index is the index of the open content block, or of the last
+	// closed one if open is false
+	index int
+	open  bool
+}
+
+func (a *anthropicEventWriter) text(delta string) error {
+	if !a.open {
+		if err := a.startBlock(formats.AnthropicTextBlock("")); err != nil {
+			return err
+		}
+	}
+	return a.events.sendJSON("content_block_delta", map[string]interface{}{
+		"type":  "content_block_delta",
+		"index": a.index,
+		"delta": map[string]string{"type": "text_delta", "text": delta},
+	})
+}
+
+func (a *anthropicEventWriter) toolCall(_ int, call llmx.ToolCall) error {
+	if err := a.closeBlock(); err != nil {
+		return err
+	}
+	// The input is sent as a delta, so the block starts empty
+	block := formats.AnthropicToolUseBlock(call)
+	block.Input = json.RawMessage("{}")
+	if err := a.startBlock(block); err != nil {
+		return err
+	}
+
+	args := string(call.Arguments)
+	if args == "" {
+		args = "{}"
+	}
+	err := a.events.sendJSON("content_block_delta", map[string]interface{}{
+		"type":  "content_block_delta",
+		"index": a.index,
+		"delta": map[string]string{"type": "input_json_delta", "partial_json": args},
+	})
+	if err != nil {
+		return err
+	}
+	return a.closeBlock()
+}
+
+func (a *anthropicEventWriter) finish(reason string, usage llmx.Usage) error {
+	if err := a.closeBlock(); err != nil {
+		return err
+	}
+	err := a.events.sendJSON("message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": formats.AnthropicStopReason(llmx.FinishReason(reason), false), "stop_sequence": nil},
+		"usage": map[string]int{"output_tokens": usage.CompletionTokens},
+	})
+	if err != nil {
+		return err
+	}
+	return a.events.sendJSON("message_stop", map[string]string{"type": "message_stop"})
+}
+
+func (a *anthropicEventWriter) fail(err error) {
+	a.events.sendJSON("error", anthropicErrorResponse(err, errorStatus(err)))
+}
+
+func (a *anthropicEventWriter) startBlock(block formats.AnthropicResponseBlock) error {
+	a.index++
+	a.open = true
+	return a.events.sendJSON("content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         a.index,
+		"content_block": block,
+	})
+}
+
+func (a *anthropicEventWriter) closeBlock() error {
+	if !a.open {
+		return nil
+	}
+	a.open = false
+	return a.events.sendJSON("content_block_stop", map[string]interface{}{
+		"type":  "content_block_stop",
+		"index": a.index,
+	})
+}
+
+// writeAnthropicError writes err as a Messages API error response
+func writeAnthropicError(w http.ResponseWriter, err error) {
+	status := writeErrorHeaders(w, err)
+	writeJSON(w, status, anthropicErrorResponse(err, status))
+}
+
+func anthropicErrorResponse(err error, status int) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "error",
+		"error": map[string]string{
+			"type":    anthropicErrorType(status),
+			"message": err.Error(),
+		},
+	}
+}
+
+// anthropicErrorType maps an HTTP status to an Anthropic error type
+func anthropicErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+func TestGateway_Messages(t *testing.T) {
+	ts := newTestGateway(t)
+
+	req, _ := http.NewRequest("POST", ts.URL+"/v1/messages", strings.NewReader(`{
+		"model": "echo-large",
+		"max_tokens": 100,
+		"system": [{"type": "text", "text": "be brief"}],
+		"messages": [
+			{"role": "user", "content": "look it up"},
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "go"}}]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "found"}]},
+				{"type": "text", "text": "thanks"}
+			]}
+		]
+	}`))
+	req.Header.Set("x-api-key", "sk-all")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var msg formats.AnthropicResponse
+	decode(t, resp, &msg)
+	if msg.Type != "message" || msg.Role != "assistant" || len(msg.Content) != 1 || *msg.Content[0].Text != "thanks" {
+		t.Errorf("unexpected response %+v", msg)
+	}
+	if *msg.StopReason != "end_turn" || msg.Usage.InputTokens != 3 || msg.Usage.OutputTokens != 2 {
+		t.Errorf("unexpected stop reason or usage %+v", msg)
+	}
+
+	got := testProvider.lastRequest()
+	roles := make([]llmx.MessageRole, len(got.Messages))
+	for i, m := range got.Messages {
+		roles[i] = m.Role
+	}
+	want := []llmx.MessageRole{llmx.RoleSystem, llmx.RoleUser, llmx.RoleAssistant, llmx.RoleTool, llmx.RoleUser}
+	if len(roles) != len(want) {
+		t.Fatalf("expected roles %v, got %v", want, roles)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Fatalf("expected roles %v, got %v", want, roles)
+		}
+	}
+	if result := got.Messages[3].Content[0].(llmx.ToolResultPart); result.ToolCallID != "toolu_1" || result.Result != "found" {
+		t.Errorf("tool result not translated: %+v", result)
+	}
+}
+
+func TestGateway_MessagesToolUse(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1/messages", "sk-all", `{
+		"model": "echo-small",
+		"messages": [{"role": "user", "content": "find go"}],
+		"tools": [{"name": "lookup", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "any", "disable_parallel_tool_use": true}
+	}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var msg formats.AnthropicResponse
+	decode(t, resp, &msg)
+	if len(msg.Content) != 1 || msg.Content[0].Type != "tool_use" || string(msg.Content[0].Input) != `{"q":"go"}` {
+		t.Errorf("unexpected content %+v", msg.Content)
+	}
+	if *msg.StopReason != "tool_use" {
+		t.Errorf("expected stop reason tool_use, got %s", *msg.StopReason)
+	}
+
+	got := testProvider.lastRequest()
+	if got.ToolChoice.Mode != llmx.ToolChoiceRequired || got.ParallelToolCalls == nil || *got.ParallelToolCalls {
+		t.Errorf("tool choice not translated: %+v %v", got.ToolChoice, got.ParallelToolCalls)
+	}
+}
+
+func TestGateway_MessagesStream(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1/messages", "sk-all", `{
+		"model": "echo-small",
+		"messages": [{"role": "user", "content": "one two"}],
+		"stream": true
+	}`)
+
+	type event struct {
+		name string
+		data map[string]interface{}
+	}
+	var events []event
+	var name string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if n, ok := strings.CutPrefix(line, "event: "); ok {
+			name = n
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var v map[string]interface{}
+			if err := json.Unmarshal([]byte(data), &v); err != nil {
+				t.Fatalf("invalid event data %q: %v", data, err)
+			}
+			if v["type"] != name {
+				t.Errorf("event %s carries type %v", name, v["type"])
+			}
+			events = append(events, event{name, v})
+		}
+	}
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.name)
+	}
+	want := "message_start content_block_start content_block_delta content_block_delta content_block_stop " +
+		"content_block_start content_block_delta content_block_stop message_delta message_stop"
+	if strings.Join(names, " ") != want {
+		t.Fatalf("unexpected event sequence:\n got %s\nwant %s", strings.Join(names, " "), want)
+	}
+
+	if block := events[5].data["content_block"].(map[string]interface{}); block["type"] != "tool_use" || events[5].data["index"] != 1.0 {
+		t.Errorf("expected tool_use block at index 1, got %v", events[5].data)
+	}
+	if delta := events[6].data["delta"].(map[string]interface{}); delta["partial_json"] != `{"q":"go"}` {
+		t.Errorf("unexpected input delta %v", delta)
+	}
+	if delta := events[8].data["delta"].(map[string]interface{}); delta["stop_reason"] != "tool_use" {
+		t.Errorf("unexpected message delta %v", delta)
+	}
+}
+
+func TestGateway_MessagesErrors(t *testing.T) {
+	ts := newTestGateway(t)
+
+	tests := []struct {
+		name, key, body, errType string
+		status                   int
+	}{
+		{"missing key", "", `{}`, "authentication_error", http.StatusUnauthorized},
+		{"model not allowed", "sk-echo", `{"model": "other", "messages": [{"role": "user", "content": "hi"}]}`, "permission_error", http.StatusForbidden},
+		{"bad block", "sk-all", `{"messages": [{"role": "user", "content": [{"type": "video"}]}]}`, "invalid_request_error", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", ts.URL+"/v1/messages", strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("x-api-key", tt.key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, resp.StatusCode)
+			}
+
+			var body struct {
+				Type  string `json:"type"`
+				Error struct {
+					Type string `json:"type"`
+				} `json:"error"`
+			}
+			decode(t, resp, &body)
+			if body.Type != "error" || body.Error.Type != tt.errType {
+				t.Errorf("expected %s error, got %+v", tt.errType, body)
+			}
+		})
+	}
+}
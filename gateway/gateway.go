@@ -0,0 +1,332 @@
+// Package gateway serves an llmx.Client over HTTP in the OpenAI wire
+// format, so services in any language can share one client's provider,
+// middleware chain and telemetry.
+//
+// The Gateway answers /v1/chat/completions, streamed as server-sent events
+// when the request sets "stream", and /v1/models. For clients written
+// against other APIs it also accepts the Anthropic Messages API at
+// /v1/messages and Gemini's generateContent and streamGenerateContent at
+// /v1beta/models/{model}. Callers authenticate with virtual API keys, each
+// of which may be limited to a set of models.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// maxRequestBody bounds the size of a request body; inline images make
+// chat requests large
+const maxRequestBody = 32 << 20
+
+// APIKey is a virtual key handed out to gateway callers. It is unrelated
+// to the provider credentials the client is configured with.
+type APIKey struct {
+	// Key is the bearer token callers send
+	Key string `json:"key"`
+
+	// Name identifies the caller, e.g. in logs and middleware
+	Name string `json:"name"`
+
+	// Models lists the models the key may use. An entry ending in "*"
+	// matches any model with that prefix. An empty list allows all models.
+	Models []string `json:"models,omitempty"`
+}
+
+// Allows reports whether the key may use model
+func (k *APIKey) Allows(model string) bool {
+	if len(k.Models) == 0 {
+		return true
+	}
+	for _, pattern := range k.Models {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(model, prefix) {
+				return true
+			}
+		} else if pattern == model {
+			return true
+		}
+	}
+	return false
+}
+
+// Gateway is an http.Handler serving an llmx.Client
+type Gateway struct {
+	client    *llmx.Client
+	keys      map[string]*APIKey
+	anonymous bool
+	models    []string
+	mux       *http.ServeMux
+}
+
+// Option configures a Gateway
+type Option func(*Gateway)
+
+// WithAPIKeys adds virtual API keys
+func WithAPIKeys(keys ...APIKey) Option {
+	return func(g *Gateway) {
+		for _, key := range keys {
+			key := key
+			g.keys[key.Key] = &key
+		}
+	}
+}
+
+// WithAnonymousAccess lets requests without an API key through, with
+// access to all models. Requests carrying an unknown key are still
+// rejected.
+func WithAnonymousAccess() Option {
+	return func(g *Gateway) {
+		g.anonymous = true
+	}
+}
+
+// WithModels sets the model IDs listed by /v1/models, replacing the
+// provider's SupportedModels
+func WithModels(models ...string) Option {
+	return func(g *Gateway) {
+		g.models = models
+	}
+}
+
+// New creates a gateway forwarding requests to client. Completions run
+// through the client's middleware chain, streaming ones included.
+func New(client *llmx.Client, opts ...Option) *Gateway {
+	g := &Gateway{
+		client: client,
+		keys:   make(map[string]*APIKey),
+		mux:    http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	g.mux.HandleFunc("POST /v1/chat/completions", g.handleChatCompletions)
+	g.mux.HandleFunc("GET /v1/models", g.handleModels)
+	g.mux.HandleFunc("GET /v1/models/{model...}", g.handleModel)
+	g.mux.HandleFunc("POST /v1/messages", g.handleMessages)
+	g.mux.HandleFunc("POST /v1beta/models/{call}", g.handleGenerateContent)
+	return g
+}
+
+// ServeHTTP implements http.Handler
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := g.authenticate(r)
+	if err != nil {
+		errorWriterFor(r.URL.Path)(w, err)
+		return
+	}
+	if key != nil {
+		r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key))
+	}
+	g.mux.ServeHTTP(w, r)
+}
+
+type apiKeyContextKey struct{}
+
+// KeyFromContext returns the virtual key of the request being served, so
+// middleware can, for example, rate limit per caller
+func KeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key, ok
+}
+
+// errorWriterFor returns the error writer of the API served at path, so
+// clients can parse errors raised before a handler runs
+func errorWriterFor(path string) func(http.ResponseWriter, error) {
+	switch {
+	case strings.HasPrefix(path, "/v1/messages"):
+		return writeAnthropicError
+	case strings.HasPrefix(path, "/v1beta/"):
+		return writeGeminiError
+	default:
+		return writeError
+	}
+}
+
+// authenticate resolves the request's API key. Keys are accepted where
+// each supported SDK sends them: a bearer token, Anthropic's x-api-key
+// header, and Gemini's x-goog-api-key header or key query parameter. It
+// returns a nil key for anonymous requests.
+func (g *Gateway) authenticate(r *http.Request) (*APIKey, error) {
+	var token string
+	if header := r.Header.Get("Authorization"); header != "" {
+		var ok bool
+		if token, ok = strings.CutPrefix(header, "Bearer "); !ok {
+			return nil, llmx.NewAuthenticationError("authorization header must use the Bearer scheme")
+		}
+	} else if header := r.Header.Get("X-Api-Key"); header != "" {
+		token = header
+	} else if header := r.Header.Get("X-Goog-Api-Key"); header != "" {
+		token = header
+	} else {
+		token = r.URL.Query().Get("key")
+	}
+
+	if token == "" {
+		if g.anonymous {
+			return nil, nil
+		}
+		return nil, llmx.NewAuthenticationError("missing API key")
+	}
+
+	key, ok := g.keys[strings.TrimSpace(token)]
+	if !ok {
+		return nil, llmx.NewAuthenticationError("invalid API key")
+	}
+	return key, nil
+}
+
+// allowed reports whether the request's key may use model
+func allowed(ctx context.Context, model string) bool {
+	key, ok := KeyFromContext(ctx)
+	return !ok || key.Allows(model)
+}
+
+// permissionError is returned when a key may not use a model
+type permissionError struct {
+	*llmx.BaseError
+}
+
+func newPermissionError(model string) *permissionError {
+	return &permissionError{&llmx.BaseError{
+		Message:   "API key is not allowed to use model " + model,
+		StatusCd:  http.StatusForbidden,
+		ErrorCode: "model_not_allowed",
+	}}
+}
+
+func (g *Gateway) handleModels(w http.ResponseWriter, r *http.Request) {
+	list := modelList{Object: "list", Data: []model{}}
+	for _, id := range g.modelIDs() {
+		if allowed(r.Context(), id) {
+			list.Data = append(list.Data, g.model(id))
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (g *Gateway) handleModel(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("model")
+	for _, known := range g.modelIDs() {
+		if known == id && allowed(r.Context(), id) {
+			writeJSON(w, http.StatusOK, g.model(id))
+			return
+		}
+	}
+	writeError(w, llmx.NewNotFoundError("model not found: "+id, "model"))
+}
+
+func (g *Gateway) modelIDs() []string {
+	if g.models != nil {
+		return g.models
+	}
+	var ids []string
+	for _, m := range g.client.Provider().SupportedModels() {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func (g *Gateway) model(id string) model {
+	return model{ID: id, Object: "model", OwnedBy: g.client.Provider().Name()}
+}
+
+func (g *Gateway) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var body formats.OpenAIRequest
+	if err := readBody(w, r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req, err := formats.FromOpenAIRequest(&body)
+	if err == nil {
+		err = g.authorize(r.Context(), req)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if body.Stream {
+		includeUsage := body.StreamOptions != nil && body.StreamOptions.IncludeUsage
+		g.stream(w, r, req, includeUsage)
+		return
+	}
+
+	resp, err := g.client.Chat(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, fromChatResponse(resp, req.Model))
+}
+
+// readBody decodes the JSON request body into v
+func readBody(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(v); err != nil {
+		return llmx.NewInvalidRequestError("invalid request body: "+err.Error(), nil)
+	}
+	return nil
+}
+
+// authorize fills in the default model and checks the caller may use it
+func (g *Gateway) authorize(ctx context.Context, req *llmx.ChatRequest) error {
+	if req.Model == "" {
+		req.Model = g.client.Config().DefaultModel
+	}
+	if !allowed(ctx, req.Model) {
+		return newPermissionError(req.Model)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeErrorHeaders sets the headers describing err and returns the
+// response status
+func writeErrorHeaders(w http.ResponseWriter, err error) int {
+	status := errorStatus(err)
+	var retryAfter time.Duration
+	var rateErr *llmx.RateLimitError
+	var overloadedErr *llmx.OverloadedError
+	switch {
+	case errors.As(err, &rateErr):
+		retryAfter = rateErr.RetryAfter
+	case errors.As(err, &overloadedErr):
+		retryAfter = overloadedErr.RetryAfter
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.5)))
+	}
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	return status
+}
+
+// errorStatus is the HTTP status for err. llmx errors carry one, except
+// provider errors that did not come from an HTTP response, which are
+// upstream failures.
+func errorStatus(err error) int {
+	var llmxErr llmx.Error
+	if !errors.As(err, &llmxErr) {
+		return http.StatusInternalServerError
+	}
+	if llmxErr.StatusCode() < 400 {
+		return http.StatusBadGateway
+	}
+	return llmxErr.StatusCode()
+}
@@ -0,0 +1,379 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+	"github.com/llmx-ai/llmx/formats"
+	"github.com/llmx-ai/llmx/middleware"
+	"github.com/llmx-ai/llmx/provider"
+	"github.com/llmx-ai/llmx/store"
+)
+
+// echoProvider answers with the text of the last message, or calls the
+// "lookup" tool when tools are offered, and records the last request
+type echoProvider struct {
+	mu   sync.Mutex
+	last *llmx.ChatRequest
+}
+
+var testProvider = &echoProvider{}
+
+func init() {
+	provider.Register("gateway-echo", func(opts map[string]interface{}) (provider.Provider, error) {
+		return testProvider, nil
+	})
+}
+
+func (p *echoProvider) Name() string { return "gateway-echo" }
+
+func (p *echoProvider) lastRequest() *llmx.ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+func (p *echoProvider) Chat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req := reqInterface.(*llmx.ChatRequest)
+	p.mu.Lock()
+	p.last = req
+	p.mu.Unlock()
+
+	if len(req.Tools) > 0 {
+		return &llmx.ChatResponse{
+			ID:           "resp_1",
+			Model:        req.Model,
+			ToolCalls:    []llmx.ToolCall{{ID: "call_1", Name: "lookup", Arguments: json.RawMessage(`{"q":"go"}`)}},
+			FinishReason: "tool_use",
+		}, nil
+	}
+	return &llmx.ChatResponse{
+		ID:           "resp_1",
+		Model:        req.Model,
+		Content:      llmx.ExtractText(req.Messages[len(req.Messages)-1]),
+		FinishReason: "end_turn",
+		Usage:        llmx.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+	}, nil
+}
+
+func (p *echoProvider) StreamChat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req := reqInterface.(*llmx.ChatRequest)
+	stream := llmx.NewChatStream(ctx)
+	go func() {
+		defer stream.Close()
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeStart})
+		for _, word := range strings.Fields(llmx.ExtractText(req.Messages[len(req.Messages)-1])) {
+			stream.SendEvent(core.StreamEvent{Type: core.EventTypeTextDelta, Data: word})
+		}
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeToolCall, Data: map[string]interface{}{
+			"id": "call_1", "name": "lookup", "args": `{"q":"go"}`,
+		}})
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeFinish, Data: "tool_calls"})
+	}()
+	return stream, nil
+}
+
+func (p *echoProvider) SupportedFeatures() provider.Features {
+	return provider.Features{Streaming: true, ToolCalling: true, ToolChoice: true, ParallelToolCalls: true}
+}
+
+func (p *echoProvider) SupportedModels() []provider.Model {
+	return []provider.Model{{ID: "echo-small"}, {ID: "echo-large"}, {ID: "other"}}
+}
+
+func newTestGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	client, err := llmx.NewClient(
+		llmx.WithProvider("gateway-echo", map[string]interface{}{"api_key": "test-key"}),
+		llmx.WithDefaultModel("echo-small"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ts := httptest.NewServer(New(client, WithAPIKeys(
+		APIKey{Key: "sk-all", Name: "all"},
+		APIKey{Key: "sk-echo", Name: "echo", Models: []string{"echo-*"}},
+	)))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, key, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+}
+
+func TestGateway_ChatCompletion(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1/chat/completions", "sk-all", `{
+		"model": "echo-large",
+		"messages": [
+			{"role": "developer", "content": "be brief"},
+			{"role": "user", "content": [{"type": "text", "text": "hello there"}]}
+		],
+		"max_completion_tokens": 50,
+		"stop": "END"
+	}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var completion formats.OpenAIResponse
+	decode(t, resp, &completion)
+	if completion.Object != "chat.completion" || completion.Model != "echo-large" {
+		t.Errorf("unexpected completion %+v", completion)
+	}
+	if len(completion.Choices) != 1 || *completion.Choices[0].Message.Content != "hello there" {
+		t.Fatalf("unexpected choices %+v", completion.Choices)
+	}
+	if *completion.Choices[0].FinishReason != "stop" {
+		t.Errorf("expected finish reason stop, got %s", *completion.Choices[0].FinishReason)
+	}
+	if completion.Usage.TotalTokens != 5 {
+		t.Errorf("expected usage to be forwarded, got %+v", completion.Usage)
+	}
+
+	req := testProvider.lastRequest()
+	if req.Messages[0].Role != llmx.RoleSystem || *req.MaxTokens != 50 || len(req.Stop) != 1 || req.Stop[0] != "END" {
+		t.Errorf("request not translated: %+v", req)
+	}
+}
+
+func TestGateway_Tools(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1/chat/completions", "sk-all", `{
+		"messages": [
+			{"role": "user", "content": "find go"},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_0", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"golang\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_0", "content": "nothing"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object", "properties": {"q": {"type": "string"}}}}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}}
+	}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var completion formats.OpenAIResponse
+	decode(t, resp, &completion)
+	calls := completion.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Function.Arguments != `{"q":"go"}` {
+		t.Errorf("unexpected tool calls %+v", calls)
+	}
+	if *completion.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("expected finish reason tool_calls, got %s", *completion.Choices[0].FinishReason)
+	}
+
+	req := testProvider.lastRequest()
+	if req.Model != "echo-small" {
+		t.Errorf("expected default model, got %s", req.Model)
+	}
+	if req.ToolChoice == nil || req.ToolChoice.Name != "lookup" {
+		t.Errorf("expected forced tool choice, got %+v", req.ToolChoice)
+	}
+	if req.Tools[0].Parameters.Properties["q"].Type != "string" {
+		t.Errorf("tool parameters not translated: %+v", req.Tools[0].Parameters)
+	}
+	if len(req.Messages[1].ToolCalls) != 1 {
+		t.Errorf("assistant tool calls not translated: %+v", req.Messages[1])
+	}
+	if result, ok := req.Messages[2].Content[0].(llmx.ToolResultPart); !ok || result.ToolCallID != "call_0" || result.Result != "nothing" {
+		t.Errorf("tool result not translated: %+v", req.Messages[2])
+	}
+}
+
+func TestGateway_StreamRateLimited(t *testing.T) {
+	client, err := llmx.NewClient(
+		llmx.WithProvider("gateway-echo", map[string]interface{}{"api_key": "test-key"}),
+		llmx.WithDefaultModel("echo-small"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	limiter := middleware.NewGCRALimiter(store.NewMemoryStore(), 0.001, 1)
+	client.Use(middleware.RateLimitByKey(limiter, func(ctx context.Context, req *llmx.ChatRequest) string {
+		key, _ := KeyFromContext(ctx)
+		return key.Name
+	}, false))
+
+	ts := httptest.NewServer(New(client, WithAPIKeys(APIKey{Key: "sk-all", Name: "all"})))
+	defer ts.Close()
+
+	body := `{"model": "echo-small", "messages": [{"role": "user", "content": "hi"}], "stream": true}`
+	resp := do(t, ts, "POST", "/v1/chat/completions", "sk-all", body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("first stream status = %d, want 200", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = do(t, ts, "POST", "/v1/chat/completions", "sk-all", body)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("rate limited stream status = %d, want 429", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("rate limited stream has no Retry-After header")
+	}
+}
+
+func TestGateway_Stream(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1/chat/completions", "sk-all", `{
+		"model": "echo-small",
+		"messages": [{"role": "user", "content": "one two"}],
+		"stream": true
+	}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", ct)
+	}
+
+	var chunks []formats.OpenAIResponse
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk formats.OpenAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if !done {
+		t.Fatal("stream did not end with [DONE]")
+	}
+
+	var text string
+	var calls []formats.OpenAIToolCall
+	for _, chunk := range chunks {
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("unexpected object %q", chunk.Object)
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != nil {
+			text += *delta.Content
+		}
+		calls = append(calls, delta.ToolCalls...)
+	}
+	if chunks[0].Choices[0].Delta.Role != "assistant" {
+		t.Errorf("expected first chunk to carry the role, got %+v", chunks[0])
+	}
+	if text != "onetwo" {
+		t.Errorf("expected streamed text onetwo, got %q", text)
+	}
+	if len(calls) != 1 || *calls[0].Index != 0 || calls[0].Function.Name != "lookup" {
+		t.Errorf("unexpected streamed tool calls %+v", calls)
+	}
+	if last := chunks[len(chunks)-1]; *last.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("expected final finish reason, got %+v", last.Choices[0])
+	}
+}
+
+func TestGateway_Keys(t *testing.T) {
+	ts := newTestGateway(t)
+	chat := `{"model": "other", "messages": [{"role": "user", "content": "hi"}]}`
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+		errTyp string
+	}{
+		{"missing key", "", http.StatusUnauthorized, "authentication_error"},
+		{"unknown key", "sk-nope", http.StatusUnauthorized, "authentication_error"},
+		{"model not allowed", "sk-echo", http.StatusForbidden, "permission_error"},
+		{"unrestricted key", "sk-all", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, ts, "POST", "/v1/chat/completions", tt.key, chat)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, resp.StatusCode)
+			}
+			if tt.errTyp != "" {
+				var body errorResponse
+				decode(t, resp, &body)
+				if body.Error.Type != tt.errTyp {
+					t.Errorf("expected error type %s, got %+v", tt.errTyp, body.Error)
+				}
+			}
+		})
+	}
+
+	t.Run("models filtered", func(t *testing.T) {
+		var list modelList
+		decode(t, do(t, ts, "GET", "/v1/models", "sk-echo", ""), &list)
+		if len(list.Data) != 2 || list.Data[0].ID != "echo-small" || list.Data[1].ID != "echo-large" {
+			t.Errorf("unexpected models %+v", list.Data)
+		}
+		if list.Data[0].OwnedBy != "gateway-echo" {
+			t.Errorf("expected owner gateway-echo, got %s", list.Data[0].OwnedBy)
+		}
+
+		if resp := do(t, ts, "GET", "/v1/models/other", "sk-echo", ""); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected disallowed model to be hidden, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestGateway_InvalidRequest(t *testing.T) {
+	ts := newTestGateway(t)
+
+	for name, body := range map[string]string{
+		"malformed":    `{`,
+		"bad role":     `{"messages": [{"role": "robot", "content": "hi"}]}`,
+		"n":            `{"n": 2, "messages": [{"role": "user", "content": "hi"}]}`,
+		"empty":        `{"messages": []}`,
+		"bad choice":   `{"tool_choice": "sometimes", "messages": [{"role": "user", "content": "hi"}]}`,
+		"unknown tool": `{"tool_choice": {"type": "function", "function": {"name": "x"}}, "messages": [{"role": "user", "content": "hi"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := do(t, ts, "POST", "/v1/chat/completions", "sk-all", body)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", resp.StatusCode)
+			}
+			var errBody errorResponse
+			decode(t, resp, &errBody)
+			if errBody.Error.Type != "invalid_request_error" || errBody.Error.Message == "" {
+				t.Errorf("unexpected error %+v", errBody.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// fromChatResponseGemini converts a response to a generateContent
+// response, filling in the model providers may leave empty
+func fromChatResponseGemini(resp *llmx.ChatResponse, requestModel string) *formats.GeminiResponse {
+	out := *resp
+	out.Model = responseModel(resp.Model, requestModel)
+	return formats.ToGeminiResponse(&out)
+}
+
+// handleGenerateContent serves POST /v1beta/models/{model}:{method}
+func (g *Gateway) handleGenerateContent(w http.ResponseWriter, r *http.Request) {
+	call := r.PathValue("call")
+	i := strings.LastIndex(call, ":")
+	if i < 0 {
+		writeGeminiError(w, llmx.NewNotFoundError("unknown method: "+call, "method"))
+		return
+	}
+	model, method := call[:i], call[i+1:]
+	if method != "generateContent" && method != "streamGenerateContent" {
+		writeGeminiError(w, llmx.NewNotFoundError("unknown method: "+method, "method"))
+		return
+	}
+
+	var body formats.GeminiRequest
+	if err := readBody(w, r, &body); err != nil {
+		writeGeminiError(w, err)
+		return
+	}
+
+	req, err := formats.FromGeminiRequest(&body, model)
+	if err == nil {
+		err = g.authorize(r.Context(), req)
+	}
+	if err != nil {
+		writeGeminiError(w, err)
+		return
+	}
+
+	if method == "streamGenerateContent" {
+		stream, err := g.client.StreamChat(r.Context(), req)
+		if err != nil {
+			writeGeminiError(w, err)
+			return
+		}
+		out := &geminiChunkWriter{model: req.Model, sse: r.URL.Query().Get("alt") == "sse"}
+		out.events.w = w
+		forwardStream(r.Context(), stream, out)
+		return
+	}
+
+	resp, err := g.client.Chat(r.Context(), req)
+	if err != nil {
+		writeGeminiError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, fromChatResponseGemini(resp, req.Model))
+}
+
+// geminiChunkWriter writes a completion as streamGenerateContent chunks,
+// as server-sent events with alt=sse and otherwise as the elements of a
+// JSON array
+type geminiChunkWriter struct {
+	events eventWriter
+	model  string
+	sse    bool
+	count  int
+}
+
+func (g *geminiChunkWriter) text(delta string) error {
+	return g.write(&formats.GeminiResponse{Candidates: []formats.GeminiCandidate{{
+		Content: formats.GeminiContent{Role: "model", Parts: []formats.GeminiPart{{Text: delta}}},
+	}}})
+}
+
+func (g *geminiChunkWriter) toolCall(_ int, call llmx.ToolCall) error {
+	return g.write(&formats.GeminiResponse{Candidates: []formats.GeminiCandidate{{
+		Content: formats.GeminiContent{Role: "model", Parts: []formats.GeminiPart{{FunctionCall: formats.GeminiFunctionCallFrom(call)}}},
+	}}})
+}
+
+func (g *geminiChunkWriter) finish(reason string, usage llmx.Usage) error {
+	err := g.write(&formats.GeminiResponse{
+		Candidates: []formats.GeminiCandidate{{
+			Content:      formats.GeminiContent{Role: "model", Parts: []formats.GeminiPart{}},
+			FinishReason: formats.GeminiFinishReason(llmx.FinishReason(reason), false),
+		}},
+		UsageMetadata: &formats.GeminiUsage{
+			PromptTokenCount:     usage.PromptTokens,
+			CandidatesTokenCount: usage.CompletionTokens,
+			TotalTokenCount:      usage.TotalTokens,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return g.end()
+}
+
+func (g *geminiChunkWriter) fail(err error) {
+	if g.write(geminiErrorResponse(err, errorStatus(err))) == nil {
+		g.end()
+	}
+}
+
+func (g *geminiChunkWriter) write(v interface{}) error {
+	if chunk, ok := v.(*formats.GeminiResponse); ok {
+		chunk.ModelVersion = g.model
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if g.sse {
+		return g.events.send("", data)
+	}
+
+	prefix := ",\n"
+	if g.count == 0 {
+		g.events.w.Header().Set("Content-Type", "application/json")
+		g.events.w.WriteHeader(http.StatusOK)
+		prefix = "["
+	}
+	g.count++
+	if _, err := fmt.Fprintf(g.events.w, "%s%s", prefix, data); err != nil {
+		return err
+	}
+	if f, ok := g.events.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// end closes the JSON array of a non-SSE stream
+func (g *geminiChunkWriter) end() error {
+	if g.sse {
+		return nil
+	}
+	_, err := fmt.Fprint(g.events.w, "]\n")
+	return err
+}
+
+// writeGeminiError writes err as a Gemini API error response
+func writeGeminiError(w http.ResponseWriter, err error) {
+	status := writeErrorHeaders(w, err)
+	writeJSON(w, status, geminiErrorResponse(err, status))
+}
+
+func geminiErrorResponse(err error, status int) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": err.Error(),
+			"status":  geminiStatus(status),
+		},
+	}
+}
+
+// geminiStatus maps an HTTP status to a Google RPC status name
+func geminiStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		return "INTERNAL"
+	}
+}
@@ -0,0 +1,164 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+func TestGateway_GenerateContent(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1beta/models/echo-large:generateContent?key=sk-all", "", `{
+		"systemInstruction": {"parts": [{"text": "be brief"}]},
+		"contents": [
+			{"role": "user", "parts": [{"text": "look it up"}]},
+			{"role": "model", "parts": [{"functionCall": {"name": "lookup", "args": {"q": "go"}}}]},
+			{"role": "user", "parts": [{"functionResponse": {"name": "lookup", "response": {"result": "found"}}}, {"text": "thanks"}]}
+		],
+		"generationConfig": {"maxOutputTokens": 64, "topK": 5}
+	}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var out formats.GeminiResponse
+	decode(t, resp, &out)
+	if len(out.Candidates) != 1 || out.Candidates[0].Content.Parts[0].Text != "thanks" {
+		t.Fatalf("unexpected response %+v", out)
+	}
+	if out.Candidates[0].FinishReason != "STOP" || out.UsageMetadata.TotalTokenCount != 5 {
+		t.Errorf("unexpected finish reason or usage %+v", out)
+	}
+
+	got := testProvider.lastRequest()
+	if got.Model != "echo-large" || *got.MaxTokens != 64 || *got.TopK != 5 {
+		t.Errorf("request not translated: %+v", got)
+	}
+	call := got.Messages[2].ToolCalls[0]
+	result := got.Messages[3].Content[0].(llmx.ToolResultPart)
+	if call.ID == "" || result.ToolCallID != call.ID || result.Result != `{"result": "found"}` {
+		t.Errorf("function response not paired with call: %+v %+v", call, result)
+	}
+}
+
+func TestGateway_GenerateContentTools(t *testing.T) {
+	ts := newTestGateway(t)
+
+	resp := do(t, ts, "POST", "/v1beta/models/echo-small:generateContent?key=sk-all", "", `{
+		"contents": [{"parts": [{"text": "find go"}]}],
+		"tools": [{"functionDeclarations": [{"name": "lookup", "parameters": {"type": "OBJECT", "properties": {"q": {"type": "STRING"}}}}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["lookup"]}}
+	}`)
+
+	var out formats.GeminiResponse
+	decode(t, resp, &out)
+	fn := out.Candidates[0].Content.Parts[0].FunctionCall
+	if fn == nil || fn.Name != "lookup" || string(fn.Args) != `{"q":"go"}` {
+		t.Errorf("unexpected parts %+v", out.Candidates[0].Content.Parts)
+	}
+
+	got := testProvider.lastRequest()
+	if got.Tools[0].Parameters.Properties["q"].Type != "string" {
+		t.Errorf("schema types not lowercased: %+v", got.Tools[0].Parameters)
+	}
+	if got.ToolChoice == nil || got.ToolChoice.Name != "lookup" {
+		t.Errorf("expected forced tool choice, got %+v", got.ToolChoice)
+	}
+}
+
+func TestGateway_StreamGenerateContent(t *testing.T) {
+	ts := newTestGateway(t)
+	body := `{"contents": [{"role": "user", "parts": [{"text": "one two"}]}]}`
+
+	check := func(t *testing.T, chunks []formats.GeminiResponse) {
+		t.Helper()
+		var text string
+		var calls int
+		for _, chunk := range chunks {
+			for _, p := range chunk.Candidates[0].Content.Parts {
+				text += p.Text
+				if p.FunctionCall != nil {
+					calls++
+				}
+			}
+		}
+		last := chunks[len(chunks)-1]
+		if text != "onetwo" || calls != 1 || last.Candidates[0].FinishReason != "STOP" {
+			t.Errorf("unexpected chunks: text %q, %d calls, last %+v", text, calls, last)
+		}
+	}
+
+	t.Run("sse", func(t *testing.T) {
+		resp := do(t, ts, "POST", "/v1beta/models/echo-small:streamGenerateContent?alt=sse", "sk-all", body)
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected event stream, got %q", ct)
+		}
+
+		var chunks []formats.GeminiResponse
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				var chunk formats.GeminiResponse
+				if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+					t.Fatalf("invalid chunk %q: %v", data, err)
+				}
+				chunks = append(chunks, chunk)
+			}
+		}
+		check(t, chunks)
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		resp := do(t, ts, "POST", "/v1beta/models/echo-small:streamGenerateContent", "sk-all", body)
+		var chunks []formats.GeminiResponse
+		decode(t, resp, &chunks)
+		check(t, chunks)
+	})
+}
+
+func TestGateway_GenerateContentErrors(t *testing.T) {
+	ts := newTestGateway(t)
+	body := `{"contents": [{"parts": [{"text": "hi"}]}]}`
+
+	tests := []struct {
+		name, path, key, status string
+		code                    int
+	}{
+		{"missing key", "/v1beta/models/echo-small:generateContent", "", "UNAUTHENTICATED", http.StatusUnauthorized},
+		{"model not allowed", "/v1beta/models/other:generateContent", "sk-echo", "PERMISSION_DENIED", http.StatusForbidden},
+		{"unknown method", "/v1beta/models/echo-small:embedContent", "sk-all", "NOT_FOUND", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", ts.URL+tt.path, strings.NewReader(body))
+			if tt.key != "" {
+				req.Header.Set("x-goog-api-key", tt.key)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.code {
+				t.Fatalf("expected %d, got %d", tt.code, resp.StatusCode)
+			}
+
+			var out struct {
+				Error struct {
+					Code   int    `json:"code"`
+					Status string `json:"status"`
+				} `json:"error"`
+			}
+			decode(t, resp, &out)
+			if out.Error.Code != tt.code || out.Error.Status != tt.status {
+				t.Errorf("expected %s error, got %+v", tt.status, out.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// fromChatResponse converts a response to a chat.completion, filling in
+// the fields providers may leave empty
+func fromChatResponse(resp *llmx.ChatResponse, requestModel string) *formats.OpenAIResponse {
+	out := *resp
+	out.ID = completionID(resp.ID)
+	out.Model = responseModel(resp.Model, requestModel)
+	out.CreatedAt = createdAt(resp.CreatedAt)
+	return formats.ToOpenAIResponse(&out)
+}
+
+func completionID(id string) string {
+	if id == "" {
+		return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	}
+	return id
+}
+
+func createdAt(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+func responseModel(model, requestModel string) string {
+	if model == "" {
+		return requestModel
+	}
+	return model
+}
+
+type modelList struct {
+	Object string  `json:"object"`
+	Data   []model `json:"data"`
+}
+
+type model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    string  `json:"code,omitempty"`
+}
+
+// writeError writes err as an OpenAI error response
+func writeError(w http.ResponseWriter, err error) {
+	status := writeErrorHeaders(w, err)
+	writeJSON(w, status, errorResponse{Error: toErrorBody(err, status)})
+}
+
+func toErrorBody(err error, status int) errorBody {
+	body := errorBody{Message: err.Error(), Type: errorType(status)}
+	var llmxErr llmx.Error
+	if errors.As(err, &llmxErr) {
+		body.Code = llmxErr.Code()
+	}
+	return body
+}
+
+// errorType maps an HTTP status to an OpenAI error type
+func errorType(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	default:
+		return "api_error"
+	}
+}
@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// streamSink writes the events of a completion stream in one API's wire
+// format. A write error ends the stream.
+type streamSink interface {
+	text(delta string) error
+	toolCall(index int, call llmx.ToolCall) error
+
+	// finish ends a completed stream; reason is in the OpenAI vocabulary
+	finish(reason string, usage llmx.Usage) error
+
+	// fail reports an error after the response has started
+	fail(err error)
+}
+
+// forwardStream copies stream to sink until the stream ends, the sink
+// fails or ctx is cancelled, and closes the stream
+func forwardStream(ctx context.Context, stream *llmx.ChatStream, sink streamSink) {
+	defer stream.Close()
+
+	toolCalls := 0
+	for event := range stream.Events() {
+		var err error
+		switch event.Type {
+		case core.EventTypeTextDelta:
+			if text, ok := event.Data.(string); ok && text != "" {
+				err = sink.text(text)
+			}
+
+		case core.EventTypeToolCall:
+			if call, ok := llmx.ToolCallFromEvent(event.Data); ok {
+				err = sink.toolCall(toolCalls, call)
+				toolCalls++
+			}
+
+		case core.EventTypeError:
+			if streamErr, ok := event.Data.(error); ok {
+				sink.fail(streamErr)
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if streamErr, ok := <-stream.Errors(); ok && streamErr != nil {
+		sink.fail(streamErr)
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	accumulated := stream.GetAccumulated()
+	sink.finish(formats.OpenAIFinishReason(accumulated.FinishReason, toolCalls > 0), accumulated.Usage)
+}
+
+// eventWriter writes server-sent events, sending the response header with
+// the first one
+type eventWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// send writes one event; name may be empty for unnamed events
+func (e *eventWriter) send(name string, data []byte) error {
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.started = true
+	}
+
+	if name != "" {
+		if _, err := fmt.Fprintf(e.w, "event: %s\n", name); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(e.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// sendJSON writes v as one event
+func (e *eventWriter) sendJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.send(name, data)
+}
+
+// stream forwards a streaming completion as chat.completion.chunk events.
+// Errors before the first event get an error response; later ones are sent
+// as an error event, since the status has already been written.
+func (g *Gateway) stream(w http.ResponseWriter, r *http.Request, req *llmx.ChatRequest, includeUsage bool) {
+	stream, err := g.client.StreamChat(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	out := &chunkWriter{
+		events:       eventWriter{w: w},
+		id:           completionID(""),
+		created:      time.Now().Unix(),
+		model:        req.Model,
+		includeUsage: includeUsage,
+	}
+	if err := out.write(&formats.OpenAIResponseMessage{Role: "assistant", Content: new(string)}, nil); err != nil {
+		stream.Close()
+		return
+	}
+	forwardStream(r.Context(), stream, out)
+}
+
+// chunkWriter writes chat.completion.chunk events
+type chunkWriter struct {
+	events       eventWriter
+	id           string
+	created      int64
+	model        string
+	includeUsage bool
+}
+
+func (c *chunkWriter) text(delta string) error {
+	return c.write(&formats.OpenAIResponseMessage{Content: &delta}, nil)
+}
+
+func (c *chunkWriter) toolCall(index int, call llmx.ToolCall) error {
+	return c.write(&formats.OpenAIResponseMessage{ToolCalls: []formats.OpenAIToolCall{formats.OpenAIToolCallFrom(index, call, true)}}, nil)
+}
+
+func (c *chunkWriter) finish(reason string, u llmx.Usage) error {
+	if err := c.write(&formats.OpenAIResponseMessage{}, &reason); err != nil {
+		return err
+	}
+	if c.includeUsage {
+		// The usage chunk has no choices
+		err := c.events.sendJSON("", &formats.OpenAIResponse{
+			ID:      c.id,
+			Object:  "chat.completion.chunk",
+			Created: c.created,
+			Model:   c.model,
+			Choices: []formats.OpenAIChoice{},
+			Usage: &formats.OpenAIUsage{
+				PromptTokens:     u.PromptTokens,
+				CompletionTokens: u.CompletionTokens,
+				TotalTokens:      u.TotalTokens,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return c.events.send("", []byte("[DONE]"))
+}
+
+func (c *chunkWriter) fail(err error) {
+	c.events.sendJSON("", errorResponse{Error: toErrorBody(err, errorStatus(err))})
+}
+
+func (c *chunkWriter) write(delta *formats.OpenAIResponseMessage, finish *string) error {
+	return c.events.sendJSON("", &formats.OpenAIResponse{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []formats.OpenAIChoice{{Index: 0, Delta: delta, FinishReason: finish}},
+	})
+}
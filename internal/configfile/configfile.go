@@ -0,0 +1,135 @@
+// Package configfile parses JSON, YAML and TOML configuration files into
+// generic maps. Integers decode to int and other numbers to float64 in
+// every format, so callers can treat the formats alike.
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Parse parses data in the format given by the extension of name: .json,
+// .yaml, .yml or .toml
+func Parse(name string, data []byte) (map[string]interface{}, error) {
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	case ".toml":
+		return ParseTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q (want .json, .yaml, .yml or .toml)", ext)
+	}
+}
+
+// ParseJSON parses a JSON object
+func ParseJSON(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var root interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	return document(root)
+}
+
+// document checks that a decoded document is a mapping and normalizes its
+// values. An empty YAML or TOML document is an empty mapping.
+func document(root interface{}) (map[string]interface{}, error) {
+	switch root.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+	default:
+		return nil, fmt.Errorf("the document must be a mapping")
+	}
+	v, err := normalize("", root)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]interface{}), nil
+}
+
+// normalize converts the values the decoders produce to those every format
+// shares: nil, bool, string, int, float64, []interface{} and
+// map[string]interface{}. path is the key path of v, for errors.
+func normalize(path string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, bool, string, int, float64:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i), nil
+		}
+		return v.Float64()
+	case map[string]interface{}:
+		for k, item := range v {
+			converted, err := normalize(join(path, k), item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: keys must be strings, got %v", displayPath(path), k)
+			}
+			converted, err := normalize(join(path, key), item)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = converted
+		}
+		return m, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := normalize(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			converted, err := normalize(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported value %v (quote it to use it as a string)", displayPath(path), v)
+	}
+}
+
+// join appends key to a key path
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// displayPath returns path for errors, naming the root of the document
+func displayPath(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
@@ -0,0 +1,179 @@
+package configfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+// want is the document every format in TestParse encodes
+var want = map[string]interface{}{
+	"provider":    "openai",
+	"temperature": 0.5,
+	"max_tokens":  1024,
+	"debug":       true,
+	"stop":        []interface{}{"a", "b # not a comment"},
+	"headers":     map[string]interface{}{"X-Team": "search"},
+	"middleware": []interface{}{
+		map[string]interface{}{"name": "retry", "max_retries": 3},
+		map[string]interface{}{"name": "cache", "ttl": "5m"},
+	},
+	"profiles": map[string]interface{}{
+		"prod": map[string]interface{}{
+			"model":   "gpt-4o",
+			"options": map[string]interface{}{"api_key": "${OPENAI_API_KEY}"},
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"config.json", `{
+			"provider": "openai",
+			"temperature": 0.5,
+			"max_tokens": 1024,
+			"debug": true,
+			"stop": ["a", "b # not a comment"],
+			"headers": {"X-Team": "search"},
+			"middleware": [
+				{"name": "retry", "max_retries": 3},
+				{"name": "cache", "ttl": "5m"}
+			],
+			"profiles": {"prod": {"model": "gpt-4o", "options": {"api_key": "${OPENAI_API_KEY}"}}}
+		}`},
+		{"config.yaml", `
+# client settings
+provider: openai
+temperature: 0.5   # sampling
+max_tokens: 1024
+debug: true
+stop: [a, "b # not a comment"]
+headers:
+  X-Team: search
+middleware:
+- name: retry
+  max_retries: 3
+- {name: cache, ttl: 5m}
+profiles:
+  prod:
+    model: gpt-4o
+    options:
+      api_key: "${OPENAI_API_KEY}"
+`},
+		{"config.toml", `
+# client settings
+provider = "openai"
+temperature = 0.5   # sampling
+max_tokens = 1_024
+debug = true
+stop = [
+  "a",
+  "b # not a comment",
+]
+headers = { X-Team = "search" }
+
+[[middleware]]
+name = "retry"
+max_retries = 3
+
+[[middleware]]
+name = "cache"
+ttl = "5m"
+
+[profiles.prod]
+model = "gpt-4o"
+options.api_key = "${OPENAI_API_KEY}"
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.name, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Parse() = %#v\nwant %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseYAML_Scalars(t *testing.T) {
+	got, err := ParseYAML([]byte(`
+empty:
+null_value: ~
+quoted: 'it''s'
+escaped: "tab\there"
+url: http://example.com:8080/path
+negative: -3
+float: 1e3
+port: &port 8080
+alias: *port
+list:
+  - 1
+  - - nested
+    - list
+  -
+    key: value
+cert: |
+  line one
+  line two
+folded: >-
+  one
+  two
+`))
+	if err != nil {
+		t.Fatalf("ParseYAML() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"empty":      nil,
+		"null_value": nil,
+		"quoted":     "it's",
+		"escaped":    "tab\there",
+		"url":        "http://example.com:8080/path",
+		"negative":   -3,
+		"float":      1000.0,
+		"port":       8080,
+		"alias":      8080,
+		"list": []interface{}{
+			1,
+			[]interface{}{"nested", "list"},
+			map[string]interface{}{"key": "value"},
+		},
+		"cert":   "line one\nline two\n",
+		"folded": "one two",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseYAML() = %#v\nwant %#v", got, expected)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"bad.yaml", "key: value\n  nested: oops\n"},
+		{"bad.yaml", "a: 1\na: 2\n"},
+		{"bad.yaml", "- item\n"},
+		{"bad.yaml", "key: [1, 2\n"},
+		{"bad.yaml", "key: *alias\n"},
+		{"bad.yaml", "1: one\n"},
+		{"bad.yaml", "created: 2001-12-14\n"},
+		{"bad.toml", "key = \n"},
+		{"bad.toml", "key = 1\nkey = 2\n"},
+		{"bad.toml", "date = 1979-05-27\n"},
+		{"bad.toml", "[table\n"},
+		{"bad.json", "[1, 2]"},
+		{"config.ini", "key=value"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.name, []byte(tt.data)); err == nil {
+			t.Errorf("Parse(%q, %q) succeeded, want an error", tt.name, tt.data)
+		}
+	}
+}
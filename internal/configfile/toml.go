@@ -0,0 +1,15 @@
+package configfile
+
+import (
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ParseTOML parses a TOML document. Dates and times are rejected, as no
+// setting takes them.
+func ParseTOML(data []byte) (map[string]interface{}, error) {
+	var root map[string]interface{}
+	if err := toml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return document(root)
+}
@@ -0,0 +1,16 @@
+package configfile
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// ParseYAML parses a YAML mapping. Anchors and aliases are resolved;
+// values other than scalars, mappings with string keys and sequences,
+// such as timestamps, are rejected.
+func ParseYAML(data []byte) (map[string]interface{}, error) {
+	var root interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return document(root)
+}
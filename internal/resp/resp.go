@@ -0,0 +1,125 @@
+// Package resp reads and writes the Redis serialization protocol (RESP2),
+// for the Redis client of the store package and its test server
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Error is an error reply
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Read reads a reply: a string for a simple string, []byte for a bulk
+// string, int64 for an integer, []interface{} for an array, nil for a nil
+// bulk string or array, and an Error for an error reply. Errors inside
+// arrays are returned as items.
+func Read(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := Read(r)
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				item = replyErr
+			} else if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// readLine reads a line ended by CRLF, without it
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// WriteCommand writes a command as an array of bulk strings and flushes w
+func WriteCommand(w *bufio.Writer, args ...string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		WriteBulk(w, []byte(arg))
+	}
+	return w.Flush()
+}
+
+// Write writes a reply of any type Read returns
+func Write(w *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case string:
+		w.WriteString("+" + v + "\r\n")
+	case Error:
+		w.WriteString("-" + string(v) + "\r\n")
+	case int64:
+		w.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	case []byte:
+		WriteBulk(w, v)
+	case []interface{}:
+		if v == nil {
+			w.WriteString("*-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			Write(w, item)
+		}
+	default:
+		Write(w, Error(fmt.Sprintf("ERR unsupported reply %T", reply)))
+	}
+}
+
+// WriteBulk writes a bulk string
+func WriteBulk(w *bufio.Writer, data []byte) {
+	fmt.Fprintf(w, "$%d\r\n", len(data))
+	w.Write(data)
+	w.WriteString("\r\n")
+}
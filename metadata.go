@@ -0,0 +1,166 @@
+package llmx
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// finishReasons maps the lowercased finish reasons of the supported
+// providers to FinishReason
+var finishReasons = map[string]FinishReason{
+	// OpenAI and compatible APIs
+	"stop":           FinishReasonStop,
+	"length":         FinishReasonLength,
+	"tool_calls":     FinishReasonToolCalls,
+	"function_call":  FinishReasonToolCalls,
+	"content_filter": FinishReasonContentFilter,
+
+	// Anthropic and Bedrock
+	"end_turn":                      FinishReasonStop,
+	"stop_sequence":                 FinishReasonStop,
+	"max_tokens":                    FinishReasonLength,
+	"model_context_window_exceeded": FinishReasonLength,
+	"tool_use":                      FinishReasonToolCalls,
+	"refusal":                       FinishReasonContentFilter,
+	"content_filtered":              FinishReasonContentFilter,
+	"guardrail_intervened":          FinishReasonContentFilter,
+
+	// Gemini
+	"safety":                  FinishReasonContentFilter,
+	"recitation":              FinishReasonContentFilter,
+	"blocklist":               FinishReasonContentFilter,
+	"prohibited_content":      FinishReasonContentFilter,
+	"spii":                    FinishReasonContentFilter,
+	"image_safety":            FinishReasonContentFilter,
+	"malformed_function_call": FinishReasonError,
+
+	// Cohere
+	"complete":    FinishReasonStop,
+	"error_toxic": FinishReasonContentFilter,
+	"error_limit": FinishReasonLength,
+	"error":       FinishReasonError,
+
+	// Hugging Face TGI
+	"eos_token": FinishReasonStop,
+
+	// Wenxin
+	"normal": FinishReasonStop,
+}
+
+// NormalizeFinishReason maps a provider's finish reason to a FinishReason.
+// Empty and unknown reasons map to "".
+func NormalizeFinishReason(raw string) FinishReason {
+	return finishReasons[strings.ToLower(strings.TrimSpace(raw))]
+}
+
+// inferFinishReason completes a normalized finish reason. Gemini and Llama
+// on Bedrock often report none, and Gemini and Cohere report a plain stop
+// when the model called tools.
+func inferFinishReason(reason FinishReason, hasToolCalls bool) FinishReason {
+	if reason != "" && reason != FinishReasonStop {
+		return reason
+	}
+	if hasToolCalls {
+		return FinishReasonToolCalls
+	}
+	return FinishReasonStop
+}
+
+// completeResponse fills in the response fields the client knows better
+// than the provider: the provider name, the latency since start, the
+// finish reasons where the provider gave none and the choices of a
+// single-choice response
+func completeResponse(resp *ChatResponse, providerName string, start time.Time) {
+	if resp.Metadata.Provider == "" {
+		resp.Metadata.Provider = providerName
+	}
+	if resp.Metadata.Latency == 0 {
+		resp.Metadata.Latency = time.Since(start)
+	}
+	if resp.FinishReason == "" {
+		resp.FinishReason = NormalizeFinishReason(resp.RawFinishReason)
+	}
+	resp.FinishReason = inferFinishReason(resp.FinishReason, len(resp.ToolCalls) > 0)
+
+	if len(resp.Choices) == 0 {
+		resp.Choices = []Choice{{
+			Content:         resp.Content,
+			ToolCalls:       resp.ToolCalls,
+			FinishReason:    resp.FinishReason,
+			RawFinishReason: resp.RawFinishReason,
+		}}
+	}
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		if choice.FinishReason == "" {
+			choice.FinishReason = NormalizeFinishReason(choice.RawFinishReason)
+		}
+		choice.FinishReason = inferFinishReason(choice.FinishReason, len(choice.ToolCalls) > 0)
+	}
+}
+
+// MetadataFromHeader returns the response metadata providers report in
+// HTTP response headers: the request ID and rate limit state
+func MetadataFromHeader(h http.Header) ResponseMetadata {
+	return ResponseMetadata{
+		RequestID: RequestIDFromHeader(h),
+		RateLimit: RateLimitFromHeader(h),
+	}
+}
+
+// RateLimitFromHeader reads the OpenAI-style x-ratelimit-* headers, also
+// sent by Azure OpenAI and Groq, and Anthropic's anthropic-ratelimit-*
+// headers. It returns nil if there are none.
+func RateLimitFromHeader(h http.Header) *RateLimitInfo {
+	info := RateLimitInfo{LimitRequests: -1, LimitTokens: -1, RemainingRequests: -1, RemainingTokens: -1}
+	found := false
+	readCount := func(dst *int, names ...string) {
+		for _, name := range names {
+			if v, err := strconv.Atoi(h.Get(name)); err == nil {
+				*dst = v
+				found = true
+				return
+			}
+		}
+	}
+	readReset := func(dst *time.Duration, names ...string) {
+		for _, name := range names {
+			if d, ok := parseReset(h.Get(name)); ok {
+				*dst = d
+				found = true
+				return
+			}
+		}
+	}
+
+	readCount(&info.LimitRequests, "X-Ratelimit-Limit-Requests", "Anthropic-Ratelimit-Requests-Limit")
+	readCount(&info.LimitTokens, "X-Ratelimit-Limit-Tokens", "Anthropic-Ratelimit-Tokens-Limit")
+	readCount(&info.RemainingRequests, "X-Ratelimit-Remaining-Requests", "Anthropic-Ratelimit-Requests-Remaining")
+	readCount(&info.RemainingTokens, "X-Ratelimit-Remaining-Tokens", "Anthropic-Ratelimit-Tokens-Remaining")
+	readReset(&info.ResetRequests, "X-Ratelimit-Reset-Requests", "Anthropic-Ratelimit-Requests-Reset")
+	readReset(&info.ResetTokens, "X-Ratelimit-Reset-Tokens", "Anthropic-Ratelimit-Tokens-Reset")
+	if !found {
+		return nil
+	}
+	return &info
+}
+
+// parseReset reads a rate limit reset given as a duration ("6m0s"),
+// seconds or an RFC 3339 time
+func parseReset(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, true
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(v * float64(time.Second)), true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
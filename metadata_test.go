@@ -0,0 +1,138 @@
+package llmx
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/core"
+)
+
+func TestNormalizeFinishReason(t *testing.T) {
+	tests := map[string]FinishReason{
+		"stop":                          FinishReasonStop,
+		"end_turn":                      FinishReasonStop,
+		"STOP":                          FinishReasonStop,
+		"COMPLETE":                      FinishReasonStop,
+		"length":                        FinishReasonLength,
+		"MAX_TOKENS":                    FinishReasonLength,
+		"model_context_window_exceeded": FinishReasonLength,
+		"tool_use":                      FinishReasonToolCalls,
+		"function_call":                 FinishReasonToolCalls,
+		"SAFETY":                        FinishReasonContentFilter,
+		"guardrail_intervened":          FinishReasonContentFilter,
+		"ERROR_TOXIC":                   FinishReasonContentFilter,
+		"MALFORMED_FUNCTION_CALL":       FinishReasonError,
+		"":                              "",
+		"something_new":                 "",
+	}
+	for raw, want := range tests {
+		if got := NormalizeFinishReason(raw); got != want {
+			t.Errorf("NormalizeFinishReason(%q): expected %q, got %q", raw, want, got)
+		}
+	}
+}
+
+func TestRateLimitFromHeader(t *testing.T) {
+	openai := http.Header{
+		"X-Ratelimit-Limit-Requests":     {"500"},
+		"X-Ratelimit-Remaining-Requests": {"499"},
+		"X-Ratelimit-Remaining-Tokens":   {"0"},
+		"X-Ratelimit-Reset-Tokens":       {"6m0s"},
+	}
+	got := RateLimitFromHeader(openai)
+	want := RateLimitInfo{LimitRequests: 500, LimitTokens: -1, RemainingRequests: 499, RemainingTokens: 0, ResetTokens: 6 * time.Minute}
+	if got == nil || *got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	anthropic := http.Header{
+		"Anthropic-Ratelimit-Tokens-Remaining": {"39000"},
+		"Anthropic-Ratelimit-Tokens-Reset":     {time.Now().Add(time.Minute).UTC().Format(time.RFC3339)},
+	}
+	got = RateLimitFromHeader(anthropic)
+	if got == nil || got.RemainingTokens != 39000 || got.RemainingRequests != -1 || got.ResetTokens <= 0 || got.ResetTokens > time.Minute {
+		t.Errorf("unexpected Anthropic rate limit %+v", got)
+	}
+
+	if got := RateLimitFromHeader(http.Header{}); got != nil {
+		t.Errorf("expected nil without rate limit headers, got %+v", got)
+	}
+}
+
+func TestClient_ResponseMetadata(t *testing.T) {
+	client, err := NewClient(WithProvider("mock", map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	req := &ChatRequest{Messages: []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "hi"}}}}}
+
+	for _, withMiddleware := range []bool{false, true} {
+		if withMiddleware {
+			client.Use(func(next Handler) Handler { return next })
+		}
+		resp, err := client.Chat(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if resp.Metadata.Provider != "mock" || resp.Metadata.Latency <= 0 {
+			t.Errorf("expected provider and latency, got %+v", resp.Metadata)
+		}
+		if resp.FinishReason != FinishReasonStop {
+			t.Errorf("expected a missing finish reason to be inferred as stop, got %q", resp.FinishReason)
+		}
+	}
+}
+
+func TestCompleteResponse_FinishReason(t *testing.T) {
+	tests := []struct {
+		name string
+		resp ChatResponse
+		want FinishReason
+	}{
+		{"normalized", ChatResponse{FinishReason: FinishReasonLength}, FinishReasonLength},
+		{"raw only", ChatResponse{RawFinishReason: "max_tokens"}, FinishReasonLength},
+		{"stop with tool calls", ChatResponse{FinishReason: FinishReasonStop, ToolCalls: []ToolCall{{Name: "f"}}}, FinishReasonToolCalls},
+		{"missing with tool calls", ChatResponse{ToolCalls: []ToolCall{{Name: "f"}}}, FinishReasonToolCalls},
+		{"filtered with tool calls", ChatResponse{FinishReason: FinishReasonContentFilter, ToolCalls: []ToolCall{{Name: "f"}}}, FinishReasonContentFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completeResponse(&tt.resp, "test", time.Now())
+			if tt.resp.FinishReason != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, tt.resp.FinishReason)
+			}
+		})
+	}
+}
+
+func TestChatStream_Metadata(t *testing.T) {
+	start := time.Now()
+	stream := NewChatStream(context.Background())
+	stream.UpdateMetadata(func(m *ResponseMetadata) { m.RequestID = "req_1" })
+	stream.started("test", start.Add(-time.Second))
+
+	go func() {
+		defer stream.Close()
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeStart})
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeTextDelta, Data: "hi"})
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeFinish, Data: "MAX_TOKENS"})
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeFinish})
+	}()
+
+	resp, err := stream.Accumulate()
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	if resp.FinishReason != FinishReasonLength || resp.RawFinishReason != "MAX_TOKENS" {
+		t.Errorf("expected length from MAX_TOKENS, got %q from %q", resp.FinishReason, resp.RawFinishReason)
+	}
+	m := resp.Metadata
+	if m.Provider != "test" || m.RequestID != "req_1" {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+	if m.TimeToFirstToken < time.Second || m.Latency < m.TimeToFirstToken {
+		t.Errorf("expected timings measured from the request, got TTFT %v, latency %v", m.TimeToFirstToken, m.Latency)
+	}
+}
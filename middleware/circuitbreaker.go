@@ -2,11 +2,14 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/store"
 )
 
 // CircuitState represents the state of circuit breaker
@@ -21,20 +24,51 @@ const (
 	StateHalfOpen
 )
 
-// CircuitBreaker implements the circuit breaker pattern
+// defaultWindowSize is the count window of failure rate mode unless set
+const defaultWindowSize = 100
+
+// CircuitStateChange describes a transition of a circuit breaker, see
+// CircuitBreaker.WithOnStateChange
+type CircuitStateChange struct {
+	// Name is the breaker's name, the model for PerModelCircuitBreaker
+	Name string
+	From CircuitState
+	To   CircuitState
+}
+
+// CircuitBreaker implements the circuit breaker pattern. By default it
+// opens after maxFailures consecutive failures; WithFailureRate makes it
+// open on the failure rate of a sliding window instead.
 type CircuitBreaker struct {
 	mu sync.RWMutex
 
+	name             string
 	maxFailures      int           // Max failures before opening
 	timeout          time.Duration // Timeout before trying half-open
 	resetSuccesses   int           // Successes needed to close from half-open
 	halfOpenRequests int           // Max requests allowed in half-open
 
+	failureRate   float64       // Failure rate opening the circuit, 0 to count consecutive failures
+	minRequests   int           // Requests in the window before the rate counts
+	window        outcomeWindow // Recent outcomes, in failure rate mode
+	isFailure     func(err error) bool
+	onStateChange []func(change CircuitStateChange)
+	now           func() time.Time
+
+	store       store.StateStore // Shares the open state with other replicas
+	storePolicy store.FailurePolicy
+
 	state            CircuitState
 	failures         int
 	successes        int
 	lastFailureTime  time.Time
+	openedAt         time.Time
 	halfOpenAttempts int
+
+	// changes are the transitions to report once mu is released, and
+	// publish whether to share the opening of the circuit then
+	changes []CircuitStateChange
+	publish bool
 }
 
 // NewCircuitBreaker creates a new circuit breaker
@@ -44,6 +78,8 @@ func NewCircuitBreaker(maxFailures int, timeout time.Duration) *CircuitBreaker {
 		timeout:          timeout,
 		resetSuccesses:   2,
 		halfOpenRequests: 3,
+		isFailure:        IsCircuitFailure,
+		now:              time.Now,
 		state:            StateClosed,
 	}
 }
@@ -60,28 +96,109 @@ func (cb *CircuitBreaker) WithHalfOpenRequests(n int) *CircuitBreaker {
 	return cb
 }
 
-// Allow checks if the request should be allowed
+// WithName names the breaker in its errors and state changes
+func (cb *CircuitBreaker) WithName(name string) *CircuitBreaker {
+	cb.name = name
+	return cb
+}
+
+// WithFailureRate opens the circuit once at least rate (0 to 1) of the
+// requests in the window failed, counting only windows of at least
+// minRequests requests. The window is the last 100 requests unless set with
+// WithCountWindow or WithTimeWindow.
+func (cb *CircuitBreaker) WithFailureRate(rate float64, minRequests int) *CircuitBreaker {
+	cb.failureRate = rate
+	cb.minRequests = minRequests
+	if cb.window == nil {
+		cb.window = newCountWindow(defaultWindowSize)
+	}
+	return cb
+}
+
+// WithCountWindow computes the failure rate over the last size requests
+func (cb *CircuitBreaker) WithCountWindow(size int) *CircuitBreaker {
+	cb.window = newCountWindow(size)
+	return cb
+}
+
+// WithTimeWindow computes the failure rate over the requests of the last
+// window
+func (cb *CircuitBreaker) WithTimeWindow(window time.Duration) *CircuitBreaker {
+	cb.window = newTimeWindow(window)
+	return cb
+}
+
+// WithFailurePredicate replaces IsCircuitFailure as the predicate of the
+// errors counting as failures. Other errors count as successes, since the
+// provider answered.
+func (cb *CircuitBreaker) WithFailurePredicate(isFailure func(err error) bool) *CircuitBreaker {
+	cb.isFailure = isFailure
+	return cb
+}
+
+// WithOnStateChange calls fn after every state change, e.g. to record it,
+// see TelemetryCircuitBreaker
+func (cb *CircuitBreaker) WithOnStateChange(fn func(change CircuitStateChange)) *CircuitBreaker {
+	cb.onStateChange = append(cb.onStateChange, fn)
+	return cb
+}
+
+// WithStore shares the open state of the breaker through s: a breaker
+// opening publishes it, and the breakers of other replicas with the same
+// name open too until its timeout. Failures are still counted by each
+// replica. While s is unreachable, store.FailOpen keeps to the replica's
+// own state and store.FailClosed refuses requests; other store errors
+// refuse them either way.
+func (cb *CircuitBreaker) WithStore(s store.StateStore, policy store.FailurePolicy) *CircuitBreaker {
+	cb.store = s
+	cb.storePolicy = policy
+	return cb
+}
+
+// Allow checks if the request should be allowed. It returns a
+// *llmx.CircuitOpenError if not.
 func (cb *CircuitBreaker) Allow() error {
+	return cb.allow(context.Background())
+}
+
+// allow checks if the request should be allowed, reading the shared state
+// within ctx
+func (cb *CircuitBreaker) allow(ctx context.Context) error {
+	var sharedOpenedAt time.Time
+	if cb.store != nil && cb.State() == StateClosed {
+		openedAt, err := cb.sharedOpenedAt(ctx)
+		if err != nil && (cb.storePolicy == store.FailClosed || !store.IsUnavailable(err)) {
+			return llmx.NewCircuitOpenError(fmt.Sprintf("circuit breaker store failed: %v", err), 0)
+		}
+		sharedOpenedAt = openedAt
+	}
+
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
 	switch cb.state {
 	case StateClosed:
+		// Another replica opened the circuit
+		if wait := cb.timeout - cb.now().Sub(sharedOpenedAt); !sharedOpenedAt.IsZero() && wait > 0 {
+			cb.transition(StateOpen)
+			cb.openedAt = sharedOpenedAt
+			cb.publish = false
+			return cb.openError("is open", wait)
+		}
 		return nil
 
 	case StateOpen:
 		// Check if timeout has passed
-		if time.Since(cb.lastFailureTime) > cb.timeout {
-			// Transition to half-open
-			cb.state = StateHalfOpen
-			cb.halfOpenAttempts = 0
-			return nil
+		if wait := cb.timeout - cb.now().Sub(cb.openedAt); wait > 0 {
+			return cb.openError("is open", wait)
 		}
-		return fmt.Errorf("circuit breaker is open")
+		cb.transition(StateHalfOpen)
+		cb.halfOpenAttempts = 1
+		return nil
 
 	case StateHalfOpen:
 		if cb.halfOpenAttempts >= cb.halfOpenRequests {
-			return fmt.Errorf("circuit breaker is half-open, max attempts reached")
+			return cb.openError("is half-open, max attempts reached", 0)
 		}
 		cb.halfOpenAttempts++
 		return nil
@@ -91,23 +208,33 @@ func (cb *CircuitBreaker) Allow() error {
 	}
 }
 
+// Record records the outcome of a request, a success if err is nil or not
+// a failure for the breaker's predicate
+func (cb *CircuitBreaker) Record(err error) {
+	if err != nil && cb.isFailure(err) {
+		cb.RecordFailure()
+		return
+	}
+	cb.RecordSuccess()
+}
+
 // RecordSuccess records a successful request
 func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
 	switch cb.state {
 	case StateClosed:
 		cb.failures = 0
+		if cb.window != nil {
+			cb.window.add(cb.now(), false)
+		}
 
 	case StateHalfOpen:
 		cb.successes++
 		if cb.successes >= cb.resetSuccesses {
 			// Close the circuit
-			cb.state = StateClosed
-			cb.failures = 0
-			cb.successes = 0
-			cb.halfOpenAttempts = 0
+			cb.transition(StateClosed)
 		}
 	}
 }
@@ -115,26 +242,103 @@ func (cb *CircuitBreaker) RecordSuccess() {
 // RecordFailure records a failed request
 func (cb *CircuitBreaker) RecordFailure() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
-	cb.lastFailureTime = time.Now()
+	now := cb.now()
+	cb.lastFailureTime = now
 
 	switch cb.state {
 	case StateClosed:
 		cb.failures++
-		if cb.failures >= cb.maxFailures {
-			// Open the circuit
-			cb.state = StateOpen
+		if cb.failureRate <= 0 {
+			if cb.failures >= cb.maxFailures {
+				cb.transition(StateOpen)
+			}
+			return
+		}
+
+		cb.window.add(now, true)
+		requests, failures := cb.window.counts(now)
+		if requests >= max(cb.minRequests, 1) && float64(failures) >= cb.failureRate*float64(requests) {
+			cb.transition(StateOpen)
 		}
 
 	case StateHalfOpen:
 		// Go back to open state
-		cb.state = StateOpen
-		cb.successes = 0
-		cb.halfOpenAttempts = 0
+		cb.transition(StateOpen)
 	}
 }
 
+// transition moves the breaker to state, resetting the counts. cb.mu must
+// be held.
+func (cb *CircuitBreaker) transition(state CircuitState) {
+	if state == cb.state {
+		return
+	}
+	cb.changes = append(cb.changes, CircuitStateChange{Name: cb.name, From: cb.state, To: state})
+	cb.state = state
+	cb.failures = 0
+	cb.successes = 0
+	cb.halfOpenAttempts = 0
+	if state == StateOpen {
+		cb.openedAt = cb.now()
+		cb.publish = cb.store != nil
+	}
+	if state == StateClosed && cb.window != nil {
+		cb.window.reset()
+	}
+}
+
+// unlock releases cb.mu, then shares an opening and reports the state
+// changes made while it was held
+func (cb *CircuitBreaker) unlock() {
+	changes := cb.changes
+	publish, openedAt := cb.publish, cb.openedAt
+	cb.changes = nil
+	cb.publish = false
+	cb.mu.Unlock()
+
+	if publish {
+		// Failing to share it leaves other replicas to open on their own
+		value := []byte(strconv.FormatInt(openedAt.UnixNano(), 10))
+		cb.store.Set(context.Background(), cb.storeKey(), value, cb.timeout)
+	}
+
+	for _, change := range changes {
+		for _, fn := range cb.onStateChange {
+			fn(change)
+		}
+	}
+}
+
+// storeKey returns the key of the shared state of the breaker
+func (cb *CircuitBreaker) storeKey() string {
+	return "llmx:breaker:" + cb.name
+}
+
+// sharedOpenedAt returns when another replica opened the circuit, zero if
+// it is not open
+func (cb *CircuitBreaker) sharedOpenedAt(ctx context.Context) (time.Time, error) {
+	value, ok, err := cb.store.Get(ctx, cb.storeKey())
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+	nanos, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// openError returns the error refusing a request, retryable after wait
+func (cb *CircuitBreaker) openError(reason string, wait time.Duration) error {
+	message := "circuit breaker " + reason
+	if cb.name != "" {
+		message = fmt.Sprintf("circuit breaker for %s %s", cb.name, reason)
+	}
+	return llmx.NewCircuitOpenError(message, wait)
+}
+
 // State returns the current state
 func (cb *CircuitBreaker) State() CircuitState {
 	cb.mu.RLock()
@@ -147,13 +351,19 @@ func (cb *CircuitBreaker) Stats() map[string]interface{} {
 	cb.mu.RLock()
 	defer cb.mu.RUnlock()
 
-	return map[string]interface{}{
-		"state":               cb.state.String(),
-		"failures":            cb.failures,
-		"successes":           cb.successes,
-		"half_open_attempts":  cb.halfOpenAttempts,
-		"last_failure_time":   cb.lastFailureTime,
+	stats := map[string]interface{}{
+		"state":              cb.state.String(),
+		"failures":           cb.failures,
+		"successes":          cb.successes,
+		"half_open_attempts": cb.halfOpenAttempts,
+		"last_failure_time":  cb.lastFailureTime,
 	}
+	if cb.window != nil {
+		requests, failures := cb.window.counts(cb.now())
+		stats["window_requests"] = requests
+		stats["window_failures"] = failures
+	}
+	return stats
 }
 
 // String returns string representation of circuit state
@@ -170,33 +380,63 @@ func (s CircuitState) String() string {
 	}
 }
 
-// CircuitBreakerMiddleware creates a circuit breaker middleware
+// IsCircuitFailure reports whether an error says the provider is unhealthy:
+// llmx errors that are retryable or server-side, and errors llmx doesn't
+// know. Client errors such as invalid requests and cancellations don't
+// count, nor do the rejections of a Scheduler, a full queue or a wait for
+// a slot that timed out, which never reached the provider.
+func IsCircuitFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, llmx.ErrQueueFull) || errors.Is(err, ErrQueueWait) {
+		return false
+	}
+
+	var llmxErr llmx.Error
+	if errors.As(err, &llmxErr) {
+		return llmxErr.Retryable() || llmxErr.StatusCode() >= 500
+	}
+	return true
+}
+
+// CircuitBreakerMiddleware creates a circuit breaker middleware.
+//
+// Middleware added first runs first, so the breaker sees the outcomes of
+// the middleware added after it. Add it after rate limiters and
+// SchedulerMiddleware, whose rejections say nothing about the provider,
+// and after RetryMiddleware, so each attempt is recorded and an open
+// circuit stops the retries. TimeoutMiddleware goes after it, so calls
+// that time out count as failures:
+//
+//	client.Use(
+//		middleware.RetryMiddleware(retry),
+//		middleware.SchedulerMiddleware(scheduler),
+//		middleware.CircuitBreakerMiddleware(breaker),
+//		middleware.TimeoutMiddleware(30*time.Second),
+//	)
 func CircuitBreakerMiddleware(cb *CircuitBreaker) Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
-			// Check if request is allowed
-			if err := cb.Allow(); err != nil {
-				return nil, llmx.NewInternalError(
-					fmt.Sprintf("circuit breaker: %v", err),
-					nil,
-				)
-			}
-
-			// Execute request
-			resp, err := next(ctx, req)
+			return guard(ctx, cb, next, req)
+		}
+	}
+}
 
-			if err != nil {
-				// Record all errors for circuit breaker
-				// (Circuit breaker should trip on any error, not just retryable ones)
-				cb.RecordFailure()
-				return nil, err
-			}
+// guard sends req through cb
+func guard(ctx context.Context, cb *CircuitBreaker, next Handler, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+	// Check if request is allowed
+	if err := cb.allow(ctx); err != nil {
+		return nil, err
+	}
 
-			// Record success
-			cb.RecordSuccess()
-			return resp, nil
-		}
+	// Execute request
+	resp, err := next(ctx, req)
+	cb.Record(err)
+	if err != nil {
+		return nil, err
 	}
+	return resp, nil
 }
 
 // PerModelCircuitBreaker manages circuit breakers per model
@@ -206,7 +446,8 @@ type PerModelCircuitBreaker struct {
 	factory  func() *CircuitBreaker
 }
 
-// NewPerModelCircuitBreaker creates a per-model circuit breaker
+// NewPerModelCircuitBreaker creates a per-model circuit breaker. Breakers
+// made by factory without a name are named after their model.
 func NewPerModelCircuitBreaker(factory func() *CircuitBreaker) *PerModelCircuitBreaker {
 	return &PerModelCircuitBreaker{
 		breakers: make(map[string]*CircuitBreaker),
@@ -233,6 +474,9 @@ func (p *PerModelCircuitBreaker) GetBreaker(model string) *CircuitBreaker {
 	}
 
 	breaker = p.factory()
+	if breaker.name == "" {
+		breaker.name = model
+	}
 	p.breakers[model] = breaker
 	return breaker
 }
@@ -241,27 +485,96 @@ func (p *PerModelCircuitBreaker) GetBreaker(model string) *CircuitBreaker {
 func CircuitBreakerPerModel(pmcb *PerModelCircuitBreaker) Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
-			breaker := pmcb.GetBreaker(req.Model)
-
-			// Check if request is allowed
-			if err := breaker.Allow(); err != nil {
-				return nil, llmx.NewInternalError(
-					fmt.Sprintf("circuit breaker for model %s: %v", req.Model, err),
-					nil,
-				)
-			}
+			return guard(ctx, pmcb.GetBreaker(req.Model), next, req)
+		}
+	}
+}
 
-			// Execute request
-			resp, err := next(ctx, req)
+// outcomeWindow counts the outcomes of recent requests
+type outcomeWindow interface {
+	add(now time.Time, failed bool)
+	counts(now time.Time) (requests, failures int)
+	reset()
+}
 
-			if err != nil {
-				// Record all errors for circuit breaker
-				breaker.RecordFailure()
-				return nil, err
-			}
+// countWindow holds the outcomes of the last requests in a ring
+type countWindow struct {
+	outcomes []bool
+	next     int
+	filled   int
+	failures int
+}
 
-			breaker.RecordSuccess()
-			return resp, nil
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]bool, max(size, 1))}
+}
+
+func (w *countWindow) add(now time.Time, failed bool) {
+	if w.filled == len(w.outcomes) {
+		if w.outcomes[w.next] {
+			w.failures--
 		}
+	} else {
+		w.filled++
+	}
+	w.outcomes[w.next] = failed
+	if failed {
+		w.failures++
 	}
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+func (w *countWindow) counts(now time.Time) (int, int) {
+	return w.filled, w.failures
+}
+
+func (w *countWindow) reset() {
+	*w = countWindow{outcomes: make([]bool, len(w.outcomes))}
+}
+
+// timeWindowBuckets is the number of buckets a time window is split into
+const timeWindowBuckets = 10
+
+// timeWindow counts outcomes in buckets of a tenth of the window, so that
+// it forgets them a bucket at a time
+type timeWindow struct {
+	bucket  time.Duration
+	buckets [timeWindowBuckets]windowBucket
+}
+
+// windowBucket counts the outcomes of the bucket starting at start
+type windowBucket struct {
+	start              int64
+	requests, failures int
+}
+
+func newTimeWindow(window time.Duration) *timeWindow {
+	return &timeWindow{bucket: max(window/timeWindowBuckets, 1)}
+}
+
+func (w *timeWindow) add(now time.Time, failed bool) {
+	index := now.UnixNano() / int64(w.bucket)
+	b := &w.buckets[index%timeWindowBuckets]
+	if b.start != index {
+		*b = windowBucket{start: index}
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *timeWindow) counts(now time.Time) (requests, failures int) {
+	index := now.UnixNano() / int64(w.bucket)
+	for _, b := range w.buckets {
+		if index-b.start < timeWindowBuckets {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
+}
+
+func (w *timeWindow) reset() {
+	w.buckets = [timeWindowBuckets]windowBucket{}
 }
@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -131,3 +132,171 @@ func TestCircuitBreakerMiddleware(t *testing.T) {
 		}
 	})
 }
+
+// fakeClock is a settable clock for breakers
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(cb *CircuitBreaker) (*CircuitBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	cb.now = clock.now
+	return cb, clock
+}
+
+func TestCircuitBreaker_OpenError(t *testing.T) {
+	cb, clock := newTestBreaker(NewCircuitBreaker(1, 30*time.Second).WithName("gpt-4o"))
+	cb.RecordFailure()
+	clock.advance(10 * time.Second)
+
+	err := cb.Allow()
+	var open *llmx.CircuitOpenError
+	if !errors.As(err, &open) || !errors.Is(err, llmx.ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v, want a circuit open error", err)
+	}
+	if open.RetryAfter != 20*time.Second {
+		t.Errorf("RetryAfter = %v, want 20s", open.RetryAfter)
+	}
+	if open.Retryable() || IsRetryable(err) {
+		t.Error("circuit open errors should not be retried")
+	}
+
+	clock.advance(20 * time.Second)
+	if err := cb.Allow(); err != nil || cb.State() != StateHalfOpen {
+		t.Errorf("Allow() = %v in state %v, want a half-open trial", err, cb.State())
+	}
+}
+
+func TestCircuitBreaker_FailurePredicate(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute)
+	invalid := llmx.NewInvalidRequestError("bad input", nil)
+	handler := CircuitBreakerMiddleware(cb)(func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		return nil, invalid
+	})
+
+	for i := 0; i < 5; i++ {
+		if _, err := handler(context.Background(), &llmx.ChatRequest{}); err != invalid {
+			t.Fatalf("request %d: error = %v, want the invalid request error", i, err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("State() = %v, invalid requests should not open the circuit", cb.State())
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"overloaded", llmx.NewOverloadedError("busy", 0), true},
+		{"provider 500", llmx.NewProviderError("test", "oops", 500, nil), true},
+		{"timeout", llmx.NewTimeoutError("slow", nil), true},
+		{"invalid request", invalid, false},
+		{"authentication", llmx.NewAuthenticationError("bad key"), false},
+		{"canceled", context.Canceled, false},
+		{"queue full", llmx.NewQueueFullError("full", "a"), false},
+		{"queue wait", llmx.NewTimeoutError("queued", fmt.Errorf("%w: %w", ErrQueueWait, context.DeadlineExceeded)), false},
+		{"unknown", errors.New("connection reset"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCircuitFailure(tt.err); got != tt.want {
+				t.Errorf("IsCircuitFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	cb, _ := newTestBreaker(NewCircuitBreaker(1, time.Minute).WithFailureRate(0.5, 10).WithCountWindow(10))
+
+	// Below the minimum volume, failures alone don't open it
+	for i := 0; i < 4; i++ {
+		cb.RecordSuccess()
+		cb.RecordFailure()
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("State() = %v after 8 requests, want closed", cb.State())
+	}
+
+	// 5 failures of 10 reach the rate
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("State() = %v at a 50%% failure rate, want open", cb.State())
+	}
+
+	// Successes push old failures out of the window
+	cb, _ = newTestBreaker(NewCircuitBreaker(1, time.Minute).WithFailureRate(0.5, 4).WithCountWindow(4))
+	cb.RecordFailure()
+	for i := 0; i < 10; i++ {
+		cb.RecordSuccess()
+	}
+	cb.RecordFailure()
+	if cb.State() != StateClosed {
+		t.Errorf("State() = %v at a 25%% failure rate, want closed", cb.State())
+	}
+}
+
+func TestCircuitBreaker_TimeWindow(t *testing.T) {
+	cb, clock := newTestBreaker(NewCircuitBreaker(1, time.Minute).WithFailureRate(0.5, 4).WithTimeWindow(10 * time.Second))
+
+	for i := 0; i < 3; i++ {
+		cb.RecordFailure()
+	}
+	clock.advance(11 * time.Second)
+
+	// The old failures expired, leaving 1 of 4
+	cb.RecordFailure()
+	for i := 0; i < 3; i++ {
+		cb.RecordSuccess()
+	}
+	if cb.State() != StateClosed {
+		t.Fatalf("State() = %v, expired failures should not count", cb.State())
+	}
+
+	cb.RecordFailure()
+	if cb.State() != StateClosed {
+		t.Fatalf("State() = %v at 2 of 5 failures, want closed", cb.State())
+	}
+	cb.RecordFailure()
+	if cb.State() != StateOpen {
+		t.Errorf("State() = %v at 3 of 6 failures, want open", cb.State())
+	}
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	var changes []CircuitStateChange
+	pmcb := NewPerModelCircuitBreaker(func() *CircuitBreaker {
+		cb, _ := newTestBreaker(NewCircuitBreaker(1, 0).
+			WithResetSuccesses(1).
+			WithOnStateChange(func(change CircuitStateChange) {
+				changes = append(changes, change)
+			}))
+		return cb
+	})
+
+	cb := pmcb.GetBreaker("gpt-4o")
+	cb.RecordFailure()
+	cb.Allow()
+	cb.RecordFailure()
+	cb.Allow()
+	cb.RecordSuccess()
+
+	want := []CircuitStateChange{
+		{Name: "gpt-4o", From: StateClosed, To: StateOpen},
+		{Name: "gpt-4o", From: StateOpen, To: StateHalfOpen},
+		{Name: "gpt-4o", From: StateHalfOpen, To: StateOpen},
+		{Name: "gpt-4o", From: StateOpen, To: StateHalfOpen},
+		{Name: "gpt-4o", From: StateHalfOpen, To: StateClosed},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Register the middleware of this package for use in config files, see
+// llmx.LoadConfig. Each is parsed into a config, which validating a
+// llmx.Config stops at, and built from it by NewClient.
+func init() {
+	llmx.RegisterMiddleware("retry", parseRetryConfig, retryConfig.middleware)
+	llmx.RegisterMiddleware("rate_limit", parseRateLimitConfig, rateLimitConfig.middleware)
+	llmx.RegisterMiddleware("token_rate_limit", parseTokenRateLimitConfig, tokenRateLimitConfig.middleware)
+	llmx.RegisterMiddleware("cache", parseCacheConfig, cacheConfig.middleware)
+	llmx.RegisterMiddleware("circuit_breaker", parseCircuitBreakerConfig, circuitBreakerConfig.middleware)
+	llmx.RegisterMiddleware("scheduler", parseSchedulerConfig, schedulerConfig.middleware)
+	llmx.RegisterMiddleware("timeout", parseTimeoutConfig, timeoutConfig.middleware)
+	llmx.RegisterMiddleware("logging", parseLoggingConfig, loggingConfig.middleware)
+}
+
+// retryConfig configures a RetryPolicy
+type retryConfig struct {
+	maxRetries     int
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+	jitter         string
+	attemptTimeout time.Duration
+	maxRetryAfter  time.Duration
+	budget         int
+	budgetRatio    float64
+}
+
+// parseRetryConfig reads max_retries, base_delay, max_delay, jitter
+// ("full", "decorrelated" or "none"), attempt_timeout, max_retry_after and
+// a budget of budget tokens refilled by budget_ratio
+func parseRetryConfig(params llmx.MiddlewareParams) (retryConfig, error) {
+	var c retryConfig
+	if err := params.Only("max_retries", "base_delay", "max_delay", "jitter", "attempt_timeout",
+		"max_retry_after", "budget", "budget_ratio"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.maxRetries, err = params.Int("max_retries", 3); err != nil {
+		return c, err
+	}
+	if c.maxRetries < 0 {
+		return c, &llmx.ParamError{Key: "max_retries", Message: "must not be negative"}
+	}
+	if c.baseDelay, err = params.Duration("base_delay", time.Second); err != nil {
+		return c, err
+	}
+	if c.maxDelay, err = params.Duration("max_delay", 30*time.Second); err != nil {
+		return c, err
+	}
+	if c.jitter, err = params.String("jitter", "full"); err != nil {
+		return c, err
+	}
+	switch c.jitter {
+	case "full", "decorrelated", "none":
+	default:
+		return c, &llmx.ParamError{Key: "jitter", Message: "must be full, decorrelated or none"}
+	}
+	if c.attemptTimeout, err = params.Duration("attempt_timeout", 0); err != nil {
+		return c, err
+	}
+	if c.maxRetryAfter, err = params.Duration("max_retry_after", time.Minute); err != nil {
+		return c, err
+	}
+	if c.budget, err = params.Int("budget", 0); err != nil {
+		return c, err
+	}
+	if c.budget < 0 {
+		return c, &llmx.ParamError{Key: "budget", Message: "must not be negative"}
+	}
+	if c.budgetRatio, err = params.Float("budget_ratio", 0.1); err != nil {
+		return c, err
+	}
+	if c.budgetRatio < 0 {
+		return c, &llmx.ParamError{Key: "budget_ratio", Message: "must not be negative"}
+	}
+	return c, nil
+}
+
+// middleware builds the RetryPolicy
+func (c retryConfig) middleware() Middleware {
+	var backoff BackoffStrategy
+	switch c.jitter {
+	case "decorrelated":
+		backoff = &DecorrelatedJitterBackoff{Base: c.baseDelay, Max: c.maxDelay}
+	case "none":
+		backoff = &ExponentialBackoff{Base: c.baseDelay, Max: c.maxDelay}
+	default:
+		backoff = &FullJitterBackoff{Base: c.baseDelay, Max: c.maxDelay}
+	}
+
+	policy := NewRetryPolicy(c.maxRetries).
+		WithBackoff(backoff).
+		WithAttemptTimeout(c.attemptTimeout).
+		WithMaxRetryAfter(c.maxRetryAfter)
+	if c.budget > 0 {
+		policy.WithBudget(NewRetryBudget(c.budget, c.budgetRatio))
+	}
+	return policy.Middleware()
+}
+
+// rateLimitConfig configures RateLimit
+type rateLimitConfig struct {
+	rps      float64
+	burst    int
+	wait     bool
+	perModel bool
+}
+
+// parseRateLimitConfig reads requests_per_second, burst, wait and
+// per_model
+func parseRateLimitConfig(params llmx.MiddlewareParams) (rateLimitConfig, error) {
+	var c rateLimitConfig
+	if err := params.Only("requests_per_second", "burst", "wait", "per_model"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.rps, err = params.Float("requests_per_second", 0); err != nil {
+		return c, err
+	}
+	if c.rps <= 0 {
+		return c, &llmx.ParamError{Key: "requests_per_second", Message: "must be positive"}
+	}
+	if c.burst, err = params.Int("burst", 1); err != nil {
+		return c, err
+	}
+	if c.burst < 1 {
+		return c, &llmx.ParamError{Key: "burst", Message: "must be at least 1"}
+	}
+	if c.wait, err = params.Bool("wait", true); err != nil {
+		return c, err
+	}
+	if c.perModel, err = params.Bool("per_model", false); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// middleware builds RateLimit, or RateLimitPerModel with per_model
+func (c rateLimitConfig) middleware() Middleware {
+	if c.perModel {
+		limiter := NewModelRateLimiter(func() RateLimiter { return NewTokenBucketLimiter(c.rps, c.burst) })
+		return RateLimitPerModel(limiter, c.wait)
+	}
+	return RateLimit(NewTokenBucketLimiter(c.rps, c.burst), c.wait)
+}
+
+// tokenRateLimitConfig configures TokenRateLimit
+type tokenRateLimitConfig struct {
+	limits   TokenLimits
+	wait     bool
+	perModel bool
+}
+
+// parseTokenRateLimitConfig reads tokens_per_minute, requests_per_minute,
+// wait and per_model
+func parseTokenRateLimitConfig(params llmx.MiddlewareParams) (tokenRateLimitConfig, error) {
+	var c tokenRateLimitConfig
+	if err := params.Only("tokens_per_minute", "requests_per_minute", "wait", "per_model"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.limits.TokensPerMinute, err = params.Int("tokens_per_minute", 0); err != nil {
+		return c, err
+	}
+	if c.limits.TokensPerMinute < 0 {
+		return c, &llmx.ParamError{Key: "tokens_per_minute", Message: "must not be negative"}
+	}
+	if c.limits.RequestsPerMinute, err = params.Int("requests_per_minute", 0); err != nil {
+		return c, err
+	}
+	if c.limits.RequestsPerMinute < 0 {
+		return c, &llmx.ParamError{Key: "requests_per_minute", Message: "must not be negative"}
+	}
+	if c.wait, err = params.Bool("wait", true); err != nil {
+		return c, err
+	}
+	if c.perModel, err = params.Bool("per_model", true); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// middleware builds TokenRateLimit, for each model unless per_model is
+// false
+func (c tokenRateLimitConfig) middleware() Middleware {
+	limiter := NewTokenLimiter(c.limits)
+	if !c.perModel {
+		limiter.WithKey(func(ctx context.Context, req *llmx.ChatRequest) string { return "" })
+	}
+	return TokenRateLimit(limiter, c.wait)
+}
+
+// schedulerConfig configures SchedulerMiddleware
+type schedulerConfig struct {
+	concurrency    int
+	maxQueue       int
+	maxTenantQueue int
+	maxWait        time.Duration
+}
+
+// parseSchedulerConfig reads concurrency, max_queue, max_tenant_queue and
+// max_wait; tenants and priorities are set on the request context with
+// WithTenant and WithPriority
+func parseSchedulerConfig(params llmx.MiddlewareParams) (schedulerConfig, error) {
+	var c schedulerConfig
+	if err := params.Only("concurrency", "max_queue", "max_tenant_queue", "max_wait"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.concurrency, err = params.Int("concurrency", 0); err != nil {
+		return c, err
+	}
+	if c.concurrency <= 0 {
+		return c, &llmx.ParamError{Key: "concurrency", Message: "must be positive"}
+	}
+	if c.maxQueue, err = params.Int("max_queue", 100); err != nil {
+		return c, err
+	}
+	if c.maxQueue < 0 {
+		return c, &llmx.ParamError{Key: "max_queue", Message: "must not be negative"}
+	}
+	if c.maxTenantQueue, err = params.Int("max_tenant_queue", 0); err != nil {
+		return c, err
+	}
+	if c.maxTenantQueue < 0 {
+		return c, &llmx.ParamError{Key: "max_tenant_queue", Message: "must not be negative"}
+	}
+	if c.maxWait, err = params.Duration("max_wait", 0); err != nil {
+		return c, err
+	}
+	if c.maxWait < 0 {
+		return c, &llmx.ParamError{Key: "max_wait", Message: "must not be negative"}
+	}
+	return c, nil
+}
+
+// middleware builds SchedulerMiddleware
+func (c schedulerConfig) middleware() Middleware {
+	scheduler := NewScheduler(c.concurrency, c.maxQueue).
+		WithTenantQueueLimit(c.maxTenantQueue).
+		WithMaxWait(c.maxWait)
+	return SchedulerMiddleware(scheduler)
+}
+
+// cacheConfig configures CacheMiddleware
+type cacheConfig struct {
+	ttl time.Duration
+}
+
+// parseCacheConfig reads ttl
+func parseCacheConfig(params llmx.MiddlewareParams) (cacheConfig, error) {
+	var c cacheConfig
+	if err := params.Only("ttl"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.ttl, err = params.Duration("ttl", 5*time.Minute); err != nil {
+		return c, err
+	}
+	if c.ttl <= 0 {
+		return c, &llmx.ParamError{Key: "ttl", Message: "must be positive"}
+	}
+	return c, nil
+}
+
+// middleware builds CacheMiddleware over a MemoryCache
+func (c cacheConfig) middleware() Middleware {
+	return CacheMiddleware(nil, c.ttl)
+}
+
+// circuitBreakerConfig configures CircuitBreakerMiddleware
+type circuitBreakerConfig struct {
+	maxFailures      int
+	timeout          time.Duration
+	resetSuccesses   int
+	halfOpenRequests int
+	perModel         bool
+	failureRate      float64
+	minRequests      int
+	windowSize       int
+	window           time.Duration
+}
+
+// parseCircuitBreakerConfig reads max_failures, timeout, reset_successes,
+// half_open_requests and per_model. With failure_rate the breaker opens on
+// the failure rate of min_requests or more in a window of the last
+// window_size requests, or of the last window.
+func parseCircuitBreakerConfig(params llmx.MiddlewareParams) (circuitBreakerConfig, error) {
+	var c circuitBreakerConfig
+	if err := params.Only("max_failures", "timeout", "reset_successes", "half_open_requests", "per_model",
+		"failure_rate", "min_requests", "window_size", "window"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.maxFailures, err = params.Int("max_failures", 5); err != nil {
+		return c, err
+	}
+	if c.maxFailures < 1 {
+		return c, &llmx.ParamError{Key: "max_failures", Message: "must be at least 1"}
+	}
+	if c.timeout, err = params.Duration("timeout", 30*time.Second); err != nil {
+		return c, err
+	}
+	if c.resetSuccesses, err = params.Int("reset_successes", 2); err != nil {
+		return c, err
+	}
+	if c.halfOpenRequests, err = params.Int("half_open_requests", 3); err != nil {
+		return c, err
+	}
+	if c.perModel, err = params.Bool("per_model", false); err != nil {
+		return c, err
+	}
+	if c.failureRate, err = params.Float("failure_rate", 0); err != nil {
+		return c, err
+	}
+	if c.failureRate < 0 || c.failureRate > 1 {
+		return c, &llmx.ParamError{Key: "failure_rate", Message: "must be between 0 and 1"}
+	}
+	if c.minRequests, err = params.Int("min_requests", 10); err != nil {
+		return c, err
+	}
+	if c.windowSize, err = params.Int("window_size", defaultWindowSize); err != nil {
+		return c, err
+	}
+	if c.windowSize < 1 {
+		return c, &llmx.ParamError{Key: "window_size", Message: "must be at least 1"}
+	}
+	if c.window, err = params.Duration("window", 0); err != nil {
+		return c, err
+	}
+	if c.window < 0 {
+		return c, &llmx.ParamError{Key: "window", Message: "must not be negative"}
+	}
+	return c, nil
+}
+
+// middleware builds CircuitBreakerMiddleware, or CircuitBreakerPerModel
+// with per_model
+func (c circuitBreakerConfig) middleware() Middleware {
+	newBreaker := func() *CircuitBreaker {
+		cb := NewCircuitBreaker(c.maxFailures, c.timeout).
+			WithResetSuccesses(c.resetSuccesses).
+			WithHalfOpenRequests(c.halfOpenRequests)
+		if c.failureRate > 0 {
+			cb.WithFailureRate(c.failureRate, c.minRequests)
+			if c.window > 0 {
+				cb.WithTimeWindow(c.window)
+			} else {
+				cb.WithCountWindow(c.windowSize)
+			}
+		}
+		return cb
+	}
+	if c.perModel {
+		return CircuitBreakerPerModel(NewPerModelCircuitBreaker(newBreaker))
+	}
+	return CircuitBreakerMiddleware(newBreaker())
+}
+
+// timeoutConfig configures Timeout
+type timeoutConfig struct {
+	timeout time.Duration
+}
+
+// parseTimeoutConfig reads timeout
+func parseTimeoutConfig(params llmx.MiddlewareParams) (timeoutConfig, error) {
+	var c timeoutConfig
+	if err := params.Only("timeout"); err != nil {
+		return c, err
+	}
+	var err error
+	if c.timeout, err = params.Duration("timeout", 0); err != nil {
+		return c, err
+	}
+	if c.timeout <= 0 {
+		return c, &llmx.ParamError{Key: "timeout", Message: "must be positive"}
+	}
+	return c, nil
+}
+
+// middleware builds Timeout
+func (c timeoutConfig) middleware() Middleware {
+	return Timeout(c.timeout)
+}
+
+// loggingConfig configures Logging, which takes no parameters
+type loggingConfig struct{}
+
+// parseLoggingConfig rejects any parameter
+func parseLoggingConfig(params llmx.MiddlewareParams) (loggingConfig, error) {
+	return loggingConfig{}, params.Only()
+}
+
+// middleware builds Logging with the default logger
+func (c loggingConfig) middleware() Middleware {
+	return Logging(nil)
+}
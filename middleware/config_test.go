@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	_ "github.com/llmx-ai/llmx/provider/ollama"
+)
+
+func loadConfig(t *testing.T, data string) (*llmx.Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "llmx.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return llmx.LoadConfig(path, llmx.WithEnvPrefix(""))
+}
+
+func TestConfig_Middleware(t *testing.T) {
+	config, err := loadConfig(t, `
+provider: ollama
+middleware:
+  - logging
+  - name: timeout
+    timeout: 30s
+  - name: retry
+    max_retries: 2
+    base_delay: 500ms
+    jitter: decorrelated
+    attempt_timeout: 20s
+    budget: 10
+  - name: rate_limit
+    requests_per_second: 5
+    burst: 10
+    per_model: true
+  - name: token_rate_limit
+    tokens_per_minute: 90000
+    requests_per_minute: 500
+  - name: circuit_breaker
+    max_failures: 3
+    timeout: 1m
+  - name: circuit_breaker
+    per_model: true
+    failure_rate: 0.5
+    min_requests: 20
+    window: 1m
+  - name: scheduler
+    concurrency: 8
+    max_tenant_queue: 20
+    max_wait: 30s
+  - name: cache
+    ttl: 10m
+`)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if len(config.Middleware) != 9 {
+		t.Errorf("got %d middleware, want 9", len(config.Middleware))
+	}
+}
+
+func TestConfig_MiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		middleware string
+		want       string
+	}{
+		{"{name: retry, max_retries: lots}", "middleware[0].max_retries"},
+		{"{name: retry, retries: 2}", "middleware[0].retries"},
+		{"{name: retry, jitter: some}", "middleware[0].jitter"},
+		{"{name: retry, budget: -1}", "middleware[0].budget"},
+		{"{name: rate_limit}", "middleware[0].requests_per_second"},
+		{"{name: token_rate_limit, tokens_per_minute: -1}", "middleware[0].tokens_per_minute"},
+		{"{name: circuit_breaker, timeout: soon}", "middleware[0].timeout"},
+		{"{name: circuit_breaker, failure_rate: 50}", "middleware[0].failure_rate"},
+		{"{name: circuit_breaker, window_size: 0}", "middleware[0].window_size"},
+		{"{name: scheduler}", "middleware[0].concurrency"},
+		{"{name: scheduler, concurrency: 4, max_wait: -1s}", "middleware[0].max_wait"},
+		{"{name: cache, ttl: 0}", "middleware[0].ttl"},
+		{"{name: timeout}", "middleware[0].timeout"},
+		{"{name: logging, level: debug}", "middleware[0].level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.middleware, func(t *testing.T) {
+			_, err := loadConfig(t, "provider: ollama\nmiddleware:\n  - "+tt.middleware+"\n")
+
+			var invalid *llmx.InvalidRequestError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("LoadConfig() error = %v, want an invalid request error", err)
+			}
+			if invalid.Details["key"] != tt.want {
+				t.Errorf("key = %v, want %q (error: %v)", invalid.Details["key"], tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/store"
+)
+
+// GCRALimiter limits the requests of each key with GCRA over a
+// store.StateStore, so that replicas sharing the store share the limit
+// instead of each bursting up to it
+type GCRALimiter struct {
+	store  store.StateStore
+	limit  store.Limit
+	prefix string
+	policy store.FailurePolicy
+	now    func() time.Time
+}
+
+// NewGCRALimiter creates a limiter of rps requests a second in bursts of
+// burst for each key, failing open when the store is unreachable. It
+// panics if rps is not positive.
+func NewGCRALimiter(s store.StateStore, rps float64, burst int) *GCRALimiter {
+	return &GCRALimiter{
+		store:  s,
+		limit:  store.PerSecond(rps, burst),
+		prefix: "llmx:ratelimit:",
+		now:    time.Now,
+	}
+}
+
+// WithPrefix sets the prefix of the store keys, "llmx:ratelimit:" by
+// default, e.g. to separate services sharing a store
+func (g *GCRALimiter) WithPrefix(prefix string) *GCRALimiter {
+	g.prefix = prefix
+	return g
+}
+
+// WithFailurePolicy sets what happens to requests while the store is
+// unreachable: store.FailOpen lets them through, store.FailClosed refuses
+// them with a *llmx.InternalError
+func (g *GCRALimiter) WithFailurePolicy(policy store.FailurePolicy) *GCRALimiter {
+	g.policy = policy
+	return g
+}
+
+// Reserve takes cost requests from key. It returns how long to wait if the
+// limit is reached, or if other requests kept updating the key so that this
+// one could not. The failure policy applies only while the store is
+// unreachable, see store.IsUnavailable; other store errors return a
+// *llmx.InternalError.
+func (g *GCRALimiter) Reserve(ctx context.Context, key string, cost int) (time.Duration, error) {
+	result, err := store.GCRA(ctx, g.store, g.prefix+key, g.limit, cost, g.now())
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrConflict):
+		// The key is contended, so the limit is likely reached
+		return g.limit.Interval() * time.Duration(max(cost, 1)), nil
+	case store.IsUnavailable(err):
+		if g.policy == store.FailOpen {
+			return 0, nil
+		}
+		return 0, llmx.NewInternalError("rate limit store unavailable", err)
+	default:
+		return 0, llmx.NewInternalError("rate limit store failed", err)
+	}
+	if !result.Allowed {
+		return result.RetryAfter, nil
+	}
+	return 0, nil
+}
+
+// Limiter returns the RateLimiter of key, for RateLimit
+func (g *GCRALimiter) Limiter(key string) RateLimiter {
+	return &gcraKeyLimiter{limiter: g, key: key}
+}
+
+// gcraKeyLimiter is the RateLimiter of a key of a GCRALimiter
+type gcraKeyLimiter struct {
+	limiter *GCRALimiter
+	key     string
+}
+
+// Allow checks if a request can proceed
+func (l *gcraKeyLimiter) Allow() bool {
+	delay, err := l.limiter.Reserve(context.Background(), l.key, 1)
+	return err == nil && delay == 0
+}
+
+// Wait waits until the request can proceed
+func (l *gcraKeyLimiter) Wait(ctx context.Context) error {
+	return l.limiter.wait(ctx, l.key)
+}
+
+// wait takes a request from key, waiting until it fits
+func (g *GCRALimiter) wait(ctx context.Context, key string) error {
+	for {
+		delay, err := g.Reserve(ctx, key, 1)
+		if err != nil || delay == 0 {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// RateLimitByKey creates a rate limiting middleware over limiter, keying
+// requests with key, e.g. KeyByModel
+func RateLimitByKey(limiter *GCRALimiter, key KeyFunc, wait bool) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+			k := key(ctx, req)
+			if wait {
+				if err := limiter.wait(ctx, k); err != nil {
+					if _, ok := err.(llmx.Error); ok {
+						return nil, err
+					}
+					return nil, llmx.NewRateLimitError(fmt.Sprintf("rate limit wait failed: %v", err), 0)
+				}
+				return next(ctx, req)
+			}
+
+			delay, err := limiter.Reserve(ctx, k, 1)
+			if err != nil {
+				return nil, err
+			}
+			if delay > 0 {
+				return nil, llmx.NewRateLimitError(fmt.Sprintf("rate limit exceeded for %q", k), delay)
+			}
+			return next(ctx, req)
+		}
+	}
+}
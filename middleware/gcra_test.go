@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/store"
+	"github.com/llmx-ai/llmx/store/redistest"
+)
+
+func TestRateLimitByKey_SharedStore(t *testing.T) {
+	server := redistest.NewServer()
+	defer server.Close()
+	shared := store.NewRedisStore(store.RedisOptions{Addr: server.Addr()})
+	defer shared.Close()
+
+	// Two replicas share a burst of 2 per model
+	ok := func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		return &llmx.ChatResponse{Content: "ok"}, nil
+	}
+	replica1 := RateLimitByKey(NewGCRALimiter(shared, 1, 2), KeyByModel, false)(ok)
+	replica2 := RateLimitByKey(NewGCRALimiter(shared, 1, 2), KeyByModel, false)(ok)
+	ctx := context.Background()
+	req := &llmx.ChatRequest{Model: "gpt-4o"}
+
+	if _, err := replica1(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := replica2(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := replica1(ctx, req)
+	var rateErr *llmx.RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter <= 0 || rateErr.RetryAfter > time.Second {
+		t.Errorf("error = %v, want a rate limit error within a second", err)
+	}
+	if _, err := replica2(ctx, &llmx.ChatRequest{Model: "gpt-4o-mini"}); err != nil {
+		t.Errorf("unexpected error for another model: %v", err)
+	}
+}
+
+func TestGCRALimiter_FailurePolicy(t *testing.T) {
+	server := redistest.NewServer()
+	shared := store.NewRedisStore(store.RedisOptions{Addr: server.Addr(), Timeout: 100 * time.Millisecond})
+	defer shared.Close()
+	server.Close()
+
+	open := NewGCRALimiter(shared, 1, 1)
+	if !open.Limiter("k").Allow() {
+		t.Error("fail-open limiter refused a request with the store down")
+	}
+
+	closed := NewGCRALimiter(shared, 1, 1).WithFailurePolicy(store.FailClosed)
+	if closed.Limiter("k").Allow() {
+		t.Error("fail-closed limiter allowed a request with the store down")
+	}
+	handler := RateLimitByKey(closed, KeyByModel, true)(mockHandler(&llmx.ChatResponse{}, nil))
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrInternal) {
+		t.Errorf("error = %v, want an internal error", err)
+	}
+}
+
+// contendedStore is a store whose values change under every update
+type contendedStore struct{ *store.MemoryStore }
+
+func (contendedStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+// brokenStore is a reachable store refusing every command
+type brokenStore struct{ *store.MemoryStore }
+
+func (brokenStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, store.RedisError("WRONGTYPE Operation against a key holding the wrong kind of value")
+}
+
+func TestGCRALimiter_StoreErrors(t *testing.T) {
+	// A contended key waits an interval rather than passing or failing
+	contended := NewGCRALimiter(contendedStore{store.NewMemoryStore()}, 10, 1)
+	delay, err := contended.Reserve(context.Background(), "k", 1)
+	if err != nil || delay != 100*time.Millisecond {
+		t.Errorf("Reserve() = %v, %v, want a wait of 100ms", delay, err)
+	}
+	handler := RateLimitByKey(contended, KeyByModel, false)(mockHandler(&llmx.ChatResponse{}, nil))
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrRateLimit) {
+		t.Errorf("error = %v, want a rate limit error", err)
+	}
+
+	// Failing open applies only to an unreachable store
+	broken := NewGCRALimiter(brokenStore{store.NewMemoryStore()}, 10, 1)
+	if _, err := broken.Reserve(context.Background(), "k", 1); !errors.Is(err, llmx.ErrInternal) {
+		t.Errorf("Reserve() error = %v, want an internal error", err)
+	}
+	if err := NewCircuitBreaker(1, time.Minute).WithStore(brokenStore{store.NewMemoryStore()}, store.FailOpen).Allow(); !errors.Is(err, llmx.ErrCircuitOpen) {
+		t.Errorf("Allow() = %v, want a circuit open error", err)
+	}
+}
+
+func TestCircuitBreaker_SharedStore(t *testing.T) {
+	shared := store.NewMemoryStore()
+	replica1 := NewCircuitBreaker(1, time.Minute).WithName("gpt-4o").WithStore(shared, store.FailOpen)
+	replica2 := NewCircuitBreaker(1, time.Minute).WithName("gpt-4o").WithStore(shared, store.FailOpen)
+	other := NewCircuitBreaker(1, time.Minute).WithName("claude").WithStore(shared, store.FailOpen)
+
+	replica1.RecordFailure()
+
+	err := replica2.Allow()
+	var open *llmx.CircuitOpenError
+	if !errors.As(err, &open) || open.RetryAfter <= 0 || open.RetryAfter > time.Minute {
+		t.Fatalf("Allow() = %v, want the circuit opened by the other replica", err)
+	}
+	if replica2.State() != StateOpen {
+		t.Errorf("State() = %v, want open", replica2.State())
+	}
+	if err := other.Allow(); err != nil {
+		t.Errorf("Allow() = %v for another breaker", err)
+	}
+}
+
+func TestCircuitBreaker_StoreFailurePolicy(t *testing.T) {
+	server := redistest.NewServer()
+	shared := store.NewRedisStore(store.RedisOptions{Addr: server.Addr(), Timeout: 100 * time.Millisecond})
+	defer shared.Close()
+	server.Close()
+
+	if err := NewCircuitBreaker(1, time.Minute).WithStore(shared, store.FailOpen).Allow(); err != nil {
+		t.Errorf("fail-open Allow() = %v with the store down", err)
+	}
+	err := NewCircuitBreaker(1, time.Minute).WithStore(shared, store.FailClosed).Allow()
+	if !errors.Is(err, llmx.ErrCircuitOpen) {
+		t.Errorf("fail-closed Allow() = %v, want a circuit open error", err)
+	}
+}
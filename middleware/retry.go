@@ -2,6 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
 	"time"
 
 	"github.com/llmx-ai/llmx"
@@ -12,6 +18,12 @@ type BackoffStrategy interface {
 	Next(attempt int) time.Duration
 }
 
+// BackoffSequence is implemented by strategies whose delays depend on the
+// previous ones. Sequence returns a fresh strategy for each request.
+type BackoffSequence interface {
+	Sequence() BackoffStrategy
+}
+
 // ExponentialBackoff implements exponential backoff strategy
 type ExponentialBackoff struct {
 	Base time.Duration
@@ -20,11 +32,7 @@ type ExponentialBackoff struct {
 
 // Next calculates the next backoff duration
 func (e *ExponentialBackoff) Next(attempt int) time.Duration {
-	duration := e.Base * time.Duration(1<<uint(attempt))
-	if duration > e.Max {
-		return e.Max
-	}
-	return duration
+	return exponential(e.Base, e.Max, attempt)
 }
 
 // NewExponentialBackoff creates a new exponential backoff strategy
@@ -35,58 +43,331 @@ func NewExponentialBackoff() BackoffStrategy {
 	}
 }
 
-// Retry creates a retry middleware
-func Retry(maxRetries int, backoff BackoffStrategy) Middleware {
-	if backoff == nil {
-		backoff = NewExponentialBackoff()
+// FullJitterBackoff waits a random duration between zero and the
+// exponential backoff of the attempt, so that clients failing together
+// don't retry together
+type FullJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next draws the next backoff duration
+func (f *FullJitterBackoff) Next(attempt int) time.Duration {
+	return randomBetween(0, exponential(f.Base, f.Max, attempt))
+}
+
+// DecorrelatedJitterBackoff waits a random duration between Base and three
+// times the previous delay, capped at Max
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Next draws a backoff duration between Base and three times the
+// exponential backoff of the attempt, for use without Sequence
+func (d *DecorrelatedJitterBackoff) Next(attempt int) time.Duration {
+	return randomBetween(d.Base, min(d.Max, 3*exponential(d.Base, d.Max, attempt)))
+}
+
+// Sequence returns a strategy that derives each delay from the previous
+func (d *DecorrelatedJitterBackoff) Sequence() BackoffStrategy {
+	return &decorrelatedSequence{base: d.Base, max: d.Max, prev: d.Base}
+}
+
+// decorrelatedSequence holds the previous delay of a request
+type decorrelatedSequence struct {
+	base, max, prev time.Duration
+}
+
+// Next draws the next delay from the previous one
+func (d *decorrelatedSequence) Next(attempt int) time.Duration {
+	d.prev = randomBetween(d.base, min(d.max, 3*d.prev))
+	return d.prev
+}
+
+// exponential returns base doubled attempt times, capped at max
+func exponential(base, max time.Duration, attempt int) time.Duration {
+	duration := base
+	for i := 0; i < attempt && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max || duration <= 0 {
+		return max
+	}
+	return duration
+}
+
+// randomBetween returns a random duration in [lo, hi]
+func randomBetween(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + time.Duration(rand.Int63n(int64(hi-lo)+1))
+}
+
+// RetryEvent describes a retry about to happen, see
+// RetryPolicy.WithOnRetry
+type RetryEvent struct {
+	// Attempt is the number of the retry, 1 for the first
+	Attempt int
+	// Err is the error of the failed attempt
+	Err error
+	// Delay is the wait before the retry
+	Delay   time.Duration
+	Request *llmx.ChatRequest
+}
+
+// RetryBudget caps the retries of all requests sharing it, so that an
+// outage doesn't multiply the load on the provider. It is a token bucket:
+// each retry takes a token, each successful request returns ratio tokens,
+// up to the initial maxTokens.
+type RetryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+// NewRetryBudget creates a retry budget of maxTokens tokens, refilled by
+// ratio tokens per successful request, e.g. NewRetryBudget(10, 0.1) allows
+// bursts of 10 retries and about one retry per 10 successes after that
+func NewRetryBudget(maxTokens int, ratio float64) *RetryBudget {
+	return &RetryBudget{
+		tokens: float64(maxTokens),
+		max:    float64(maxTokens),
+		ratio:  ratio,
+	}
+}
+
+// Tokens returns the retries currently left
+func (b *RetryBudget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// withdraw takes a token for a retry, if one is left
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// deposit returns ratio tokens after a successful request
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.max, b.tokens+b.ratio)
+}
+
+// retryRule retries, or not, errors matching target
+type retryRule struct {
+	target error
+	retry  bool
+}
+
+// RetryPolicy configures the retry middleware built by Middleware
+type RetryPolicy struct {
+	maxRetries     int
+	backoff        BackoffStrategy
+	attemptTimeout time.Duration
+	maxRetryAfter  time.Duration
+	budget         *RetryBudget
+	rules          []retryRule
+	retryable      func(err error) bool
+	onRetry        []func(ctx context.Context, event RetryEvent)
+}
+
+// NewRetryPolicy creates a retry policy of up to maxRetries retries with
+// full jitter backoff from 1s to 30s, honoring Retry-After up to a minute
+func NewRetryPolicy(maxRetries int) *RetryPolicy {
+	return &RetryPolicy{
+		maxRetries:    maxRetries,
+		backoff:       &FullJitterBackoff{Base: time.Second, Max: 30 * time.Second},
+		maxRetryAfter: time.Minute,
+		retryable:     IsRetryable,
 	}
+}
+
+// WithBackoff sets the backoff strategy
+func (p *RetryPolicy) WithBackoff(backoff BackoffStrategy) *RetryPolicy {
+	p.backoff = backoff
+	return p
+}
+
+// WithAttemptTimeout limits each attempt to timeout. An attempt timing out
+// fails with a retryable *llmx.TimeoutError while the request's own
+// context is still live.
+func (p *RetryPolicy) WithAttemptTimeout(timeout time.Duration) *RetryPolicy {
+	p.attemptTimeout = timeout
+	return p
+}
+
+// WithMaxRetryAfter sets the longest Retry-After of a rate limited or
+// overloaded provider that is waited for; errors asking for longer are
+// returned. Zero waits however long the provider asks.
+func (p *RetryPolicy) WithMaxRetryAfter(max time.Duration) *RetryPolicy {
+	p.maxRetryAfter = max
+	return p
+}
+
+// WithBudget draws retries from budget, usually shared by all requests of
+// a client
+func (p *RetryPolicy) WithBudget(budget *RetryBudget) *RetryPolicy {
+	p.budget = budget
+	return p
+}
 
+// WithRetryOn retries, or not, errors matching target with errors.Is,
+// e.g. WithRetryOn(llmx.ErrTimeout, false). Rules are checked in the order
+// they were added, before the retryable predicate.
+func (p *RetryPolicy) WithRetryOn(target error, retry bool) *RetryPolicy {
+	p.rules = append(p.rules, retryRule{target: target, retry: retry})
+	return p
+}
+
+// WithRetryable replaces IsRetryable as the predicate of errors no rule
+// matched
+func (p *RetryPolicy) WithRetryable(retryable func(err error) bool) *RetryPolicy {
+	p.retryable = retryable
+	return p
+}
+
+// WithOnRetry calls fn before every retry, e.g. to record it, see
+// TelemetryRetries
+func (p *RetryPolicy) WithOnRetry(fn func(ctx context.Context, event RetryEvent)) *RetryPolicy {
+	p.onRetry = append(p.onRetry, fn)
+	return p
+}
+
+// shouldRetry applies the rules, then the retryable predicate, to err
+func (p *RetryPolicy) shouldRetry(err error) bool {
+	for _, rule := range p.rules {
+		if errors.Is(err, rule.target) {
+			return rule.retry
+		}
+	}
+	return p.retryable(err)
+}
+
+// Middleware returns the retry middleware of the policy
+func (p *RetryPolicy) Middleware() Middleware {
 	return func(next Handler) Handler {
 		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
-			var resp *llmx.ChatResponse
-			var err error
-
-			for attempt := 0; attempt <= maxRetries; attempt++ {
-				resp, err = next(ctx, req)
+			backoff := p.backoff
+			if sequence, ok := backoff.(BackoffSequence); ok {
+				backoff = sequence.Sequence()
+			}
 
-				// If successful, return
+			for attempt := 0; ; attempt++ {
+				resp, err := p.attempt(ctx, next, req)
 				if err == nil {
+					p.budget.deposit()
 					return resp, nil
 				}
 
-				// Check if error is retryable
-				if !isRetryable(err) {
+				// Stop once the request is canceled or out of time, after
+				// the last attempt, and on errors not worth retrying
+				if ctx.Err() != nil || attempt >= p.maxRetries || !p.shouldRetry(err) {
 					return nil, err
 				}
 
-				// Don't sleep after last attempt
-				if attempt < maxRetries {
-					delay := backoff.Next(attempt)
-					select {
-					case <-time.After(delay):
-						// Continue to next attempt
-					case <-ctx.Done():
-						return nil, ctx.Err()
+				delay := backoff.Next(attempt)
+				if retryAfter := retryAfter(err); retryAfter > 0 {
+					if p.maxRetryAfter > 0 && retryAfter > p.maxRetryAfter {
+						return nil, err
 					}
+					delay = max(delay, retryAfter)
+				}
+				if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+					return nil, err
+				}
+				if !p.budget.withdraw() {
+					return nil, err
+				}
+
+				event := RetryEvent{Attempt: attempt + 1, Err: err, Delay: delay, Request: req}
+				for _, fn := range p.onRetry {
+					fn(ctx, event)
 				}
-			}
 
-			return nil, err
+				timer := time.NewTimer(delay)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				}
+			}
 		}
 	}
 }
 
-// isRetryable checks if an error should be retried
-func isRetryable(err error) bool {
-	if err == nil {
+// attempt sends req once, within the attempt timeout if any
+func (p *RetryPolicy) attempt(ctx context.Context, next Handler, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+	if p.attemptTimeout <= 0 {
+		return next(ctx, req)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, p.attemptTimeout)
+	defer cancel()
+	resp, err := next(attemptCtx, req)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil && !errors.Is(err, llmx.ErrTimeout) {
+		err = llmx.NewTimeoutError(fmt.Sprintf("attempt timed out after %v", p.attemptTimeout), err)
+	}
+	return resp, err
+}
+
+// Retry creates a retry middleware of up to maxRetries retries, waiting
+// backoff, exponential backoff if nil, or the provider's Retry-After
+// between attempts. See RetryPolicy for jitter, budgets and timeouts.
+func Retry(maxRetries int, backoff BackoffStrategy) Middleware {
+	if backoff == nil {
+		backoff = NewExponentialBackoff()
+	}
+	return NewRetryPolicy(maxRetries).WithBackoff(backoff).Middleware()
+}
+
+// IsRetryable reports whether an error is worth retrying: llmx errors
+// that say so, network errors and timeouts, but not cancellations
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
 		return false
 	}
 
 	// Check for llmx error types
-	if llmxErr, ok := err.(llmx.Error); ok {
+	var llmxErr llmx.Error
+	if errors.As(err, &llmxErr) {
 		return llmxErr.Retryable()
 	}
 
-	// Default: don't retry unknown errors
-	return false
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryAfter returns the wait a rate limited or overloaded provider asked
+// for, zero if none
+func retryAfter(err error) time.Duration {
+	var rateLimited *llmx.RateLimitError
+	if errors.As(err, &rateLimited) {
+		return rateLimited.RetryAfter
+	}
+	var overloaded *llmx.OverloadedError
+	if errors.As(err, &overloaded) {
+		return overloaded.RetryAfter
+	}
+	return 0
 }
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// noBackoff retries immediately
+type noBackoff struct{}
+
+func (noBackoff) Next(attempt int) time.Duration { return 0 }
+
+// failingHandler fails with errs in turn, then succeeds, counting attempts
+func failingHandler(attempts *int, errs ...error) Handler {
+	return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		*attempts++
+		if *attempts <= len(errs) {
+			return nil, errs[*attempts-1]
+		}
+		return &llmx.ChatResponse{Content: "success"}, nil
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	full := &FullJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+	decorrelated := (&DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second}).Sequence()
+
+	prev := 100 * time.Millisecond
+	for attempt := 0; attempt < 20; attempt++ {
+		if d := full.Next(attempt); d < 0 || d > exponential(full.Base, full.Max, attempt) {
+			t.Errorf("full jitter Next(%d) = %v, out of range", attempt, d)
+		}
+		d := decorrelated.Next(attempt)
+		if d < 100*time.Millisecond || d > min(time.Second, 3*prev) {
+			t.Errorf("decorrelated Next(%d) = %v after %v, out of range", attempt, d, prev)
+		}
+		prev = d
+	}
+
+	if got := exponential(time.Second, 30*time.Second, 100); got != 30*time.Second {
+		t.Errorf("exponential() = %v, want the cap without overflow", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", llmx.NewRateLimitError("slow down", 0), true},
+		{"wrapped overloaded", fmt.Errorf("chat: %w", llmx.NewOverloadedError("busy", 0)), true},
+		{"invalid request", llmx.NewInvalidRequestError("bad", nil), false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded), true},
+		{"canceled", fmt.Errorf("post: %w", context.Canceled), false},
+		{"unknown", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_RetryAfter(t *testing.T) {
+	var delays []time.Duration
+	policy := NewRetryPolicy(2).
+		WithBackoff(noBackoff{}).
+		WithOnRetry(func(ctx context.Context, event RetryEvent) {
+			delays = append(delays, event.Delay)
+		})
+
+	attempts := 0
+	handler := policy.Middleware()(failingHandler(&attempts, llmx.NewRateLimitError("slow down", 20*time.Millisecond)))
+
+	start := time.Now()
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected to wait for Retry-After, took %v", elapsed)
+	}
+	if len(delays) != 1 || delays[0] != 20*time.Millisecond {
+		t.Errorf("Expected one retry after 20ms, got %v", delays)
+	}
+
+	// A Retry-After beyond the cap is not waited for
+	attempts = 0
+	handler = NewRetryPolicy(2).WithMaxRetryAfter(time.Second).
+		Middleware()(failingHandler(&attempts, llmx.NewRateLimitError("slow down", time.Hour)))
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrRateLimit) || attempts != 1 {
+		t.Errorf("Expected the rate limit error after 1 attempt, got %v after %d", err, attempts)
+	}
+
+	// Nor one beyond the request's deadline
+	attempts = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	handler = NewRetryPolicy(2).Middleware()(failingHandler(&attempts, llmx.NewOverloadedError("busy", 30*time.Second)))
+	if _, err := handler(ctx, &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrOverloaded) || attempts != 1 {
+		t.Errorf("Expected the overloaded error after 1 attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestRetryPolicy_AttemptTimeout(t *testing.T) {
+	attempts := 0
+	handler := NewRetryPolicy(2).
+		WithBackoff(noBackoff{}).
+		WithAttemptTimeout(10 * time.Millisecond).
+		Middleware()(func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &llmx.ChatResponse{Content: "success"}, nil
+	})
+
+	resp, err := handler(context.Background(), &llmx.ChatRequest{})
+	if err != nil || resp.Content != "success" {
+		t.Fatalf("Expected success after a timed out attempt, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+	// The last attempt's timeout is reported as an llmx timeout
+	attempts = 0
+	handler = NewRetryPolicy(0).WithAttemptTimeout(time.Millisecond).
+		Middleware()(func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrTimeout) {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestRetryPolicy_Rules(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		err      error
+		attempts int
+	}{
+		{"disabled class", NewRetryPolicy(3).WithRetryOn(llmx.ErrTimeout, false),
+			llmx.NewTimeoutError("slow", nil), 1},
+		{"enabled class", NewRetryPolicy(3).WithRetryOn(boom, true), fmt.Errorf("chat: %w", boom), 2},
+		{"custom predicate", NewRetryPolicy(3).WithRetryable(func(err error) bool { return err.Error() == "boom" }),
+			boom, 2},
+		{"rules before predicate", NewRetryPolicy(3).WithRetryOn(llmx.ErrRateLimit, false),
+			llmx.NewRateLimitError("slow down", 0), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			handler := tt.policy.WithBackoff(noBackoff{}).Middleware()(failingHandler(&attempts, tt.err))
+			handler(context.Background(), &llmx.ChatRequest{})
+			if attempts != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Budget(t *testing.T) {
+	budget := NewRetryBudget(2, 0.5)
+	policy := NewRetryPolicy(5).WithBackoff(noBackoff{}).WithBudget(budget)
+	overloaded := llmx.NewOverloadedError("busy", 0)
+
+	// Two retries drain the budget, then failures are returned as they are
+	attempts := 0
+	handler := policy.Middleware()(failingHandler(&attempts, overloaded, overloaded, overloaded, overloaded))
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrOverloaded) {
+		t.Fatalf("Expected the overloaded error, got %v", err)
+	}
+	if attempts != 3 || budget.Tokens() != 0 {
+		t.Errorf("Expected 3 attempts and an empty budget, got %d attempts and %v tokens", attempts, budget.Tokens())
+	}
+
+	// Successes refill it
+	for i := 0; i < 2; i++ {
+		attempts = 0
+		policy.Middleware()(failingHandler(&attempts))(context.Background(), &llmx.ChatRequest{})
+	}
+	if budget.Tokens() != 1 {
+		t.Errorf("Expected 1 token after 2 successes, got %v", budget.Tokens())
+	}
+}
+
+func TestRetryPolicy_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	handler := NewRetryPolicy(3).
+		WithBackoff(&ExponentialBackoff{Base: time.Hour, Max: time.Hour}).
+		WithOnRetry(func(ctx context.Context, event RetryEvent) { cancel() }).
+		Middleware()(failingHandler(&attempts, llmx.NewOverloadedError("busy", 0)))
+
+	if _, err := handler(ctx, &llmx.ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
@@ -0,0 +1,382 @@
+package middleware
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Priority is the scheduling class of a request, see WithPriority
+type Priority int
+
+const (
+	// PriorityBatch is for background jobs that can wait
+	PriorityBatch Priority = iota
+	// PriorityNormal is the priority of requests without one
+	PriorityNormal
+	// PriorityInteractive is for requests a user is waiting on
+	PriorityInteractive
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBatch:
+		return "batch"
+	case PriorityNormal:
+		return "normal"
+	case PriorityInteractive:
+		return "interactive"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// ErrQueueWait is the cause of the *llmx.TimeoutError a Scheduler returns
+// when a request waits too long for a slot, telling it apart from the
+// timeouts of providers with errors.Is
+var ErrQueueWait = errors.New("scheduler: no free slot in time")
+
+// defaultPriorityWeights are the shares of the queue each priority gets
+// while all are waiting
+var defaultPriorityWeights = map[Priority]float64{
+	PriorityBatch:       1,
+	PriorityNormal:      4,
+	PriorityInteractive: 16,
+}
+
+type priorityContextKey struct{}
+
+type tenantContextKey struct{}
+
+// WithPriority returns a copy of ctx whose requests a Scheduler queues at
+// priority p
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, p)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, or
+// PriorityNormal
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityContextKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// WithTenant returns a copy of ctx whose requests a Scheduler queues as
+// tenant's
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// KeyByTenant keys requests by the tenant set with WithTenant
+func KeyByTenant(ctx context.Context, req *llmx.ChatRequest) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// Scheduler bounds the requests in flight, queueing the rest with weighted
+// fair queuing: each tenant and priority is a flow that gets a share of the
+// slots proportional to its weight, the tenant's weight times the
+// priority's. Interactive requests thus go ahead of batch jobs without
+// starving them, and a tenant with a long backlog does not hold up the
+// others. Requests only queue while every slot is taken.
+type Scheduler struct {
+	mu              sync.Mutex
+	concurrency     int
+	maxQueue        int
+	maxTenantQueue  int
+	maxWait         time.Duration
+	tenant          KeyFunc
+	priorityWeights map[Priority]float64
+	tenantWeights   map[string]float64
+
+	running int
+	queue   waitQueue
+	flows   map[flowKey]*flow
+	queued  map[string]int // queued requests of each tenant
+	vtime   float64        // start tag of the last request dispatched
+	seq     uint64
+}
+
+// flowKey identifies a flow of a Scheduler
+type flowKey struct {
+	tenant   string
+	priority Priority
+}
+
+// flow is the state of a flow with queued requests
+type flow struct {
+	finish float64 // finish tag of its last queued request
+	queued int
+}
+
+// waiter is a queued request
+type waiter struct {
+	flow          flowKey
+	start, finish float64
+	seq           uint64
+	index         int // in the queue, -1 once dispatched or removed
+	ready         chan struct{}
+}
+
+// NewScheduler creates a scheduler running up to concurrency requests at
+// once and queueing up to maxQueue more, or any number if maxQueue is zero.
+// Tenants are set with WithTenant.
+func NewScheduler(concurrency, maxQueue int) *Scheduler {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	weights := make(map[Priority]float64, len(defaultPriorityWeights))
+	for p, w := range defaultPriorityWeights {
+		weights[p] = w
+	}
+	return &Scheduler{
+		concurrency:     concurrency,
+		maxQueue:        maxQueue,
+		tenant:          KeyByTenant,
+		priorityWeights: weights,
+		tenantWeights:   make(map[string]float64),
+		flows:           make(map[flowKey]*flow),
+		queued:          make(map[string]int),
+	}
+}
+
+// WithTenantKey sets how requests are assigned to tenants, KeyByTenant by
+// default, e.g. KeyByContext with the gateway's API key
+func (s *Scheduler) WithTenantKey(key KeyFunc) *Scheduler {
+	s.tenant = key
+	return s
+}
+
+// WithTenantQueueLimit limits the requests a tenant may have queued, so
+// that a noisy tenant gets QueueFullError before filling the queue
+func (s *Scheduler) WithTenantQueueLimit(n int) *Scheduler {
+	s.maxTenantQueue = n
+	return s
+}
+
+// WithMaxWait caps the time a request waits in the queue, on top of the
+// deadline of its context; it then fails with a *llmx.TimeoutError
+func (s *Scheduler) WithMaxWait(d time.Duration) *Scheduler {
+	s.maxWait = d
+	return s
+}
+
+// WithPriorityWeight sets the share of priority p, by default 1 for batch,
+// 4 for normal and 16 for interactive requests
+func (s *Scheduler) WithPriorityWeight(p Priority, weight float64) *Scheduler {
+	s.priorityWeights[p] = weight
+	return s
+}
+
+// WithTenantWeight sets the share of tenant, 1 by default
+func (s *Scheduler) WithTenantWeight(tenant string, weight float64) *Scheduler {
+	s.tenantWeights[tenant] = weight
+	return s
+}
+
+// Acquire waits for a slot for req and returns the function releasing it.
+// It fails with a *llmx.QueueFullError if the queue is full, and with a
+// *llmx.TimeoutError wrapping ErrQueueWait if the wait exceeds the max wait
+// or the deadline of ctx.
+func (s *Scheduler) Acquire(ctx context.Context, req *llmx.ChatRequest) (func(), error) {
+	tenant := s.tenant(ctx, req)
+	key := flowKey{tenant: tenant, priority: PriorityFromContext(ctx)}
+
+	s.mu.Lock()
+	if s.running < s.concurrency && len(s.queue) == 0 {
+		s.running++
+		s.mu.Unlock()
+		return s.releaseFunc(), nil
+	}
+	if s.maxQueue > 0 && len(s.queue) >= s.maxQueue {
+		s.mu.Unlock()
+		return nil, llmx.NewQueueFullError(fmt.Sprintf("scheduler queue full (%d requests)", s.maxQueue), tenant)
+	}
+	if s.maxTenantQueue > 0 && s.queued[tenant] >= s.maxTenantQueue {
+		s.mu.Unlock()
+		return nil, llmx.NewQueueFullError(fmt.Sprintf("scheduler queue full for tenant %q (%d requests)", tenant, s.maxTenantQueue), tenant)
+	}
+	w := s.enqueue(key)
+	s.mu.Unlock()
+
+	waitCtx := ctx
+	if s.maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, s.maxWait)
+		defer cancel()
+	}
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		return s.releaseFunc(), nil
+	case <-waitCtx.Done():
+	}
+
+	s.mu.Lock()
+	if w.index < 0 {
+		// Dispatched while giving up: keep the slot unless ctx is done
+		s.mu.Unlock()
+		if err := ctx.Err(); err != nil {
+			s.release()
+			return nil, err
+		}
+		return s.releaseFunc(), nil
+	}
+	s.remove(w)
+	s.mu.Unlock()
+
+	err := waitCtx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, llmx.NewTimeoutError(fmt.Sprintf("request queued for %v without a free slot", time.Since(start).Round(time.Millisecond)), fmt.Errorf("%w: %w", ErrQueueWait, err))
+	}
+	return nil, err
+}
+
+// Stats returns scheduler statistics
+func (s *Scheduler) Stats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]interface{}{
+		"concurrency": s.concurrency,
+		"running":     s.running,
+		"queued":      len(s.queue),
+		"tenants":     len(s.queued),
+	}
+}
+
+// enqueue queues a request of flow key, tagging it to finish after the
+// flow's previous request by its cost over its weight. s.mu must be held.
+func (s *Scheduler) enqueue(key flowKey) *waiter {
+	f := s.flows[key]
+	if f == nil {
+		f = &flow{}
+		s.flows[key] = f
+	}
+	start := max(s.vtime, f.finish)
+	f.finish = start + 1/s.weight(key)
+	f.queued++
+	s.queued[key.tenant]++
+
+	s.seq++
+	w := &waiter{flow: key, start: start, finish: f.finish, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.queue, w)
+	return w
+}
+
+// weight returns the weight of flow key. s.mu must be held.
+func (s *Scheduler) weight(key flowKey) float64 {
+	weight, ok := s.priorityWeights[key.priority]
+	if !ok || weight <= 0 {
+		weight = 1
+	}
+	if tw, ok := s.tenantWeights[key.tenant]; ok && tw > 0 {
+		weight *= tw
+	}
+	return weight
+}
+
+// remove drops w from the queue, giving its share back to its flow if it
+// was the flow's last request. s.mu must be held.
+func (s *Scheduler) remove(w *waiter) {
+	heap.Remove(&s.queue, w.index)
+	if f := s.flows[w.flow]; f != nil && f.finish == w.finish {
+		f.finish = w.start
+	}
+	s.dequeued(w)
+}
+
+// dequeued updates the counts of w's flow once it left the queue. Flows
+// are forgotten once empty. s.mu must be held.
+func (s *Scheduler) dequeued(w *waiter) {
+	if f := s.flows[w.flow]; f != nil {
+		if f.queued--; f.queued <= 0 {
+			delete(s.flows, w.flow)
+		}
+	}
+	if s.queued[w.flow.tenant]--; s.queued[w.flow.tenant] <= 0 {
+		delete(s.queued, w.flow.tenant)
+	}
+}
+
+// releaseFunc returns a function releasing a slot once, however often it
+// is called
+func (s *Scheduler) releaseFunc() func() {
+	var once sync.Once
+	return func() { once.Do(s.release) }
+}
+
+// release frees a slot and hands the free slots to the queued requests
+// with the earliest finish tags
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	for s.running < s.concurrency && len(s.queue) > 0 {
+		w := heap.Pop(&s.queue).(*waiter)
+		s.vtime = w.start
+		s.dequeued(w)
+		s.running++
+		close(w.ready)
+	}
+}
+
+// SchedulerMiddleware creates a middleware running requests through s.
+// Requests hold their slot until the rest of the chain returns, so add it
+// before CircuitBreakerMiddleware and Timeout: slots are then
+// held for the calls to the provider only, see CircuitBreakerMiddleware.
+func SchedulerMiddleware(s *Scheduler) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+			release, err := s.Acquire(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			return next(ctx, req)
+		}
+	}
+}
+
+// waitQueue is a heap of waiters by finish tag, then arrival
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].finish != q[j].finish {
+		return q[i].finish < q[j].finish
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
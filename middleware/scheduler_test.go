@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// schedulerOrder records the order in which a scheduler serves requests
+type schedulerOrder struct {
+	mu     sync.Mutex
+	served []string
+	wg     sync.WaitGroup
+}
+
+// queue starts a request named name on ctx and waits until it is queued
+func (o *schedulerOrder) queue(t *testing.T, s *Scheduler, ctx context.Context, name string) {
+	t.Helper()
+	want := s.Stats()["queued"].(int) + 1
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		release, err := s.Acquire(ctx, &llmx.ChatRequest{})
+		if err != nil {
+			t.Errorf("Acquire(%s) error = %v", name, err)
+			return
+		}
+		o.mu.Lock()
+		o.served = append(o.served, name)
+		o.mu.Unlock()
+		release()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for s.Stats()["queued"].(int) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("request %s was not queued", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// wait waits for the queued requests and returns the order they were served
+func (o *schedulerOrder) wait() []string {
+	o.wg.Wait()
+	return o.served
+}
+
+// holdSlots takes all n slots of s and returns the function releasing them
+func holdSlots(t *testing.T, s *Scheduler, n int) func() {
+	t.Helper()
+	releases := make([]func(), n)
+	for i := range releases {
+		release, err := s.Acquire(context.Background(), &llmx.ChatRequest{})
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		releases[i] = release
+	}
+	return func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+}
+
+func TestScheduler_Concurrency(t *testing.T) {
+	s := NewScheduler(2, 0)
+
+	var running, peak int32
+	handler := SchedulerMiddleware(s)(func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return &llmx.ChatResponse{}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := handler(context.Background(), &llmx.ChatRequest{}); err != nil {
+				t.Errorf("handler() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+	if stats := s.Stats(); stats["running"] != 0 || stats["queued"] != 0 {
+		t.Errorf("Stats() = %v, want nothing running or queued", stats)
+	}
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	s := NewScheduler(1, 0)
+	release := holdSlots(t, s, 1)
+
+	batch := WithPriority(context.Background(), PriorityBatch)
+	order := &schedulerOrder{}
+	order.queue(t, s, batch, "batch1")
+	order.queue(t, s, batch, "batch2")
+	order.queue(t, s, context.Background(), "normal")
+	order.queue(t, s, WithPriority(context.Background(), PriorityInteractive), "interactive")
+	release()
+
+	got := order.wait()
+	want := []string{"interactive", "normal", "batch1", "batch2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("served %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_TenantFairness(t *testing.T) {
+	s := NewScheduler(1, 0)
+	release := holdSlots(t, s, 1)
+
+	noisy := WithTenant(context.Background(), "noisy")
+	quiet := WithTenant(context.Background(), "quiet")
+	order := &schedulerOrder{}
+	for i := 0; i < 6; i++ {
+		order.queue(t, s, noisy, "noisy")
+	}
+	order.queue(t, s, quiet, "quiet")
+	order.queue(t, s, quiet, "quiet")
+	release()
+
+	got := order.wait()
+	want := []string{"noisy", "quiet", "noisy", "quiet", "noisy", "noisy", "noisy", "noisy"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("served %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_TenantWeight(t *testing.T) {
+	s := NewScheduler(1, 0).WithTenantWeight("premium", 2)
+	release := holdSlots(t, s, 1)
+
+	order := &schedulerOrder{}
+	for i := 0; i < 3; i++ {
+		order.queue(t, s, WithTenant(context.Background(), "free"), "free")
+	}
+	for i := 0; i < 4; i++ {
+		order.queue(t, s, WithTenant(context.Background(), "premium"), "premium")
+	}
+	release()
+
+	got := order.wait()
+	want := []string{"premium", "free", "premium", "premium", "free", "premium", "free"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("served %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_QueueFull(t *testing.T) {
+	s := NewScheduler(1, 2).WithTenantQueueLimit(1)
+	release := holdSlots(t, s, 1)
+
+	ctx := context.Background()
+	order := &schedulerOrder{}
+	defer order.wait()
+	defer release()
+	order.queue(t, s, WithTenant(ctx, "a"), "a")
+
+	_, err := s.Acquire(WithTenant(ctx, "a"), &llmx.ChatRequest{})
+	var full *llmx.QueueFullError
+	if !errors.As(err, &full) || full.Tenant != "a" {
+		t.Fatalf("Acquire() error = %v, want a queue full error for tenant a", err)
+	}
+	if !errors.Is(err, llmx.ErrQueueFull) || !full.Retryable() {
+		t.Errorf("Acquire() error = %v, want a retryable ErrQueueFull", err)
+	}
+
+	order.queue(t, s, WithTenant(ctx, "b"), "b")
+	if _, err := s.Acquire(WithTenant(ctx, "c"), &llmx.ChatRequest{}); !errors.As(err, &full) {
+		t.Fatalf("Acquire() error = %v, want a queue full error", err)
+	}
+}
+
+func TestScheduler_MaxWait(t *testing.T) {
+	s := NewScheduler(1, 0).WithMaxWait(20 * time.Millisecond)
+	release := holdSlots(t, s, 1)
+	defer release()
+
+	_, err := s.Acquire(context.Background(), &llmx.ChatRequest{})
+	var timeout *llmx.TimeoutError
+	if !errors.As(err, &timeout) {
+		t.Fatalf("Acquire() error = %v, want a timeout error", err)
+	}
+	if !errors.Is(err, ErrQueueWait) || IsCircuitFailure(err) {
+		t.Errorf("Acquire() error = %v, want ErrQueueWait, not a circuit failure", err)
+	}
+	if stats := s.Stats(); stats["queued"] != 0 || stats["tenants"] != 0 {
+		t.Errorf("Stats() = %v, want the request dropped from the queue", stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.Acquire(ctx, &llmx.ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestScheduler_ReleaseOnce(t *testing.T) {
+	s := NewScheduler(1, 0)
+	release, err := s.Acquire(context.Background(), &llmx.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	release()
+	release()
+
+	if running := s.Stats()["running"]; running != 0 {
+		t.Errorf("running = %v, want 0", running)
+	}
+}
@@ -67,7 +67,7 @@ func Telemetry(tel *observability.Telemetry) Middleware {
 			span.SetAttributes(
 				attribute.String("response.id", resp.ID),
 				attribute.String("response.model", resp.Model),
-				attribute.String("finish_reason", resp.FinishReason),
+				attribute.String("finish_reason", string(resp.FinishReason)),
 				attribute.Int("tokens.prompt", resp.Usage.PromptTokens),
 				attribute.Int("tokens.completion", resp.Usage.CompletionTokens),
 				attribute.Int("tokens.total", resp.Usage.TotalTokens),
@@ -81,6 +81,32 @@ func Telemetry(tel *observability.Telemetry) Middleware {
 	}
 }
 
+// TelemetryRetries returns a RetryPolicy callback recording every retry as
+// a metric and as an event of the current span:
+//
+//	NewRetryPolicy(3).WithOnRetry(TelemetryRetries(tel))
+func TelemetryRetries(tel *observability.Telemetry) func(ctx context.Context, event RetryEvent) {
+	return func(ctx context.Context, event RetryEvent) {
+		errorType := getErrorType(event.Err)
+		tel.RecordRetry(ctx, getProviderFromModel(event.Request.Model), event.Request.Model, errorType, event.Attempt)
+		trace.SpanFromContext(ctx).AddEvent("llmx.retry", trace.WithAttributes(
+			attribute.Int("attempt", event.Attempt),
+			attribute.String("error_type", errorType),
+			attribute.Int64("delay_ms", event.Delay.Milliseconds()),
+		))
+	}
+}
+
+// TelemetryCircuitBreaker returns a CircuitBreaker callback recording its
+// state as a gauge, by model for per-model breakers:
+//
+//	NewCircuitBreaker(5, 30*time.Second).WithOnStateChange(TelemetryCircuitBreaker(tel))
+func TelemetryCircuitBreaker(tel *observability.Telemetry) func(change CircuitStateChange) {
+	return func(change CircuitStateChange) {
+		tel.RecordCircuitState(context.Background(), getProviderFromModel(change.Name), change.Name, int64(change.To))
+	}
+}
+
 // getProviderFromModel extracts provider name from model string
 func getProviderFromModel(model string) string {
 	// Simple heuristic
@@ -0,0 +1,424 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// DefaultCompletionTokens is the completion EstimateTokens reserves for
+// requests without MaxTokens
+const DefaultCompletionTokens = 1024
+
+// TokenLimits are the limits of a key of a TokenLimiter, zero for none
+type TokenLimits struct {
+	TokensPerMinute   int
+	RequestsPerMinute int
+}
+
+// KeyFunc returns the key of a request, whose limits it counts against
+type KeyFunc func(ctx context.Context, req *llmx.ChatRequest) string
+
+// KeyByModel keys requests by model
+func KeyByModel(ctx context.Context, req *llmx.ChatRequest) string {
+	return req.Model
+}
+
+// KeyByContext keys requests by the string stored under key in their
+// context, e.g. a tenant or API key ID set by the caller
+func KeyByContext(key interface{}) KeyFunc {
+	return func(ctx context.Context, req *llmx.ChatRequest) string {
+		value, _ := ctx.Value(key).(string)
+		return value
+	}
+}
+
+// JoinKeys keys requests by all of keys, e.g. JoinKeys(tenant, KeyByModel)
+// for per-tenant limits of each model
+func JoinKeys(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context, req *llmx.ChatRequest) string {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key(ctx, req)
+		}
+		return strings.Join(parts, "/")
+	}
+}
+
+// TokenEstimator estimates the tokens a request will consume, prompt and
+// completion
+type TokenEstimator func(req *llmx.ChatRequest) int
+
+// EstimateTokens estimates the tokens of req from the length of its
+// messages and tools, at about four characters a token, plus MaxTokens, or
+// DefaultCompletionTokens, for each choice
+func EstimateTokens(req *llmx.ChatRequest) int {
+	chars := 0
+	tokens := 0
+	for _, msg := range req.Messages {
+		tokens += 4 // role and message framing
+		for _, part := range msg.Content {
+			switch p := part.(type) {
+			case llmx.TextPart:
+				chars += len(p.Text)
+			case llmx.ToolResultPart:
+				chars += len(p.Result)
+			case llmx.ImagePart:
+				if p.Detail == "low" {
+					tokens += 85
+				} else {
+					tokens += 765
+				}
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			chars += len(call.Name) + len(call.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		chars += len(tool.Name) + len(tool.Description)
+		if tool.Parameters != nil {
+			if schema, err := json.Marshal(tool.Parameters); err == nil {
+				chars += len(schema)
+			}
+		}
+	}
+	tokens += (chars + 3) / 4
+
+	completion := DefaultCompletionTokens
+	if req.MaxTokens != nil {
+		completion = *req.MaxTokens
+	}
+	choices := 1
+	if req.N != nil && *req.N > 1 {
+		choices = *req.N
+	}
+	return tokens + completion*choices
+}
+
+// TokenLimiter limits the tokens and requests per minute of each key,
+// model by default. A request reserves its estimated tokens before it is
+// sent, and is charged its actual usage once it completes. The rate limit
+// state providers report in response headers tightens the configured
+// limits, so that replicas sharing a provider quota back off together; a
+// 429 pauses the key for its Retry-After.
+//
+// Keys with nothing in flight and full buckets are dropped as new keys
+// arrive, keeping limiters keyed by tenant or API key bounded. A dropped key
+// starts over from its configured limits.
+type TokenLimiter struct {
+	limits   TokenLimits
+	limitsOf func(key string) TokenLimits
+	key      KeyFunc
+	estimate TokenEstimator
+	now      func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*tokenLimit
+	sweepAt int // number of keys at which idle ones are dropped
+}
+
+// minSweep is the number of keys a TokenLimiter holds before dropping idle
+// ones
+const minSweep = 1024
+
+// NewTokenLimiter creates a token limiter applying limits to each model
+func NewTokenLimiter(limits TokenLimits) *TokenLimiter {
+	return &TokenLimiter{
+		limits:   limits,
+		key:      KeyByModel,
+		estimate: EstimateTokens,
+		now:      time.Now,
+		keys:     make(map[string]*tokenLimit),
+		sweepAt:  minSweep,
+	}
+}
+
+// WithKey sets the key of requests, e.g. KeyByContext(tenantKey{})
+func (l *TokenLimiter) WithKey(key KeyFunc) *TokenLimiter {
+	l.key = key
+	return l
+}
+
+// WithKeyLimits sets the limits of each key, replacing the limits given to
+// NewTokenLimiter, e.g. to give tenants their own quotas
+func (l *TokenLimiter) WithKeyLimits(limitsOf func(key string) TokenLimits) *TokenLimiter {
+	l.limitsOf = limitsOf
+	return l
+}
+
+// WithEstimator replaces EstimateTokens, e.g. with a real tokenizer
+func (l *TokenLimiter) WithEstimator(estimate TokenEstimator) *TokenLimiter {
+	l.estimate = estimate
+	return l
+}
+
+// Available returns the tokens and requests key may use now, -1 for
+// those without a limit
+func (l *TokenLimiter) Available(key string) (tokens, requests int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state := l.get(key, now)
+	state.tokens.refill(now)
+	state.requests.refill(now)
+	return state.tokens.available(), state.requests.available()
+}
+
+// get returns the state of key, creating it. l.mu must be held.
+func (l *TokenLimiter) get(key string, now time.Time) *tokenLimit {
+	state, ok := l.keys[key]
+	if !ok {
+		if len(l.keys) >= l.sweepAt {
+			l.sweep(now)
+			l.sweepAt = max(2*len(l.keys), minSweep)
+		}
+		limits := l.limits
+		if l.limitsOf != nil {
+			limits = l.limitsOf(key)
+		}
+		state = &tokenLimit{
+			tokens:   newRateBucket(limits.TokensPerMinute, now),
+			requests: newRateBucket(limits.RequestsPerMinute, now),
+		}
+		l.keys[key] = state
+	}
+	return state
+}
+
+// sweep drops the keys that are idle at now. l.mu must be held.
+func (l *TokenLimiter) sweep(now time.Time) {
+	for key, state := range l.keys {
+		if state.idle(now) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// reserve takes cost tokens and a request from key, waiting for them if
+// wait is set
+func (l *TokenLimiter) reserve(ctx context.Context, key string, cost int, wait bool) (reservation, error) {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		r, delay := l.get(key, now).reserve(now, cost)
+		l.mu.Unlock()
+		if delay <= 0 {
+			return r, nil
+		}
+
+		if !wait {
+			return r, llmx.NewRateLimitError(fmt.Sprintf("token rate limit exceeded for %q", key), delay)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return r, llmx.NewRateLimitError(fmt.Sprintf("token rate limit for %q would outlast the deadline", key), delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return r, llmx.NewRateLimitError(fmt.Sprintf("rate limit wait failed: %v", ctx.Err()), 0)
+		}
+	}
+}
+
+// settle charges key the actual usage of a request that made r
+func (l *TokenLimiter) settle(key string, r reservation, resp *llmx.ChatResponse, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.get(key, now).settle(now, r, resp, err)
+}
+
+// reservation is what a request took from a key
+type reservation struct {
+	cost    int     // estimated tokens
+	charged float64 // tokens taken from the bucket, at most its limit
+}
+
+// tokenLimit is the state of a key of a TokenLimiter
+type tokenLimit struct {
+	tokens       rateBucket
+	requests     rateBucket
+	reserved     int // tokens reserved by requests in flight
+	inFlight     int
+	blockedUntil time.Time
+}
+
+// idle reports whether the key has nothing in flight and full buckets, so
+// that dropping it changes nothing but limits the provider tightened
+func (t *tokenLimit) idle(now time.Time) bool {
+	if t.inFlight > 0 || now.Before(t.blockedUntil) {
+		return false
+	}
+	t.tokens.refill(now)
+	t.requests.refill(now)
+	return t.tokens.full() && t.requests.full()
+}
+
+// reserve takes cost tokens and a request, or returns how long to wait for
+// them
+func (t *tokenLimit) reserve(now time.Time, cost int) (reservation, time.Duration) {
+	t.tokens.refill(now)
+	t.requests.refill(now)
+
+	// A request larger than the limit goes alone, once the bucket is full
+	amount := float64(cost)
+	if t.tokens.limit > 0 {
+		amount = min(amount, t.tokens.limit)
+	}
+	delay := max(t.blockedUntil.Sub(now), t.tokens.wait(amount), t.requests.wait(1))
+	if delay > 0 {
+		return reservation{}, delay
+	}
+
+	t.tokens.tokens -= amount
+	t.requests.tokens--
+	t.reserved += cost
+	t.inFlight++
+	return reservation{cost: cost, charged: amount}, 0
+}
+
+// settle replaces reservation r with the actual usage, then applies the
+// rate limit state the provider reported
+func (t *tokenLimit) settle(now time.Time, r reservation, resp *llmx.ChatResponse, err error) {
+	t.reserved -= r.cost
+	t.inFlight--
+	t.tokens.refill(now)
+	t.requests.refill(now)
+
+	if err != nil {
+		// Failed requests are not charged, but a rate limited key waits
+		// for the provider
+		t.tokens.refund(r.charged)
+		if delay := retryAfter(err); delay > 0 {
+			t.blockedUntil = now.Add(delay)
+		}
+		return
+	}
+	if resp == nil {
+		return
+	}
+
+	used := resp.Usage.TotalTokens
+	if used == 0 {
+		used = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+	}
+	if used > 0 {
+		t.tokens.refund(r.charged - float64(used))
+	}
+
+	if info := resp.Metadata.RateLimit; info != nil {
+		t.tokens.adapt(info.LimitTokens, info.RemainingTokens, info.ResetTokens, t.reserved)
+		t.requests.adapt(info.LimitRequests, info.RemainingRequests, info.ResetRequests, t.inFlight)
+	}
+}
+
+// rateBucket is a token bucket refilled continuously up to its limit per
+// minute
+type rateBucket struct {
+	max    float64 // configured limit, 0 for none
+	limit  float64 // limit in effect, max tightened by the provider's
+	rate   float64 // refill per minute
+	tokens float64
+	last   time.Time
+}
+
+func newRateBucket(perMinute int, now time.Time) rateBucket {
+	limit := float64(max(perMinute, 0))
+	return rateBucket{max: limit, limit: limit, rate: limit, tokens: limit, last: now}
+}
+
+// refill adds the tokens earned since the last refill
+func (b *rateBucket) refill(now time.Time) {
+	if b.limit > 0 && now.After(b.last) {
+		b.tokens = min(b.limit, b.tokens+b.rate*now.Sub(b.last).Minutes())
+	}
+	b.last = now
+}
+
+// wait returns how long until the bucket holds amount
+func (b *rateBucket) wait(amount float64) time.Duration {
+	if b.limit <= 0 || b.tokens >= amount {
+		return 0
+	}
+	if b.rate <= 0 {
+		return time.Minute
+	}
+	return time.Duration((amount - b.tokens) / b.rate * float64(time.Minute))
+}
+
+// refund returns amount to the bucket, or takes it if negative
+func (b *rateBucket) refund(amount float64) {
+	if b.limit > 0 {
+		b.tokens = min(b.limit, b.tokens+amount)
+	}
+}
+
+// full reports whether the bucket holds its limit, or has none
+func (b *rateBucket) full() bool {
+	return b.limit <= 0 || b.tokens >= b.limit
+}
+
+// available returns the whole tokens in the bucket, -1 without a limit
+func (b *rateBucket) available() int {
+	if b.limit <= 0 {
+		return -1
+	}
+	return int(b.tokens)
+}
+
+// adapt applies the limit, remaining count and time to full reset a
+// provider reported, -1 and zero if unknown, given the count reserved by
+// requests still in flight. The provider can only tighten the configured
+// limit.
+func (b *rateBucket) adapt(limit, remaining int, reset time.Duration, reserved int) {
+	if limit > 0 {
+		reported := float64(limit)
+		if b.max > 0 {
+			reported = min(reported, b.max)
+		}
+		if b.limit <= 0 {
+			b.tokens = reported
+		}
+		b.limit = reported
+		b.rate = reported
+		b.tokens = min(b.tokens, b.limit)
+	}
+	if remaining < 0 || b.limit <= 0 {
+		return
+	}
+
+	b.tokens = min(b.tokens, float64(remaining-reserved))
+	if reset > 0 && limit > remaining {
+		b.rate = min(b.limit, float64(limit-remaining)/reset.Minutes())
+	}
+}
+
+// TokenRateLimit creates a middleware limiting the tokens and requests per
+// minute of each key of limiter. Requests over the limit wait for it if
+// wait is set, or fail with a *llmx.RateLimitError saying how long to wait.
+func TokenRateLimit(limiter *TokenLimiter, wait bool) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+			key := limiter.key(ctx, req)
+			cost := limiter.estimate(req)
+			r, err := limiter.reserve(ctx, key, cost, wait)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := next(ctx, req)
+			limiter.settle(key, r, resp, err)
+			return resp, err
+		}
+	}
+}
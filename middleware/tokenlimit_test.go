@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+func newTestTokenLimiter(limits TokenLimits) (*TokenLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	limiter := NewTokenLimiter(limits).WithEstimator(func(req *llmx.ChatRequest) int {
+		return *req.MaxTokens
+	})
+	limiter.now = clock.now
+	return limiter, clock
+}
+
+// usageHandler answers with usage total tokens and the rate limit info
+func usageHandler(total int, info *llmx.RateLimitInfo) Handler {
+	return func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		return &llmx.ChatResponse{
+			Usage:    llmx.Usage{TotalTokens: total},
+			Metadata: llmx.ResponseMetadata{RateLimit: info},
+		}, nil
+	}
+}
+
+func tokensRequest(model string, tokens int) *llmx.ChatRequest {
+	return &llmx.ChatRequest{Model: model, MaxTokens: &tokens}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	maxTokens, n := 100, 2
+	req := &llmx.ChatRequest{
+		Messages: []llmx.Message{
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{
+				llmx.TextPart{Text: strings.Repeat("a", 400)},
+				llmx.ImagePart{URL: "https://example.com/cat.png", Detail: "low"},
+			}},
+		},
+		MaxTokens: &maxTokens,
+		N:         &n,
+	}
+
+	// 4 framing + 100 text + 85 image + 2 * 100 completion
+	if got := EstimateTokens(req); got != 389 {
+		t.Errorf("EstimateTokens() = %d, want 389", got)
+	}
+	if got := EstimateTokens(&llmx.ChatRequest{}); got != DefaultCompletionTokens {
+		t.Errorf("EstimateTokens() = %d, want %d without MaxTokens", got, DefaultCompletionTokens)
+	}
+}
+
+func TestTokenRateLimit_ReserveAndReconcile(t *testing.T) {
+	limiter, clock := newTestTokenLimiter(TokenLimits{TokensPerMinute: 1000})
+	handler := TokenRateLimit(limiter, false)(usageHandler(100, nil))
+	ctx := context.Background()
+
+	// The reservation of 600 is settled at the 100 used
+	if _, err := handler(ctx, tokensRequest("gpt-4o", 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 900 {
+		t.Errorf("Available() = %d tokens, want 900", tokens)
+	}
+
+	// A request needing more than is left fails with how long to wait
+	limiter.WithEstimator(func(req *llmx.ChatRequest) int { return 950 })
+	_, err := handler(ctx, tokensRequest("gpt-4o", 950))
+	var rateErr *llmx.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("error = %v, want a rate limit error", err)
+	}
+	if rateErr.RetryAfter != 3*time.Second {
+		t.Errorf("RetryAfter = %v, want 3s to refill 50 tokens", rateErr.RetryAfter)
+	}
+
+	// Other models have their own limit
+	if _, err := handler(ctx, tokensRequest("gpt-4o-mini", 950)); err != nil {
+		t.Errorf("unexpected error for another model: %v", err)
+	}
+
+	clock.advance(3 * time.Second)
+	if _, err := handler(ctx, tokensRequest("gpt-4o", 950)); err != nil {
+		t.Errorf("unexpected error after refill: %v", err)
+	}
+}
+
+func TestTokenRateLimit_Oversized(t *testing.T) {
+	limiter, clock := newTestTokenLimiter(TokenLimits{TokensPerMinute: 1000})
+	handler := TokenRateLimit(limiter, false)(usageHandler(200, nil))
+	ctx := context.Background()
+
+	// A request estimated over the limit takes the full bucket and is
+	// settled from that, not from its estimate
+	if _, err := handler(ctx, tokensRequest("gpt-4o", 5000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 800 {
+		t.Errorf("Available() = %d tokens, want 800 after using 200", tokens)
+	}
+
+	clock.advance(12 * time.Second)
+	if _, err := handler(ctx, tokensRequest("gpt-4o", 5000)); err != nil {
+		t.Fatalf("unexpected error after refill: %v", err)
+	}
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 800 {
+		t.Errorf("Available() = %d tokens, want 800 after using 200 again", tokens)
+	}
+}
+
+func TestTokenRateLimit_Headers(t *testing.T) {
+	limiter, clock := newTestTokenLimiter(TokenLimits{})
+	info := &llmx.RateLimitInfo{
+		LimitRequests:     -1,
+		RemainingRequests: -1,
+		LimitTokens:       10000,
+		RemainingTokens:   400,
+		ResetTokens:       96 * time.Second,
+	}
+	ctx := context.Background()
+
+	// Without configured limits, the provider's are adopted
+	if _, err := TokenRateLimit(limiter, false)(usageHandler(100, info))(ctx, tokensRequest("gpt-4o", 100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens, requests := limiter.Available("gpt-4o"); tokens != 400 || requests != -1 {
+		t.Errorf("Available() = %d, %d, want 400 tokens and no request limit", tokens, requests)
+	}
+
+	// Refilling 9600 tokens in 96s is 100 a second
+	clock.advance(time.Second)
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 500 {
+		t.Errorf("Available() = %d tokens after a second, want 500", tokens)
+	}
+
+	// The provider can't loosen configured limits
+	limiter, _ = newTestTokenLimiter(TokenLimits{TokensPerMinute: 1000})
+	TokenRateLimit(limiter, false)(usageHandler(100, info))(ctx, tokensRequest("gpt-4o", 100))
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 400 {
+		t.Errorf("Available() = %d tokens, want the reported 400", tokens)
+	}
+	info.RemainingTokens = 9000
+	TokenRateLimit(limiter, false)(usageHandler(100, info))(ctx, tokensRequest("gpt-4o", 100))
+	if tokens, _ := limiter.Available("gpt-4o"); tokens > 1000 {
+		t.Errorf("Available() = %d tokens, want at most the configured 1000", tokens)
+	}
+}
+
+func TestTokenRateLimit_RetryAfter(t *testing.T) {
+	limiter, clock := newTestTokenLimiter(TokenLimits{TokensPerMinute: 1000})
+	ctx := context.Background()
+	rateLimited := TokenRateLimit(limiter, false)(func(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
+		return nil, llmx.NewRateLimitError("slow down", 10*time.Second)
+	})
+
+	if _, err := rateLimited(ctx, tokensRequest("gpt-4o", 100)); err == nil {
+		t.Fatal("expected the provider's error")
+	}
+	if tokens, _ := limiter.Available("gpt-4o"); tokens != 1000 {
+		t.Errorf("Available() = %d tokens, failed requests should be refunded", tokens)
+	}
+
+	handler := TokenRateLimit(limiter, false)(usageHandler(100, nil))
+	_, err := handler(ctx, tokensRequest("gpt-4o", 100))
+	var rateErr *llmx.RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter != 10*time.Second {
+		t.Fatalf("error = %v, want to wait out the provider's Retry-After", err)
+	}
+
+	clock.advance(10 * time.Second)
+	if _, err := handler(ctx, tokensRequest("gpt-4o", 100)); err != nil {
+		t.Errorf("unexpected error after Retry-After: %v", err)
+	}
+}
+
+type tenantKey struct{}
+
+func TestTokenRateLimit_Keys(t *testing.T) {
+	limiter, _ := newTestTokenLimiter(TokenLimits{})
+	limiter.WithKey(JoinKeys(KeyByContext(tenantKey{}), KeyByModel)).
+		WithKeyLimits(func(key string) TokenLimits {
+			if strings.HasPrefix(key, "free/") {
+				return TokenLimits{RequestsPerMinute: 1}
+			}
+			return TokenLimits{RequestsPerMinute: 100}
+		})
+	handler := TokenRateLimit(limiter, false)(usageHandler(10, nil))
+
+	free := context.WithValue(context.Background(), tenantKey{}, "free")
+	paid := context.WithValue(context.Background(), tenantKey{}, "paid")
+
+	if _, err := handler(free, tokensRequest("gpt-4o", 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := handler(free, tokensRequest("gpt-4o", 10)); !errors.Is(err, llmx.ErrRateLimit) {
+		t.Errorf("error = %v, want the free tenant limited", err)
+	}
+	if _, err := handler(paid, tokensRequest("gpt-4o", 10)); err != nil {
+		t.Errorf("unexpected error for the paid tenant: %v", err)
+	}
+	if _, requests := limiter.Available("paid/gpt-4o"); requests != 99 {
+		t.Errorf("Available() = %d requests, want 99", requests)
+	}
+}
+
+func TestTokenRateLimit_DropsIdleKeys(t *testing.T) {
+	limiter, clock := newTestTokenLimiter(TokenLimits{RequestsPerMinute: 10})
+	handler := TokenRateLimit(limiter, false)(usageHandler(10, nil))
+	ctx := context.Background()
+
+	for i := 0; i < minSweep; i++ {
+		if _, err := handler(ctx, tokensRequest(fmt.Sprintf("model-%d", i), 10)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Every key has refilled but model-0, used again
+	clock.advance(time.Minute)
+	if _, err := handler(ctx, tokensRequest("model-0", 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := handler(ctx, tokensRequest("new", 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(limiter.keys) != 2 {
+		t.Errorf("limiter holds %d keys, want model-0 and new", len(limiter.keys))
+	}
+	if _, requests := limiter.Available("model-0"); requests != 9 {
+		t.Errorf("Available(model-0) = %d requests, want 9", requests)
+	}
+}
+
+func TestTokenRateLimit_Wait(t *testing.T) {
+	limiter := NewTokenLimiter(TokenLimits{RequestsPerMinute: 600})
+	handler := TokenRateLimit(limiter, true)(usageHandler(10, nil))
+
+	// 600 a minute is one every 100ms once the first 600 are spent
+	for i := 0; i < 600; i++ {
+		handler(context.Background(), &llmx.ChatRequest{})
+	}
+	start := time.Now()
+	if _, err := handler(context.Background(), &llmx.ChatRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected to wait for a request, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := handler(ctx, &llmx.ChatRequest{}); !errors.Is(err, llmx.ErrRateLimit) {
+		t.Errorf("error = %v, want a rate limit error past the deadline", err)
+	}
+}
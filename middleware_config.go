@@ -0,0 +1,274 @@
+package llmx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MiddlewareConfig declares a middleware by name, as in the middleware
+// section of a config file
+type MiddlewareConfig struct {
+	// Name is the name the middleware was registered under, e.g. "retry"
+	Name string
+
+	// Params holds the middleware's parameters
+	Params MiddlewareParams
+}
+
+// middlewareFactory validates the declared parameters of a middleware and
+// builds it from them
+type middlewareFactory struct {
+	validate func(params MiddlewareParams) error
+	build    func(params MiddlewareParams) (Middleware, error)
+}
+
+var (
+	middlewareRegistry = make(map[string]middlewareFactory)
+	middlewareMu       sync.RWMutex
+)
+
+// RegisterMiddleware makes a middleware available to configuration under
+// name. parse checks the declared parameters and returns the settings that
+// build makes the middleware from. Config.Validate only parses, so that
+// validating a config allocates no caches or limiters and starts no
+// goroutines. The middleware package registers its middleware when
+// imported.
+func RegisterMiddleware[T any](name string, parse func(params MiddlewareParams) (T, error), build func(settings T) Middleware) {
+	middlewareMu.Lock()
+	defer middlewareMu.Unlock()
+	middlewareRegistry[name] = middlewareFactory{
+		validate: func(params MiddlewareParams) error {
+			_, err := parse(params)
+			return err
+		},
+		build: func(params MiddlewareParams) (Middleware, error) {
+			settings, err := parse(params)
+			if err != nil {
+				return nil, err
+			}
+			return build(settings), nil
+		},
+	}
+}
+
+func lookupMiddleware(name string) (middlewareFactory, bool) {
+	middlewareMu.RLock()
+	defer middlewareMu.RUnlock()
+	factory, ok := middlewareRegistry[name]
+	return factory, ok
+}
+
+// MiddlewareParams holds the parameters of a declared middleware. The
+// accessors return the default for missing keys and a *ParamError for
+// values of the wrong type.
+type MiddlewareParams map[string]interface{}
+
+// ParamError reports an invalid middleware parameter
+type ParamError struct {
+	Key     string
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return e.Key + " " + e.Message
+}
+
+// Only returns an error for any parameter not in keys, catching typos
+func (p MiddlewareParams) Only(keys ...string) error {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k] = true
+	}
+	var unknown []string
+	for k := range p {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return &ParamError{Key: unknown[0], Message: "is not a known parameter"}
+}
+
+// String returns a string parameter
+func (p MiddlewareParams) String(key, def string) (string, error) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", &ParamError{Key: key, Message: "must be a string"}
+	}
+	return s, nil
+}
+
+// Int returns an integer parameter
+func (p MiddlewareParams) Int(key string, def int) (int, error) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	i, ok := intValue(v)
+	if !ok {
+		return 0, &ParamError{Key: key, Message: "must be an integer"}
+	}
+	return i, nil
+}
+
+// Float returns a numeric parameter
+func (p MiddlewareParams) Float(key string, def float64) (float64, error) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	f, ok := floatValue(v)
+	if !ok {
+		return 0, &ParamError{Key: key, Message: "must be a number"}
+	}
+	return f, nil
+}
+
+// Bool returns a boolean parameter
+func (p MiddlewareParams) Bool(key string, def bool) (bool, error) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	b, ok := boolValue(v)
+	if !ok {
+		return false, &ParamError{Key: key, Message: "must be a boolean"}
+	}
+	return b, nil
+}
+
+// Duration returns a duration parameter, written as a Go duration such as
+// "30s" or as a number of seconds
+func (p MiddlewareParams) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := p[key]
+	if !ok || v == nil {
+		return def, nil
+	}
+	d, ok := durationValue(v)
+	if !ok {
+		return 0, &ParamError{Key: key, Message: `must be a duration such as "30s"`}
+	}
+	return d, nil
+}
+
+// The value helpers below accept the types config files decode to, and
+// strings, as environment variables are strings
+
+func intValue(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func floatValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func boolValue(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+func durationValue(v interface{}) (time.Duration, bool) {
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, true
+		}
+	}
+	if seconds, ok := floatValue(v); ok {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// validateMiddleware checks the declared middleware and their parameters
+// without building them, reporting errors at the key path of the offending
+// entry
+func (c *Config) validateMiddleware() error {
+	for i, mc := range c.Middleware {
+		factory, path, err := c.middlewareFactory(i, mc)
+		if err != nil {
+			return err
+		}
+		if err := factory.validate(mc.Params); err != nil {
+			return paramError(path, err)
+		}
+	}
+	return nil
+}
+
+// buildMiddleware builds the declared middleware stack, reporting errors
+// at the key path of the offending entry
+func (c *Config) buildMiddleware() ([]Middleware, error) {
+	mws := make([]Middleware, 0, len(c.Middleware))
+	for i, mc := range c.Middleware {
+		factory, path, err := c.middlewareFactory(i, mc)
+		if err != nil {
+			return nil, err
+		}
+		mw, err := factory.build(mc.Params)
+		if err != nil {
+			return nil, paramError(path, err)
+		}
+		mws = append(mws, mw)
+	}
+	return mws, nil
+}
+
+// middlewareFactory returns the factory of the i-th declared middleware
+// and the key path of its entry
+func (c *Config) middlewareFactory(i int, mc MiddlewareConfig) (middlewareFactory, string, error) {
+	path := fmt.Sprintf("%s[%d]", c.keyPath("middleware"), i)
+	factory, ok := lookupMiddleware(mc.Name)
+	if !ok {
+		return factory, path, keyError(path+".name", fmt.Sprintf("names unknown middleware %q (is github.com/llmx-ai/llmx/middleware imported?)", mc.Name))
+	}
+	return factory, path, nil
+}
+
+// paramError reports err, returned for the parameters of the middleware
+// at path, at the key path of the offending parameter
+func paramError(path string, err error) error {
+	if paramErr, ok := err.(*ParamError); ok {
+		return keyError(path+"."+paramErr.Key, paramErr.Message)
+	}
+	return keyError(path, err.Error())
+}
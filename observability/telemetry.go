@@ -23,11 +23,13 @@ type Telemetry struct {
 	config *Config
 
 	// Metrics
-	requestCounter    metric.Int64Counter
-	requestDuration   metric.Float64Histogram
-	tokenCounter      metric.Int64Counter
-	errorCounter      metric.Int64Counter
+	requestCounter     metric.Int64Counter
+	requestDuration    metric.Float64Histogram
+	tokenCounter       metric.Int64Counter
+	errorCounter       metric.Int64Counter
 	streamEventCounter metric.Int64Counter
+	retryCounter       metric.Int64Counter
+	circuitState       metric.Int64Gauge
 }
 
 // New creates a new Telemetry instance
@@ -109,6 +111,24 @@ func New(config *Config) (*Telemetry, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		tel.retryCounter, err = meter.Int64Counter(
+			"llmx.retries.total",
+			metric.WithDescription("Total number of retried requests"),
+			metric.WithUnit("{retry}"),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tel.circuitState, err = meter.Int64Gauge(
+			"llmx.circuit_breaker.state",
+			metric.WithDescription("State of circuit breakers: 0 closed, 1 open, 2 half-open"),
+			metric.WithUnit("{state}"),
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return tel, nil
@@ -195,3 +215,34 @@ func (t *Telemetry) RecordStreamEvent(ctx context.Context, provider, model, even
 
 	t.streamEventCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
 }
+
+// RecordRetry records a retry of a request that failed with errorType
+func (t *Telemetry) RecordRetry(ctx context.Context, provider, model, errorType string, attempt int) {
+	if t.retryCounter == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("provider", provider),
+		attribute.String("model", model),
+		attribute.String("error_type", errorType),
+		attribute.Int("attempt", attempt),
+	}
+
+	t.retryCounter.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+// RecordCircuitState records the state of the circuit breaker of a model:
+// 0 closed, 1 open, 2 half-open
+func (t *Telemetry) RecordCircuitState(ctx context.Context, provider, model string, state int64) {
+	if t.circuitState == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("provider", provider),
+		attribute.String("model", model),
+	}
+
+	t.circuitState.Record(ctx, state, metric.WithAttributes(attrs...))
+}
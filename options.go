@@ -1,8 +1,11 @@
 package llmx
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
+
+	"github.com/llmx-ai/llmx/auth"
 )
 
 // Option is a function that modifies the config
@@ -75,6 +78,23 @@ func WithOpenAICompatible(baseURL, apiKey string) Option {
 	}
 }
 
+// WithCredentials supplies the provider's credential, usually its API key,
+// from p, see CredentialProvider
+func WithCredentials(p CredentialProvider) Option {
+	return func(c *Config) {
+		c.Credentials = p
+	}
+}
+
+// WithAuthenticator authenticates every request sent to the provider with
+// a, in place of the provider's own scheme. The provider's API key may then
+// be left out.
+func WithAuthenticator(a auth.Authenticator) Option {
+	return func(c *Config) {
+		c.Authenticator = a
+	}
+}
+
 // WithDefaultModel sets the default model
 func WithDefaultModel(model string) Option {
 	return func(c *Config) {
@@ -120,6 +140,38 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithHeader adds a header to every request sent to the provider
+func WithHeader(key, value string) Option {
+	return func(c *Config) {
+		if c.Headers == nil {
+			c.Headers = make(http.Header)
+		}
+		c.Headers.Add(key, value)
+	}
+}
+
+// WithProxy routes provider requests through the proxy at proxyURL
+func WithProxy(proxyURL string) Option {
+	return func(c *Config) {
+		c.ProxyURL = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS settings used to reach the provider
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Config) {
+		c.TLSConfig = config
+	}
+}
+
+// WithChoiceEmulation lets requests for several choices reach providers
+// without native support, which then receive one request per choice
+func WithChoiceEmulation(enabled bool) Option {
+	return func(c *Config) {
+		c.EmulateChoices = enabled
+	}
+}
+
 // WithDebug enables debug mode
 func WithDebug(debug bool) Option {
 	return func(c *Config) {
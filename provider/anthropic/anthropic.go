@@ -13,9 +13,19 @@ type AnthropicProvider struct {
 	apiKey string
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Credential:       "api_key",
+	CredentialHeader: "x-api-key",
+}
+
 func init() {
+	llmx.RegisterProviderOptions[RequestOptions]()
 	provider.Register("anthropic", NewAnthropicProvider)
+	provider.RegisterOptions("anthropic", options)
 	provider.Register("claude", NewAnthropicProvider) // Alias
+	provider.RegisterOptions("claude", options)
 }
 
 // NewAnthropicProvider creates a new Anthropic provider
@@ -37,10 +47,13 @@ func (p *AnthropicProvider) Name() string {
 
 // Chat sends a chat request
 func (p *AnthropicProvider) Chat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
-	_, ok := reqInterface.(*llmx.ChatRequest)
+	req, ok := reqInterface.(*llmx.ChatRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid request type")
 	}
+	if _, err := convertRequest(req); err != nil {
+		return nil, err
+	}
 
 	// TODO: Implement actual Anthropic API call
 	// For now, return placeholder
@@ -49,10 +62,13 @@ func (p *AnthropicProvider) Chat(ctx context.Context, reqInterface interface{})
 
 // StreamChat sends a streaming chat request
 func (p *AnthropicProvider) StreamChat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
-	_, ok := reqInterface.(*llmx.ChatRequest)
+	req, ok := reqInterface.(*llmx.ChatRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid request type")
 	}
+	if _, err := convertRequest(req); err != nil {
+		return nil, err
+	}
 
 	// TODO: Implement streaming
 	return nil, fmt.Errorf("Anthropic streaming: not yet fully implemented")
@@ -61,14 +77,17 @@ func (p *AnthropicProvider) StreamChat(ctx context.Context, reqInterface interfa
 // SupportedFeatures returns supported features
 func (p *AnthropicProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:     true,
-		ToolCalling:   true,
-		Vision:        true,
-		JSONMode:      false,
-		ReasoningMode: true, // Claude thinking
-		CacheControl:  true, // Prompt caching
-		MultiModal:    true,
-		Embedding:     false,
+		Streaming:   true,
+		ToolCalling: true,
+		// convertRequest maps tool_choice, but Chat doesn't send requests yet
+		ToolChoice:        false,
+		ParallelToolCalls: false,
+		Vision:            true,
+		JSONMode:          false,
+		ReasoningMode:     true, // Claude thinking
+		CacheControl:      true, // Prompt caching
+		MultiModal:        true,
+		Embedding:         false,
 	}
 }
 
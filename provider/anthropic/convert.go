@@ -0,0 +1,41 @@
+package anthropic
+
+import (
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// messagesRequest is a Messages API request: formats.ToAnthropicRequest's
+// wire format with the parameters of RequestOptions
+type messagesRequest struct {
+	*formats.AnthropicRequest
+	Metadata    *messagesMetadata `json:"metadata,omitempty"`
+	ServiceTier string            `json:"service_tier,omitempty"`
+}
+
+// messagesMetadata is the Messages API metadata parameter
+type messagesMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+// convertRequest converts an llmx request to a Messages API request
+func convertRequest(req *llmx.ChatRequest) (*messagesRequest, error) {
+	wire, err := formats.ToAnthropicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	wire.ToolChoice = convertToolChoice(req)
+	anthropicReq := &messagesRequest{AnthropicRequest: wire}
+	applyRequestOptions(anthropicReq, req)
+	return anthropicReq, nil
+}
+
+// toolChoice is the Messages API tool_choice parameter
+type toolChoice = formats.AnthropicToolChoice
+
+// convertToolChoice converts the request's tool choice and parallel tool
+// call setting to Anthropic's tool_choice: auto, any, tool or none, with
+// disable_parallel_tool_use
+func convertToolChoice(req *llmx.ChatRequest) *toolChoice {
+	return formats.AnthropicToolChoiceFrom(req)
+}
@@ -0,0 +1,104 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+)
+
+func TestConvertRequest_Options(t *testing.T) {
+	req := &llmx.ChatRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}}},
+	}
+	req.SetProviderOptions(RequestOptions{Metadata: &Metadata{UserID: "user-1"}, ServiceTier: "standard_only"})
+	if err := llmx.ValidateProviderOptions(req.ProviderOptions); err != nil {
+		t.Fatalf("ValidateProviderOptions() error = %v", err)
+	}
+
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatalf("convertRequest() error = %v", err)
+	}
+	data, err := json.Marshal(anthropicReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["model"] != req.Model || body["service_tier"] != "standard_only" {
+		t.Errorf("unexpected request %s", data)
+	}
+	if metadata, _ := body["metadata"].(map[string]interface{}); metadata["user_id"] != "user-1" {
+		t.Errorf("unexpected metadata in %s", data)
+	}
+
+	req.SetProviderOptions(RequestOptions{ServiceTier: "priority"})
+	if err := llmx.ValidateProviderOptions(req.ProviderOptions); err == nil {
+		t.Error("expected an unsupported service tier to be rejected")
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name string
+		req  *llmx.ChatRequest
+		want *toolChoice
+	}{
+		{
+			name: "unset",
+			req:  &llmx.ChatRequest{},
+			want: nil,
+		},
+		{
+			name: "required",
+			req:  &llmx.ChatRequest{ToolChoice: &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}},
+			want: &toolChoice{Type: "any"},
+		},
+		{
+			name: "named tool without parallel calls",
+			req:  &llmx.ChatRequest{ToolChoice: llmx.ForceTool("search"), ParallelToolCalls: &disabled},
+			want: &toolChoice{Type: "tool", Name: "search", DisableParallelToolUse: true},
+		},
+		{
+			name: "parallel calls disabled only",
+			req:  &llmx.ChatRequest{ParallelToolCalls: &disabled},
+			want: &toolChoice{Type: "auto", DisableParallelToolUse: true},
+		},
+		{
+			name: "none",
+			req:  &llmx.ChatRequest{ToolChoice: &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}, ParallelToolCalls: &disabled},
+			want: &toolChoice{Type: "none"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertToolChoice(tt.req)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("convertToolChoice() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && *got != *tt.want {
+				t.Errorf("convertToolChoice() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+
+	anthropicReq, err := convertRequest(&llmx.ChatRequest{
+		Messages:          []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}}},
+		Tools:             []llmx.Tool{{Name: "search"}},
+		ToolChoice:        llmx.ForceTool("search"),
+		ParallelToolCalls: &disabled,
+	})
+	if err != nil {
+		t.Fatalf("convertRequest() error = %v", err)
+	}
+	want := toolChoice{Type: "tool", Name: "search", DisableParallelToolUse: true}
+	if anthropicReq.ToolChoice == nil || *anthropicReq.ToolChoice != want {
+		t.Errorf("convertRequest() tool_choice = %+v, want %+v", anthropicReq.ToolChoice, want)
+	}
+}
@@ -0,0 +1,56 @@
+package anthropic
+
+import (
+	"fmt"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Metadata describes the request for Anthropic's abuse detection
+type Metadata struct {
+	// UserID is an opaque identifier for the end user (no PII)
+	UserID string
+}
+
+// RequestOptions holds Anthropic-specific request parameters. Attach them
+// with ChatRequest.SetProviderOptions.
+type RequestOptions struct {
+	Metadata *Metadata
+
+	// ServiceTier is "auto" or "standard_only"
+	ServiceTier string
+}
+
+// ProviderName implements llmx.ProviderRequestOptions
+func (o RequestOptions) ProviderName() string {
+	return "anthropic"
+}
+
+// Validate checks option values before a request is sent
+func (o RequestOptions) Validate() error {
+	switch o.ServiceTier {
+	case "", "auto", "standard_only":
+	default:
+		return fmt.Errorf("unsupported service tier %q", o.ServiceTier)
+	}
+
+	if o.Metadata != nil && len(o.Metadata.UserID) > 256 {
+		return fmt.Errorf("metadata user ID must be at most 256 characters")
+	}
+
+	return nil
+}
+
+// applyRequestOptions copies the Anthropic options attached to req onto a
+// Messages API request
+func applyRequestOptions(r *messagesRequest, req *llmx.ChatRequest) {
+	opts, ok := llmx.ProviderOptionsFor[RequestOptions](req)
+	if !ok {
+		return
+	}
+
+	if opts.Metadata != nil {
+		r.Metadata = &messagesMetadata{UserID: opts.Metadata.UserID}
+	}
+	r.ServiceTier = opts.ServiceTier
+}
@@ -3,10 +3,11 @@ package azure
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/auth"
 	"github.com/llmx-ai/llmx/provider"
+	openaiprovider "github.com/llmx-ai/llmx/provider/openai"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -15,26 +16,60 @@ type AzureProvider struct {
 	client *openai.Client
 }
 
+// options declares the options of the provider, see provider.OptionSpec.
+// Requests are authenticated with api_key, or with Entra ID tokens of the
+// application client_id in tenant_id.
+var options = provider.OptionSpec{
+	Required:         []string{"endpoint", "deployment"},
+	Optional:         []string{"api_key", "api_version", "tenant_id", "client_id", "client_secret"},
+	Credential:       "api_key",
+	CredentialHeader: "api-key",
+	Check: func(opts map[string]interface{}) error {
+		if provider.HasOption(opts, "api_key") {
+			return nil
+		}
+		for _, key := range []string{"tenant_id", "client_id", "client_secret"} {
+			if !provider.HasOption(opts, key) {
+				return &provider.OptionError{Key: key, Message: "is required without an api_key"}
+			}
+		}
+		return nil
+	},
+}
+
 func init() {
 	provider.Register("azure", NewAzureProvider)
+	provider.RegisterOptions("azure", options)
 	provider.Register("azure-openai", NewAzureProvider) // Alias
+	provider.RegisterOptions("azure-openai", options)
 }
 
 // NewAzureProvider creates a new Azure OpenAI provider
 func NewAzureProvider(opts map[string]interface{}) (provider.Provider, error) {
-	apiKey, hasKey := opts["api_key"].(string)
+	apiKey, _ := opts["api_key"].(string)
 	endpoint, hasEndpoint := opts["endpoint"].(string)
 
-	if !hasKey || apiKey == "" {
-		return nil, fmt.Errorf("api_key is required for Azure provider")
-	}
-
 	if !hasEndpoint || endpoint == "" {
 		return nil, fmt.Errorf("endpoint is required for Azure provider")
 	}
 
 	// Azure OpenAI configuration
 	config := openai.DefaultAzureConfig(apiKey, endpoint)
+	httpClient := provider.HTTPClient(opts)
+
+	if apiKey == "" {
+		// Entra ID: the HTTP client sets the bearer token of every request
+		tenantID, _ := opts["tenant_id"].(string)
+		clientID, _ := opts["client_id"].(string)
+		clientSecret, _ := opts["client_secret"].(string)
+		if tenantID == "" || clientID == "" || clientSecret == "" {
+			return nil, fmt.Errorf("api_key, or tenant_id, client_id and client_secret, are required for Azure provider")
+		}
+		tokens := auth.AzureEntraID(tenantID, clientID, clientSecret)
+		tokens.HTTPClient = httpClient
+		config.APIType = openai.APITypeAzureAD
+		httpClient = auth.NewClient(httpClient, auth.Bearer(tokens))
+	}
 
 	// Optional: API version
 	if apiVersion, ok := opts["api_version"].(string); ok {
@@ -42,6 +77,7 @@ func NewAzureProvider(opts map[string]interface{}) (provider.Provider, error) {
 	} else {
 		config.APIVersion = "2024-02-15-preview" // Default version
 	}
+	config.HTTPClient = openaiprovider.CaptureErrors(httpClient)
 
 	return &AzureProvider{
 		client: openai.NewClientWithConfig(config),
@@ -61,16 +97,20 @@ func (p *AzureProvider) Chat(ctx context.Context, reqInterface interface{}) (int
 	}
 
 	// Convert request (same as OpenAI)
-	openaiReq := p.convertRequest(req)
+	openaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Call Azure OpenAI API
+	ctx = openaiprovider.WithErrorCapture(ctx)
 	resp, err := p.client.CreateChatCompletion(ctx, openaiReq)
 	if err != nil {
-		return nil, p.convertError(err)
+		return nil, p.convertError(ctx, err)
 	}
 
 	// Convert response (same as OpenAI)
-	return p.convertResponse(&resp), nil
+	return p.convertResponse(&resp)
 }
 
 // StreamChat sends a streaming chat request
@@ -81,16 +121,23 @@ func (p *AzureProvider) StreamChat(ctx context.Context, reqInterface interface{}
 	}
 
 	// Convert request
-	openaiReq := p.convertRequest(req)
+	openaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create stream
+	ctx = openaiprovider.WithErrorCapture(ctx)
 	stream, err := p.client.CreateChatCompletionStream(ctx, openaiReq)
 	if err != nil {
-		return nil, p.convertError(err)
+		return nil, p.convertError(ctx, err)
 	}
 
 	// Create llmx stream
 	chatStream := llmx.NewChatStream(ctx)
+	chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+		*m = openaiprovider.StreamMetadata(stream)
+	})
 
 	// Start goroutine to handle streaming (reuse OpenAI logic)
 	go p.handleStream(ctx, stream, chatStream)
@@ -101,13 +148,18 @@ func (p *AzureProvider) StreamChat(ctx context.Context, reqInterface interface{}
 // SupportedFeatures returns supported features
 func (p *AzureProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:    true,
-		ToolCalling:  true,
-		Vision:       true,
-		JSONMode:     true,
-		MultiModal:   true,
-		Embedding:    true,
-		CacheControl: false,
+		Streaming:         true,
+		ToolCalling:       true,
+		ToolChoice:        true,
+		ParallelToolCalls: true,
+		MultipleChoices:   true,
+		Logprobs:          true,
+		Seed:              true,
+		Vision:            true,
+		JSONMode:          true,
+		MultiModal:        true,
+		Embedding:         true,
+		CacheControl:      false,
 	}
 }
 
@@ -145,128 +197,17 @@ func (p *AzureProvider) SupportedModels() []provider.Model {
 }
 
 // convertRequest converts llmx request to OpenAI request
-// (Identical to OpenAI provider)
-func (p *AzureProvider) convertRequest(req *llmx.ChatRequest) openai.ChatCompletionRequest {
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = p.convertMessage(msg)
-	}
-
-	openaiReq := openai.ChatCompletionRequest{
-		Model:    req.Model,
-		Messages: messages,
-	}
-
-	if req.Temperature != nil {
-		openaiReq.Temperature = float32(*req.Temperature)
-	}
-
-	if req.MaxTokens != nil {
-		openaiReq.MaxTokens = *req.MaxTokens
-	}
-
-	if req.TopP != nil {
-		openaiReq.TopP = float32(*req.TopP)
-	}
-
-	if len(req.Stop) > 0 {
-		openaiReq.Stop = req.Stop
-	}
-
-	return openaiReq
-}
-
-// convertMessage converts a llmx message to OpenAI message
-func (p *AzureProvider) convertMessage(msg llmx.Message) openai.ChatCompletionMessage {
-	var content string
-	var multiContent []openai.ChatMessagePart
-
-	// Process content parts
-	for _, part := range msg.Content {
-		switch v := part.(type) {
-		case llmx.TextPart:
-			if len(multiContent) == 0 {
-				content = v.Text
-			} else {
-				multiContent = append(multiContent, openai.ChatMessagePart{
-					Type: openai.ChatMessagePartTypeText,
-					Text: v.Text,
-				})
-			}
-		case llmx.ImagePart:
-			// If we have an image, we need to use multiContent
-			if content != "" && len(multiContent) == 0 {
-				multiContent = append(multiContent, openai.ChatMessagePart{
-					Type: openai.ChatMessagePartTypeText,
-					Text: content,
-				})
-				content = ""
-			}
-			imageURL := v.URL
-			if v.Base64 != "" {
-				imageURL = v.Base64
-			}
-			multiContent = append(multiContent, openai.ChatMessagePart{
-				Type: openai.ChatMessagePartTypeImageURL,
-				ImageURL: &openai.ChatMessageImageURL{
-					URL:    imageURL,
-					Detail: openai.ImageURLDetail(v.Detail),
-				},
-			})
-		}
-	}
-
-	message := openai.ChatCompletionMessage{
-		Role: string(msg.Role),
-	}
-
-	if len(multiContent) > 0 {
-		message.MultiContent = multiContent
-	} else {
-		message.Content = content
-	}
-
-	return message
+// (Azure accepts the OpenAI request format and options)
+func (p *AzureProvider) convertRequest(req *llmx.ChatRequest) (openai.ChatCompletionRequest, error) {
+	return openaiprovider.ConvertRequest(req)
 }
 
 // convertResponse converts OpenAI response to llmx response
-func (p *AzureProvider) convertResponse(resp *openai.ChatCompletionResponse) *llmx.ChatResponse {
-	if len(resp.Choices) == 0 {
-		return &llmx.ChatResponse{}
-	}
-
-	choice := resp.Choices[0]
-
-	return &llmx.ChatResponse{
-		ID:      resp.ID,
-		Model:   resp.Model,
-		Content: choice.Message.Content,
-		Usage: llmx.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
-		FinishReason: string(choice.FinishReason),
-		CreatedAt:    time.Unix(int64(resp.Created), 0),
-		Raw:          resp,
-	}
+func (p *AzureProvider) convertResponse(resp *openai.ChatCompletionResponse) (*llmx.ChatResponse, error) {
+	return openaiprovider.ConvertResponse(resp)
 }
 
-// convertError converts OpenAI errors to llmx errors
-func (p *AzureProvider) convertError(err error) error {
-	if apiErr, ok := err.(*openai.APIError); ok {
-		switch apiErr.HTTPStatusCode {
-		case 401:
-			return llmx.NewAuthenticationError(apiErr.Message)
-		case 429:
-			return llmx.NewRateLimitError(apiErr.Message, 60*time.Second)
-		case 400:
-			return llmx.NewInvalidRequestError(apiErr.Message, nil)
-		case 404:
-			return llmx.NewNotFoundError(apiErr.Message, "deployment")
-		default:
-			return llmx.NewProviderError("azure", apiErr.Message, apiErr.HTTPStatusCode, err)
-		}
-	}
-	return llmx.NewInternalError("Azure OpenAI API error", err)
+// convertError converts Azure OpenAI errors to llmx errors
+func (p *AzureProvider) convertError(ctx context.Context, err error) error {
+	return openaiprovider.ConvertError(ctx, "azure", err)
 }
@@ -0,0 +1,101 @@
+package azure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(req *http.Request, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestAzureProvider_EntraID(t *testing.T) {
+	var tokenRequests int
+	var gotAuth, gotAPIKey string
+	httpClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "login.microsoftonline.com" {
+			tokenRequests++
+			req.ParseForm()
+			if req.URL.Path != "/tenant/oauth2/v2.0/token" || req.PostForm.Get("client_id") != "app" {
+				t.Errorf("unexpected token request %s %v", req.URL, req.PostForm)
+			}
+			return jsonResponse(req, `{"access_token":"entra-token","expires_in":3600}`), nil
+		}
+		gotAuth, gotAPIKey = req.Header.Get("Authorization"), req.Header.Get("api-key")
+		return jsonResponse(req, `{"id":"chatcmpl-1","model":"gpt-4o","choices":[
+			{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`), nil
+	})}
+
+	p, err := NewAzureProvider(map[string]interface{}{
+		"endpoint":      "https://contoso.openai.azure.com",
+		"tenant_id":     "tenant",
+		"client_id":     "app",
+		"client_secret": "entra-test-secret",
+		"http_client":   httpClient,
+	})
+	if err != nil {
+		t.Fatalf("NewAzureProvider() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+			Model:    "gpt-4o",
+			Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "hi"}}}},
+		})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+
+	if gotAuth != "Bearer entra-token" || gotAPIKey != "" {
+		t.Errorf("Authorization = %q, api-key = %q, want the Entra ID token", gotAuth, gotAPIKey)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requested %d times, want 1", tokenRequests)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]interface{}
+		want string
+	}{
+		{"api key", map[string]interface{}{"endpoint": "e", "deployment": "d", "api_key": "k"}, ""},
+		{"entra id", map[string]interface{}{"endpoint": "e", "deployment": "d", "tenant_id": "t", "client_id": "c", "client_secret": "s"}, ""},
+		{"no credentials", map[string]interface{}{"endpoint": "e", "deployment": "d"}, "tenant_id is required without an api_key"},
+		{"partial entra id", map[string]interface{}{"endpoint": "e", "deployment": "d", "tenant_id": "t", "client_id": "c"}, "client_secret is required without an api_key"},
+		{"no endpoint", map[string]interface{}{"deployment": "d", "api_key": "k"}, "endpoint is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := options.ValidateOptions(tt.opts)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateOptions() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ValidateOptions() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
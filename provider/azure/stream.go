@@ -6,6 +6,7 @@ import (
 
 	"github.com/llmx-ai/llmx"
 	"github.com/llmx-ai/llmx/core"
+	openaiprovider "github.com/llmx-ai/llmx/provider/openai"
 	openai "github.com/sashabaranov/go-openai"
 )
 
@@ -24,6 +25,8 @@ func (p *AzureProvider) handleStream(
 		Type: core.EventTypeStart,
 	})
 
+	var toolCalls openaiprovider.ToolCalls
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -33,16 +36,23 @@ func (p *AzureProvider) handleStream(
 			response, err := stream.Recv()
 			if err == io.EOF {
 				// Stream finished
+				toolCalls.Send(chatStream)
 				chatStream.SendEvent(core.StreamEvent{
 					Type: core.EventTypeFinish,
 				})
 				return
 			}
 			if err != nil {
-				chatStream.SendError(p.convertError(err))
+				chatStream.SendError(p.convertError(ctx, err))
 				return
 			}
 
+			if fingerprint := response.SystemFingerprint; fingerprint != "" {
+				chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+					m.SystemFingerprint = fingerprint
+				})
+			}
+
 			// Process response chunks
 			for _, choice := range response.Choices {
 				// Text delta
@@ -53,24 +63,12 @@ func (p *AzureProvider) handleStream(
 					})
 				}
 
-				// Tool calls
-				if len(choice.Delta.ToolCalls) > 0 {
-					for _, toolCall := range choice.Delta.ToolCalls {
-						if toolCall.Function.Name != "" {
-							chatStream.SendEvent(core.StreamEvent{
-								Type: core.EventTypeToolCall,
-								Data: map[string]interface{}{
-									"id":   toolCall.ID,
-									"name": toolCall.Function.Name,
-									"args": toolCall.Function.Arguments,
-								},
-							})
-						}
-					}
-				}
+				// Tool calls are sent once their arguments are complete
+				toolCalls.Add(choice.Delta.ToolCalls)
 
 				// Check finish reason
 				if choice.FinishReason != "" {
+					toolCalls.Send(chatStream)
 					chatStream.SendEvent(core.StreamEvent{
 						Type: core.EventTypeFinish,
 						Data: string(choice.FinishReason),
@@ -1,10 +1,11 @@
 // Package bedrock provides an Amazon Bedrock provider implementation for llmx.
-// Bedrock offers access to multiple foundation models (Claude, Llama, Titan, etc.) on AWS.
+// Bedrock offers access to multiple foundation models (Claude, Llama, Titan, Nova,
+// Mistral, Cohere, etc.) on AWS. All model families are served through the
+// Converse and ConverseStream APIs, which share one message format.
 package bedrock
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,18 +13,45 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
 	"github.com/llmx-ai/llmx"
-	"github.com/llmx-ai/llmx/core"
 	"github.com/llmx-ai/llmx/provider"
 )
 
+// ConverseAPI is the subset of the Bedrock runtime API used by the provider.
+// The real client is adapted by NewBedrockProvider; tests can supply a stub
+// through NewBedrockProviderWithClient.
+type ConverseAPI interface {
+	Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error)
+	ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error)
+}
+
 // BedrockProvider implements the Provider interface for Amazon Bedrock
 type BedrockProvider struct {
-	client *bedrockruntime.Client
+	client ConverseAPI
 	region string
 }
 
+// options declares the options of the provider, see provider.OptionSpec.
+// Without access keys, the default AWS credential chain is used.
+var options = provider.OptionSpec{
+	Optional: []string{"region", "access_key_id", "secret_access_key", "session_token"},
+	Check: func(opts map[string]interface{}) error {
+		hasID := provider.HasOption(opts, "access_key_id")
+		hasSecret := provider.HasOption(opts, "secret_access_key")
+		switch {
+		case hasID && !hasSecret:
+			return &provider.OptionError{Key: "secret_access_key", Message: "is required with access_key_id"}
+		case hasSecret && !hasID:
+			return &provider.OptionError{Key: "access_key_id", Message: "is required with secret_access_key"}
+		case provider.HasOption(opts, "session_token") && !hasID:
+			return &provider.OptionError{Key: "access_key_id", Message: "is required with session_token"}
+		}
+		return nil
+	},
+}
+
 func init() {
 	provider.Register("bedrock", NewBedrockProvider)
+	provider.RegisterOptions("bedrock", options)
 }
 
 // NewBedrockProvider creates a new Bedrock provider
@@ -39,21 +67,24 @@ func NewBedrockProvider(opts map[string]interface{}) (provider.Provider, error)
 	// Check if credentials are provided
 	accessKeyID, hasAccessKey := opts["access_key_id"].(string)
 	secretAccessKey, hasSecretKey := opts["secret_access_key"].(string)
+	sessionToken, _ := opts["session_token"].(string)
 
 	if hasAccessKey && hasSecretKey && accessKeyID != "" && secretAccessKey != "" {
 		// Use provided credentials
 		cfg, err = config.LoadDefaultConfig(context.Background(),
 			config.WithRegion(region),
+			withHTTPClient(opts),
 			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
 				accessKeyID,
 				secretAccessKey,
-				"",
+				sessionToken,
 			)),
 		)
 	} else {
 		// Use default credential chain (环境变量, ~/.aws/credentials, IAM role等)
 		cfg, err = config.LoadDefaultConfig(context.Background(),
 			config.WithRegion(region),
+			withHTTPClient(opts),
 		)
 	}
 
@@ -61,12 +92,27 @@ func NewBedrockProvider(opts map[string]interface{}) (provider.Provider, error)
 		return nil, fmt.Errorf("bedrock: failed to load AWS config: %w", err)
 	}
 
-	client := bedrockruntime.NewFromConfig(cfg)
+	return NewBedrockProviderWithClient(&sdkClient{client: bedrockruntime.NewFromConfig(cfg)}, region), nil
+}
+
+// withHTTPClient makes the AWS SDK send requests with the injected
+// *http.Client, if any
+func withHTTPClient(opts map[string]interface{}) func(*config.LoadOptions) error {
+	return func(o *config.LoadOptions) error {
+		if httpClient := provider.HTTPClient(opts); httpClient != nil {
+			o.HTTPClient = httpClient
+		}
+		return nil
+	}
+}
 
+// NewBedrockProviderWithClient creates a Bedrock provider backed by the given
+// Converse API implementation
+func NewBedrockProviderWithClient(client ConverseAPI, region string) *BedrockProvider {
 	return &BedrockProvider{
 		client: client,
 		region: region,
-	}, nil
+	}
 }
 
 // Name returns the provider name
@@ -84,6 +130,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 8192,
 			InputCost:       3.0,
 			OutputCost:      15.0,
+			Capabilities:    []string{"chat", "vision", "tools"},
 		},
 		{
 			ID:              "anthropic.claude-3-5-sonnet-20240620-v1:0",
@@ -92,6 +139,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 8192,
 			InputCost:       3.0,
 			OutputCost:      15.0,
+			Capabilities:    []string{"chat", "vision", "tools"},
 		},
 		{
 			ID:              "anthropic.claude-3-opus-20240229-v1:0",
@@ -100,6 +148,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 4096,
 			InputCost:       15.0,
 			OutputCost:      75.0,
+			Capabilities:    []string{"chat", "vision", "tools"},
 		},
 		{
 			ID:              "anthropic.claude-3-sonnet-20240229-v1:0",
@@ -108,6 +157,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 4096,
 			InputCost:       3.0,
 			OutputCost:      15.0,
+			Capabilities:    []string{"chat", "vision", "tools"},
 		},
 		{
 			ID:              "anthropic.claude-3-haiku-20240307-v1:0",
@@ -116,6 +166,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 4096,
 			InputCost:       0.25,
 			OutputCost:      1.25,
+			Capabilities:    []string{"chat", "vision", "tools"},
 		},
 		{
 			ID:              "meta.llama3-1-70b-instruct-v1:0",
@@ -124,6 +175,7 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 4096,
 			InputCost:       0.99,
 			OutputCost:      0.99,
+			Capabilities:    []string{"chat", "tools"},
 		},
 		{
 			ID:              "meta.llama3-1-8b-instruct-v1:0",
@@ -132,6 +184,88 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 			MaxOutputTokens: 4096,
 			InputCost:       0.22,
 			OutputCost:      0.22,
+			Capabilities:    []string{"chat", "tools"},
+		},
+		{
+			ID:              "amazon.nova-pro-v1:0",
+			Name:            "Amazon Nova Pro",
+			ContextWindow:   300000,
+			MaxOutputTokens: 5120,
+			InputCost:       0.8,
+			OutputCost:      3.2,
+			Capabilities:    []string{"chat", "vision", "tools"},
+		},
+		{
+			ID:              "amazon.nova-lite-v1:0",
+			Name:            "Amazon Nova Lite",
+			ContextWindow:   300000,
+			MaxOutputTokens: 5120,
+			InputCost:       0.06,
+			OutputCost:      0.24,
+			Capabilities:    []string{"chat", "vision", "tools"},
+		},
+		{
+			ID:              "amazon.nova-micro-v1:0",
+			Name:            "Amazon Nova Micro",
+			ContextWindow:   128000,
+			MaxOutputTokens: 5120,
+			InputCost:       0.035,
+			OutputCost:      0.14,
+			Capabilities:    []string{"chat", "tools"},
+		},
+		{
+			ID:              "amazon.titan-text-premier-v1:0",
+			Name:            "Amazon Titan Text Premier",
+			ContextWindow:   32000,
+			MaxOutputTokens: 3072,
+			InputCost:       0.5,
+			OutputCost:      1.5,
+			Capabilities:    []string{"chat"},
+		},
+		{
+			ID:              "amazon.titan-text-express-v1",
+			Name:            "Amazon Titan Text Express",
+			ContextWindow:   8192,
+			MaxOutputTokens: 8192,
+			InputCost:       0.2,
+			OutputCost:      0.6,
+			Capabilities:    []string{"chat"},
+		},
+		{
+			ID:              "mistral.mistral-large-2407-v1:0",
+			Name:            "Mistral Large (24.07)",
+			ContextWindow:   128000,
+			MaxOutputTokens: 8192,
+			InputCost:       2.0,
+			OutputCost:      6.0,
+			Capabilities:    []string{"chat", "tools"},
+		},
+		{
+			ID:              "mistral.mixtral-8x7b-instruct-v0:1",
+			Name:            "Mixtral 8x7B Instruct",
+			ContextWindow:   32000,
+			MaxOutputTokens: 4096,
+			InputCost:       0.45,
+			OutputCost:      0.7,
+			Capabilities:    []string{"chat"},
+		},
+		{
+			ID:              "cohere.command-r-plus-v1:0",
+			Name:            "Cohere Command R+",
+			ContextWindow:   128000,
+			MaxOutputTokens: 4096,
+			InputCost:       3.0,
+			OutputCost:      15.0,
+			Capabilities:    []string{"chat", "tools"},
+		},
+		{
+			ID:              "cohere.command-r-v1:0",
+			Name:            "Cohere Command R",
+			ContextWindow:   128000,
+			MaxOutputTokens: 4096,
+			InputCost:       0.5,
+			OutputCost:      1.5,
+			Capabilities:    []string{"chat", "tools"},
 		},
 	}
 }
@@ -140,8 +274,9 @@ func (p *BedrockProvider) SupportedModels() []provider.Model {
 func (p *BedrockProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
 		Streaming:   true,
-		ToolCalling: true,  // Claude models support tools
-		Vision:      true,  // Claude 3 models support vision
+		ToolCalling: true,  // Claude, Nova, Llama 3.1+, Mistral Large and Command R
+		ToolChoice:  true,  // Per model family; "none" is not supported
+		Vision:      true,  // Claude 3, Nova Pro/Lite and Llama 3.2 vision models
 		JSONMode:    false, // Not standardized across models
 	}
 }
@@ -150,164 +285,46 @@ func (p *BedrockProvider) SupportedFeatures() provider.Features {
 func (p *BedrockProvider) Chat(ctx context.Context, req interface{}) (interface{}, error) {
 	chatReq, ok := req.(*llmx.ChatRequest)
 	if !ok {
-		return nil, fmt.Errorf("bedrock: invalid request type")
-	}
-
-	// Determine model family and convert request accordingly
-	if isClaudeModel(chatReq.Model) {
-		return p.chatClaude(ctx, chatReq)
-	} else if isLlamaModel(chatReq.Model) {
-		return p.chatLlama(ctx, chatReq)
+		return nil, fmt.Errorf("bedrock: invalid request type %T, expected *llmx.ChatRequest", req)
 	}
 
-	return nil, fmt.Errorf("bedrock: unsupported model %s", chatReq.Model)
-}
-
-// StreamChat sends a streaming chat request to Bedrock
-func (p *BedrockProvider) StreamChat(ctx context.Context, req interface{}) (interface{}, error) {
-	chatReq, ok := req.(*llmx.ChatRequest)
-	if !ok {
-		return nil, fmt.Errorf("bedrock: invalid request type")
-	}
-
-	// Determine model family
-	if isClaudeModel(chatReq.Model) {
-		return p.streamChatClaude(ctx, chatReq)
-	} else if isLlamaModel(chatReq.Model) {
-		return p.streamChatLlama(ctx, chatReq)
-	}
-
-	return nil, fmt.Errorf("bedrock: unsupported model %s", chatReq.Model)
-}
-
-// chatClaude handles Claude-specific chat requests
-func (p *BedrockProvider) chatClaude(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
-	// Build Claude request
-	claudeReq := map[string]interface{}{
-		"anthropic_version": "bedrock-2023-05-31",
-		"max_tokens":        4096,
-	}
-
-	// Extract system prompt and messages
-	var systemPrompt string
-	var messages []map[string]interface{}
-
-	for _, msg := range req.Messages {
-		var content string
-		if len(msg.Content) > 0 {
-			if textPart, ok := msg.Content[0].(*llmx.TextPart); ok {
-				content = textPart.Text
-			}
-		}
-		
-		if msg.Role == llmx.RoleSystem {
-			systemPrompt = content
-		} else {
-			messages = append(messages, map[string]interface{}{
-				"role":    convertRoleForClaude(msg.Role),
-				"content": content,
-			})
-		}
-	}
-
-	claudeReq["messages"] = messages
-	if systemPrompt != "" {
-		claudeReq["system"] = systemPrompt
-	}
-
-	// Set optional parameters
-	if req.Temperature != nil {
-		claudeReq["temperature"] = *req.Temperature
-	}
-	if req.MaxTokens != nil {
-		claudeReq["max_tokens"] = *req.MaxTokens
-	}
-	if req.TopP != nil {
-		claudeReq["top_p"] = *req.TopP
-	}
-
-	// Convert tools if present
-	if len(req.Tools) > 0 {
-		claudeReq["tools"] = convertToolsForClaude(req.Tools)
-	}
-
-	// Marshal request
-	requestBody, err := json.Marshal(claudeReq)
+	input, err := p.convertRequest(chatReq)
 	if err != nil {
-		return nil, fmt.Errorf("bedrock: failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	// Call Bedrock API
-	output, err := p.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-		ModelId:     aws.String(req.Model),
-		Body:        requestBody,
-		ContentType: aws.String("application/json"),
+	output, err := p.client.Converse(ctx, &bedrockruntime.ConverseInput{
+		ModelId:         input.modelID,
+		Messages:        input.messages,
+		System:          input.system,
+		InferenceConfig: input.inferenceConfig,
+		ToolConfig:      input.toolConfig,
 	})
 	if err != nil {
 		return nil, p.convertError(err)
 	}
 
-	// Parse response
-	var claudeResp map[string]interface{}
-	if err := json.Unmarshal(output.Body, &claudeResp); err != nil {
-		return nil, fmt.Errorf("bedrock: failed to unmarshal response: %w", err)
-	}
-
-	// Convert to llmx response
-	return p.convertClaudeResponse(claudeResp, req.Model), nil
+	return p.convertResponse(output, chatReq.Model)
 }
 
-// streamChatClaude handles Claude-specific streaming requests
-func (p *BedrockProvider) streamChatClaude(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatStream, error) {
-	// Build Claude request (same as non-streaming)
-	claudeReq := map[string]interface{}{
-		"anthropic_version": "bedrock-2023-05-31",
-		"max_tokens":        4096,
-	}
-
-	var systemPrompt string
-	var messages []map[string]interface{}
-
-	for _, msg := range req.Messages {
-		var content string
-		if len(msg.Content) > 0 {
-			if textPart, ok := msg.Content[0].(*llmx.TextPart); ok {
-				content = textPart.Text
-			}
-		}
-		
-		if msg.Role == llmx.RoleSystem {
-			systemPrompt = content
-		} else {
-			messages = append(messages, map[string]interface{}{
-				"role":    convertRoleForClaude(msg.Role),
-				"content": content,
-			})
-		}
-	}
-
-	claudeReq["messages"] = messages
-	if systemPrompt != "" {
-		claudeReq["system"] = systemPrompt
-	}
-
-	if req.Temperature != nil {
-		claudeReq["temperature"] = *req.Temperature
-	}
-	if req.MaxTokens != nil {
-		claudeReq["max_tokens"] = *req.MaxTokens
+// StreamChat sends a streaming chat request to Bedrock
+func (p *BedrockProvider) StreamChat(ctx context.Context, req interface{}) (interface{}, error) {
+	chatReq, ok := req.(*llmx.ChatRequest)
+	if !ok {
+		return nil, fmt.Errorf("bedrock: invalid request type %T, expected *llmx.ChatRequest", req)
 	}
 
-	requestBody, err := json.Marshal(claudeReq)
+	input, err := p.convertRequest(chatReq)
 	if err != nil {
-		return nil, fmt.Errorf("bedrock: failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	// Call streaming API
-	output, err := p.client.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
-		ModelId:     aws.String(req.Model),
-		Body:        requestBody,
-		ContentType: aws.String("application/json"),
+	stream, err := p.client.ConverseStream(ctx, &bedrockruntime.ConverseStreamInput{
+		ModelId:         input.modelID,
+		Messages:        input.messages,
+		System:          input.system,
+		InferenceConfig: input.inferenceConfig,
+		ToolConfig:      input.toolConfig,
 	})
 	if err != nil {
 		return nil, p.convertError(err)
@@ -317,299 +334,24 @@ func (p *BedrockProvider) streamChatClaude(ctx context.Context, req *llmx.ChatRe
 	chatStream := llmx.NewChatStream(ctx)
 
 	// Start goroutine to handle streaming
-	go p.handleClaudeStream(ctx, output, chatStream)
+	go p.handleStream(ctx, stream, chatStream)
 
 	return chatStream, nil
 }
 
-// chatLlama handles Llama-specific chat requests (simplified)
-func (p *BedrockProvider) chatLlama(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatResponse, error) {
-	// Build Llama request (Meta format)
-	llamaReq := map[string]interface{}{
-		"prompt":      buildLlamaPrompt(req.Messages),
-		"max_gen_len": 2048,
-	}
-
-	if req.Temperature != nil {
-		llamaReq["temperature"] = *req.Temperature
-	}
-	if req.TopP != nil {
-		llamaReq["top_p"] = *req.TopP
-	}
-
-	requestBody, err := json.Marshal(llamaReq)
-	if err != nil {
-		return nil, fmt.Errorf("bedrock: failed to marshal request: %w", err)
-	}
-
-	output, err := p.client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
-		ModelId:     aws.String(req.Model),
-		Body:        requestBody,
-		ContentType: aws.String("application/json"),
-	})
-	if err != nil {
-		return nil, p.convertError(err)
-	}
-
-	var llamaResp map[string]interface{}
-	if err := json.Unmarshal(output.Body, &llamaResp); err != nil {
-		return nil, fmt.Errorf("bedrock: failed to unmarshal response: %w", err)
-	}
+// sdkClient adapts the AWS SDK client to ConverseAPI
+type sdkClient struct {
+	client *bedrockruntime.Client
+}
 
-	return p.convertLlamaResponse(llamaResp, req.Model), nil
+func (c *sdkClient) Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	return c.client.Converse(ctx, input)
 }
 
-// streamChatLlama handles Llama streaming (simplified placeholder)
-func (p *BedrockProvider) streamChatLlama(ctx context.Context, req *llmx.ChatRequest) (*llmx.ChatStream, error) {
-	// Llama streaming is similar to Claude but with different event format
-	// For now, return non-streaming response wrapped in stream
-	resp, err := p.chatLlama(ctx, req)
+func (c *sdkClient) ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error) {
+	output, err := c.client.ConverseStream(ctx, input)
 	if err != nil {
 		return nil, err
 	}
-
-	chatStream := llmx.NewChatStream(ctx)
-	go func() {
-		defer chatStream.Close()
-		chatStream.SendEvent(core.StreamEvent{
-			Type: core.EventTypeStart,
-		})
-		if resp.Content != "" {
-			chatStream.SendEvent(core.StreamEvent{
-				Type: core.EventTypeTextDelta,
-				Data: resp.Content,
-			})
-		}
-		chatStream.SendEvent(core.StreamEvent{
-			Type: core.EventTypeFinish,
-		})
-	}()
-
-	return chatStream, nil
-}
-
-// handleClaudeStream processes Claude streaming responses
-func (p *BedrockProvider) handleClaudeStream(ctx context.Context, output *bedrockruntime.InvokeModelWithResponseStreamOutput, chatStream *llmx.ChatStream) {
-	defer chatStream.Close()
-
-	chatStream.SendEvent(core.StreamEvent{
-		Type: core.EventTypeStart,
-	})
-
-	stream := output.GetStream()
-	eventStream := stream.Events()
-	
-	for {
-		select {
-		case <-ctx.Done():
-			chatStream.SendError(ctx.Err())
-			return
-		case event, ok := <-eventStream:
-			if !ok {
-				// Channel closed
-				chatStream.SendEvent(core.StreamEvent{
-					Type: core.EventTypeFinish,
-				})
-				return
-			}
-			
-			// Handle payload chunk - simplified type handling
-			chunkBytes, ok := extractEventBytes(event)
-			if ok {
-				// Parse chunk
-				var chunkData map[string]interface{}
-				if err := json.Unmarshal(chunkBytes, &chunkData); err != nil {
-					continue
-				}
-
-				// Handle different chunk types
-				if chunkType, ok := chunkData["type"].(string); ok {
-					switch chunkType {
-					case "content_block_delta":
-						if delta, ok := chunkData["delta"].(map[string]interface{}); ok {
-							if text, ok := delta["text"].(string); ok {
-								chatStream.SendEvent(core.StreamEvent{
-									Type: core.EventTypeTextDelta,
-									Data: text,
-								})
-							}
-						}
-					case "message_stop":
-						chatStream.SendEvent(core.StreamEvent{
-							Type: core.EventTypeFinish,
-							Data: "stop",
-						})
-						return
-					}
-				}
-			}
-		}
-	}
-
-	if err := stream.Err(); err != nil {
-		chatStream.SendError(p.convertError(err))
-		return
-	}
-
-	chatStream.SendEvent(core.StreamEvent{
-		Type: core.EventTypeFinish,
-	})
-}
-
-// Helper functions
-
-func isClaudeModel(model string) bool {
-	return len(model) >= 10 && model[:10] == "anthropic."
-}
-
-func isLlamaModel(model string) bool {
-	return len(model) >= 5 && model[:5] == "meta."
-}
-
-func extractTextContent(content []llmx.ContentPart) string {
-	// For simplified response structure, we don't use ContentPart
-	// This function is kept for compatibility but not used
-	return ""
-}
-
-func convertRoleForClaude(role llmx.MessageRole) string {
-	if role == llmx.RoleAssistant {
-		return "assistant"
-	}
-	return "user"
-}
-
-// convertContentForClaude is no longer used - simplified to string content
-
-func convertToolsForClaude(tools []llmx.Tool) []map[string]interface{}  {
-	var claudeTools []map[string]interface{}
-	for _, tool := range tools {
-		claudeTools = append(claudeTools, map[string]interface{}{
-			"name":         tool.Name,
-			"description":  tool.Description,
-			"input_schema": tool.Parameters,
-		})
-	}
-	return claudeTools
-}
-
-func (p *BedrockProvider) convertClaudeResponse(resp map[string]interface{}, model string) *llmx.ChatResponse {
-	llmxResp := &llmx.ChatResponse{
-		Model:        model,
-		FinishReason: getFinishReason(resp),
-	}
-
-	// Extract text content
-	if contentArray, ok := resp["content"].([]interface{}); ok {
-		for _, item := range contentArray {
-			if itemMap, ok := item.(map[string]interface{}); ok {
-				if text, ok := itemMap["text"].(string); ok {
-					llmxResp.Content += text
-				}
-			}
-		}
-	}
-
-	// Set usage if available
-	if usage, ok := resp["usage"].(map[string]interface{}); ok {
-		if inputTokens, ok := usage["input_tokens"].(float64); ok {
-			llmxResp.Usage.PromptTokens = int(inputTokens)
-		}
-		if outputTokens, ok := usage["output_tokens"].(float64); ok {
-			llmxResp.Usage.CompletionTokens = int(outputTokens)
-			llmxResp.Usage.TotalTokens = llmxResp.Usage.PromptTokens + int(outputTokens)
-		}
-	}
-
-	return llmxResp
-}
-
-func (p *BedrockProvider) convertLlamaResponse(resp map[string]interface{}, model string) *llmx.ChatResponse {
-	llmxResp := &llmx.ChatResponse{
-		Model:        model,
-		FinishReason: "stop",
-	}
-
-	if generation, ok := resp["generation"].(string); ok {
-		llmxResp.Content = generation
-	}
-
-	return llmxResp
-}
-
-func buildLlamaPrompt(messages []llmx.Message) string {
-	// Simple prompt building for Llama
-	var prompt string
-	for _, msg := range messages {
-		if len(msg.Content) == 0 {
-			continue
-		}
-		// Extract text from first content part
-		var text string
-		if textPart, ok := msg.Content[0].(*llmx.TextPart); ok {
-			text = textPart.Text
-		}
-		
-		if msg.Role == llmx.RoleSystem {
-			prompt += "[INST] <<SYS>>\n" + text + "\n<</SYS>>\n\n"
-		} else if msg.Role == llmx.RoleUser {
-			prompt += text + " [/INST] "
-		} else {
-			prompt += text + " "
-		}
-	}
-	return prompt
-}
-
-func getFinishReason(resp map[string]interface{}) string {
-	if stopReason, ok := resp["stop_reason"].(string); ok {
-		switch stopReason {
-		case "end_turn":
-			return "stop"
-		case "max_tokens":
-			return "length"
-		case "stop_sequence":
-			return "stop"
-		default:
-			return stopReason
-		}
-	}
-	return "stop"
-}
-
-func (p *BedrockProvider) convertError(err error) error {
-	errMsg := err.Error()
-
-	if contains(errMsg, "throttling") || contains(errMsg, "rate") {
-		return llmx.NewRateLimitError(errMsg, 0)
-	} else if contains(errMsg, "unauthorized") || contains(errMsg, "access denied") {
-		return llmx.NewAuthenticationError(errMsg)
-	} else if contains(errMsg, "not found") {
-		return llmx.NewNotFoundError(errMsg, "model")
-	} else if contains(errMsg, "invalid") || contains(errMsg, "validation") {
-		return llmx.NewInvalidRequestError(errMsg, nil)
-	}
-
-	return llmx.NewInternalError(errMsg, err)
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
-		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-			len(s) > len(substr)+1 && s[1:len(substr)+1] == substr))
-}
-
-// extractEventBytes extracts bytes from Bedrock stream event
-func extractEventBytes(event interface{}) ([]byte, bool) {
-	// Use reflection to extract bytes from event
-	// This is a workaround for the complex event types in AWS SDK
-	type hasBytes interface {
-		GetBytes() []byte
-	}
-	
-	if v, ok := event.(hasBytes); ok {
-		return v.GetBytes(), true
-	}
-	
-	return nil, false
+	return output.GetStream(), nil
 }
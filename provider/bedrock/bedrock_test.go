@@ -0,0 +1,518 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+)
+
+// stubClient is a ConverseAPI implementation for tests
+type stubClient struct {
+	converseInput *bedrockruntime.ConverseInput
+	streamInput   *bedrockruntime.ConverseStreamInput
+
+	output *bedrockruntime.ConverseOutput
+	events []types.ConverseStreamOutput
+	err    error
+}
+
+func (s *stubClient) Converse(ctx context.Context, input *bedrockruntime.ConverseInput) (*bedrockruntime.ConverseOutput, error) {
+	s.converseInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.output, nil
+}
+
+func (s *stubClient) ConverseStream(ctx context.Context, input *bedrockruntime.ConverseStreamInput) (bedrockruntime.ConverseStreamOutputReader, error) {
+	s.streamInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	ch := make(chan types.ConverseStreamOutput, len(s.events))
+	for _, e := range s.events {
+		ch <- e
+	}
+	close(ch)
+	return &stubStream{events: ch}, nil
+}
+
+type stubStream struct {
+	events chan types.ConverseStreamOutput
+}
+
+func (s *stubStream) Events() <-chan types.ConverseStreamOutput { return s.events }
+func (s *stubStream) Close() error                              { return nil }
+func (s *stubStream) Err() error                                { return nil }
+
+func textOutput(text string) *bedrockruntime.ConverseOutput {
+	return &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role:    types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: text}},
+		}},
+		StopReason: types.StopReasonEndTurn,
+		Usage: &types.TokenUsage{
+			InputTokens:  aws.Int32(12),
+			OutputTokens: aws.Int32(5),
+			TotalTokens:  aws.Int32(17),
+		},
+	}
+}
+
+func userMessage(text string) llmx.Message {
+	return llmx.Message{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: text}}}
+}
+
+func TestBedrockProvider_Chat(t *testing.T) {
+	stub := &stubClient{output: textOutput("Hello from Nova")}
+	p := NewBedrockProviderWithClient(stub, "us-east-1")
+
+	respInterface, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model: "amazon.nova-pro-v1:0",
+		Messages: []llmx.Message{
+			{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: "Be brief."}}},
+			userMessage("Hi"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	resp := respInterface.(*llmx.ChatResponse)
+	if resp.Content != "Hello from Nova" {
+		t.Errorf("expected content 'Hello from Nova', got %q", resp.Content)
+	}
+	if resp.FinishReason != "stop" {
+		t.Errorf("expected finish reason 'stop', got %q", resp.FinishReason)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 5 || resp.Usage.TotalTokens != 17 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+
+	input := stub.converseInput
+	if len(input.System) != 1 {
+		t.Fatalf("expected 1 system block, got %d", len(input.System))
+	}
+	if text := input.System[0].(*types.SystemContentBlockMemberText).Value; text != "Be brief." {
+		t.Errorf("expected system prompt 'Be brief.', got %q", text)
+	}
+	if len(input.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(input.Messages))
+	}
+	if text := input.Messages[0].Content[0].(*types.ContentBlockMemberText).Value; text != "Hi" {
+		t.Errorf("expected user text 'Hi', got %q", text)
+	}
+}
+
+func TestBedrockProvider_SystemPromptFolding(t *testing.T) {
+	stub := &stubClient{output: textOutput("ok")}
+	p := NewBedrockProviderWithClient(stub, "us-east-1")
+
+	_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model: "amazon.titan-text-express-v1",
+		Messages: []llmx.Message{
+			{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: "You are terse."}}},
+			userMessage("Hi"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	input := stub.converseInput
+	if len(input.System) != 0 {
+		t.Errorf("titan should not receive system blocks, got %d", len(input.System))
+	}
+	content := input.Messages[0].Content
+	if len(content) != 2 {
+		t.Fatalf("expected system prompt folded into first user turn, got %d blocks", len(content))
+	}
+	if text := content[0].(*types.ContentBlockMemberText).Value; text != "You are terse." {
+		t.Errorf("expected folded system prompt, got %q", text)
+	}
+}
+
+func TestBedrockProvider_ToolRoundTrip(t *testing.T) {
+	stub := &stubClient{output: &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role: types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("tooluse_1"),
+					Name:      aws.String("get_weather"),
+					Input:     document.NewLazyDocument(map[string]interface{}{"city": "Paris"}),
+				}},
+			},
+		}},
+		StopReason: types.StopReasonToolUse,
+	}}
+	p := NewBedrockProviderWithClient(stub, "us-east-1")
+
+	req := &llmx.ChatRequest{
+		Model: "mistral.mistral-large-2407-v1:0",
+		Messages: []llmx.Message{
+			userMessage("Weather in Paris and Rome?"),
+			{
+				Role:    llmx.RoleAssistant,
+				Content: []llmx.ContentPart{llmx.TextPart{Text: "Checking."}},
+				ToolCalls: []llmx.ToolCall{
+					{ID: "a", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)},
+					{ID: "b", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Rome"}`)},
+				},
+			},
+			{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: "a", Result: "sunny"}}},
+			{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: "b", Result: "boom", IsError: true}}},
+		},
+		Tools: []llmx.Tool{{
+			Name:        "get_weather",
+			Description: "Get the weather",
+			Parameters: &llmx.Schema{
+				Type:       "object",
+				Properties: map[string]*llmx.Schema{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+		}},
+	}
+
+	respInterface, err := p.Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	resp := respInterface.(*llmx.ChatResponse)
+	if resp.FinishReason != "tool_calls" {
+		t.Errorf("expected finish reason 'tool_calls', got %q", resp.FinishReason)
+	}
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	if resp.ToolCalls[0].ID != "tooluse_1" || resp.ToolCalls[0].Name != "get_weather" {
+		t.Errorf("unexpected tool call: %+v", resp.ToolCalls[0])
+	}
+	if string(resp.ToolCalls[0].Arguments) != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool arguments: %s", resp.ToolCalls[0].Arguments)
+	}
+
+	input := stub.converseInput
+	if len(input.Messages) != 3 {
+		t.Fatalf("expected user, assistant, user(tool results) messages, got %d", len(input.Messages))
+	}
+	assistant := input.Messages[1]
+	if len(assistant.Content) != 3 {
+		t.Errorf("expected text + 2 tool use blocks, got %d", len(assistant.Content))
+	}
+	results := input.Messages[2]
+	if results.Role != types.ConversationRoleUser || len(results.Content) != 2 {
+		t.Fatalf("expected tool results merged into one user message, got %+v", results)
+	}
+	second := results.Content[1].(*types.ContentBlockMemberToolResult).Value
+	if aws.ToString(second.ToolUseId) != "b" || second.Status != types.ToolResultStatusError {
+		t.Errorf("unexpected tool result: %+v", second)
+	}
+	if input.ToolConfig == nil || len(input.ToolConfig.Tools) != 1 {
+		t.Fatal("expected tool configuration")
+	}
+}
+
+func TestBedrockProvider_UnsupportedCapabilities(t *testing.T) {
+	p := NewBedrockProviderWithClient(&stubClient{output: textOutput("ok")}, "us-east-1")
+
+	t.Run("tools on titan", func(t *testing.T) {
+		_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+			Model:    "amazon.titan-text-express-v1",
+			Messages: []llmx.Message{userMessage("hi")},
+			Tools:    []llmx.Tool{{Name: "noop"}},
+		})
+		var invalid *llmx.InvalidRequestError
+		if !errors.As(err, &invalid) {
+			t.Errorf("expected InvalidRequestError, got %v", err)
+		}
+	})
+
+	t.Run("images on cohere", func(t *testing.T) {
+		_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+			Model: "cohere.command-r-v1:0",
+			Messages: []llmx.Message{{
+				Role:    llmx.RoleUser,
+				Content: []llmx.ContentPart{llmx.ImagePart{Base64: "aGVsbG8="}},
+			}},
+		})
+		if err == nil {
+			t.Error("expected error for image input")
+		}
+	})
+}
+
+func TestBedrockProvider_ToolChoice(t *testing.T) {
+	tools := []llmx.Tool{{Name: "get_weather"}}
+
+	t.Run("named tool on claude", func(t *testing.T) {
+		stub := &stubClient{output: textOutput("ok")}
+		p := NewBedrockProviderWithClient(stub, "us-east-1")
+		_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+			Model:      "anthropic.claude-3-haiku-20240307-v1:0",
+			Messages:   []llmx.Message{userMessage("weather?")},
+			Tools:      tools,
+			ToolChoice: llmx.ForceTool("get_weather"),
+		})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		choice, ok := stub.converseInput.ToolConfig.ToolChoice.(*types.ToolChoiceMemberTool)
+		if !ok {
+			t.Fatalf("expected ToolChoiceMemberTool, got %T", stub.converseInput.ToolConfig.ToolChoice)
+		}
+		if aws.ToString(choice.Value.Name) != "get_weather" {
+			t.Errorf("expected tool get_weather, got %s", aws.ToString(choice.Value.Name))
+		}
+	})
+
+	t.Run("required on nova", func(t *testing.T) {
+		stub := &stubClient{output: textOutput("ok")}
+		p := NewBedrockProviderWithClient(stub, "us-east-1")
+		_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+			Model:      "amazon.nova-lite-v1:0",
+			Messages:   []llmx.Message{userMessage("weather?")},
+			Tools:      tools,
+			ToolChoice: &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired},
+		})
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		if _, ok := stub.converseInput.ToolConfig.ToolChoice.(*types.ToolChoiceMemberAny); !ok {
+			t.Errorf("expected ToolChoiceMemberAny, got %T", stub.converseInput.ToolConfig.ToolChoice)
+		}
+	})
+
+	unsupported := []struct {
+		name   string
+		model  string
+		choice *llmx.ToolChoice
+	}{
+		{"none on claude", "anthropic.claude-3-haiku-20240307-v1:0", &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}},
+		{"required on llama", "meta.llama3-1-70b-instruct-v1:0", &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}},
+	}
+	for _, tt := range unsupported {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewBedrockProviderWithClient(&stubClient{output: textOutput("ok")}, "us-east-1")
+			_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+				Model:      tt.model,
+				Messages:   []llmx.Message{userMessage("weather?")},
+				Tools:      tools,
+				ToolChoice: tt.choice,
+			})
+			var unsupportedErr *llmx.UnsupportedFeatureError
+			if !errors.As(err, &unsupportedErr) {
+				t.Errorf("expected UnsupportedFeatureError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBedrockProvider_Image(t *testing.T) {
+	stub := &stubClient{output: textOutput("a cat")}
+	p := NewBedrockProviderWithClient(stub, "us-east-1")
+
+	_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model: "anthropic.claude-3-haiku-20240307-v1:0",
+		Messages: []llmx.Message{{
+			Role: llmx.RoleUser,
+			Content: []llmx.ContentPart{
+				llmx.TextPart{Text: "What is this?"},
+				llmx.ImagePart{Base64: "data:image/jpeg;base64,aGVsbG8="},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	image := stub.converseInput.Messages[0].Content[1].(*types.ContentBlockMemberImage).Value
+	if image.Format != types.ImageFormatJpeg {
+		t.Errorf("expected jpeg format, got %s", image.Format)
+	}
+	if string(image.Source.(*types.ImageSourceMemberBytes).Value) != "hello" {
+		t.Error("expected decoded image bytes")
+	}
+}
+
+func TestBedrockProvider_StreamChat(t *testing.T) {
+	stub := &stubClient{events: []types.ConverseStreamOutput{
+		&types.ConverseStreamOutputMemberMessageStart{Value: types.MessageStartEvent{Role: types.ConversationRoleAssistant}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "Hel"},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(0),
+			Delta:             &types.ContentBlockDeltaMemberText{Value: "lo"},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockStart{Value: types.ContentBlockStartEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Start: &types.ContentBlockStartMemberToolUse{Value: types.ToolUseBlockStart{
+				ToolUseId: aws.String("t1"),
+				Name:      aws.String("lookup"),
+			}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`{"q":`)}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockDelta{Value: types.ContentBlockDeltaEvent{
+			ContentBlockIndex: aws.Int32(1),
+			Delta:             &types.ContentBlockDeltaMemberToolUse{Value: types.ToolUseBlockDelta{Input: aws.String(`"x"}`)}},
+		}},
+		&types.ConverseStreamOutputMemberContentBlockStop{Value: types.ContentBlockStopEvent{ContentBlockIndex: aws.Int32(1)}},
+		&types.ConverseStreamOutputMemberMessageStop{Value: types.MessageStopEvent{StopReason: types.StopReasonToolUse}},
+		&types.ConverseStreamOutputMemberMetadata{Value: types.ConverseStreamMetadataEvent{
+			Usage: &types.TokenUsage{InputTokens: aws.Int32(9), OutputTokens: aws.Int32(4), TotalTokens: aws.Int32(13)},
+		}},
+	}}
+	p := NewBedrockProviderWithClient(stub, "us-east-1")
+
+	streamInterface, err := p.StreamChat(context.Background(), &llmx.ChatRequest{
+		Model:    "meta.llama3-1-70b-instruct-v1:0",
+		Messages: []llmx.Message{userMessage("hi")},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+	stream := streamInterface.(*llmx.ChatStream)
+
+	var text string
+	var toolCall map[string]interface{}
+	var finish interface{}
+	var usage llmx.Usage
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventTypeTextDelta:
+			text += event.Data.(string)
+		case core.EventTypeUsage:
+			usage = event.Data.(llmx.Usage)
+		case core.EventTypeToolCall:
+			toolCall = event.Data.(map[string]interface{})
+		case core.EventTypeFinish:
+			finish = event.Data
+		}
+	}
+
+	if text != "Hello" {
+		t.Errorf("expected streamed text 'Hello', got %q", text)
+	}
+	if toolCall == nil || toolCall["name"] != "lookup" || toolCall["args"] != `{"q":"x"}` {
+		t.Errorf("unexpected tool call event: %v", toolCall)
+	}
+	if finish != "tool_calls" {
+		t.Errorf("expected finish reason 'tool_calls', got %v", finish)
+	}
+	if usage.PromptTokens != 9 || usage.CompletionTokens != 4 || usage.TotalTokens != 13 {
+		t.Errorf("unexpected usage event: %+v", usage)
+	}
+	if got := stream.GetAccumulated().Usage; got != usage {
+		t.Errorf("accumulated usage = %+v, want %+v", got, usage)
+	}
+	if aws.ToString(stub.streamInput.ModelId) != "meta.llama3-1-70b-instruct-v1:0" {
+		t.Errorf("unexpected model id: %s", aws.ToString(stub.streamInput.ModelId))
+	}
+}
+
+// sdkError wraps an exception the way the AWS SDK returns it
+func sdkError(status int, exception error) error {
+	return &smithy.OperationError{
+		ServiceID:     "Bedrock Runtime",
+		OperationName: "Converse",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      exception,
+			},
+			RequestID: "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d",
+		},
+	}
+}
+
+func TestBedrockProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		requestID string
+	}{
+		{"throttling message", errors.New("ThrottlingException: Too many requests"), llmx.ErrRateLimit, ""},
+		{"throttling", sdkError(429, &types.ThrottlingException{Message: aws.String("Too many requests, please wait before trying again.")}),
+			llmx.ErrRateLimit, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"quota", sdkError(400, &types.ServiceQuotaExceededException{Message: aws.String("Your account has exceeded its quota for tokens per day.")}),
+			llmx.ErrQuotaExceeded, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"context length", sdkError(400, &types.ValidationException{Message: aws.String("Input is too long for requested model.")}),
+			llmx.ErrContextLengthExceeded, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"validation", sdkError(400, &types.ValidationException{Message: aws.String("The provided model identifier is invalid.")}),
+			llmx.ErrInvalidRequest, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"access denied", sdkError(403, &types.AccessDeniedException{Message: aws.String("You don't have access to the model with the specified model ID.")}),
+			llmx.ErrAuthentication, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"model not found", sdkError(404, &types.ResourceNotFoundException{Message: aws.String("Could not resolve the foundation model from the provided model identifier.")}),
+			llmx.ErrModelNotFound, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"model not ready", sdkError(429, &types.ModelNotReadyException{Message: aws.String("Model is not ready for inference.")}),
+			llmx.ErrRateLimit, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"unavailable", sdkError(503, &types.ServiceUnavailableException{Message: aws.String("Service unavailable.")}),
+			llmx.ErrOverloaded, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+		{"model timeout", sdkError(408, &types.ModelTimeoutException{Message: aws.String("Model has timed out in processing the request.")}),
+			llmx.ErrTimeout, "4b0b2a8e-6f6c-4c55-9b1e-1d2f3a4b5c6d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewBedrockProviderWithClient(&stubClient{err: tt.err}, "us-east-1")
+
+			_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+				Model:    "anthropic.claude-3-haiku-20240307-v1:0",
+				Messages: []llmx.Message{userMessage("hi")},
+			})
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var base *llmx.BaseError
+			if !errors.As(err, &base) || base.RequestID != tt.requestID {
+				t.Errorf("expected request ID %q, got %+v", tt.requestID, base)
+			}
+		})
+	}
+}
+
+func TestDetectFamily(t *testing.T) {
+	tests := []struct {
+		model  string
+		family string
+		system bool
+		tools  bool
+	}{
+		{"anthropic.claude-3-haiku-20240307-v1:0", "anthropic", true, true},
+		{"us.anthropic.claude-3-5-sonnet-20241022-v2:0", "anthropic", true, true},
+		{"amazon.titan-text-premier-v1:0", "titan", false, false},
+		{"amazon.nova-micro-v1:0", "nova", true, true},
+		{"meta.llama3-8b-instruct-v1:0", "llama", true, false},
+		{"meta.llama3-1-8b-instruct-v1:0", "llama", true, true},
+		{"mistral.mistral-7b-instruct-v0:2", "mistral", false, false},
+		{"cohere.command-r-plus-v1:0", "cohere", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			f := detectFamily(tt.model)
+			if f.name != tt.family || f.system != tt.system || f.tools != tt.tools {
+				t.Errorf("detectFamily(%s) = %+v", tt.model, f)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package bedrock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/llmx-ai/llmx"
+)
+
+// modelFamily describes what a Bedrock model family accepts through Converse
+type modelFamily struct {
+	name       string
+	system     bool // Accepts a system prompt
+	tools      bool // Accepts a tool configuration
+	toolChoice bool // Accepts "any" and named tool choices
+	vision     bool // Accepts image content blocks
+}
+
+// detectFamily returns the capabilities of a Bedrock model ID.
+// Cross-region inference profile prefixes such as "us." are ignored.
+func detectFamily(modelID string) modelFamily {
+	id := modelID
+	for _, prefix := range []string{"us.", "eu.", "apac.", "us-gov."} {
+		id = strings.TrimPrefix(id, prefix)
+	}
+
+	switch {
+	case strings.HasPrefix(id, "anthropic."):
+		return modelFamily{name: "anthropic", system: true, tools: true, toolChoice: true, vision: true}
+	case strings.HasPrefix(id, "amazon.nova-micro"):
+		return modelFamily{name: "nova", system: true, tools: true, toolChoice: true}
+	case strings.HasPrefix(id, "amazon.nova"):
+		return modelFamily{name: "nova", system: true, tools: true, toolChoice: true, vision: true}
+	case strings.HasPrefix(id, "amazon.titan"):
+		return modelFamily{name: "titan"}
+	case strings.HasPrefix(id, "meta.llama3-2-11b"), strings.HasPrefix(id, "meta.llama3-2-90b"):
+		return modelFamily{name: "llama", system: true, tools: true, vision: true}
+	case strings.HasPrefix(id, "meta.llama3-1"), strings.HasPrefix(id, "meta.llama3-2"), strings.HasPrefix(id, "meta.llama3-3"), strings.HasPrefix(id, "meta.llama4"):
+		return modelFamily{name: "llama", system: true, tools: true}
+	case strings.HasPrefix(id, "meta."):
+		return modelFamily{name: "llama", system: true}
+	case strings.HasPrefix(id, "mistral.mistral-large"), strings.HasPrefix(id, "mistral.pixtral"):
+		return modelFamily{name: "mistral", system: true, tools: true, toolChoice: true}
+	case strings.HasPrefix(id, "mistral."):
+		return modelFamily{name: "mistral"}
+	case strings.HasPrefix(id, "cohere.command-r"):
+		return modelFamily{name: "cohere", system: true, tools: true}
+	case strings.HasPrefix(id, "cohere."):
+		return modelFamily{name: "cohere"}
+	default:
+		// Unknown families are assumed to support the full Converse surface;
+		// Bedrock reports a validation error if they do not.
+		return modelFamily{name: "unknown", system: true, tools: true, toolChoice: true, vision: true}
+	}
+}
+
+// converseInput holds the fields shared by ConverseInput and ConverseStreamInput
+type converseInput struct {
+	modelID         *string
+	messages        []types.Message
+	system          []types.SystemContentBlock
+	inferenceConfig *types.InferenceConfiguration
+	toolConfig      *types.ToolConfiguration
+}
+
+// convertRequest converts a llmx request to the Converse request shape
+func (p *BedrockProvider) convertRequest(req *llmx.ChatRequest) (*converseInput, error) {
+	if req.Model == "" {
+		return nil, llmx.NewInvalidRequestError("bedrock: model is required", nil)
+	}
+
+	family := detectFamily(req.Model)
+	input := &converseInput{
+		modelID: aws.String(req.Model),
+	}
+
+	var systemPrompt []string
+	for _, msg := range req.Messages {
+		if msg.Role == llmx.RoleSystem {
+			if text := llmx.ExtractText(msg); text != "" {
+				systemPrompt = append(systemPrompt, text)
+			}
+			continue
+		}
+
+		role, blocks, err := convertMessage(msg, family)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// Converse requires alternating roles, so consecutive messages with
+		// the same role (e.g. several tool results) are merged.
+		if n := len(input.messages); n > 0 && input.messages[n-1].Role == role {
+			input.messages[n-1].Content = append(input.messages[n-1].Content, blocks...)
+			continue
+		}
+		input.messages = append(input.messages, types.Message{
+			Role:    role,
+			Content: blocks,
+		})
+	}
+
+	if len(systemPrompt) > 0 {
+		if family.system {
+			for _, text := range systemPrompt {
+				input.system = append(input.system, &types.SystemContentBlockMemberText{Value: text})
+			}
+		} else {
+			// Families without system prompt support receive it as a preamble
+			// to the first user turn.
+			prefix := &types.ContentBlockMemberText{Value: strings.Join(systemPrompt, "\n")}
+			if len(input.messages) > 0 && input.messages[0].Role == types.ConversationRoleUser {
+				input.messages[0].Content = append([]types.ContentBlock{prefix}, input.messages[0].Content...)
+			} else {
+				input.messages = append([]types.Message{{
+					Role:    types.ConversationRoleUser,
+					Content: []types.ContentBlock{prefix},
+				}}, input.messages...)
+			}
+		}
+	}
+
+	if len(input.messages) == 0 {
+		return nil, llmx.NewInvalidRequestError("bedrock: at least one non-system message is required", nil)
+	}
+
+	inference := &types.InferenceConfiguration{}
+	hasInference := false
+	if req.Temperature != nil {
+		inference.Temperature = aws.Float32(float32(*req.Temperature))
+		hasInference = true
+	}
+	if req.MaxTokens != nil {
+		inference.MaxTokens = aws.Int32(int32(*req.MaxTokens))
+		hasInference = true
+	}
+	if req.TopP != nil {
+		inference.TopP = aws.Float32(float32(*req.TopP))
+		hasInference = true
+	}
+	if len(req.Stop) > 0 {
+		inference.StopSequences = req.Stop
+		hasInference = true
+	}
+	if hasInference {
+		input.inferenceConfig = inference
+	}
+
+	if len(req.Tools) > 0 {
+		if !family.tools {
+			return nil, llmx.NewInvalidRequestError(
+				fmt.Sprintf("bedrock: model %s does not support tool calling", req.Model),
+				map[string]interface{}{"model": req.Model},
+			)
+		}
+		toolConfig, err := convertTools(req.Tools)
+		if err != nil {
+			return nil, err
+		}
+		if req.ToolChoice != nil {
+			choice, err := convertToolChoice(req.ToolChoice, req.Model, family)
+			if err != nil {
+				return nil, err
+			}
+			toolConfig.ToolChoice = choice
+		}
+		input.toolConfig = toolConfig
+	}
+
+	return input, nil
+}
+
+// convertMessage converts a llmx message to a Converse role and content blocks
+func convertMessage(msg llmx.Message, family modelFamily) (types.ConversationRole, []types.ContentBlock, error) {
+	role := types.ConversationRoleUser
+	if msg.Role == llmx.RoleAssistant {
+		role = types.ConversationRoleAssistant
+	}
+
+	var blocks []types.ContentBlock
+	for _, part := range msg.Content {
+		switch v := part.(type) {
+		case llmx.TextPart:
+			if v.Text != "" {
+				blocks = append(blocks, &types.ContentBlockMemberText{Value: v.Text})
+			}
+
+		case llmx.ImagePart:
+			if !family.vision {
+				return "", nil, llmx.NewInvalidRequestError(
+					fmt.Sprintf("bedrock: %s models do not support image input", family.name), nil)
+			}
+			image, err := convertImage(v)
+			if err != nil {
+				return "", nil, err
+			}
+			blocks = append(blocks, &types.ContentBlockMemberImage{Value: *image})
+
+		case llmx.ToolCall:
+			blocks = append(blocks, convertToolCall(v))
+
+		case llmx.ToolResultPart:
+			status := types.ToolResultStatusSuccess
+			if v.IsError {
+				status = types.ToolResultStatusError
+			}
+			blocks = append(blocks, &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: aws.String(v.ToolCallID),
+				Content: []types.ToolResultContentBlock{
+					&types.ToolResultContentBlockMemberText{Value: v.Result},
+				},
+				Status: status,
+			}})
+		}
+	}
+
+	for _, call := range msg.ToolCalls {
+		blocks = append(blocks, convertToolCall(call))
+	}
+
+	return role, blocks, nil
+}
+
+// convertToolCall converts a llmx tool call to a Converse tool use block
+func convertToolCall(call llmx.ToolCall) types.ContentBlock {
+	var args interface{} = map[string]interface{}{}
+	if len(call.Arguments) > 0 {
+		var decoded interface{}
+		if err := json.Unmarshal(call.Arguments, &decoded); err == nil && decoded != nil {
+			args = decoded
+		}
+	}
+	return &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+		ToolUseId: aws.String(call.ID),
+		Name:      aws.String(call.Name),
+		Input:     document.NewLazyDocument(args),
+	}}
+}
+
+// convertImage converts an image part to a Converse image block.
+// Bedrock only accepts inline bytes or S3 locations.
+func convertImage(img llmx.ImagePart) (*types.ImageBlock, error) {
+	if strings.HasPrefix(img.URL, "s3://") {
+		return &types.ImageBlock{
+			Format: imageFormatFromName(img.URL),
+			Source: &types.ImageSourceMemberS3Location{Value: types.S3Location{Uri: aws.String(img.URL)}},
+		}, nil
+	}
+
+	data := img.Base64
+	if data == "" && strings.HasPrefix(img.URL, "data:") {
+		data = img.URL
+	}
+	if data == "" {
+		return nil, llmx.NewInvalidRequestError("bedrock: images must be base64 encoded or s3:// URLs", nil)
+	}
+
+	format := types.ImageFormatPng
+	if strings.HasPrefix(data, "data:") {
+		// data:image/jpeg;base64,....
+		header, payload, ok := strings.Cut(data, ",")
+		if !ok {
+			return nil, llmx.NewInvalidRequestError("bedrock: malformed image data URL", nil)
+		}
+		mediaType := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		format = imageFormatFromName(mediaType)
+		data = payload
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, llmx.NewInvalidRequestError(fmt.Sprintf("bedrock: invalid base64 image: %v", err), nil)
+	}
+
+	return &types.ImageBlock{
+		Format: format,
+		Source: &types.ImageSourceMemberBytes{Value: raw},
+	}, nil
+}
+
+func imageFormatFromName(name string) types.ImageFormat {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, "jpeg"), strings.HasSuffix(name, "jpg"):
+		return types.ImageFormatJpeg
+	case strings.HasSuffix(name, "gif"):
+		return types.ImageFormatGif
+	case strings.HasSuffix(name, "webp"):
+		return types.ImageFormatWebp
+	default:
+		return types.ImageFormatPng
+	}
+}
+
+// convertTools converts llmx tools to a Converse tool configuration
+func convertTools(tools []llmx.Tool) (*types.ToolConfiguration, error) {
+	config := &types.ToolConfiguration{}
+	for _, tool := range tools {
+		schema, err := schemaDocument(tool.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("bedrock: invalid schema for tool %s: %w", tool.Name, err)
+		}
+		spec := types.ToolSpecification{
+			Name:        aws.String(tool.Name),
+			InputSchema: &types.ToolInputSchemaMemberJson{Value: schema},
+		}
+		if tool.Description != "" {
+			spec.Description = aws.String(tool.Description)
+		}
+		config.Tools = append(config.Tools, &types.ToolMemberToolSpec{Value: spec})
+	}
+	return config, nil
+}
+
+// convertToolChoice converts a llmx tool choice to a Converse tool choice.
+// Converse has no "none" choice, and only some families accept anything
+// other than "auto".
+func convertToolChoice(choice *llmx.ToolChoice, model string, family modelFamily) (types.ToolChoice, error) {
+	switch choice.Mode {
+	case llmx.ToolChoiceAuto:
+		return &types.ToolChoiceMemberAuto{Value: types.AutoToolChoice{}}, nil
+	case llmx.ToolChoiceNone:
+		return nil, llmx.NewUnsupportedFeatureError("bedrock", "tool_choice",
+			"tool choice \"none\" is not supported by the Converse API")
+	}
+
+	if !family.toolChoice {
+		return nil, llmx.NewUnsupportedFeatureError("bedrock", "tool_choice",
+			fmt.Sprintf("model %s only supports tool choice \"auto\"", model))
+	}
+
+	if choice.Mode == llmx.ToolChoiceFunction {
+		return &types.ToolChoiceMemberTool{Value: types.SpecificToolChoice{Name: aws.String(choice.Name)}}, nil
+	}
+	return &types.ToolChoiceMemberAny{Value: types.AnyToolChoice{}}, nil
+}
+
+// schemaDocument converts a llmx schema to a Smithy document.
+// The schema is round-tripped through JSON so its json tags are honored.
+func schemaDocument(schema *llmx.Schema) (document.Interface, error) {
+	if schema == nil {
+		return document.NewLazyDocument(map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}), nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return document.NewLazyDocument(v), nil
+}
+
+// convertResponse converts a Converse response to a llmx response
+func (p *BedrockProvider) convertResponse(output *bedrockruntime.ConverseOutput, model string) (*llmx.ChatResponse, error) {
+	resp := &llmx.ChatResponse{
+		Model:           model,
+		FinishReason:    convertStopReason(output.StopReason),
+		RawFinishReason: string(output.StopReason),
+		CreatedAt:       time.Now(),
+		Raw:             output,
+	}
+	resp.Metadata.RequestID, _ = awsmiddleware.GetRequestIDMetadata(output.ResultMetadata)
+
+	if msg, ok := output.Output.(*types.ConverseOutputMemberMessage); ok {
+		for _, block := range msg.Value.Content {
+			switch v := block.(type) {
+			case *types.ContentBlockMemberText:
+				resp.Content += v.Value
+			case *types.ContentBlockMemberToolUse:
+				call, err := convertToolUse(v.Value)
+				if err != nil {
+					return nil, err
+				}
+				resp.ToolCalls = append(resp.ToolCalls, call)
+			}
+		}
+	}
+
+	if output.Usage != nil {
+		resp.Usage = convertUsage(output.Usage)
+	}
+
+	return resp, nil
+}
+
+// convertToolUse converts a Converse tool use block to a llmx tool call
+func convertToolUse(block types.ToolUseBlock) (llmx.ToolCall, error) {
+	args := json.RawMessage("{}")
+	if block.Input != nil {
+		data, err := block.Input.MarshalSmithyDocument()
+		if err != nil {
+			return llmx.ToolCall{}, fmt.Errorf("bedrock: failed to decode tool input: %w", err)
+		}
+		args = data
+	}
+	return llmx.ToolCall{
+		ID:        aws.ToString(block.ToolUseId),
+		Name:      aws.ToString(block.Name),
+		Arguments: args,
+	}, nil
+}
+
+func convertUsage(usage *types.TokenUsage) llmx.Usage {
+	result := llmx.Usage{
+		PromptTokens:     int(aws.ToInt32(usage.InputTokens)),
+		CompletionTokens: int(aws.ToInt32(usage.OutputTokens)),
+		TotalTokens:      int(aws.ToInt32(usage.TotalTokens)),
+	}
+	if result.TotalTokens == 0 {
+		result.TotalTokens = result.PromptTokens + result.CompletionTokens
+	}
+	return result
+}
+
+// convertStopReason maps Converse stop reasons to llmx finish reasons
+func convertStopReason(reason types.StopReason) llmx.FinishReason {
+	if reason == "" {
+		return llmx.FinishReasonStop
+	}
+	return llmx.NormalizeFinishReason(string(reason))
+}
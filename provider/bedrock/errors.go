@@ -0,0 +1,71 @@
+package bedrock
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// exceptionStatus maps Bedrock exception names to their HTTP status
+var exceptionStatus = []struct {
+	code   string
+	status int
+}{
+	{"ThrottlingException", http.StatusTooManyRequests},
+	{"TooManyRequestsException", http.StatusTooManyRequests},
+	{"ServiceQuotaExceededException", http.StatusTooManyRequests},
+	{"ValidationException", http.StatusBadRequest},
+	{"AccessDeniedException", http.StatusForbidden},
+	{"UnrecognizedClientException", http.StatusUnauthorized},
+	{"ExpiredTokenException", http.StatusUnauthorized},
+	{"ResourceNotFoundException", http.StatusNotFound},
+	{"ModelTimeoutException", http.StatusRequestTimeout},
+	{"ModelNotReadyException", http.StatusServiceUnavailable},
+	{"ServiceUnavailableException", http.StatusServiceUnavailable},
+	{"InternalServerException", http.StatusInternalServerError},
+	{"ModelErrorException", http.StatusInternalServerError},
+	{"ModelStreamErrorException", http.StatusInternalServerError},
+}
+
+// convertError converts Bedrock errors to llmx errors. SDK errors carry
+// the exception name, HTTP status and request ID; errors that lost them
+// are recognised by the exception name in their message.
+func (p *BedrockProvider) convertError(err error) error {
+	info := llmx.ErrorInfo{Provider: "bedrock", Cause: err, Message: err.Error()}
+
+	var apiErr interface {
+		ErrorCode() string
+		ErrorMessage() string
+	}
+	if errors.As(err, &apiErr) {
+		info.Code = apiErr.ErrorCode()
+		info.Message = apiErr.ErrorMessage()
+	} else {
+		for _, e := range exceptionStatus {
+			if strings.Contains(info.Message, e.code) {
+				info.Code = e.code
+				break
+			}
+		}
+	}
+
+	var httpErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpErr) {
+		info.StatusCode = httpErr.HTTPStatusCode()
+	} else {
+		for _, e := range exceptionStatus {
+			if info.Code == e.code {
+				info.StatusCode = e.status
+				break
+			}
+		}
+	}
+
+	var idErr interface{ ServiceRequestID() string }
+	if errors.As(err, &idErr) {
+		info.RequestID = idErr.ServiceRequestID()
+	}
+	return llmx.ClassifyError(info)
+}
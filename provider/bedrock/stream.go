@@ -0,0 +1,114 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+)
+
+// pendingToolCall accumulates a tool call streamed across content block deltas
+type pendingToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// handleStream processes ConverseStream events and sends them to the chat stream
+func (p *BedrockProvider) handleStream(
+	ctx context.Context,
+	stream bedrockruntime.ConverseStreamOutputReader,
+	chatStream *llmx.ChatStream,
+) {
+	defer stream.Close()
+	defer chatStream.Close()
+
+	chatStream.SendEvent(core.StreamEvent{
+		Type: core.EventTypeStart,
+	})
+
+	toolCalls := make(map[int32]*pendingToolCall)
+	finishReason := ""
+	events := stream.Events()
+
+	for {
+		select {
+		case <-ctx.Done():
+			chatStream.SendError(ctx.Err())
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				if err := stream.Err(); err != nil {
+					chatStream.SendError(p.convertError(err))
+					return
+				}
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeFinish,
+					Data: finishReason,
+				})
+				return
+			}
+
+			switch v := event.(type) {
+			case *types.ConverseStreamOutputMemberContentBlockStart:
+				if start, ok := v.Value.Start.(*types.ContentBlockStartMemberToolUse); ok {
+					toolCalls[aws.ToInt32(v.Value.ContentBlockIndex)] = &pendingToolCall{
+						id:   aws.ToString(start.Value.ToolUseId),
+						name: aws.ToString(start.Value.Name),
+					}
+				}
+
+			case *types.ConverseStreamOutputMemberContentBlockDelta:
+				switch delta := v.Value.Delta.(type) {
+				case *types.ContentBlockDeltaMemberText:
+					if delta.Value != "" {
+						chatStream.SendEvent(core.StreamEvent{
+							Type: core.EventTypeTextDelta,
+							Data: delta.Value,
+						})
+					}
+				case *types.ContentBlockDeltaMemberToolUse:
+					if call, ok := toolCalls[aws.ToInt32(v.Value.ContentBlockIndex)]; ok {
+						call.args.WriteString(aws.ToString(delta.Value.Input))
+					}
+				}
+
+			case *types.ConverseStreamOutputMemberContentBlockStop:
+				index := aws.ToInt32(v.Value.ContentBlockIndex)
+				if call, ok := toolCalls[index]; ok {
+					delete(toolCalls, index)
+					args := call.args.String()
+					if args == "" || !json.Valid([]byte(args)) {
+						args = "{}"
+					}
+					chatStream.SendEvent(core.StreamEvent{
+						Type: core.EventTypeToolCall,
+						Data: map[string]interface{}{
+							"id":   call.id,
+							"name": call.name,
+							"args": args,
+						},
+					})
+				}
+
+			case *types.ConverseStreamOutputMemberMessageStop:
+				finishReason = string(convertStopReason(v.Value.StopReason))
+
+			case *types.ConverseStreamOutputMemberMetadata:
+				// Token usage arrives after the message stops
+				if v.Value.Usage != nil {
+					chatStream.SendEvent(core.StreamEvent{
+						Type: core.EventTypeUsage,
+						Data: convertUsage(v.Value.Usage),
+					})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+)
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *CohereProvider {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p, err := NewCohereProvider(map[string]interface{}{
+		"api_key":  "test-key",
+		"base_url": server.URL,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return p.(*CohereProvider)
+}
+
+func decodeBody(t *testing.T, r *http.Request) map[string]interface{} {
+	t.Helper()
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("failed to decode request body: %v", err)
+	}
+	return body
+}
+
+func TestConvertTools(t *testing.T) {
+	p := &CohereProvider{}
+	req, err := p.convertRequest(&llmx.ChatRequest{
+		Model:    "command-r",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather?"}}}},
+		Tools: []llmx.Tool{{
+			Name:        "get_weather",
+			Description: "Get the weather",
+			Parameters: &llmx.Schema{
+				Type: "object",
+				Properties: map[string]*llmx.Schema{
+					"city":  {Type: "string", Description: "City name"},
+					"days":  {Type: "integer"},
+					"unit":  {Type: "string", Enum: []interface{}{"celsius", "fahrenheit"}},
+					"hours": {Type: "array", Items: &llmx.Schema{Type: "number"}},
+				},
+				Required: []string{"city"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tools := req.Tools
+	if len(tools) != 1 {
+		t.Fatalf("expected 1 tool, got %d", len(tools))
+	}
+	defs := tools[0].ParameterDefinitions
+
+	tests := []struct {
+		name     string
+		typ      string
+		required bool
+	}{
+		{"city", "str", true},
+		{"days", "int", false},
+		{"unit", "str", false},
+		{"hours", "List[float]", false},
+	}
+	for _, tt := range tests {
+		def, ok := defs[tt.name]
+		if !ok {
+			t.Fatalf("missing parameter definition %q", tt.name)
+		}
+		if def.Type != tt.typ {
+			t.Errorf("%s: expected type %q, got %q", tt.name, tt.typ, def.Type)
+		}
+		if def.Required == nil || *def.Required != tt.required {
+			t.Errorf("%s: expected required %v, got %v", tt.name, tt.required, def.Required)
+		}
+	}
+
+	if desc := defs["unit"].Description; desc == nil || !strings.Contains(*desc, "celsius, fahrenheit") {
+		t.Errorf("expected enum values in description, got %v", desc)
+	}
+}
+
+// responseToolCalls returns the tool calls of a response making calls
+func responseToolCalls(t *testing.T, calls ...*cohere.ToolCall) []llmx.ToolCall {
+	t.Helper()
+
+	resp, err := (&CohereProvider{}).convertResponse(&cohere.NonStreamedChatResponse{ToolCalls: calls}, "command-r")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return resp.ToolCalls
+}
+
+func TestConvertRequest(t *testing.T) {
+	p := &CohereProvider{}
+
+	t.Run("history and preamble", func(t *testing.T) {
+		req, err := p.convertRequest(&llmx.ChatRequest{
+			Model: "command-r",
+			Messages: []llmx.Message{
+				{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: "Be brief."}}},
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}},
+				{Role: llmx.RoleAssistant, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hello!"}}},
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "How are you?"}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if req.Preamble == nil || *req.Preamble != "Be brief." {
+			t.Errorf("expected preamble 'Be brief.', got %v", req.Preamble)
+		}
+		if req.Message != "How are you?" {
+			t.Errorf("expected message 'How are you?', got %q", req.Message)
+		}
+		if len(req.ChatHistory) != 2 {
+			t.Fatalf("expected 2 history messages, got %d", len(req.ChatHistory))
+		}
+		if req.ChatHistory[0].User == nil || req.ChatHistory[0].User.Message != "Hi" {
+			t.Errorf("expected user history message 'Hi', got %+v", req.ChatHistory[0])
+		}
+		if req.ChatHistory[1].Chatbot == nil || req.ChatHistory[1].Chatbot.Message != "Hello!" {
+			t.Errorf("expected chatbot history message 'Hello!', got %+v", req.ChatHistory[1])
+		}
+	})
+
+	t.Run("trailing tool results", func(t *testing.T) {
+		req, err := p.convertRequest(&llmx.ChatRequest{
+			Model: "command-r",
+			Messages: []llmx.Message{
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather in Paris?"}}},
+				{Role: llmx.RoleAssistant, ToolCalls: []llmx.ToolCall{{
+					ID:        "get_weather_0",
+					Name:      "get_weather",
+					Arguments: json.RawMessage(`{"city":"Paris"}`),
+				}}},
+				{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{
+					ToolCallID: "get_weather_0",
+					Result:     `{"temperature":21}`,
+				}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if req.Message != "" {
+			t.Errorf("expected empty message, got %q", req.Message)
+		}
+		if len(req.ChatHistory) != 2 {
+			t.Fatalf("expected 2 history messages, got %d", len(req.ChatHistory))
+		}
+		chatbot := req.ChatHistory[1].Chatbot
+		if chatbot == nil || len(chatbot.ToolCalls) != 1 || chatbot.ToolCalls[0].Parameters["city"] != "Paris" {
+			t.Errorf("expected chatbot tool call with city=Paris, got %+v", chatbot)
+		}
+		if len(req.ToolResults) != 1 {
+			t.Fatalf("expected 1 tool result, got %d", len(req.ToolResults))
+		}
+		result := req.ToolResults[0]
+		if result.Call.Name != "get_weather" {
+			t.Errorf("expected call name get_weather, got %s", result.Call.Name)
+		}
+		if result.Outputs[0]["temperature"] != float64(21) {
+			t.Errorf("expected temperature output 21, got %v", result.Outputs[0])
+		}
+	})
+
+	t.Run("tool calls across turns", func(t *testing.T) {
+		// Each response numbers its calls from zero; the IDs must still
+		// pair results with the call of their own turn
+		first := responseToolCalls(t, &cohere.ToolCall{Name: "get_weather", Parameters: map[string]interface{}{"city": "Paris"}})
+		second := responseToolCalls(t, &cohere.ToolCall{Name: "get_weather", Parameters: map[string]interface{}{"city": "Rome"}})
+		if first[0].ID == second[0].ID {
+			t.Fatalf("calls of two responses share the ID %q", first[0].ID)
+		}
+
+		req, err := p.convertRequest(&llmx.ChatRequest{
+			Model: "command-r",
+			Messages: []llmx.Message{
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather in Paris?"}}},
+				{Role: llmx.RoleAssistant, ToolCalls: first},
+				{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: first[0].ID, Result: `{"temperature":21}`}}},
+				{Role: llmx.RoleAssistant, Content: []llmx.ContentPart{llmx.TextPart{Text: "21 degrees."}}},
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "And Rome?"}}},
+				{Role: llmx.RoleAssistant, ToolCalls: second},
+				{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: second[0].ID, Result: `{"temperature":25}`}}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(req.ToolResults) != 1 || req.ToolResults[0].Call.Parameters["city"] != "Rome" {
+			t.Errorf("expected the result paired with the Rome call, got %+v", req.ToolResults)
+		}
+	})
+
+	t.Run("unknown tool call", func(t *testing.T) {
+		_, err := p.convertRequest(&llmx.ChatRequest{
+			Model: "command-r",
+			Messages: []llmx.Message{
+				{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}},
+				{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{
+					ToolCallID: "missing",
+					Result:     "done",
+				}}},
+			},
+		})
+		if err == nil {
+			t.Error("expected error for unknown tool call")
+		}
+	})
+
+	t.Run("request options", func(t *testing.T) {
+		chatReq := &llmx.ChatRequest{
+			Model:    "command-r",
+			Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}}},
+		}
+		chatReq.SetProviderOptions(RequestOptions{
+			Documents:       []Document{{"id": "doc1", "snippet": "Paris is in France"}},
+			Connectors:      []Connector{{ID: "web-search"}},
+			CitationQuality: "accurate",
+		})
+
+		req, err := p.convertRequest(chatReq)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(req.Documents) != 1 || req.Documents[0]["id"] != "doc1" {
+			t.Errorf("expected document doc1, got %v", req.Documents)
+		}
+		if len(req.Connectors) != 1 || req.Connectors[0].Id != "web-search" {
+			t.Errorf("expected web-search connector, got %v", req.Connectors)
+		}
+		if req.CitationQuality == nil || *req.CitationQuality != "accurate" {
+			t.Errorf("expected citation quality accurate, got %v", req.CitationQuality)
+		}
+	})
+}
+
+func TestCohereProvider_Chat(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat" {
+			t.Errorf("expected path /v1/chat, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Error("missing or incorrect Authorization header")
+		}
+		body := decodeBody(t, r)
+		if body["message"] != "What is the capital of France?" {
+			t.Errorf("unexpected message %v", body["message"])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"text": "Paris is the capital.",
+			"generation_id": "gen-1",
+			"finish_reason": "COMPLETE",
+			"citations": [{"start": 0, "end": 5, "text": "Paris", "document_ids": ["doc1"]}],
+			"meta": {"tokens": {"input_tokens": 10, "output_tokens": 5}}
+		}`)
+	})
+
+	resp, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model:    "command-r",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "What is the capital of France?"}}}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	chatResp := resp.(*llmx.ChatResponse)
+	if chatResp.Content != "Paris is the capital." {
+		t.Errorf("expected content 'Paris is the capital.', got %q", chatResp.Content)
+	}
+	if chatResp.FinishReason != "stop" {
+		t.Errorf("expected finish reason stop, got %s", chatResp.FinishReason)
+	}
+	if chatResp.Usage.TotalTokens != 15 {
+		t.Errorf("expected 15 total tokens, got %d", chatResp.Usage.TotalTokens)
+	}
+	if len(chatResp.Citations) != 1 || chatResp.Citations[0].DocumentIDs[0] != "doc1" {
+		t.Errorf("expected citation for doc1, got %+v", chatResp.Citations)
+	}
+}
+
+func TestCohereProvider_ChatToolCalls(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		body := decodeBody(t, r)
+		tools, _ := body["tools"].([]interface{})
+		if len(tools) != 1 {
+			t.Errorf("expected 1 tool, got %v", body["tools"])
+			return
+		}
+		defs, _ := tools[0].(map[string]interface{})["parameter_definitions"].(map[string]interface{})
+		if _, ok := defs["city"]; !ok {
+			t.Errorf("expected city parameter definition, got %v", defs)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"text": "",
+			"finish_reason": "COMPLETE",
+			"tool_calls": [{"name": "get_weather", "parameters": {"city": "Paris"}}]
+		}`)
+	})
+
+	resp, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model:    "command-r",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather in Paris?"}}}},
+		Tools: []llmx.Tool{{
+			Name:        "get_weather",
+			Description: "Get the weather",
+			Parameters: &llmx.Schema{
+				Type:       "object",
+				Properties: map[string]*llmx.Schema{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	chatResp := resp.(*llmx.ChatResponse)
+	if chatResp.FinishReason != "tool_calls" {
+		t.Errorf("expected finish reason tool_calls, got %s", chatResp.FinishReason)
+	}
+	if len(chatResp.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(chatResp.ToolCalls))
+	}
+	call := chatResp.ToolCalls[0]
+	if !strings.HasPrefix(call.ID, "get_weather_") || !strings.HasSuffix(call.ID, "_0") || call.Name != "get_weather" {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+	if string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("expected arguments {\"city\":\"Paris\"}, got %s", call.Arguments)
+	}
+}
+
+func TestCohereProvider_StreamChat(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		body := decodeBody(t, r)
+		if body["stream"] != true {
+			t.Errorf("expected stream=true, got %v", body["stream"])
+		}
+
+		w.Header().Set("Content-Type", "application/stream+json")
+		events := []string{
+			`{"event_type":"stream-start","generation_id":"gen-1"}`,
+			`{"event_type":"text-generation","text":"Paris "}`,
+			`{"event_type":"text-generation","text":"is the capital."}`,
+			`{"event_type":"citation-generation","citations":[{"start":0,"end":5,"text":"Paris","document_ids":["doc1"]}]}`,
+			`{"event_type":"tool-calls-generation","tool_calls":[{"name":"lookup","parameters":{"q":"Paris"}}]}`,
+			`{"event_type":"stream-end","finish_reason":"COMPLETE","response":{"text":"Paris is the capital."}}`,
+		}
+		for _, event := range events {
+			io.WriteString(w, event+"\n")
+		}
+	})
+
+	resp, err := p.StreamChat(context.Background(), &llmx.ChatRequest{
+		Model:    "command-r",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Capital of France?"}}}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	stream := resp.(*llmx.ChatStream)
+	var text string
+	var citations []llmx.Citation
+	var toolCalls []map[string]interface{}
+	var finishReason interface{}
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventTypeTextDelta:
+			text += event.Data.(string)
+		case core.EventTypeCitation:
+			citations = append(citations, event.Data.([]llmx.Citation)...)
+		case core.EventTypeToolCall:
+			toolCalls = append(toolCalls, event.Data.(map[string]interface{}))
+		case core.EventTypeFinish:
+			finishReason = event.Data
+		}
+	}
+
+	if text != "Paris is the capital." {
+		t.Errorf("expected text 'Paris is the capital.', got %q", text)
+	}
+	if len(citations) != 1 || citations[0].Text != "Paris" {
+		t.Errorf("expected one citation for 'Paris', got %+v", citations)
+	}
+	if len(toolCalls) != 1 || toolCalls[0]["name"] != "lookup" || toolCalls[0]["args"] != `{"q":"Paris"}` {
+		t.Errorf("unexpected tool calls %+v", toolCalls)
+	}
+	if finishReason != "tool_calls" {
+		t.Errorf("expected finish reason tool_calls, got %v", finishReason)
+	}
+}
+
+func TestConvertFinishReason(t *testing.T) {
+	tests := map[string]llmx.FinishReason{
+		"COMPLETE":      "stop",
+		"STOP_SEQUENCE": "stop",
+		"MAX_TOKENS":    "length",
+		"ERROR_TOXIC":   "content_filter",
+		"ERROR":         "error",
+	}
+	for in, want := range tests {
+		resp, err := fromWire(&cohere.ChatStreamEndEvent{FinishReason: cohere.ChatStreamEndEventFinishReason(in)})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got := resp.FinishReason; got != want {
+			t.Errorf("finish reason %q: expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestCohereProvider_ChatErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+	}{
+		{"rate limit", 429, `{"message":"You are using a Trial key, which is limited to 10 API calls / minute."}`, llmx.ErrRateLimit},
+		{"unauthorized", 401, `{"message":"invalid api token"}`, llmx.ErrAuthentication},
+		{"too long", 400, `{"message":"too many tokens: size limit exceeded by 1234 tokens. Try using shorter or fewer inputs."}`, llmx.ErrContextLengthExceeded},
+		{"model not found", 404, `{"message":"model 'command-x' not found, make sure the correct model ID was used and that you have access to the model."}`, llmx.ErrModelNotFound},
+		{"unavailable", 503, `{"message":"service unavailable"}`, llmx.ErrOverloaded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Request-Id", "co-req-1")
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			_, err := p.Chat(context.Background(), &llmx.ChatRequest{
+				Model:    "command-r",
+				Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "hi"}}}},
+			})
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var base *llmx.BaseError
+			if !errors.As(err, &base) || base.RequestID != "co-req-1" || len(base.RawBody) == 0 {
+				t.Errorf("expected request ID and body to be kept, got %+v", base)
+			}
+			var rateErr *llmx.RateLimitError
+			if errors.As(err, &rateErr) && rateErr.RetryAfter != time.Second {
+				t.Errorf("expected Retry-After 1s, got %v", rateErr.RetryAfter)
+			}
+		})
+	}
+}
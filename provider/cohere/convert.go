@@ -0,0 +1,102 @@
+package cohere
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// convertRequest converts llmx.ChatRequest to Cohere format. The conversion
+// is formats.ToCohereRequest's; the SDK types mirror the same JSON.
+func (p *CohereProvider) convertRequest(req *llmx.ChatRequest) (*cohere.ChatRequest, error) {
+	if req.ToolChoice != nil && req.ToolChoice.Mode != llmx.ToolChoiceAuto {
+		return nil, llmx.NewUnsupportedFeatureError("cohere", "tool_choice",
+			fmt.Sprintf("tool choice %q is not supported", req.ToolChoice.Mode))
+	}
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls {
+		return nil, llmx.NewUnsupportedFeatureError("cohere", "parallel_tool_calls",
+			"parallel tool calls cannot be disabled")
+	}
+
+	wire, err := formats.ToCohereRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	cohereReq := &cohere.ChatRequest{}
+	if err := transcode(wire, cohereReq); err != nil {
+		return nil, err
+	}
+
+	if opts, ok := llmx.ProviderOptionsFor[RequestOptions](req); ok {
+		applyOptions(cohereReq, opts)
+	}
+
+	return cohereReq, nil
+}
+
+// toStreamRequest converts a chat request to its streaming equivalent
+func toStreamRequest(req *cohere.ChatRequest) *cohere.ChatStreamRequest {
+	streamReq := &cohere.ChatStreamRequest{
+		Message:           req.Message,
+		Model:             req.Model,
+		Preamble:          req.Preamble,
+		ChatHistory:       req.ChatHistory,
+		Connectors:        req.Connectors,
+		SearchQueriesOnly: req.SearchQueriesOnly,
+		Documents:         req.Documents,
+		Temperature:       req.Temperature,
+		MaxTokens:         req.MaxTokens,
+		K:                 req.K,
+		P:                 req.P,
+		Seed:              req.Seed,
+		StopSequences:     req.StopSequences,
+		Tools:             req.Tools,
+		ToolResults:       req.ToolResults,
+	}
+	if req.CitationQuality != nil {
+		streamReq.CitationQuality = cohere.ChatStreamRequestCitationQuality(*req.CitationQuality).Ptr()
+	}
+	if req.PromptTruncation != nil {
+		streamReq.PromptTruncation = cohere.ChatStreamRequestPromptTruncation(*req.PromptTruncation).Ptr()
+	}
+	if req.SafetyMode != nil {
+		streamReq.SafetyMode = cohere.ChatStreamRequestSafetyMode(*req.SafetyMode).Ptr()
+	}
+	return streamReq
+}
+
+// convertResponse converts Cohere response to llmx format with
+// formats.FromCohereResponse
+func (p *CohereProvider) convertResponse(resp *cohere.NonStreamedChatResponse, model string) (*llmx.ChatResponse, error) {
+	llmxResp, err := fromWire(resp)
+	if err != nil {
+		return nil, err
+	}
+	llmxResp.Model = model // Cohere doesn't return the model in the response
+	llmxResp.CreatedAt = time.Now()
+	llmxResp.Raw = resp
+	return llmxResp, nil
+}
+
+// fromWire converts a response, or a stream event carrying some of its
+// fields, with formats.FromCohereResponse
+func fromWire(v interface{}) (*llmx.ChatResponse, error) {
+	var wire formats.CohereResponse
+	if err := transcode(v, &wire); err != nil {
+		return nil, err
+	}
+	return formats.FromCohereResponse(&wire)
+}
+
+// transcode copies from into to through their JSON encoding
+func transcode(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
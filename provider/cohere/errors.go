@@ -0,0 +1,80 @@
+package cohere
+
+import (
+	"encoding/json"
+	"errors"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	"github.com/cohere-ai/cohere-go/v2/core"
+	"github.com/llmx-ai/llmx"
+)
+
+// apiError returns the status, headers and decoded body of a Cohere SDK
+// error
+func apiError(err error) (*core.APIError, interface{}) {
+	var (
+		badRequest      *cohere.BadRequestError
+		unauthorized    *cohere.UnauthorizedError
+		forbidden       *cohere.ForbiddenError
+		notFound        *cohere.NotFoundError
+		unprocessable   *cohere.UnprocessableEntityError
+		tooManyRequests *cohere.TooManyRequestsError
+		invalidToken    *cohere.InvalidTokenError
+		clientClosed    *cohere.ClientClosedRequestError
+		internal        *cohere.InternalServerError
+		notImplemented  *cohere.NotImplementedError
+		unavailable     *cohere.ServiceUnavailableError
+		gatewayTimeout  *cohere.GatewayTimeoutError
+		generic         *core.APIError
+	)
+	switch {
+	case errors.As(err, &badRequest):
+		return badRequest.APIError, badRequest.Body
+	case errors.As(err, &unauthorized):
+		return unauthorized.APIError, unauthorized.Body
+	case errors.As(err, &forbidden):
+		return forbidden.APIError, forbidden.Body
+	case errors.As(err, &notFound):
+		return notFound.APIError, notFound.Body
+	case errors.As(err, &unprocessable):
+		return unprocessable.APIError, unprocessable.Body
+	case errors.As(err, &tooManyRequests):
+		return tooManyRequests.APIError, tooManyRequests.Body
+	case errors.As(err, &invalidToken):
+		return invalidToken.APIError, invalidToken.Body
+	case errors.As(err, &clientClosed):
+		return clientClosed.APIError, clientClosed.Body
+	case errors.As(err, &internal):
+		return internal.APIError, internal.Body
+	case errors.As(err, &notImplemented):
+		return notImplemented.APIError, notImplemented.Body
+	case errors.As(err, &unavailable):
+		return unavailable.APIError, unavailable.Body
+	case errors.As(err, &gatewayTimeout):
+		return gatewayTimeout.APIError, gatewayTimeout.Body
+	case errors.As(err, &generic):
+		return generic, nil
+	}
+	return nil, nil
+}
+
+// convertError converts Cohere errors to llmx errors
+func (p *CohereProvider) convertError(err error) error {
+	info := llmx.ErrorInfo{Provider: "cohere", Cause: err, Message: err.Error()}
+
+	apiErr, body := apiError(err)
+	if apiErr != nil {
+		info.StatusCode = apiErr.StatusCode
+		info.RequestID = llmx.RequestIDFromHeader(apiErr.Header)
+		info.RetryAfter = llmx.ParseRetryAfter(apiErr.Header)
+	}
+	if body != nil {
+		info.Body, _ = json.Marshal(body)
+		if fields, ok := body.(map[string]interface{}); ok {
+			if msg, ok := fields["message"].(string); ok {
+				info.Message = msg
+			}
+		}
+	}
+	return llmx.ClassifyError(info)
+}
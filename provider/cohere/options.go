@@ -0,0 +1,110 @@
+package cohere
+
+import (
+	"fmt"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+)
+
+// Document is a grounding document for retrieval-augmented generation.
+// Cohere expects string fields such as "id", "title" and "snippet"; the
+// "id" field is what citations refer back to.
+type Document map[string]string
+
+// Connector enables a Cohere RAG connector (e.g. "web-search") for a request
+type Connector struct {
+	ID                string                 `json:"id"`
+	UserAccessToken   string                 `json:"user_access_token,omitempty"`
+	ContinueOnFailure bool                   `json:"continue_on_failure,omitempty"`
+	Options           map[string]interface{} `json:"options,omitempty"`
+}
+
+// RequestOptions holds Cohere-specific request parameters. Attach them with
+// ChatRequest.SetProviderOptions.
+type RequestOptions struct {
+	// Documents ground the response; citations reference their IDs
+	Documents []Document `json:"documents,omitempty"`
+
+	// Connectors retrieve documents on Cohere's side
+	Connectors []Connector `json:"connectors,omitempty"`
+
+	// CitationQuality is "accurate" or "fast"
+	CitationQuality string `json:"citation_quality,omitempty"`
+
+	// PromptTruncation is "AUTO", "AUTO_PRESERVE_ORDER" or "OFF"
+	PromptTruncation string `json:"prompt_truncation,omitempty"`
+
+	// SafetyMode is "CONTEXTUAL", "STRICT" or "NONE"
+	SafetyMode string `json:"safety_mode,omitempty"`
+
+	// SearchQueriesOnly returns generated search queries without a reply
+	SearchQueriesOnly bool `json:"search_queries_only,omitempty"`
+}
+
+// ProviderName implements llmx.ProviderRequestOptions
+func (o RequestOptions) ProviderName() string {
+	return "cohere"
+}
+
+// Validate checks option values before a request is sent
+func (o RequestOptions) Validate() error {
+	switch o.CitationQuality {
+	case "", "accurate", "fast":
+	default:
+		return fmt.Errorf("unsupported citation quality %q", o.CitationQuality)
+	}
+
+	switch o.PromptTruncation {
+	case "", "AUTO", "AUTO_PRESERVE_ORDER", "OFF":
+	default:
+		return fmt.Errorf("unsupported prompt truncation %q", o.PromptTruncation)
+	}
+
+	switch o.SafetyMode {
+	case "", "CONTEXTUAL", "STRICT", "NONE":
+	default:
+		return fmt.Errorf("unsupported safety mode %q", o.SafetyMode)
+	}
+
+	for i, c := range o.Connectors {
+		if c.ID == "" {
+			return fmt.Errorf("connector %d: ID is required", i)
+		}
+	}
+
+	return nil
+}
+
+// applyOptions copies RequestOptions onto a Cohere chat request
+func applyOptions(cohereReq *cohere.ChatRequest, opts RequestOptions) {
+	for _, doc := range opts.Documents {
+		cohereReq.Documents = append(cohereReq.Documents, cohere.ChatDocument(doc))
+	}
+
+	for _, c := range opts.Connectors {
+		connector := &cohere.ChatConnector{
+			Id:      c.ID,
+			Options: c.Options,
+		}
+		if c.UserAccessToken != "" {
+			connector.UserAccessToken = cohere.String(c.UserAccessToken)
+		}
+		if c.ContinueOnFailure {
+			connector.ContinueOnFailure = cohere.Bool(true)
+		}
+		cohereReq.Connectors = append(cohereReq.Connectors, connector)
+	}
+
+	if opts.CitationQuality != "" {
+		cohereReq.CitationQuality = cohere.ChatRequestCitationQuality(opts.CitationQuality).Ptr()
+	}
+	if opts.PromptTruncation != "" {
+		cohereReq.PromptTruncation = cohere.ChatRequestPromptTruncation(opts.PromptTruncation).Ptr()
+	}
+	if opts.SafetyMode != "" {
+		cohereReq.SafetyMode = cohere.ChatRequestSafetyMode(opts.SafetyMode).Ptr()
+	}
+	if opts.SearchQueriesOnly {
+		cohereReq.SearchQueriesOnly = cohere.Bool(true)
+	}
+}
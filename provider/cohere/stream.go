@@ -0,0 +1,109 @@
+package cohere
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	coherecore "github.com/cohere-ai/cohere-go/v2/core"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+)
+
+// handleStream processes Cohere stream events and sends them to the chat stream
+func (p *CohereProvider) handleStream(
+	ctx context.Context,
+	stream *coherecore.Stream[cohere.StreamedChatResponse],
+	chatStream *llmx.ChatStream,
+) {
+	defer stream.Close()
+	defer chatStream.Close()
+
+	chatStream.SendEvent(core.StreamEvent{
+		Type: core.EventTypeStart,
+	})
+
+	toolCalls := 0
+	for {
+		if ctx.Err() != nil {
+			chatStream.SendError(ctx.Err())
+			return
+		}
+
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			chatStream.SendError(p.convertError(err))
+			return
+		}
+
+		switch event.EventType {
+		case "text-generation":
+			if event.TextGeneration != nil && event.TextGeneration.Text != "" {
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeTextDelta,
+					Data: event.TextGeneration.Text,
+				})
+			}
+
+		case "citation-generation":
+			if event.CitationGeneration == nil {
+				continue
+			}
+			resp, err := fromWire(event.CitationGeneration)
+			if err != nil {
+				chatStream.SendError(err)
+				return
+			}
+			if len(resp.Citations) > 0 {
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeCitation,
+					Data: resp.Citations,
+				})
+			}
+
+		case "tool-calls-generation":
+			if event.ToolCallsGeneration == nil {
+				continue
+			}
+			resp, err := fromWire(event.ToolCallsGeneration)
+			if err != nil {
+				chatStream.SendError(err)
+				return
+			}
+			for _, call := range resp.ToolCalls {
+				toolCalls++
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeToolCall,
+					Data: map[string]interface{}{
+						"id":   call.ID,
+						"name": call.Name,
+						"args": string(call.Arguments),
+					},
+				})
+			}
+
+		case "stream-end":
+			finishReason := llmx.FinishReasonStop
+			if event.StreamEnd != nil {
+				resp, err := fromWire(event.StreamEnd)
+				if err != nil {
+					chatStream.SendError(err)
+					return
+				}
+				finishReason = resp.FinishReason
+			}
+			if finishReason == llmx.FinishReasonStop && toolCalls > 0 {
+				finishReason = llmx.FinishReasonToolCalls
+			}
+			chatStream.SendEvent(core.StreamEvent{
+				Type: core.EventTypeFinish,
+				Data: string(finishReason),
+			})
+			return
+		}
+	}
+}
@@ -21,8 +21,18 @@ type DeepSeekProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("deepseek", NewDeepSeekProvider)
+	provider.RegisterOptions("deepseek", options)
 }
 
 // NewDeepSeekProvider creates a new DeepSeek provider
@@ -44,8 +54,9 @@ func NewDeepSeekProvider(opts map[string]interface{}) (provider.Provider, error)
 
 	// Create OpenAI provider with DeepSeek configuration
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -92,6 +103,7 @@ func (p *DeepSeekProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
 		Streaming:   true,
 		ToolCalling: true,
+		ToolChoice:  true,
 		Vision:      false, // DeepSeek doesn't support vision currently
 		JSONMode:    true,
 		// SystemPrompt not needed
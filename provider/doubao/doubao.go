@@ -5,18 +5,14 @@ package doubao
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"sort"
-	"strings"
 	"time"
 
 	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/auth"
 	"github.com/llmx-ai/llmx/core"
 	"github.com/llmx-ai/llmx/provider"
 )
@@ -24,21 +20,29 @@ import (
 const (
 	// API base URL
 	baseURL = "https://open.volcengineapi.com"
-	// Service name
+	// Service name and region of the request signature
 	serviceName = "ml_maas"
+	region      = "cn-north-1"
 	// API version
 	apiVersion = "2024-01-01"
 )
 
 // DoubaoProvider implements the Provider interface for ByteDance Doubao
 type DoubaoProvider struct {
-	apiKey     string
-	secretKey  string
+	// httpClient signs every request with the key pair
 	httpClient *http.Client
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required: []string{"api_key", "secret_key"},
+	// Requests are signed with the key pair
+	Credential: "api_key",
+}
+
 func init() {
 	provider.Register("doubao", NewDoubaoProvider)
+	provider.RegisterOptions("doubao", options)
 }
 
 // NewDoubaoProvider creates a new Doubao provider
@@ -53,10 +57,13 @@ func NewDoubaoProvider(opts map[string]interface{}) (provider.Provider, error) {
 		return nil, fmt.Errorf("doubao: secret_key is required")
 	}
 
+	httpClient := provider.HTTPClient(opts)
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
 	return &DoubaoProvider{
-		apiKey:     apiKey,
-		secretKey:  secretKey,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: auth.NewClient(httpClient, auth.Volcengine(apiKey, secretKey, region, serviceName)),
 	}, nil
 }
 
@@ -129,17 +136,12 @@ func (p *DoubaoProvider) Chat(ctx context.Context, req interface{}) (interface{}
 		return nil, fmt.Errorf("doubao: failed to create request: %w", err)
 	}
 
-	// Sign request
-	if err := p.signRequest(httpReq, requestBody); err != nil {
-		return nil, fmt.Errorf("doubao: failed to sign request: %w", err)
-	}
-
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	// Send request
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("doubao: request failed: %w", err)
+		return nil, llmx.ClassifyError(llmx.ErrorInfo{Provider: "doubao", Message: "request failed", Cause: err})
 	}
 	defer resp.Body.Close()
 
@@ -151,7 +153,7 @@ func (p *DoubaoProvider) Chat(ctx context.Context, req interface{}) (interface{}
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		return nil, p.convertError(body, resp.StatusCode)
+		return nil, p.convertError(resp.Header, body, resp.StatusCode)
 	}
 
 	// Parse response
@@ -166,7 +168,7 @@ func (p *DoubaoProvider) Chat(ctx context.Context, req interface{}) (interface{}
 	}
 
 	// Convert to llmx response
-	return p.convertResponse(doubaoResp, chatReq.Model), nil
+	return p.convertResponse(doubaoResp, chatReq.Model, resp.Header), nil
 }
 
 // StreamChat sends a streaming chat request to Doubao
@@ -193,21 +195,25 @@ func (p *DoubaoProvider) StreamChat(ctx context.Context, req interface{}) (inter
 		return nil, fmt.Errorf("doubao: failed to create request: %w", err)
 	}
 
-	// Sign request
-	if err := p.signRequest(httpReq, requestBody); err != nil {
-		return nil, fmt.Errorf("doubao: failed to sign request: %w", err)
-	}
-
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	// Send request
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("doubao: request failed: %w", err)
+		return nil, llmx.ClassifyError(llmx.ErrorInfo{Provider: "doubao", Message: "request failed", Cause: err})
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, p.convertError(resp.Header, body, resp.StatusCode)
 	}
 
 	// Create llmx stream
 	chatStream := llmx.NewChatStream(ctx)
+	chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+		*m = llmx.MetadataFromHeader(resp.Header)
+	})
 
 	// Start goroutine to handle streaming
 	go p.handleStream(ctx, resp, chatStream)
@@ -215,104 +221,6 @@ func (p *DoubaoProvider) StreamChat(ctx context.Context, req interface{}) (inter
 	return chatStream, nil
 }
 
-// signRequest signs the HTTP request using Volcano Engine signature algorithm
-func (p *DoubaoProvider) signRequest(req *http.Request, body []byte) error {
-	// This is a simplified version of Volcano Engine's signature
-	// In production, you should use the official SDK or implement the full algorithm
-
-	timestamp := time.Now().UTC().Format("20060102T150405Z")
-	date := timestamp[:8]
-
-	// Set required headers
-	req.Header.Set("X-Date", timestamp)
-	req.Header.Set("X-Content-Sha256", hashSHA256(body))
-
-	// Build canonical request
-	canonicalHeaders := p.buildCanonicalHeaders(req)
-	signedHeaders := p.getSignedHeaders(req)
-
-	canonicalRequest := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
-		req.Method,
-		req.URL.Path,
-		req.URL.RawQuery,
-		canonicalHeaders,
-		signedHeaders,
-		hashSHA256(body),
-	)
-
-	// Build string to sign
-	credentialScope := fmt.Sprintf("%s/%s/%s/request", date, "cn-north-1", serviceName)
-	stringToSign := fmt.Sprintf("HMAC-SHA256\n%s\n%s\n%s",
-		timestamp,
-		credentialScope,
-		hashSHA256([]byte(canonicalRequest)),
-	)
-
-	// Calculate signature
-	signature := p.calculateSignature(stringToSign, date)
-
-	// Build authorization header
-	authorization := fmt.Sprintf("HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
-		p.apiKey,
-		credentialScope,
-		signedHeaders,
-		signature,
-	)
-
-	req.Header.Set("Authorization", authorization)
-
-	return nil
-}
-
-// Helper functions for signing
-
-func (p *DoubaoProvider) buildCanonicalHeaders(req *http.Request) string {
-	var headers []string
-	for k, v := range req.Header {
-		if shouldSignHeader(k) {
-			headers = append(headers, fmt.Sprintf("%s:%s", strings.ToLower(k), strings.TrimSpace(v[0])))
-		}
-	}
-	sort.Strings(headers)
-	return strings.Join(headers, "\n") + "\n"
-}
-
-func (p *DoubaoProvider) getSignedHeaders(req *http.Request) string {
-	var headers []string
-	for k := range req.Header {
-		if shouldSignHeader(k) {
-			headers = append(headers, strings.ToLower(k))
-		}
-	}
-	sort.Strings(headers)
-	return strings.Join(headers, ";")
-}
-
-func (p *DoubaoProvider) calculateSignature(stringToSign, date string) string {
-	kDate := hmacSHA256([]byte(p.secretKey), []byte(date))
-	kRegion := hmacSHA256(kDate, []byte("cn-north-1"))
-	kService := hmacSHA256(kRegion, []byte(serviceName))
-	kSigning := hmacSHA256(kService, []byte("request"))
-	signature := hmacSHA256(kSigning, []byte(stringToSign))
-	return hex.EncodeToString(signature)
-}
-
-func shouldSignHeader(header string) bool {
-	lower := strings.ToLower(header)
-	return lower == "content-type" || lower == "host" || strings.HasPrefix(lower, "x-")
-}
-
-func hashSHA256(data []byte) string {
-	hash := sha256.Sum256(data)
-	return hex.EncodeToString(hash[:])
-}
-
-func hmacSHA256(key, data []byte) []byte {
-	h := hmac.New(sha256.New, key)
-	h.Write(data)
-	return h.Sum(nil)
-}
-
 // convertRequest converts llmx.ChatRequest to Doubao format
 func (p *DoubaoProvider) convertRequest(req *llmx.ChatRequest) map[string]interface{} {
 	doubaoReq := map[string]interface{}{
@@ -355,10 +263,13 @@ func (p *DoubaoProvider) convertRequest(req *llmx.ChatRequest) map[string]interf
 }
 
 // convertResponse converts Doubao response to llmx format
-func (p *DoubaoProvider) convertResponse(resp map[string]interface{}, model string) *llmx.ChatResponse {
+func (p *DoubaoProvider) convertResponse(resp map[string]interface{}, model string, header http.Header) *llmx.ChatResponse {
 	llmxResp := &llmx.ChatResponse{
-		Model:        model,
-		FinishReason: "stop",
+		Model:    model,
+		Metadata: llmx.MetadataFromHeader(header),
+	}
+	if id, ok := resp["id"].(string); ok {
+		llmxResp.ID = id
 	}
 
 	// Extract choices
@@ -369,7 +280,10 @@ func (p *DoubaoProvider) convertResponse(resp map[string]interface{}, model stri
 					llmxResp.Content = text
 				}
 			}
-			llmxResp.FinishReason = getFinishReason(choice)
+			if reason, ok := choice["finish_reason"].(string); ok {
+				llmxResp.RawFinishReason = reason
+				llmxResp.FinishReason = llmx.NormalizeFinishReason(reason)
+			}
 		}
 	}
 
@@ -485,62 +399,3 @@ func convertTools(tools []llmx.Tool) []map[string]interface{} {
 	}
 	return doubaoTools
 }
-
-func getFinishReason(choice map[string]interface{}) string {
-	if reason, ok := choice["finish_reason"].(string); ok {
-		return reason
-	}
-	return "stop"
-}
-
-func (p *DoubaoProvider) convertError(body []byte, statusCode int) error {
-	var errResp map[string]interface{}
-	json.Unmarshal(body, &errResp)
-
-	errMsg := fmt.Sprintf("doubao: HTTP %d", statusCode)
-	if msg, ok := errResp["error_msg"].(string); ok {
-		errMsg = msg
-	} else if msg, ok := errResp["message"].(string); ok {
-		errMsg = msg
-	}
-
-	if statusCode == 429 {
-		return llmx.NewRateLimitError(errMsg, 0)
-	} else if statusCode == 401 || statusCode == 403 {
-		return llmx.NewAuthenticationError(errMsg)
-	} else if statusCode == 404 {
-		return llmx.NewNotFoundError(errMsg, "model")
-	} else if statusCode >= 400 && statusCode < 500 {
-		return llmx.NewInvalidRequestError(errMsg, nil)
-	}
-
-	return llmx.NewInternalError(errMsg, nil)
-}
-
-func (p *DoubaoProvider) convertAPIError(resp map[string]interface{}) error {
-	errCode := ""
-	if code, ok := resp["error_code"].(string); ok {
-		errCode = code
-	}
-
-	errMsg := ""
-	if msg, ok := resp["error_msg"].(string); ok {
-		errMsg = msg
-	} else if msg, ok := resp["message"].(string); ok {
-		errMsg = msg
-	}
-
-	fullMsg := fmt.Sprintf("doubao API error %s: %s", errCode, errMsg)
-
-	// Map error codes to llmx error types
-	switch errCode {
-	case "RATE_LIMIT_EXCEEDED":
-		return llmx.NewRateLimitError(fullMsg, 0)
-	case "AUTHENTICATION_FAILED", "PERMISSION_DENIED":
-		return llmx.NewAuthenticationError(fullMsg)
-	case "INVALID_REQUEST", "INVALID_PARAMETER":
-		return llmx.NewInvalidRequestError(fullMsg, nil)
-	default:
-		return llmx.NewInternalError(fullMsg, nil)
-	}
-}
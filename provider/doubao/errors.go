@@ -0,0 +1,84 @@
+package doubao
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// errorStatus maps Doubao error codes to the matching HTTP status
+var errorStatus = map[string]int{
+	"RATE_LIMIT_EXCEEDED":     http.StatusTooManyRequests,
+	"QUOTA_EXCEEDED":          http.StatusTooManyRequests,
+	"AUTHENTICATION_FAILED":   http.StatusUnauthorized,
+	"PERMISSION_DENIED":       http.StatusForbidden,
+	"INVALID_REQUEST":         http.StatusBadRequest,
+	"INVALID_PARAMETER":       http.StatusBadRequest,
+	"CONTEXT_LENGTH_EXCEEDED": http.StatusBadRequest,
+	"SENSITIVE_CONTENT":       http.StatusBadRequest,
+	"MODEL_NOT_FOUND":         http.StatusNotFound,
+	"SERVICE_OVERLOADED":      http.StatusServiceUnavailable,
+	"INTERNAL_ERROR":          http.StatusInternalServerError,
+}
+
+// errorCodes name the Doubao error codes that ClassifyError cannot tell
+// from their status alone
+var errorCodes = map[string]string{
+	"QUOTA_EXCEEDED":    "insufficient_quota",
+	"SENSITIVE_CONTENT": "content_filter",
+}
+
+// convertError converts a failed HTTP response to an llmx error
+func (p *DoubaoProvider) convertError(header http.Header, body []byte, statusCode int) error {
+	info := llmx.ErrorInfo{
+		Provider:   "doubao",
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf("HTTP %d", statusCode),
+		RequestID:  llmx.RequestIDFromHeader(header),
+		RetryAfter: llmx.ParseRetryAfter(header),
+		Body:       body,
+	}
+	var errResp map[string]interface{}
+	if json.Unmarshal(body, &errResp) == nil {
+		apiErrorInfo(errResp, &info)
+	}
+	return llmx.ClassifyError(info)
+}
+
+// convertAPIError converts an error reported in a response body, with an
+// HTTP 200 status, to an llmx error
+func (p *DoubaoProvider) convertAPIError(resp map[string]interface{}) error {
+	info := llmx.ErrorInfo{Provider: "doubao"}
+	info.Body, _ = json.Marshal(resp)
+	apiErrorInfo(resp, &info)
+	return llmx.ClassifyError(info)
+}
+
+// apiErrorInfo fills info from a Doubao error body
+func apiErrorInfo(resp map[string]interface{}, info *llmx.ErrorInfo) {
+	errCode, _ := resp["error_code"].(string)
+	errMsg, ok := resp["error_msg"].(string)
+	if !ok {
+		errMsg, _ = resp["message"].(string)
+	}
+	if errCode == "" && errMsg == "" {
+		return
+	}
+
+	info.Message = strings.TrimPrefix(fmt.Sprintf("API error %s: %s", errCode, errMsg), "API error : ")
+	info.Code = errCode
+	if code, ok := errorCodes[errCode]; ok {
+		info.Code = code
+	}
+	if status, ok := errorStatus[errCode]; ok {
+		info.StatusCode = status
+	} else if info.StatusCode < 400 {
+		info.StatusCode = 0
+	}
+	if id, ok := resp["request_id"].(string); ok && info.RequestID == "" {
+		info.RequestID = id
+	}
+}
@@ -0,0 +1,51 @@
+package doubao
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+)
+
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+	}{
+		{"rate limit", 429, `{"error_code":"RATE_LIMIT_EXCEEDED","error_msg":"Too many requests","request_id":"tt-req-1"}`, llmx.ErrRateLimit},
+		{"quota", 429, `{"error_code":"QUOTA_EXCEEDED","error_msg":"Your account quota has been used up","request_id":"tt-req-1"}`, llmx.ErrQuotaExceeded},
+		{"context length", 400, `{"error_code":"CONTEXT_LENGTH_EXCEEDED","error_msg":"Total tokens exceed the model limit","request_id":"tt-req-1"}`, llmx.ErrContextLengthExceeded},
+		{"sensitive", 400, `{"error_code":"SENSITIVE_CONTENT","error_msg":"The request contains sensitive content","request_id":"tt-req-1"}`, llmx.ErrContentFiltered},
+		{"model not found", 404, `{"error_code":"MODEL_NOT_FOUND","error_msg":"Model doubao-pro-9k does not exist","request_id":"tt-req-1"}`, llmx.ErrModelNotFound},
+		{"auth", 401, `{"error_code":"AUTHENTICATION_FAILED","error_msg":"Invalid API key","request_id":"tt-req-1"}`, llmx.ErrAuthentication},
+		{"overloaded", 503, `{"error_code":"SERVICE_OVERLOADED","error_msg":"Service is busy","request_id":"tt-req-1"}`, llmx.ErrOverloaded},
+	}
+
+	p := &DoubaoProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.convertError(nil, []byte(tt.body), tt.status)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var base *llmx.BaseError
+			if !errors.As(err, &base) || base.RequestID != "tt-req-1" || string(base.RawBody) != tt.body {
+				t.Errorf("expected request ID and body to be kept, got %+v", base)
+			}
+		})
+	}
+}
+
+func TestConvertAPIError(t *testing.T) {
+	var resp map[string]interface{}
+	json.Unmarshal([]byte(`{"error_code":"RATE_LIMIT_EXCEEDED","error_msg":"Too many requests"}`), &resp)
+
+	err := (&DoubaoProvider{}).convertAPIError(resp)
+	var rateErr *llmx.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Errorf("expected RateLimitError for an error in a 200 response, got %T: %v", err, err)
+	}
+}
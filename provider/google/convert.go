@@ -0,0 +1,257 @@
+package google
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+)
+
+// The conversions here are formats.ToGeminiRequest's and
+// formats.FromGeminiResponse's. The genai types have no JSON encoding, so
+// the wire types are mapped onto them field by field.
+
+// convertRequest configures model for a request and returns the chat
+// history and the parts of the message to send
+func (p *GoogleProvider) convertRequest(model *genai.GenerativeModel, req *llmx.ChatRequest) ([]*genai.Content, []genai.Part, error) {
+	wire, err := formats.ToGeminiRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Chat sessions always ask for a single candidate and genai has no
+	// seed, so CandidateCount and Seed are not carried over
+	if config := wire.GenerationConfig; config != nil {
+		if config.Temperature != nil {
+			temp := float32(*config.Temperature)
+			model.Temperature = &temp
+		}
+		if config.MaxOutputTokens != nil {
+			maxTokens := int32(*config.MaxOutputTokens)
+			model.MaxOutputTokens = &maxTokens
+		}
+		if config.TopP != nil {
+			topP := float32(*config.TopP)
+			model.TopP = &topP
+		}
+		if config.TopK != nil {
+			topK := int32(*config.TopK)
+			model.TopK = &topK
+		}
+		model.StopSequences = config.StopSequences
+	}
+
+	if wire.SystemInstruction != nil {
+		system, err := toContent(*wire.SystemInstruction)
+		if err != nil {
+			return nil, nil, err
+		}
+		model.SystemInstruction = system
+	}
+
+	for _, tool := range wire.Tools {
+		declarations := make([]*genai.FunctionDeclaration, 0, len(tool.FunctionDeclarations))
+		for _, declaration := range tool.FunctionDeclarations {
+			params, err := toSchema(declaration.Parameters)
+			if err != nil {
+				return nil, nil, llmx.NewInvalidRequestError(
+					fmt.Sprintf("google: function %s: invalid parameters: %v", declaration.Name, err), nil)
+			}
+			declarations = append(declarations, &genai.FunctionDeclaration{
+				Name:        declaration.Name,
+				Description: declaration.Description,
+				Parameters:  params,
+			})
+		}
+		model.Tools = append(model.Tools, &genai.Tool{FunctionDeclarations: declarations})
+	}
+
+	if wire.ToolConfig != nil && wire.ToolConfig.FunctionCallingConfig != nil {
+		config := wire.ToolConfig.FunctionCallingConfig
+		model.ToolConfig = &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode:                 functionCallingModes[config.Mode],
+			AllowedFunctionNames: config.AllowedFunctionNames,
+		}}
+	}
+
+	var history []*genai.Content
+	for _, wireContent := range wire.Contents {
+		content, err := toContent(wireContent)
+		if err != nil {
+			return nil, nil, err
+		}
+		history = append(history, content)
+	}
+
+	// A last user turn is the message to send
+	if n := len(history); n > 0 && history[n-1].Role == "user" {
+		return history[:n-1], history[n-1].Parts, nil
+	}
+	return history, nil, nil
+}
+
+// functionCallingModes maps the API names of function calling modes
+var functionCallingModes = map[string]genai.FunctionCallingMode{
+	"AUTO": genai.FunctionCallingAuto,
+	"ANY":  genai.FunctionCallingAny,
+	"NONE": genai.FunctionCallingNone,
+}
+
+// toContent maps a wire content to a genai content
+func toContent(content formats.GeminiContent) (*genai.Content, error) {
+	out := &genai.Content{Role: content.Role}
+	for _, part := range content.Parts {
+		switch {
+		case part.InlineData != nil:
+			data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+			if err != nil {
+				return nil, llmx.NewInvalidRequestError("google: invalid inline data: "+err.Error(), nil)
+			}
+			out.Parts = append(out.Parts, genai.Blob{MIMEType: part.InlineData.MimeType, Data: data})
+		case part.FileData != nil:
+			out.Parts = append(out.Parts, genai.FileData{MIMEType: part.FileData.MimeType, FileURI: part.FileData.FileURI})
+		case part.FunctionCall != nil:
+			args := make(map[string]any)
+			if len(part.FunctionCall.Args) > 0 {
+				if err := json.Unmarshal(part.FunctionCall.Args, &args); err != nil {
+					return nil, llmx.NewInvalidRequestError(
+						fmt.Sprintf("google: invalid arguments for function call %q: %v", part.FunctionCall.Name, err), nil)
+				}
+			}
+			out.Parts = append(out.Parts, genai.FunctionCall{Name: part.FunctionCall.Name, Args: args})
+		case part.FunctionResponse != nil:
+			var response map[string]any
+			if err := json.Unmarshal(part.FunctionResponse.Response, &response); err != nil {
+				return nil, llmx.NewInvalidRequestError(
+					fmt.Sprintf("google: invalid response for function %q: %v", part.FunctionResponse.Name, err), nil)
+			}
+			out.Parts = append(out.Parts, genai.FunctionResponse{Name: part.FunctionResponse.Name, Response: response})
+		default:
+			out.Parts = append(out.Parts, genai.Text(part.Text))
+		}
+	}
+	return out, nil
+}
+
+// toSchema maps encoded parameters, which use Gemini's upper-case type
+// names, to a genai schema
+func toSchema(raw json.RawMessage) (*genai.Schema, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var schema llmx.Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	return convertSchema(&schema), nil
+}
+
+// convertSchema converts a schema with upper-case type names
+func convertSchema(schema *llmx.Schema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	// Gemini requires a type; values of any type are described as strings,
+	// while llmx validates the arguments against the untyped schema
+	schemaType, ok := schemaTypes[strings.ToUpper(schema.Type)]
+	if !ok {
+		schemaType = genai.TypeString
+	}
+
+	out := &genai.Schema{
+		Type:        schemaType,
+		Description: schema.Description,
+		Required:    schema.Required,
+		Items:       convertSchema(schema.Items),
+	}
+	for _, v := range schema.Enum {
+		out.Enum = append(out.Enum, fmt.Sprint(v))
+	}
+	if len(schema.Properties) > 0 {
+		out.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			out.Properties[name] = convertSchema(prop)
+		}
+	}
+	return out
+}
+
+// schemaTypes maps the API names of schema types
+var schemaTypes = map[string]genai.Type{
+	"STRING":  genai.TypeString,
+	"NUMBER":  genai.TypeNumber,
+	"INTEGER": genai.TypeInteger,
+	"BOOLEAN": genai.TypeBoolean,
+	"ARRAY":   genai.TypeArray,
+	"OBJECT":  genai.TypeObject,
+}
+
+// convertResponse converts Gemini response to llmx response with
+// formats.FromGeminiResponse
+func (p *GoogleProvider) convertResponse(resp *genai.GenerateContentResponse, model string) (*llmx.ChatResponse, error) {
+	response, err := formats.FromGeminiResponse(toWireResponse(resp))
+	if err != nil {
+		return nil, err
+	}
+	response.Model = model
+	response.CreatedAt = time.Now()
+	response.Raw = resp
+	return response, nil
+}
+
+// toWireResponse maps a genai response, or stream chunk, to the wire type
+func toWireResponse(resp *genai.GenerateContentResponse) *formats.GeminiResponse {
+	wire := &formats.GeminiResponse{}
+	for _, candidate := range resp.Candidates {
+		wireCandidate := formats.GeminiCandidate{
+			Content:      formats.GeminiContent{Role: "model"},
+			FinishReason: finishReasonNames[candidate.FinishReason],
+			Index:        int(candidate.Index),
+		}
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				switch v := part.(type) {
+				case genai.Text:
+					wireCandidate.Content.Parts = append(wireCandidate.Content.Parts, formats.GeminiPart{Text: string(v)})
+				case genai.FunctionCall:
+					args, err := json.Marshal(v.Args)
+					if err != nil || v.Args == nil {
+						args = []byte("{}")
+					}
+					wireCandidate.Content.Parts = append(wireCandidate.Content.Parts, formats.GeminiPart{
+						FunctionCall: &formats.GeminiFunctionCall{Name: v.Name, Args: args},
+					})
+				}
+			}
+		}
+		wire.Candidates = append(wire.Candidates, wireCandidate)
+	}
+
+	if usage := resp.UsageMetadata; usage != nil {
+		wire.UsageMetadata = &formats.GeminiUsage{
+			PromptTokenCount:     int(usage.PromptTokenCount),
+			CandidatesTokenCount: int(usage.CandidatesTokenCount),
+			TotalTokenCount:      int(usage.TotalTokenCount),
+		}
+	}
+	return wire
+}
+
+// finishReasonNames are the API names of Gemini finish reasons
+var finishReasonNames = map[genai.FinishReason]string{
+	genai.FinishReasonStop:                  "STOP",
+	genai.FinishReasonMaxTokens:             "MAX_TOKENS",
+	genai.FinishReasonSafety:                "SAFETY",
+	genai.FinishReasonRecitation:            "RECITATION",
+	genai.FinishReasonOther:                 "OTHER",
+	genai.FinishReasonBlocklist:             "BLOCKLIST",
+	genai.FinishReasonProhibitedContent:     "PROHIBITED_CONTENT",
+	genai.FinishReasonSpii:                  "SPII",
+	genai.FinishReasonMalformedFunctionCall: "MALFORMED_FUNCTION_CALL",
+}
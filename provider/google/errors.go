@@ -0,0 +1,68 @@
+package google
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"cloud.google.com/go/vertexai/genai"
+	"github.com/llmx-ai/llmx"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcStatus maps gRPC codes to the HTTP status the REST API would return
+var grpcStatus = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unknown:            http.StatusInternalServerError,
+}
+
+// convertError converts Google errors to llmx errors. The REST transport
+// fails with *googleapi.Error, the gRPC one with a status error, and
+// blocked prompts or responses with *genai.BlockedError.
+func (p *GoogleProvider) convertError(err error) error {
+	info := llmx.ErrorInfo{Provider: "google", Cause: err, Message: err.Error()}
+
+	var blocked *genai.BlockedError
+	var apiErr *googleapi.Error
+	switch {
+	case errors.As(err, &blocked):
+		info.StatusCode = http.StatusBadRequest
+		info.Code = "content_filter"
+	case errors.As(err, &apiErr):
+		info.StatusCode = apiErr.Code
+		info.Message = apiErr.Message
+		info.Body = []byte(apiErr.Body)
+		info.RequestID = llmx.RequestIDFromHeader(apiErr.Header)
+		info.RetryAfter = llmx.ParseRetryAfter(apiErr.Header)
+
+		var body struct {
+			Error struct {
+				Status string `json:"status"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(info.Body, &body) == nil {
+			info.Code = body.Error.Status
+		}
+		for _, item := range apiErr.Errors {
+			info.Code += " " + item.Reason
+		}
+	default:
+		if st, ok := status.FromError(err); ok && st.Code() != codes.OK {
+			info.StatusCode = grpcStatus[st.Code()]
+			info.Code = st.Code().String()
+			info.Message = st.Message()
+		}
+	}
+	return llmx.ClassifyError(info)
+}
@@ -0,0 +1,68 @@
+package google
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		requestID string
+	}{
+		{"rest quota", &googleapi.Error{
+			Code:    429,
+			Message: "Quota exceeded for aiplatform.googleapis.com/generate_content_requests_per_minute_per_project_per_base_model with base model: gemini-1.5-pro.",
+			Body:    `{"error":{"code":429,"message":"Quota exceeded for aiplatform.googleapis.com/generate_content_requests_per_minute_per_project_per_base_model with base model: gemini-1.5-pro.","status":"RESOURCE_EXHAUSTED"}}`,
+			Header:  http.Header{"X-Goog-Request-Id": {"goog-req-1"}},
+		}, llmx.ErrRateLimit, "goog-req-1"},
+		{"rest context length", &googleapi.Error{
+			Code:    400,
+			Message: "The input token count (1200000) exceeds the maximum number of tokens allowed (1048576).",
+			Body:    `{"error":{"code":400,"message":"The input token count (1200000) exceeds the maximum number of tokens allowed (1048576).","status":"INVALID_ARGUMENT"}}`,
+		}, llmx.ErrContextLengthExceeded, ""},
+		{"rest model not found", &googleapi.Error{
+			Code:    404,
+			Message: "Publisher Model `projects/p/locations/us-central1/publishers/google/models/gemini-9` not found.",
+			Body:    `{"error":{"code":404,"message":"Publisher Model ` + "`projects/p/locations/us-central1/publishers/google/models/gemini-9`" + ` not found.","status":"NOT_FOUND"}}`,
+		}, llmx.ErrModelNotFound, ""},
+		{"rest permission denied", &googleapi.Error{
+			Code:    403,
+			Message: "Permission 'aiplatform.endpoints.predict' denied on resource.",
+			Body:    `{"error":{"code":403,"message":"Permission 'aiplatform.endpoints.predict' denied on resource.","status":"PERMISSION_DENIED"}}`,
+		}, llmx.ErrAuthentication, ""},
+		{"rest unavailable", &googleapi.Error{
+			Code:    503,
+			Message: "The model is overloaded. Please try again later.",
+			Body:    `{"error":{"code":503,"message":"The model is overloaded. Please try again later.","status":"UNAVAILABLE"}}`,
+		}, llmx.ErrOverloaded, ""},
+		{"grpc resource exhausted", status.Error(codes.ResourceExhausted, "Resource exhausted. Please try again later."), llmx.ErrRateLimit, ""},
+		{"grpc deadline", status.Error(codes.DeadlineExceeded, "Deadline Exceeded"), llmx.ErrTimeout, ""},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "Request contains an invalid argument."), llmx.ErrInvalidRequest, ""},
+	}
+
+	p := &GoogleProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.convertError(tt.err)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var base *llmx.BaseError
+			if !errors.As(err, &base) || base.RequestID != tt.requestID {
+				t.Errorf("expected request ID %q, got %+v", tt.requestID, base)
+			}
+			if apiErr, ok := tt.err.(*googleapi.Error); ok && string(base.RawBody) != apiErr.Body {
+				t.Errorf("expected the raw body to be kept, got %q", base.RawBody)
+			}
+		})
+	}
+}
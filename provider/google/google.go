@@ -3,12 +3,13 @@ package google
 import (
 	"context"
 	"fmt"
-	"time"
+	"net/http"
 
 	"cloud.google.com/go/vertexai/genai"
 	"github.com/llmx-ai/llmx"
 	"github.com/llmx-ai/llmx/provider"
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 // GoogleProvider implements the Provider interface for Google Gemini
@@ -18,9 +19,24 @@ type GoogleProvider struct {
 	location  string
 }
 
+// options declares the options of the provider, see provider.OptionSpec.
+// Without an api_key, the default credentials of project_id are used.
+var options = provider.OptionSpec{
+	Optional:   []string{"api_key", "project_id", "location"},
+	Credential: "api_key",
+	Check: func(opts map[string]interface{}) error {
+		if !provider.HasOption(opts, "api_key") && !provider.HasOption(opts, "project_id") {
+			return &provider.OptionError{Key: "api_key", Message: "or project_id is required"}
+		}
+		return nil
+	},
+}
+
 func init() {
 	provider.Register("google", NewGoogleProvider)
+	provider.RegisterOptions("google", options)
 	provider.Register("gemini", NewGoogleProvider) // Alias
+	provider.RegisterOptions("gemini", options)
 }
 
 // NewGoogleProvider creates a new Google provider
@@ -34,19 +50,27 @@ func NewGoogleProvider(opts map[string]interface{}) (provider.Provider, error) {
 	}
 
 	ctx := context.Background()
-	var client *genai.Client
-	var err error
+	var clientOpts []option.ClientOption
 
 	if hasKey && apiKey != "" {
 		// Use API key authentication
-		client, err = genai.NewClient(ctx, projectID, location, option.WithAPIKey(apiKey))
-	} else if hasProject && projectID != "" {
-		// Use default credentials
-		client, err = genai.NewClient(ctx, projectID, location)
-	} else {
+		clientOpts = append(clientOpts, option.WithAPIKey(apiKey))
+	} else if !hasProject || projectID == "" {
 		return nil, fmt.Errorf("either api_key or project_id is required for Google provider")
 	}
+	// Otherwise use default credentials
+
+	if httpClient := provider.HTTPClient(opts); httpClient != nil {
+		// An injected client only works over REST, and replaces the SDK's
+		// authenticated transport, so wrap its transport with the credentials
+		authed, err := authenticatedClient(ctx, httpClient, clientOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Google client: %w", err)
+		}
+		clientOpts = []option.ClientOption{genai.WithREST(), option.WithHTTPClient(authed)}
+	}
 
+	client, err := genai.NewClient(ctx, projectID, location, clientOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Google client: %w", err)
 	}
@@ -58,6 +82,23 @@ func NewGoogleProvider(opts map[string]interface{}) (provider.Provider, error) {
 	}, nil
 }
 
+// authenticatedClient copies httpClient with its transport wrapped to
+// authenticate requests with the credentials in opts
+func authenticatedClient(ctx context.Context, httpClient *http.Client, opts []option.ClientOption) (*http.Client, error) {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	opts = append(opts, option.WithScopes("https://www.googleapis.com/auth/cloud-platform"))
+	transport, err := htransport.NewTransport(ctx, base, opts...)
+	if err != nil {
+		return nil, err
+	}
+	authed := *httpClient
+	authed.Transport = transport
+	return &authed, nil
+}
+
 // Name returns the provider name
 func (p *GoogleProvider) Name() string {
 	return "google"
@@ -73,11 +114,11 @@ func (p *GoogleProvider) Chat(ctx context.Context, reqInterface interface{}) (in
 	// Get model
 	model := p.client.GenerativeModel(req.Model)
 
-	// Configure model
-	p.configureModel(model, req)
-
-	// Convert messages to Gemini format
-	history, lastMessage := p.convertMessages(req.Messages)
+	// Configure model and convert messages to Gemini format
+	history, lastMessage, err := p.convertRequest(model, req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Start chat session
 	chat := model.StartChat()
@@ -90,7 +131,7 @@ func (p *GoogleProvider) Chat(ctx context.Context, reqInterface interface{}) (in
 	}
 
 	// Convert response
-	return p.convertResponse(resp, req.Model), nil
+	return p.convertResponse(resp, req.Model)
 }
 
 // StreamChat sends a streaming chat request
@@ -103,11 +144,11 @@ func (p *GoogleProvider) StreamChat(ctx context.Context, reqInterface interface{
 	// Get model
 	model := p.client.GenerativeModel(req.Model)
 
-	// Configure model
-	p.configureModel(model, req)
-
-	// Convert messages
-	history, lastMessage := p.convertMessages(req.Messages)
+	// Configure model and convert messages to Gemini format
+	history, lastMessage, err := p.convertRequest(model, req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Start chat session
 	chat := model.StartChat()
@@ -125,11 +166,13 @@ func (p *GoogleProvider) StreamChat(ctx context.Context, reqInterface interface{
 	return chatStream, nil
 }
 
-// SupportedFeatures returns supported features
+// SupportedFeatures returns supported features. Chat sessions always ask
+// for a single candidate, so several choices need WithChoiceEmulation.
 func (p *GoogleProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
 		Streaming:     true,
 		ToolCalling:   true,
+		ToolChoice:    true,
 		Vision:        true,
 		JSONMode:      true,
 		ReasoningMode: false,
@@ -172,125 +215,6 @@ func (p *GoogleProvider) SupportedModels() []provider.Model {
 	}
 }
 
-// configureModel configures the Gemini model with request parameters
-func (p *GoogleProvider) configureModel(model *genai.GenerativeModel, req *llmx.ChatRequest) {
-	if req.Temperature != nil {
-		temp := float32(*req.Temperature)
-		model.Temperature = &temp
-	}
-
-	if req.MaxTokens != nil {
-		maxTokens := int32(*req.MaxTokens)
-		model.MaxOutputTokens = &maxTokens
-	}
-
-	if req.TopP != nil {
-		topP := float32(*req.TopP)
-		model.TopP = &topP
-	}
-
-	if req.TopK != nil {
-		topK := int32(*req.TopK)
-		model.TopK = &topK
-	}
-
-	if len(req.Stop) > 0 {
-		model.StopSequences = req.Stop
-	}
-}
-
-// convertMessages converts llmx messages to Gemini format
-func (p *GoogleProvider) convertMessages(messages []llmx.Message) ([]*genai.Content, []genai.Part) {
-	var history []*genai.Content
-	var lastUserMessage []genai.Part
-
-	for i, msg := range messages {
-		// Skip system messages (Gemini handles them differently)
-		if msg.Role == llmx.RoleSystem {
-			continue
-		}
-
-		role := "user"
-		if msg.Role == llmx.RoleAssistant {
-			role = "model"
-		}
-
-		parts := p.convertContentParts(msg.Content)
-
-		// Last user message goes separately
-		if i == len(messages)-1 && msg.Role == llmx.RoleUser {
-			lastUserMessage = parts
-		} else {
-			history = append(history, &genai.Content{
-				Role:  role,
-				Parts: parts,
-			})
-		}
-	}
-
-	return history, lastUserMessage
-}
-
-// convertContentParts converts content parts to Gemini parts
-func (p *GoogleProvider) convertContentParts(parts []llmx.ContentPart) []genai.Part {
-	var geminiParts []genai.Part
-
-	for _, part := range parts {
-		switch v := part.(type) {
-		case llmx.TextPart:
-			geminiParts = append(geminiParts, genai.Text(v.Text))
-
-		case llmx.ImagePart:
-			// Gemini supports inline data
-			if v.Base64 != "" {
-				// Note: This is simplified, actual implementation would need
-				// to decode base64 and create proper image data
-				// geminiParts = append(geminiParts, genai.ImageData("image/jpeg", data))
-			}
-		}
-	}
-
-	return geminiParts
-}
-
-// convertResponse converts Gemini response to llmx response
-func (p *GoogleProvider) convertResponse(resp *genai.GenerateContentResponse, model string) *llmx.ChatResponse {
-	var content string
-
-	if len(resp.Candidates) > 0 {
-		candidate := resp.Candidates[0]
-		if candidate.Content != nil {
-			for _, part := range candidate.Content.Parts {
-				if text, ok := part.(genai.Text); ok {
-					content += string(text)
-				}
-			}
-		}
-	}
-
-	var usage llmx.Usage
-	if resp.UsageMetadata != nil {
-		usage = llmx.Usage{
-			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
-			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
-			TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
-		}
-	}
-
-	return &llmx.ChatResponse{
-		Model:     model,
-		Content:   content,
-		Usage:     usage,
-		CreatedAt: time.Now(),
-		Raw:       resp,
-	}
-}
-
-// convertError converts Google errors to llmx errors
-func (p *GoogleProvider) convertError(err error) error {
-	return llmx.NewProviderError("google", err.Error(), 500, err)
-}
-
 // Close closes the Google client
 func (p *GoogleProvider) Close() error {
 	return p.client.Close()
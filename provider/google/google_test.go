@@ -0,0 +1,132 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// redirect sends every request to the server at base
+type redirect struct {
+	base *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = r.base.Scheme, r.base.Host, ""
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGoogle_MultipleChoices(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"answer %d"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":2,"candidatesTokenCount":2,"totalTokenCount":4}}`, n)
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	client, err := llmx.NewClient(
+		llmx.WithProvider("google", map[string]interface{}{
+			"api_key":     "test-key",
+			"project_id":  "p",
+			"http_client": &http.Client{Transport: redirect{base}},
+		}),
+		llmx.WithDefaultModel("gemini-1.5-flash"),
+		llmx.WithChoiceEmulation(true),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	n := 3
+	resp, err := client.Chat(context.Background(), &llmx.ChatRequest{
+		Messages: []llmx.Message{
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "hi"}}},
+			{Role: llmx.RoleAssistant, Content: []llmx.ContentPart{llmx.TextPart{Text: "hello"}}},
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "again"}}},
+		},
+		N: &n,
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(resp.Choices) != n {
+		t.Fatalf("len(Choices) = %d, want %d", len(resp.Choices), n)
+	}
+	if requests != int32(n) {
+		t.Errorf("sent %d requests, want %d", requests, n)
+	}
+}
+
+func TestGoogle_ConvertsThroughFormats(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Rome"}}}]},"finishReason":"STOP"}]}`)
+	}))
+	defer ts.Close()
+	base, _ := url.Parse(ts.URL)
+
+	p, err := NewGoogleProvider(map[string]interface{}{
+		"api_key":     "test-key",
+		"project_id":  "p",
+		"http_client": &http.Client{Transport: redirect{base}},
+	})
+	if err != nil {
+		t.Fatalf("NewGoogleProvider() error = %v", err)
+	}
+
+	call := llmx.ToolCall{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}
+	out, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model: "gemini-1.5-flash",
+		Messages: []llmx.Message{
+			{Role: llmx.RoleSystem, Content: []llmx.ContentPart{llmx.TextPart{Text: "Be brief."}}},
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather in Paris?"}}},
+			{Role: llmx.RoleAssistant, ToolCalls: []llmx.ToolCall{call}},
+			{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: call.ID, Result: `{"temperature":21}`}}},
+		},
+		Tools: []llmx.Tool{{
+			Name: "get_weather",
+			Parameters: &llmx.Schema{
+				Type:       "object",
+				Properties: map[string]*llmx.Schema{"city": {Type: "string"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if _, ok := body["systemInstruction"]; !ok {
+		t.Errorf("system message not sent as systemInstruction: %v", body)
+	}
+	contents, _ := body["contents"].([]interface{})
+	if len(contents) != 3 {
+		t.Fatalf("len(contents) = %d, want 3: %v", len(contents), body["contents"])
+	}
+	last, _ := json.Marshal(contents[2])
+	if !strings.Contains(string(last), `"functionResponse"`) || !strings.Contains(string(last), `"get_weather"`) {
+		t.Errorf("tool result not sent as a function response: %s", last)
+	}
+
+	resp := out.(*llmx.ChatResponse)
+	if len(resp.ToolCalls) != 1 || string(resp.ToolCalls[0].Arguments) != `{"city":"Rome"}` {
+		t.Errorf("ToolCalls = %+v", resp.ToolCalls)
+	}
+	if resp.FinishReason != llmx.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %s, want %s", resp.FinishReason, llmx.FinishReasonToolCalls)
+	}
+}
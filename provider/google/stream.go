@@ -2,10 +2,12 @@ package google
 
 import (
 	"context"
+	"fmt"
 
 	"cloud.google.com/go/vertexai/genai"
 	"github.com/llmx-ai/llmx"
 	"github.com/llmx-ai/llmx/core"
+	"github.com/llmx-ai/llmx/formats"
 )
 
 // handleStream processes the Google stream and sends events to the chat stream
@@ -21,6 +23,7 @@ func (p *GoogleProvider) handleStream(
 		Type: core.EventTypeStart,
 	})
 
+	toolCalls := 0
 	for {
 		select {
 		case <-ctx.Done():
@@ -40,20 +43,46 @@ func (p *GoogleProvider) handleStream(
 				return
 			}
 
-			// Process response chunks
-			if len(resp.Candidates) > 0 {
-				candidate := resp.Candidates[0]
-				if candidate.Content != nil {
-					for _, part := range candidate.Content.Parts {
-						if text, ok := part.(genai.Text); ok {
-							chatStream.SendEvent(core.StreamEvent{
-								Type: core.EventTypeTextDelta,
-								Data: string(text),
-							})
-						}
-					}
+			// Process response chunks. Function calls are numbered across
+			// chunks, so each keeps a distinct ID.
+			wire := toWireResponse(resp)
+			if len(wire.Candidates) == 0 {
+				continue
+			}
+			candidate := wire.Candidates[0]
+			for _, part := range candidate.Content.Parts {
+				if call := part.FunctionCall; call != nil {
+					call.ID = fmt.Sprintf("%s_%d", call.Name, toolCalls)
+					toolCalls++
 				}
 			}
+			chunk, err := formats.FromGeminiResponse(&formats.GeminiResponse{Candidates: []formats.GeminiCandidate{candidate}})
+			if err != nil {
+				chatStream.SendError(err)
+				return
+			}
+			if chunk.Content != "" {
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeTextDelta,
+					Data: chunk.Content,
+				})
+			}
+			for _, call := range chunk.ToolCalls {
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeToolCall,
+					Data: map[string]interface{}{
+						"id":   call.ID,
+						"name": call.Name,
+						"args": string(call.Arguments),
+					},
+				})
+			}
+			if candidate.FinishReason != "" {
+				chatStream.SendEvent(core.StreamEvent{
+					Type: core.EventTypeFinish,
+					Data: candidate.FinishReason,
+				})
+			}
 		}
 	}
 }
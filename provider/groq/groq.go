@@ -21,8 +21,18 @@ type GroqProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("groq", NewGroqProvider)
+	provider.RegisterOptions("groq", options)
 }
 
 // NewGroqProvider creates a new Groq provider
@@ -44,8 +54,9 @@ func NewGroqProvider(opts map[string]interface{}) (provider.Provider, error) {
 
 	// Create OpenAI provider with Groq configuration
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -144,10 +155,12 @@ func (p *GroqProvider) SupportedModels() []provider.Model {
 // SupportedFeatures returns the features supported by Groq
 func (p *GroqProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:   true,
-		ToolCalling: true,
-		Vision:      false, // Groq doesn't support vision yet
-		JSONMode:    true,
+		Streaming:         true,
+		ToolCalling:       true,
+		ToolChoice:        true,
+		ParallelToolCalls: true,
+		Vision:            false, // Groq doesn't support vision yet
+		JSONMode:          true,
 		// SystemPrompt not needed
 	}
 }
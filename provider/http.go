@@ -0,0 +1,13 @@
+package provider
+
+import "net/http"
+
+// OptHTTPClient is the option key carrying the *http.Client a provider
+// sends its requests with
+const OptHTTPClient = "http_client"
+
+// HTTPClient returns the *http.Client in opts, or nil if none was given
+func HTTPClient(opts map[string]interface{}) *http.Client {
+	client, _ := opts[OptHTTPClient].(*http.Client)
+	return client
+}
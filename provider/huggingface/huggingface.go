@@ -20,8 +20,18 @@ type HuggingFaceProvider struct {
 	*openai.OpenAIProvider
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("huggingface", NewHuggingFaceProvider)
+	provider.RegisterOptions("huggingface", options)
 }
 
 // NewHuggingFaceProvider creates a new Hugging Face provider
@@ -38,8 +48,9 @@ func NewHuggingFaceProvider(opts map[string]interface{}) (provider.Provider, err
 
 	// HF Inference API is OpenAI-compatible
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
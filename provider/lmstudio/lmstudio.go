@@ -20,8 +20,17 @@ type LMStudioProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Optional:         []string{"base_url", "api_key"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("lmstudio", NewLMStudioProvider)
+	provider.RegisterOptions("lmstudio", options)
 }
 
 // NewLMStudioProvider creates a new LM Studio provider
@@ -39,8 +48,9 @@ func NewLMStudioProvider(opts map[string]interface{}) (provider.Provider, error)
 
 	// Create OpenAI-compatible provider
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -20,8 +20,17 @@ type LocalAIProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Optional:         []string{"base_url", "api_key"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("localai", NewLocalAIProvider)
+	provider.RegisterOptions("localai", options)
 }
 
 // NewLocalAIProvider creates a new LocalAI provider
@@ -39,8 +48,9 @@ func NewLocalAIProvider(opts map[string]interface{}) (provider.Provider, error)
 
 	// Create OpenAI-compatible provider with LocalAI configuration
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
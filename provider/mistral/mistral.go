@@ -23,8 +23,18 @@ type MistralProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("mistral", NewMistralProvider)
+	provider.RegisterOptions("mistral", options)
 }
 
 // NewMistralProvider creates a new Mistral provider
@@ -41,8 +51,9 @@ func NewMistralProvider(opts map[string]interface{}) (provider.Provider, error)
 
 	// Mistral API is OpenAI-compatible
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -123,10 +134,12 @@ func (p *MistralProvider) SupportedModels() []provider.Model {
 // SupportedFeatures returns the features supported by Mistral
 func (p *MistralProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:   true,
-		ToolCalling: true,
-		Vision:      false,
-		JSONMode:    true,
+		Streaming:         true,
+		ToolCalling:       true,
+		ToolChoice:        true,
+		ParallelToolCalls: true,
+		Vision:            false,
+		JSONMode:          true,
 		// SystemPrompt not needed
 	}
 }
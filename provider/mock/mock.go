@@ -0,0 +1,372 @@
+// Package mock provides a scripted provider for testing code built on
+// llmx without network access.
+//
+// Responses are queued with Enqueue or attached to request matchers with
+// On, and can inject errors such as rate limits and 5xx provider errors.
+// Streaming requests are answered by splitting the scripted content into
+// chunks. Every request is recorded for assertions:
+//
+//	p := mock.New()
+//	p.On(mock.HasTools(), mock.ToolCall("get_weather", map[string]string{"city": "Paris"}))
+//	p.Enqueue(mock.RateLimited(time.Second), mock.Text("sunny"))
+//
+//	client, _ := mock.NewClient(p)
+//	resp, err := client.Chat(ctx, req)
+//	p.LastRequest()
+//
+// Importing the package also registers the "mock" provider, which replies
+// with the instance passed in the "provider" option, or with a fresh
+// provider answering "mock response".
+package mock
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+	"github.com/llmx-ai/llmx/provider"
+)
+
+// options declares the options of the provider, see provider.OptionSpec.
+// The options of the provider it stands in for are accepted and ignored.
+var options = provider.OptionSpec{
+	Optional:   []string{"provider"},
+	AnyOptions: true,
+}
+
+func init() {
+	provider.Register("mock", NewMockProvider)
+	provider.RegisterOptions("mock", options)
+}
+
+// DefaultContent is the reply of a provider with nothing scripted
+const DefaultContent = "mock response"
+
+// MockProvider is a scripted provider.Provider
+type MockProvider struct {
+	mu       sync.Mutex
+	rules    []*Rule
+	queue    []*Response
+	fallback *Response
+	requests []*llmx.ChatRequest
+
+	chunkSize  int
+	chunkDelay time.Duration
+	features   provider.Features
+	models     []provider.Model
+}
+
+// Option configures a MockProvider
+type Option func(*MockProvider)
+
+// WithDefaultResponse sets the reply used when no rule matches and the
+// queue is empty. Without one such requests fail.
+func WithDefaultResponse(resp *Response) Option {
+	return func(p *MockProvider) {
+		p.fallback = resp
+	}
+}
+
+// WithChunkSize sets how many characters each streamed text chunk
+// carries; the default is 4
+func WithChunkSize(n int) Option {
+	return func(p *MockProvider) {
+		p.chunkSize = n
+	}
+}
+
+// WithChunkDelay sets the pause before each streamed chunk
+func WithChunkDelay(d time.Duration) Option {
+	return func(p *MockProvider) {
+		p.chunkDelay = d
+	}
+}
+
+// WithFeatures replaces the features the provider reports, which default
+// to everything the mock can script
+func WithFeatures(features provider.Features) Option {
+	return func(p *MockProvider) {
+		p.features = features
+	}
+}
+
+// WithModels sets the models the provider reports
+func WithModels(models ...provider.Model) Option {
+	return func(p *MockProvider) {
+		p.models = models
+	}
+}
+
+// New creates a mock provider with nothing scripted
+func New(opts ...Option) *MockProvider {
+	p := &MockProvider{
+		chunkSize: 4,
+		features: provider.Features{
+			Streaming:         true,
+			ToolCalling:       true,
+			ToolChoice:        true,
+			ParallelToolCalls: true,
+			Vision:            true,
+			JSONMode:          true,
+			MultiModal:        true,
+		},
+		models: []provider.Model{{ID: "mock-model", Name: "Mock Model"}},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewMockProvider is the registry factory for "mock". It returns the
+// *MockProvider in opts["provider"] if there is one.
+func NewMockProvider(opts map[string]interface{}) (provider.Provider, error) {
+	if p, ok := opts["provider"].(*MockProvider); ok {
+		return p, nil
+	}
+	return New(WithDefaultResponse(Text(DefaultContent))), nil
+}
+
+// NewClient creates an llmx client backed by p
+func NewClient(p *MockProvider, opts ...llmx.Option) (*llmx.Client, error) {
+	opts = append([]llmx.Option{
+		llmx.WithProvider("mock", map[string]interface{}{"provider": p}),
+		llmx.WithDefaultModel("mock-model"),
+	}, opts...)
+	return llmx.NewClient(opts...)
+}
+
+// Enqueue adds responses used, in order, for requests no rule matches
+func (p *MockProvider) Enqueue(responses ...*Response) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queue = append(p.queue, responses...)
+	return p
+}
+
+// On replies to requests matching m with responses, in order. Rules are
+// tried in the order they were added; an exhausted rule no longer
+// matches unless Repeat was called.
+func (p *MockProvider) On(m Matcher, responses ...*Response) *Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rule := &Rule{match: m, responses: responses}
+	p.rules = append(p.rules, rule)
+	return rule
+}
+
+// Requests returns the requests received so far
+func (p *MockProvider) Requests() []*llmx.ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*llmx.ChatRequest(nil), p.requests...)
+}
+
+// LastRequest returns the most recent request, or nil
+func (p *MockProvider) LastRequest() *llmx.ChatRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.requests) == 0 {
+		return nil
+	}
+	return p.requests[len(p.requests)-1]
+}
+
+// Reset drops scripted responses and recorded requests
+func (p *MockProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = nil
+	p.queue = nil
+	p.requests = nil
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// SupportedFeatures returns the features the provider reports
+func (p *MockProvider) SupportedFeatures() provider.Features {
+	return p.features
+}
+
+// SupportedModels returns the models the provider reports
+func (p *MockProvider) SupportedModels() []provider.Model {
+	return p.models
+}
+
+// respond records req and picks its scripted response
+func (p *MockProvider) respond(req *llmx.ChatRequest) (*Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = append(p.requests, req)
+
+	for _, rule := range p.rules {
+		if len(rule.responses) > 0 && rule.match(req) {
+			return rule.next(), nil
+		}
+	}
+	if len(p.queue) > 0 {
+		resp := p.queue[0]
+		p.queue = p.queue[1:]
+		return resp, nil
+	}
+	if p.fallback != nil {
+		return p.fallback, nil
+	}
+	return nil, llmx.NewInternalError("mock: no scripted response for request", nil)
+}
+
+// Chat returns the next scripted response
+func (p *MockProvider) Chat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req, ok := reqInterface.(*llmx.ChatRequest)
+	if !ok {
+		return nil, llmx.NewInvalidRequestError("invalid request type", nil)
+	}
+
+	resp, err := p.respond(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, resp.Delay); err != nil {
+		return nil, err
+	}
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+	return resp.toChatResponse(req.Model), nil
+}
+
+// StreamChat streams the next scripted response, splitting its content
+// into chunks
+func (p *MockProvider) StreamChat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req, ok := reqInterface.(*llmx.ChatRequest)
+	if !ok {
+		return nil, llmx.NewInvalidRequestError("invalid request type", nil)
+	}
+
+	resp, err := p.respond(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Err != nil {
+		if err := sleep(ctx, resp.Delay); err != nil {
+			return nil, err
+		}
+		return nil, resp.Err
+	}
+
+	stream := llmx.NewChatStream(ctx)
+	go p.stream(ctx, stream, resp)
+	return stream, nil
+}
+
+func (p *MockProvider) stream(ctx context.Context, stream *llmx.ChatStream, resp *Response) {
+	defer stream.Close()
+
+	delay := p.chunkDelay
+	if delay == 0 {
+		delay = resp.Delay
+	}
+
+	stream.SendEvent(core.StreamEvent{Type: core.EventTypeStart})
+
+	for i, chunk := range chunks(resp.Content, p.chunkSize) {
+		if resp.StreamErr != nil && i == resp.StreamErrAfter {
+			stream.SendError(resp.StreamErr)
+			return
+		}
+		if err := sleep(ctx, delay); err != nil {
+			stream.SendError(err)
+			return
+		}
+		stream.SendEvent(core.StreamEvent{Type: core.EventTypeTextDelta, Data: chunk})
+	}
+	if resp.StreamErr != nil {
+		stream.SendError(resp.StreamErr)
+		return
+	}
+
+	for _, call := range resp.ToolCalls {
+		stream.SendEvent(core.StreamEvent{
+			Type: core.EventTypeToolCall,
+			Data: map[string]interface{}{
+				"id":   call.ID,
+				"name": call.Name,
+				"args": string(call.Arguments),
+			},
+		})
+	}
+
+	stream.SendEvent(core.StreamEvent{Type: core.EventTypeFinish, Data: resp.finishReason()})
+}
+
+func (r *Response) finishReason() string {
+	switch {
+	case r.FinishReason != "":
+		return r.FinishReason
+	case len(r.ToolCalls) > 0:
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
+
+func (r *Response) toChatResponse(model string) *llmx.ChatResponse {
+	return &llmx.ChatResponse{
+		ID:              "mock-" + nextID(),
+		Model:           model,
+		Content:         r.Content,
+		ToolCalls:       append([]llmx.ToolCall(nil), r.ToolCalls...),
+		Usage:           r.Usage,
+		FinishReason:    llmx.NormalizeFinishReason(r.finishReason()),
+		RawFinishReason: r.finishReason(),
+		CreatedAt:       time.Now(),
+	}
+}
+
+// chunks splits s into pieces of n characters
+func chunks(s string, n int) []string {
+	if n <= 0 {
+		n = len(s)
+	}
+	var out []string
+	for len(s) > 0 {
+		end, count := 0, 0
+		for end < len(s) && count < n {
+			_, size := utf8.DecodeRuneInString(s[end:])
+			end += size
+			count++
+		}
+		out = append(out, s[:end])
+		s = s[end:]
+	}
+	return out
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var ids atomic.Int64
+
+func nextID() string {
+	return strconv.FormatInt(ids.Add(1), 10)
+}
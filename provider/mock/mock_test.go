@@ -0,0 +1,257 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+	"github.com/llmx-ai/llmx/middleware"
+)
+
+func userRequest(text string) *llmx.ChatRequest {
+	return &llmx.ChatRequest{
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: text}}}},
+	}
+}
+
+func TestMockProvider_Script(t *testing.T) {
+	p := New()
+	p.On(LastUserMessage("weather"), ToolCall("get_weather", map[string]string{"city": "Paris"}))
+	p.On(Model("special"), Text("special")).Repeat()
+	p.Enqueue(Text("first"), Text("second").WithUsage(3, 2))
+
+	client, err := NewClient(p)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     *llmx.ChatRequest
+		content string
+		tool    string
+	}{
+		{"queue", userRequest("hi"), "first", ""},
+		{"matcher", userRequest("what's the weather?"), "", "get_weather"},
+		{"exhausted matcher falls through", userRequest("weather again"), "second", ""},
+		{"repeat", &llmx.ChatRequest{Model: "special", Messages: userRequest("x").Messages}, "special", ""},
+		{"repeat again", &llmx.ChatRequest{Model: "special", Messages: userRequest("x").Messages}, "special", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Chat(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if resp.Content != tt.content {
+				t.Errorf("expected content %q, got %q", tt.content, resp.Content)
+			}
+			if tt.tool != "" && (len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != tt.tool || resp.FinishReason != "tool_calls") {
+				t.Errorf("expected call to %s, got %+v", tt.tool, resp)
+			}
+		})
+	}
+
+	if _, err := client.Chat(ctx, userRequest("nothing left")); err == nil {
+		t.Error("expected an error once the script is exhausted")
+	}
+	if n := len(p.Requests()); n != 6 {
+		t.Errorf("expected 6 recorded requests, got %d", n)
+	}
+	if last := p.LastRequest(); llmx.ExtractText(last.Messages[0]) != "nothing left" || last.Model != "mock-model" {
+		t.Errorf("unexpected last request %+v", last)
+	}
+}
+
+func TestMockProvider_Errors(t *testing.T) {
+	p := New()
+	p.Enqueue(RateLimited(2*time.Second), ServerError(503), Text("ok").WithDelay(time.Hour))
+
+	client, _ := NewClient(p)
+	ctx := context.Background()
+
+	var rateErr *llmx.RateLimitError
+	if _, err := client.Chat(ctx, userRequest("a")); !errors.As(err, &rateErr) || rateErr.RetryAfter != 2*time.Second {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+
+	var provErr *llmx.ProviderError
+	if _, err := client.Chat(ctx, userRequest("b")); !errors.As(err, &provErr) || provErr.StatusCode() != 503 || !provErr.Retryable() {
+		t.Errorf("expected retryable 503, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Chat(ctx, userRequest("c")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected delay to honor the context, got %v", err)
+	}
+}
+
+func TestMockProvider_Stream(t *testing.T) {
+	p := New(WithChunkSize(3), WithChunkDelay(time.Millisecond))
+	p.Enqueue(&Response{
+		Content:   "héllo wörld",
+		ToolCalls: []llmx.ToolCall{NewToolCall("lookup", `{"q":"go"}`)},
+	})
+	client, _ := NewClient(p)
+
+	stream, err := client.StreamChat(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+
+	var deltas []string
+	var calls []map[string]interface{}
+	var finish string
+	for event := range stream.Events() {
+		switch event.Type {
+		case core.EventTypeTextDelta:
+			deltas = append(deltas, event.Data.(string))
+		case core.EventTypeToolCall:
+			calls = append(calls, event.Data.(map[string]interface{}))
+		case core.EventTypeFinish:
+			finish = event.Data.(string)
+		}
+	}
+
+	want := []string{"hél", "lo ", "wör", "ld"}
+	if len(deltas) != len(want) {
+		t.Fatalf("expected chunks %q, got %q", want, deltas)
+	}
+	for i := range want {
+		if deltas[i] != want[i] {
+			t.Fatalf("expected chunks %q, got %q", want, deltas)
+		}
+	}
+	if len(calls) != 1 || calls[0]["name"] != "lookup" || calls[0]["args"] != `{"q":"go"}` {
+		t.Errorf("unexpected tool calls %v", calls)
+	}
+	if finish != "tool_calls" {
+		t.Errorf("expected finish reason tool_calls, got %q", finish)
+	}
+}
+
+// storeSignal is a cache telling when a response is stored
+type storeSignal struct {
+	middleware.Cache
+	stored chan struct{}
+}
+
+func (c storeSignal) Set(key string, value interface{}, ttl time.Duration) {
+	c.Cache.Set(key, value, ttl)
+	c.stored <- struct{}{}
+}
+
+func TestMockProvider_StreamCached(t *testing.T) {
+	p := New()
+	p.Enqueue(ToolCall("get_weather", map[string]string{"city": "Paris"}))
+	client, _ := NewClient(p)
+	cache := storeSignal{middleware.NewMemoryCache(), make(chan struct{}, 1)}
+	client.Use(middleware.CacheMiddleware(cache, time.Minute))
+	ctx := context.Background()
+
+	stream, err := client.StreamChat(ctx, userRequest("weather"))
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+	if _, err := stream.Accumulate(); err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+	<-cache.stored
+
+	// Nothing else is scripted, so the reply comes from the cache
+	resp, err := client.Chat(ctx, userRequest("weather"))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.FinishReason != llmx.FinishReasonToolCalls || len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected the streamed tool call, got %q with %+v", resp.FinishReason, resp.ToolCalls)
+	}
+	call := resp.ToolCalls[0]
+	if call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` || call.ID == "" {
+		t.Errorf("unexpected tool call %+v (arguments %s)", call, call.Arguments)
+	}
+	if len(resp.Choices) != 1 || len(resp.Choices[0].ToolCalls) != 1 {
+		t.Errorf("expected the tool call in the choice, got %+v", resp.Choices)
+	}
+}
+
+func TestMockProvider_StreamErrors(t *testing.T) {
+	p := New(WithChunkSize(1))
+	boom := errors.New("connection reset")
+	p.Enqueue(ServerError(500), Text("abcdef").FailStreamAfter(2, boom))
+	client, _ := NewClient(p)
+	ctx := context.Background()
+
+	if _, err := client.StreamChat(ctx, userRequest("a")); err == nil {
+		t.Error("expected StreamChat to fail before streaming")
+	}
+
+	stream, err := client.StreamChat(ctx, userRequest("b"))
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+	var text string
+	for event := range stream.Events() {
+		if event.Type == core.EventTypeTextDelta {
+			text += event.Data.(string)
+		}
+	}
+	if text != "ab" {
+		t.Errorf("expected 2 chunks before the error, got %q", text)
+	}
+	if err := <-stream.Errors(); !errors.Is(err, boom) {
+		t.Errorf("expected injected stream error, got %v", err)
+	}
+}
+
+func TestMockProvider_Registry(t *testing.T) {
+	client, err := llmx.NewClient(llmx.WithProvider("mock", nil), llmx.WithDefaultModel("mock-model"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	resp, err := client.Chat(context.Background(), userRequest("hi"))
+	if err != nil || resp.Content != DefaultContent {
+		t.Errorf("expected default response, got %+v, %v", resp, err)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	req := &llmx.ChatRequest{
+		Model: "m",
+		Messages: []llmx.Message{
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "first question"}}},
+			{Role: llmx.RoleAssistant, Content: []llmx.ContentPart{llmx.TextPart{Text: "answer"}}},
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "second question"}}},
+		},
+		Tools: []llmx.Tool{{Name: "search"}},
+	}
+
+	tests := []struct {
+		name  string
+		match Matcher
+		want  bool
+	}{
+		{"model", Model("m"), true},
+		{"other model", Model("n"), false},
+		{"last user message", LastUserMessage("second"), true},
+		{"earlier user message", LastUserMessage("first"), false},
+		{"has tools", HasTools(), true},
+		{"has tool", HasTool("search"), true},
+		{"missing tool", HasTool("fetch"), false},
+		{"tool result", HasToolResult(), false},
+		{"all", All(Model("m"), HasTool("search")), true},
+		{"all fails", All(Model("m"), HasTool("fetch")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match(req); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
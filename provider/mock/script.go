@@ -0,0 +1,187 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Response scripts one reply of the mock provider
+type Response struct {
+	Content      string
+	ToolCalls    []llmx.ToolCall
+	FinishReason string
+	Usage        llmx.Usage
+
+	// Err is returned instead of a response. For streaming requests it is
+	// returned from StreamChat, before the stream starts.
+	Err error
+
+	// StreamErr is sent on the stream after StreamErrAfter text chunks,
+	// ending it early. Chat ignores it.
+	StreamErr      error
+	StreamErrAfter int
+
+	// Delay is waited before replying, or before each chunk when
+	// streaming if the provider has no chunk delay
+	Delay time.Duration
+}
+
+// Text returns a response with the given content
+func Text(content string) *Response {
+	return &Response{Content: content}
+}
+
+// ToolCall returns a response calling the named tool. args is marshalled
+// to JSON unless it already is a string or json.RawMessage.
+func ToolCall(name string, args interface{}) *Response {
+	return &Response{ToolCalls: []llmx.ToolCall{NewToolCall(name, args)}}
+}
+
+// NewToolCall builds a tool call with a generated ID, for scripting
+// responses that call several tools
+func NewToolCall(name string, args interface{}) llmx.ToolCall {
+	var raw json.RawMessage
+	switch v := args.(type) {
+	case nil:
+		raw = json.RawMessage("{}")
+	case string:
+		raw = json.RawMessage(v)
+	case json.RawMessage:
+		raw = v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("mock: cannot marshal arguments of %s: %v", name, err))
+		}
+		raw = data
+	}
+	return llmx.ToolCall{ID: "call_" + name + "_" + nextID(), Name: name, Arguments: raw}
+}
+
+// Error returns a response failing with err
+func Error(err error) *Response {
+	return &Response{Err: err}
+}
+
+// RateLimited returns a response failing with a rate limit error
+func RateLimited(retryAfter time.Duration) *Response {
+	return Error(llmx.NewRateLimitError("mock: rate limit exceeded", retryAfter))
+}
+
+// ServerError returns a response failing with a provider error carrying
+// the given HTTP status, such as 500 or 503
+func ServerError(status int) *Response {
+	return Error(llmx.NewProviderError("mock", fmt.Sprintf("mock: %s", http.StatusText(status)), status, nil))
+}
+
+// WithDelay sets the response's delay
+func (r *Response) WithDelay(d time.Duration) *Response {
+	r.Delay = d
+	return r
+}
+
+// WithUsage sets the response's token usage
+func (r *Response) WithUsage(prompt, completion int) *Response {
+	r.Usage = llmx.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+	return r
+}
+
+// FailStreamAfter makes a streamed response fail with err after n chunks
+func (r *Response) FailStreamAfter(n int, err error) *Response {
+	r.StreamErrAfter = n
+	r.StreamErr = err
+	return r
+}
+
+// Matcher selects the requests a rule applies to
+type Matcher func(req *llmx.ChatRequest) bool
+
+// Any matches every request
+func Any() Matcher {
+	return func(req *llmx.ChatRequest) bool { return true }
+}
+
+// Model matches requests for the named model
+func Model(name string) Matcher {
+	return func(req *llmx.ChatRequest) bool { return req.Model == name }
+}
+
+// LastUserMessage matches requests whose last user message contains
+// substr
+func LastUserMessage(substr string) Matcher {
+	return func(req *llmx.ChatRequest) bool {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == llmx.RoleUser {
+				return strings.Contains(llmx.ExtractText(req.Messages[i]), substr)
+			}
+		}
+		return false
+	}
+}
+
+// HasTools matches requests offering at least one tool
+func HasTools() Matcher {
+	return func(req *llmx.ChatRequest) bool { return len(req.Tools) > 0 }
+}
+
+// HasTool matches requests offering the named tool
+func HasTool(name string) Matcher {
+	return func(req *llmx.ChatRequest) bool {
+		for _, tool := range req.Tools {
+			if tool.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasToolResult matches requests whose last message is a tool result,
+// i.e. the turn after the model called a tool
+func HasToolResult() Matcher {
+	return func(req *llmx.ChatRequest) bool {
+		return len(req.Messages) > 0 && req.Messages[len(req.Messages)-1].Role == llmx.RoleTool
+	}
+}
+
+// All matches requests matched by every matcher
+func All(matchers ...Matcher) Matcher {
+	return func(req *llmx.ChatRequest) bool {
+		for _, m := range matchers {
+			if !m(req) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Rule replies to matching requests with its responses in order
+type Rule struct {
+	match     Matcher
+	responses []*Response
+	repeat    bool
+}
+
+// Repeat keeps replying with the last response once the others are used
+func (r *Rule) Repeat() *Rule {
+	r.repeat = true
+	return r
+}
+
+// next returns the rule's next response, or nil once it is exhausted
+func (r *Rule) next() *Response {
+	if len(r.responses) == 0 {
+		return nil
+	}
+	resp := r.responses[0]
+	if len(r.responses) > 1 || !r.repeat {
+		r.responses = r.responses[1:]
+	}
+	return resp
+}
@@ -21,8 +21,17 @@ type OllamaProvider struct {
 	baseURL                string
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Optional:         []string{"base_url", "api_key"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("ollama", NewOllamaProvider)
+	provider.RegisterOptions("ollama", options)
 }
 
 // NewOllamaProvider creates a new Ollama provider
@@ -40,8 +49,9 @@ func NewOllamaProvider(opts map[string]interface{}) (provider.Provider, error) {
 
 	// Create OpenAI-compatible provider
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
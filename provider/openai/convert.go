@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/formats"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ConvertRequest converts an llmx request to an OpenAI chat completion
+// request. It is shared with providers that speak the OpenAI wire format.
+// The conversion is formats.ToOpenAIRequest's; the SDK types mirror the
+// same JSON.
+func ConvertRequest(req *llmx.ChatRequest) (openai.ChatCompletionRequest, error) {
+	var openaiReq openai.ChatCompletionRequest
+	wire, err := formats.ToOpenAIRequest(req)
+	if err != nil {
+		return openaiReq, err
+	}
+	if err := transcode(wire, &openaiReq); err != nil {
+		return openaiReq, err
+	}
+
+	// Keep the typed tool choice the SDK documents rather than a map
+	if len(wire.ToolChoice) > 0 {
+		var mode string
+		if json.Unmarshal(wire.ToolChoice, &mode) == nil {
+			openaiReq.ToolChoice = mode
+		} else {
+			var choice openai.ToolChoice
+			if err := json.Unmarshal(wire.ToolChoice, &choice); err != nil {
+				return openaiReq, err
+			}
+			openaiReq.ToolChoice = choice
+		}
+	}
+
+	applyRequestOptions(&openaiReq, req)
+	return openaiReq, nil
+}
+
+// ConvertResponse converts an OpenAI chat completion to an llmx response
+// with formats.FromOpenAIResponse
+func ConvertResponse(resp *openai.ChatCompletionResponse) (*llmx.ChatResponse, error) {
+	var wire formats.OpenAIResponse
+	if err := transcode(resp, &wire); err != nil {
+		return nil, err
+	}
+	converted, err := formats.FromOpenAIResponse(&wire)
+	if err != nil {
+		return nil, err
+	}
+	converted.Metadata = responseMetadata(resp.Header(), resp.SystemFingerprint)
+	converted.Raw = resp
+	return converted, nil
+}
+
+// transcode copies from into to through their JSON encoding
+func transcode(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+// responseMetadata returns the metadata of a chat completion from its
+// response headers
+func responseMetadata(header http.Header, fingerprint string) llmx.ResponseMetadata {
+	metadata := llmx.MetadataFromHeader(header)
+	metadata.SystemFingerprint = fingerprint
+	return metadata
+}
+
+// StreamMetadata returns the metadata of a chat completion stream known
+// before its first chunk
+func StreamMetadata(stream *openai.ChatCompletionStream) llmx.ResponseMetadata {
+	return responseMetadata(stream.Header(), "")
+}
@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/llmx-ai/llmx"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// go-openai drops the headers of failed responses, which carry the
+// request ID and Retry-After. CaptureErrors installs a transport that keeps
+// them in the request context, where ConvertError finds them.
+
+// failedResponse is the last failed response of a request
+type failedResponse struct {
+	header http.Header
+	body   []byte
+}
+
+type failedResponseKey struct{}
+
+// WithErrorCapture returns a context in which requests sent by a client
+// from CaptureErrors record their failed response for ConvertError
+func WithErrorCapture(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failedResponseKey{}, &failedResponse{})
+}
+
+// CaptureErrors returns a copy of client recording failed responses
+func CaptureErrors(client *http.Client) *http.Client {
+	captured := &http.Client{}
+	if client != nil {
+		*captured = *client
+	}
+	base := captured.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	captured.Transport = &captureTransport{base: base}
+	return captured
+}
+
+type captureTransport struct {
+	base http.RoundTripper
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	failed, ok := req.Context().Value(failedResponseKey{}).(*failedResponse)
+	if !ok {
+		return resp, nil
+	}
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, nil
+	}
+	failed.header = resp.Header
+	failed.body = body
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped
+// transport
+func (t *captureTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// ConvertError maps a go-openai error to an llmx error. ctx is the request
+// context; if it comes from WithErrorCapture the error carries the
+// response's request ID, Retry-After and body.
+func ConvertError(ctx context.Context, providerName string, err error) error {
+	info := llmx.ErrorInfo{Provider: providerName, Cause: err}
+
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		info.StatusCode = apiErr.HTTPStatusCode
+		info.Message = apiErr.Message
+		info.Code = apiErr.Type
+		if apiErr.Code != nil {
+			info.Code += " " + fmt.Sprint(apiErr.Code)
+		}
+		if apiErr.InnerError != nil {
+			info.Code += " " + apiErr.InnerError.Code
+		}
+	case errors.As(err, &reqErr):
+		info.StatusCode = reqErr.HTTPStatusCode
+		info.Body = reqErr.Body
+		if len(reqErr.Body) > 0 {
+			info.Message = string(reqErr.Body)
+		}
+	}
+
+	if failed, ok := ctx.Value(failedResponseKey{}).(*failedResponse); ok && failed.header != nil {
+		info.RequestID = llmx.RequestIDFromHeader(failed.header)
+		info.RetryAfter = llmx.ParseRetryAfter(failed.header)
+		info.Body = failed.body
+	}
+	return llmx.ClassifyError(info)
+}
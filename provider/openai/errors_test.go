@@ -0,0 +1,131 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// Error responses as returned by the OpenAI API
+var recordedErrors = []struct {
+	name     string
+	status   int
+	header   http.Header
+	body     string
+	sentinel error
+}{
+	{
+		name:     "rate limit",
+		status:   429,
+		header:   http.Header{"Retry-After": {"20"}},
+		body:     `{"error":{"message":"Rate limit reached for gpt-4o in organization org-x on requests per min (RPM): Limit 500, Used 500, Requested 1.","type":"requests","param":null,"code":"rate_limit_exceeded"}}`,
+		sentinel: llmx.ErrRateLimit,
+	},
+	{
+		name:     "quota",
+		status:   429,
+		body:     `{"error":{"message":"You exceeded your current quota, please check your plan and billing details.","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+		sentinel: llmx.ErrQuotaExceeded,
+	},
+	{
+		name:     "context length",
+		status:   400,
+		body:     `{"error":{"message":"This model's maximum context length is 128000 tokens. However, your messages resulted in 130412 tokens. Please reduce the length of the messages.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`,
+		sentinel: llmx.ErrContextLengthExceeded,
+	},
+	{
+		name:     "content filter",
+		status:   400,
+		body:     `{"error":{"message":"The response was filtered due to the prompt triggering Azure OpenAI's content management policy.","type":null,"param":"prompt","code":"content_filter","status":400,"innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{"hate":{"filtered":true,"severity":"high"}}}}}`,
+		sentinel: llmx.ErrContentFiltered,
+	},
+	{
+		name:     "model not found",
+		status:   404,
+		body:     `{"error":{"message":"The model ` + "`gpt-5o`" + ` does not exist or you do not have access to it.","type":"invalid_request_error","param":null,"code":"model_not_found"}}`,
+		sentinel: llmx.ErrModelNotFound,
+	},
+	{
+		name:     "invalid api key",
+		status:   401,
+		body:     `{"error":{"message":"Incorrect API key provided: sk-test. You can find your API key at https://platform.openai.com/account/api-keys.","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`,
+		sentinel: llmx.ErrAuthentication,
+	},
+	{
+		name:     "overloaded",
+		status:   503,
+		body:     `{"error":{"message":"The engine is currently overloaded, please try again later","type":"server_error","param":null,"code":null}}`,
+		sentinel: llmx.ErrOverloaded,
+	},
+	{
+		name:     "server error",
+		status:   500,
+		body:     `{"error":{"message":"The server had an error while processing your request. Sorry about that!","type":"server_error","param":null,"code":null}}`,
+		sentinel: llmx.ErrProvider,
+	},
+	{
+		name:     "gateway html",
+		status:   502,
+		body:     `<html><head><title>502 Bad Gateway</title></head><body><center><h1>502 Bad Gateway</h1></center></body></html>`,
+		sentinel: llmx.ErrProvider,
+	},
+}
+
+func TestOpenAIProvider_Chat_Errors(t *testing.T) {
+	for _, tt := range recordedErrors {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Request-Id", "req_abc123")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			p, err := NewOpenAIProvider(map[string]interface{}{
+				"api_key":  "test-key",
+				"base_url": server.URL,
+			})
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			_, err = p.Chat(context.Background(), &llmx.ChatRequest{
+				Model:    "gpt-4o",
+				Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "hi"}}}},
+			})
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+
+			var llmxErr llmx.Error
+			if !errors.As(err, &llmxErr) || llmxErr.StatusCode() == 0 {
+				t.Errorf("expected an llmx.Error, got %v", err)
+			}
+			var rawErr *llmx.BaseError
+			if !errors.As(err, &rawErr) {
+				t.Fatalf("expected a BaseError in %T", err)
+			}
+			if rawErr.RequestID != "req_abc123" {
+				t.Errorf("expected request ID req_abc123, got %q", rawErr.RequestID)
+			}
+			if string(rawErr.RawBody) != tt.body {
+				t.Errorf("expected the raw body to be kept, got %q", rawErr.RawBody)
+			}
+
+			var rateErr *llmx.RateLimitError
+			if errors.As(err, &rateErr) && tt.header != nil && rateErr.RetryAfter != 20*time.Second {
+				t.Errorf("expected Retry-After 20s, got %v", rateErr.RetryAfter)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package openai
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/llmx-ai/llmx"
 	"github.com/llmx-ai/llmx/provider"
@@ -15,9 +14,21 @@ type OpenAIProvider struct {
 	client *openai.Client
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url", "organization"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
+	llmx.RegisterProviderOptions[RequestOptions]()
 	provider.Register("openai", NewOpenAIProvider)
+	provider.RegisterOptions("openai", options)
 	provider.Register("compatible", NewOpenAIProvider) // For OpenAI-compatible APIs
+	provider.RegisterOptions("compatible", options)
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
@@ -33,6 +44,10 @@ func NewOpenAIProvider(opts map[string]interface{}) (provider.Provider, error) {
 	if baseURL, ok := opts["base_url"].(string); ok && baseURL != "" {
 		config.BaseURL = baseURL
 	}
+	if org, ok := opts["organization"].(string); ok {
+		config.OrgID = org
+	}
+	config.HTTPClient = CaptureErrors(provider.HTTPClient(opts))
 
 	return &OpenAIProvider{
 		client: openai.NewClientWithConfig(config),
@@ -53,16 +68,20 @@ func (p *OpenAIProvider) Chat(ctx context.Context, reqInterface interface{}) (in
 	}
 
 	// Convert request
-	openaiReq := p.convertRequest(req)
+	openaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Call OpenAI API
+	ctx = WithErrorCapture(ctx)
 	resp, err := p.client.CreateChatCompletion(ctx, openaiReq)
 	if err != nil {
-		return nil, p.convertError(err)
+		return nil, p.convertError(ctx, err)
 	}
 
 	// Convert response
-	return p.convertResponse(&resp), nil
+	return p.convertResponse(&resp)
 }
 
 // StreamChat sends a streaming chat request
@@ -74,16 +93,23 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, reqInterface interface{
 	}
 
 	// Convert request
-	openaiReq := p.convertRequest(req)
+	openaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create stream
+	ctx = WithErrorCapture(ctx)
 	stream, err := p.client.CreateChatCompletionStream(ctx, openaiReq)
 	if err != nil {
-		return nil, p.convertError(err)
+		return nil, p.convertError(ctx, err)
 	}
 
 	// Create llmx stream
 	chatStream := llmx.NewChatStream(ctx)
+	chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+		*m = StreamMetadata(stream)
+	})
 
 	// Start goroutine to handle streaming
 	go p.handleStream(ctx, stream, chatStream)
@@ -94,13 +120,18 @@ func (p *OpenAIProvider) StreamChat(ctx context.Context, reqInterface interface{
 // SupportedFeatures returns supported features
 func (p *OpenAIProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:    true,
-		ToolCalling:  true,
-		Vision:       true,
-		JSONMode:     true,
-		MultiModal:   true,
-		Embedding:    true,
-		CacheControl: false,
+		Streaming:         true,
+		ToolCalling:       true,
+		ToolChoice:        true,
+		ParallelToolCalls: true,
+		MultipleChoices:   true,
+		Logprobs:          true,
+		Seed:              true,
+		Vision:            true,
+		JSONMode:          true,
+		MultiModal:        true,
+		Embedding:         true,
+		CacheControl:      false,
 	}
 }
 
@@ -138,127 +169,16 @@ func (p *OpenAIProvider) SupportedModels() []provider.Model {
 }
 
 // convertRequest converts llmx request to OpenAI request
-func (p *OpenAIProvider) convertRequest(req *llmx.ChatRequest) openai.ChatCompletionRequest {
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = p.convertMessage(msg)
-	}
-
-	openaiReq := openai.ChatCompletionRequest{
-		Model:    req.Model,
-		Messages: messages,
-	}
-
-	if req.Temperature != nil {
-		openaiReq.Temperature = float32(*req.Temperature)
-	}
-
-	if req.MaxTokens != nil {
-		openaiReq.MaxTokens = *req.MaxTokens
-	}
-
-	if req.TopP != nil {
-		openaiReq.TopP = float32(*req.TopP)
-	}
-
-	if len(req.Stop) > 0 {
-		openaiReq.Stop = req.Stop
-	}
-
-	return openaiReq
-}
-
-// convertMessage converts a llmx message to OpenAI message
-func (p *OpenAIProvider) convertMessage(msg llmx.Message) openai.ChatCompletionMessage {
-	var content string
-	var multiContent []openai.ChatMessagePart
-
-	// Process content parts
-	for _, part := range msg.Content {
-		switch v := part.(type) {
-		case llmx.TextPart:
-			if len(multiContent) == 0 {
-				content = v.Text
-			} else {
-				multiContent = append(multiContent, openai.ChatMessagePart{
-					Type: openai.ChatMessagePartTypeText,
-					Text: v.Text,
-				})
-			}
-		case llmx.ImagePart:
-			// If we have an image, we need to use multiContent
-			if content != "" && len(multiContent) == 0 {
-				multiContent = append(multiContent, openai.ChatMessagePart{
-					Type: openai.ChatMessagePartTypeText,
-					Text: content,
-				})
-				content = ""
-			}
-			imageURL := v.URL
-			if v.Base64 != "" {
-				imageURL = v.Base64
-			}
-			multiContent = append(multiContent, openai.ChatMessagePart{
-				Type: openai.ChatMessagePartTypeImageURL,
-				ImageURL: &openai.ChatMessageImageURL{
-					URL:    imageURL,
-					Detail: openai.ImageURLDetail(v.Detail),
-				},
-			})
-		}
-	}
-
-	message := openai.ChatCompletionMessage{
-		Role: string(msg.Role),
-	}
-
-	if len(multiContent) > 0 {
-		message.MultiContent = multiContent
-	} else {
-		message.Content = content
-	}
-
-	return message
+func (p *OpenAIProvider) convertRequest(req *llmx.ChatRequest) (openai.ChatCompletionRequest, error) {
+	return ConvertRequest(req)
 }
 
 // convertResponse converts OpenAI response to llmx response
-func (p *OpenAIProvider) convertResponse(resp *openai.ChatCompletionResponse) *llmx.ChatResponse {
-	if len(resp.Choices) == 0 {
-		return &llmx.ChatResponse{}
-	}
-
-	choice := resp.Choices[0]
-
-	return &llmx.ChatResponse{
-		ID:      resp.ID,
-		Model:   resp.Model,
-		Content: choice.Message.Content,
-		Usage: llmx.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
-		FinishReason: string(choice.FinishReason),
-		CreatedAt:    time.Unix(int64(resp.Created), 0),
-		Raw:          resp,
-	}
+func (p *OpenAIProvider) convertResponse(resp *openai.ChatCompletionResponse) (*llmx.ChatResponse, error) {
+	return ConvertResponse(resp)
 }
 
 // convertError converts OpenAI errors to llmx errors
-func (p *OpenAIProvider) convertError(err error) error {
-	if apiErr, ok := err.(*openai.APIError); ok {
-		switch apiErr.HTTPStatusCode {
-		case 401:
-			return llmx.NewAuthenticationError(apiErr.Message)
-		case 429:
-			return llmx.NewRateLimitError(apiErr.Message, 60*time.Second)
-		case 400:
-			return llmx.NewInvalidRequestError(apiErr.Message, nil)
-		case 404:
-			return llmx.NewNotFoundError(apiErr.Message, "model")
-		default:
-			return llmx.NewProviderError("openai", apiErr.Message, apiErr.HTTPStatusCode, err)
-		}
-	}
-	return llmx.NewInternalError("OpenAI API error", err)
+func (p *OpenAIProvider) convertError(ctx context.Context, err error) error {
+	return ConvertError(ctx, "openai", err)
 }
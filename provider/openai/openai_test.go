@@ -3,12 +3,14 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/llmx-ai/llmx"
+	openai "github.com/sashabaranov/go-openai"
 )
 
 func TestNewOpenAIProvider(t *testing.T) {
@@ -262,7 +264,10 @@ func TestConvertRequest(t *testing.T) {
 			},
 		}
 
-		openaiReq := p.convertRequest(req)
+		openaiReq, err := p.convertRequest(req)
+		if err != nil {
+			t.Fatalf("convertRequest() error = %v", err)
+		}
 
 		if openaiReq.Model != "gpt-4" {
 			t.Errorf("expected model 'gpt-4', got %s", openaiReq.Model)
@@ -296,7 +301,10 @@ func TestConvertRequest(t *testing.T) {
 			},
 		}
 
-		openaiReq := p.convertRequest(req)
+		openaiReq, err := p.convertRequest(req)
+		if err != nil {
+			t.Fatalf("convertRequest() error = %v", err)
+		}
 
 		if openaiReq.Temperature != 0.7 {
 			t.Errorf("expected temperature 0.7, got %f", openaiReq.Temperature)
@@ -318,12 +326,176 @@ func TestConvertRequest(t *testing.T) {
 			},
 		}
 
-		openaiReq := p.convertRequest(req)
+		openaiReq, err := p.convertRequest(req)
+		if err != nil {
+			t.Fatalf("convertRequest() error = %v", err)
+		}
 
 		if openaiReq.MaxTokens != 1000 {
 			t.Errorf("expected max_tokens 1000, got %d", openaiReq.MaxTokens)
 		}
 	})
+
+	t.Run("with request options", func(t *testing.T) {
+		seed := 42
+		parallel := false
+		req := &llmx.ChatRequest{
+			Model: "gpt-4",
+			Messages: []llmx.Message{
+				{
+					Role: llmx.RoleUser,
+					Content: []llmx.ContentPart{
+						llmx.TextPart{Text: "Hello"},
+					},
+				},
+			},
+		}
+		req.SetProviderOptions(RequestOptions{
+			Seed:              &seed,
+			LogitBias:         map[string]int{"1639": -100},
+			User:              "user-1",
+			ParallelToolCalls: &parallel,
+			ResponseFormat: &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+			},
+		})
+
+		openaiReq, err := p.convertRequest(req)
+		if err != nil {
+			t.Fatalf("convertRequest() error = %v", err)
+		}
+
+		if openaiReq.Seed == nil || *openaiReq.Seed != 42 {
+			t.Errorf("expected seed 42, got %v", openaiReq.Seed)
+		}
+		if openaiReq.LogitBias["1639"] != -100 {
+			t.Errorf("expected logit bias -100, got %v", openaiReq.LogitBias)
+		}
+		if openaiReq.User != "user-1" {
+			t.Errorf("expected user 'user-1', got %s", openaiReq.User)
+		}
+		if openaiReq.ParallelToolCalls != parallel {
+			t.Errorf("expected parallel_tool_calls false, got %v", openaiReq.ParallelToolCalls)
+		}
+		if openaiReq.ResponseFormat == nil || openaiReq.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeJSONObject {
+			t.Errorf("expected json_object response format, got %v", openaiReq.ResponseFormat)
+		}
+	})
+}
+
+func TestConvertRequest_Tools(t *testing.T) {
+	parallel := false
+	req := &llmx.ChatRequest{
+		Model: "gpt-4",
+		Messages: []llmx.Message{
+			{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather in Paris?"}}},
+			{
+				Role:      llmx.RoleAssistant,
+				ToolCalls: []llmx.ToolCall{{ID: "call_1", Name: "get_weather", Arguments: json.RawMessage(`{"city":"Paris"}`)}},
+			},
+			{Role: llmx.RoleTool, Content: []llmx.ContentPart{llmx.ToolResultPart{ToolCallID: "call_1", Result: "sunny"}}},
+		},
+		Tools:             []llmx.Tool{{Name: "get_weather", Description: "Get the weather"}},
+		ToolChoice:        llmx.ForceTool("get_weather"),
+		ParallelToolCalls: &parallel,
+	}
+
+	openaiReq, err := ConvertRequest(req)
+	if err != nil {
+		t.Fatalf("ConvertRequest() error = %v", err)
+	}
+
+	if len(openaiReq.Tools) != 1 || openaiReq.Tools[0].Function.Name != "get_weather" {
+		t.Fatalf("expected get_weather tool, got %+v", openaiReq.Tools)
+	}
+	choice, ok := openaiReq.ToolChoice.(openai.ToolChoice)
+	if !ok || choice.Function.Name != "get_weather" {
+		t.Errorf("expected function tool choice, got %#v", openaiReq.ToolChoice)
+	}
+	if openaiReq.ParallelToolCalls != false {
+		t.Errorf("expected parallel_tool_calls false, got %v", openaiReq.ParallelToolCalls)
+	}
+
+	if len(openaiReq.Messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(openaiReq.Messages))
+	}
+	if calls := openaiReq.Messages[1].ToolCalls; len(calls) != 1 || calls[0].ID != "call_1" {
+		t.Errorf("expected assistant tool call call_1, got %+v", calls)
+	}
+	if msg := openaiReq.Messages[2]; msg.Role != openai.ChatMessageRoleTool || msg.ToolCallID != "call_1" || msg.Content != "sunny" {
+		t.Errorf("unexpected tool message %+v", msg)
+	}
+
+	t.Run("mode", func(t *testing.T) {
+		req.ToolChoice = &llmx.ToolChoice{Mode: llmx.ToolChoiceRequired}
+		openaiReq, err := ConvertRequest(req)
+		if err != nil {
+			t.Fatalf("ConvertRequest() error = %v", err)
+		}
+		if got := openaiReq.ToolChoice; got != "required" {
+			t.Errorf("expected tool_choice 'required', got %#v", got)
+		}
+	})
+}
+
+func TestConvertResponse_ToolCalls(t *testing.T) {
+	resp, err := ConvertResponse(&openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role: openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ToolCall{{
+					ID:       "call_1",
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+				}},
+			},
+			FinishReason: openai.FinishReasonToolCalls,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("ConvertResponse() error = %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	if call := resp.ToolCalls[0]; call.ID != "call_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call %+v", call)
+	}
+}
+
+func TestRequestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    RequestOptions
+		wantErr bool
+	}{
+		{
+			name: "empty options",
+			opts: RequestOptions{},
+		},
+		{
+			name:    "logit bias out of range",
+			opts:    RequestOptions{LogitBias: map[string]int{"1639": 101}},
+			wantErr: true,
+		},
+		{
+			name: "json schema without schema",
+			opts: RequestOptions{ResponseFormat: &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
 }
 
 func TestConvertError(t *testing.T) {
@@ -339,7 +511,7 @@ func TestConvertError(t *testing.T) {
 	p := provider.(*OpenAIProvider)
 
 	t.Run("generic error", func(t *testing.T) {
-		err := p.convertError(http.ErrServerClosed)
+		err := p.convertError(context.Background(), http.ErrServerClosed)
 
 		llmxErr, ok := err.(llmx.Error)
 		if !ok {
@@ -351,3 +523,143 @@ func TestConvertError(t *testing.T) {
 		}
 	})
 }
+
+func TestOpenAIProvider_ChatMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_meta")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "59")
+		w.Header().Set("X-Ratelimit-Remaining-Tokens", "149984")
+		w.Header().Set("X-Ratelimit-Reset-Requests", "1s")
+		io.WriteString(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"gpt-4o","system_fingerprint":"fp_44709d6fcb",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"length"}],`+
+			`"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	}))
+	defer server.Close()
+
+	p, err := NewOpenAIProvider(map[string]interface{}{"api_key": "test-key", "base_url": server.URL})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	respInterface, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	resp := respInterface.(*llmx.ChatResponse)
+
+	if resp.FinishReason != llmx.FinishReasonLength || resp.RawFinishReason != "length" {
+		t.Errorf("unexpected finish reason %q (raw %q)", resp.FinishReason, resp.RawFinishReason)
+	}
+	m := resp.Metadata
+	if m.RequestID != "req_meta" || m.SystemFingerprint != "fp_44709d6fcb" {
+		t.Errorf("unexpected metadata %+v", m)
+	}
+	if m.RateLimit == nil || m.RateLimit.RemainingRequests != 59 || m.RateLimit.RemainingTokens != 149984 ||
+		m.RateLimit.ResetRequests != time.Second || m.RateLimit.LimitTokens != -1 {
+		t.Errorf("unexpected rate limit %+v", m.RateLimit)
+	}
+}
+
+func TestOpenAIProvider_ChatChoices(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"chatcmpl-2","object":"chat.completion","created":1700000000,"model":"gpt-4o",`+
+			`"choices":[`+
+			`{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop",`+
+			`"logprobs":{"content":[{"token":"Hi","logprob":-0.1,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":-0.1,"bytes":[72,105]},{"token":"Hey","logprob":-2.5,"bytes":[72,101,121]}]}]}},`+
+			`{"index":1,"message":{"role":"assistant","content":"Hey"},"finish_reason":"length",`+
+			`"logprobs":{"content":[{"token":"Hey","logprob":-2.5,"bytes":[72,101,121],"top_logprobs":[]}]}}],`+
+			`"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)
+	}))
+	defer server.Close()
+
+	p, err := NewOpenAIProvider(map[string]interface{}{"api_key": "test-key", "base_url": server.URL})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	n, topLogprobs, seed := 2, 2, 42
+	respInterface, err := p.Chat(context.Background(), &llmx.ChatRequest{
+		Model:       "gpt-4o",
+		Messages:    []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Hi"}}}},
+		N:           &n,
+		Logprobs:    true,
+		TopLogprobs: &topLogprobs,
+		Seed:        &seed,
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	resp := respInterface.(*llmx.ChatResponse)
+
+	if body["n"] != float64(2) || body["logprobs"] != true || body["top_logprobs"] != float64(2) || body["seed"] != float64(42) {
+		t.Errorf("unexpected sampling parameters in request %v", body)
+	}
+	if len(resp.Choices) != 2 {
+		t.Fatalf("expected 2 choices, got %d", len(resp.Choices))
+	}
+	if resp.Content != "Hi" || resp.FinishReason != llmx.FinishReasonStop {
+		t.Errorf("expected top-level fields of the first choice, got %q %q", resp.Content, resp.FinishReason)
+	}
+	second := resp.Choices[1]
+	if second.Index != 1 || second.Content != "Hey" || second.FinishReason != llmx.FinishReasonLength {
+		t.Errorf("unexpected second choice %+v", second)
+	}
+	logprobs := resp.Choices[0].Logprobs
+	if len(logprobs) != 1 || logprobs[0].Token != "Hi" || logprobs[0].Logprob != -0.1 || string(logprobs[0].Bytes) != "Hi" {
+		t.Fatalf("unexpected logprobs %+v", logprobs)
+	}
+	if len(logprobs[0].TopLogprobs) != 2 || logprobs[0].TopLogprobs[1].Token != "Hey" {
+		t.Errorf("unexpected top logprobs %+v", logprobs[0].TopLogprobs)
+	}
+}
+
+func TestOpenAIProvider_StreamToolCalls(t *testing.T) {
+	chunks := []string{
+		`{"id":"c","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"id":"c","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			io.WriteString(w, "data: "+chunk+"\n\n")
+		}
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p, err := NewOpenAIProvider(map[string]interface{}{"api_key": "test-key", "base_url": server.URL})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	streamInterface, err := p.StreamChat(context.Background(), &llmx.ChatRequest{
+		Model:    "gpt-4o",
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "Weather?"}}}},
+	})
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+	resp, err := streamInterface.(*llmx.ChatStream).Accumulate()
+	if err != nil {
+		t.Fatalf("Accumulate() error = %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %+v", resp.ToolCalls)
+	}
+	call := resp.ToolCalls[0]
+	if call.ID != "call_1" || call.Name != "get_weather" || string(call.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("unexpected tool call %+v (arguments %s)", call, call.Arguments)
+	}
+	if resp.FinishReason != llmx.FinishReasonToolCalls || len(resp.Choices) != 1 || len(resp.Choices[0].ToolCalls) != 1 {
+		t.Errorf("unexpected finish reason %q or choices %+v", resp.FinishReason, resp.Choices)
+	}
+}
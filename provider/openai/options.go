@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"fmt"
+
+	"github.com/llmx-ai/llmx"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RequestOptions holds OpenAI-specific request parameters. Attach them with
+// ChatRequest.SetProviderOptions. OpenAI-compatible providers built on this
+// package (groq, deepseek, ollama, ...) read the same options.
+type RequestOptions struct {
+	// Seed makes sampling deterministic on a best-effort basis; it
+	// overrides ChatRequest.Seed
+	Seed *int `json:"seed,omitempty"`
+
+	// LogitBias maps token IDs (as strings) to a bias between -100 and 100
+	LogitBias map[string]int `json:"logit_bias,omitempty"`
+
+	// User identifies the end user for abuse monitoring
+	User string `json:"user,omitempty"`
+
+	// ParallelToolCalls enables or disables parallel function calling
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// ResponseFormat selects text, JSON object or JSON schema output
+	ResponseFormat *openai.ChatCompletionResponseFormat `json:"response_format,omitempty"`
+}
+
+// ProviderName implements llmx.ProviderRequestOptions
+func (o RequestOptions) ProviderName() string {
+	return "openai"
+}
+
+// Validate checks option values before a request is sent
+func (o RequestOptions) Validate() error {
+	for token, bias := range o.LogitBias {
+		if bias < -100 || bias > 100 {
+			return fmt.Errorf("logit bias for token %s must be between -100 and 100, got %d", token, bias)
+		}
+	}
+
+	if o.ResponseFormat != nil {
+		switch o.ResponseFormat.Type {
+		case openai.ChatCompletionResponseFormatTypeText, openai.ChatCompletionResponseFormatTypeJSONObject:
+		case openai.ChatCompletionResponseFormatTypeJSONSchema:
+			if o.ResponseFormat.JSONSchema == nil {
+				return fmt.Errorf("response format json_schema requires a schema")
+			}
+		default:
+			return fmt.Errorf("unsupported response format type %q", o.ResponseFormat.Type)
+		}
+	}
+
+	return nil
+}
+
+// applyRequestOptions copies the OpenAI options attached to req onto an
+// OpenAI chat completion request
+func applyRequestOptions(openaiReq *openai.ChatCompletionRequest, req *llmx.ChatRequest) {
+	opts, ok := llmx.ProviderOptionsFor[RequestOptions](req)
+	if !ok {
+		return
+	}
+
+	if opts.Seed != nil {
+		seed := *opts.Seed
+		openaiReq.Seed = &seed
+	}
+	if len(opts.LogitBias) > 0 {
+		openaiReq.LogitBias = opts.LogitBias
+	}
+	if opts.User != "" {
+		openaiReq.User = opts.User
+	}
+	if opts.ParallelToolCalls != nil {
+		openaiReq.ParallelToolCalls = *opts.ParallelToolCalls
+	}
+	if opts.ResponseFormat != nil {
+		openaiReq.ResponseFormat = opts.ResponseFormat
+	}
+}
@@ -23,6 +23,8 @@ func (p *OpenAIProvider) handleStream(
 		Type: core.EventTypeStart,
 	})
 
+	var toolCalls ToolCalls
+
 	// Use a channel for receiving stream responses to support cancellation
 	type streamResult struct {
 		response openai.ChatCompletionStreamResponse
@@ -50,16 +52,23 @@ func (p *OpenAIProvider) handleStream(
 		case result := <-resultChan:
 			if result.err == io.EOF {
 				// Stream finished
+				toolCalls.Send(chatStream)
 				chatStream.SendEvent(core.StreamEvent{
 					Type: core.EventTypeFinish,
 				})
 				return
 			}
 			if result.err != nil {
-				chatStream.SendError(p.convertError(result.err))
+				chatStream.SendError(p.convertError(ctx, result.err))
 				return
 			}
 
+			if fingerprint := result.response.SystemFingerprint; fingerprint != "" {
+				chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+					m.SystemFingerprint = fingerprint
+				})
+			}
+
 			// Process response chunks
 			for _, choice := range result.response.Choices {
 				// Check context before processing each chunk
@@ -78,25 +87,12 @@ func (p *OpenAIProvider) handleStream(
 					})
 				}
 
-				// Tool calls
-				if len(choice.Delta.ToolCalls) > 0 {
-					for _, toolCall := range choice.Delta.ToolCalls {
-						// For now, send full tool call (can be optimized later)
-						if toolCall.Function.Name != "" {
-							chatStream.SendEvent(core.StreamEvent{
-								Type: core.EventTypeToolCall,
-								Data: map[string]interface{}{
-									"id":   toolCall.ID,
-									"name": toolCall.Function.Name,
-									"args": toolCall.Function.Arguments,
-								},
-							})
-						}
-					}
-				}
+				// Tool calls are sent once their arguments are complete
+				toolCalls.Add(choice.Delta.ToolCalls)
 
 				// Check finish reason
 				if choice.FinishReason != "" {
+					toolCalls.Send(chatStream)
 					chatStream.SendEvent(core.StreamEvent{
 						Type: core.EventTypeFinish,
 						Data: string(choice.FinishReason),
@@ -106,3 +102,57 @@ func (p *OpenAIProvider) handleStream(
 		}
 	}
 }
+
+// ToolCalls gathers the tool calls of a chat completion stream, whose
+// arguments arrive in pieces, so each is sent complete
+type ToolCalls struct {
+	calls []openai.ToolCall
+}
+
+// Add merges the tool call deltas of a chunk
+func (t *ToolCalls) Add(deltas []openai.ToolCall) {
+	for _, delta := range deltas {
+		call := t.find(delta)
+		if call == nil {
+			t.calls = append(t.calls, delta)
+			continue
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// find returns the call a delta continues: the one with its index or,
+// without indexes, the last one unless the delta starts a call
+func (t *ToolCalls) find(delta openai.ToolCall) *openai.ToolCall {
+	if delta.Index != nil {
+		for i := range t.calls {
+			if index := t.calls[i].Index; index != nil && *index == *delta.Index {
+				return &t.calls[i]
+			}
+		}
+		return nil
+	}
+	if delta.ID != "" || len(t.calls) == 0 {
+		return nil
+	}
+	return &t.calls[len(t.calls)-1]
+}
+
+// Send sends the gathered tool calls to chatStream and forgets them
+func (t *ToolCalls) Send(chatStream *llmx.ChatStream) {
+	for _, call := range t.calls {
+		chatStream.SendEvent(core.StreamEvent{
+			Type: core.EventTypeToolCall,
+			Data: map[string]interface{}{
+				"id":   call.ID,
+				"name": call.Function.Name,
+				"args": call.Function.Arguments,
+			},
+		})
+	}
+	t.calls = nil
+}
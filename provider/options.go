@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// OptionValidator is implemented by providers that validate their options
+// before they are created, so that a misconfigured client fails in
+// Config.Validate with the offending key instead of in the factory
+type OptionValidator interface {
+	// Options declares the options the provider reads
+	Options() OptionSpec
+
+	// ValidateOptions checks opts, returning an *OptionError for the
+	// first invalid option
+	ValidateOptions(opts map[string]interface{}) error
+}
+
+// OptionSpec declares the required and optional options of a provider. It
+// implements OptionValidator for providers that need no checks beyond the
+// presence of the required options and the absence of undeclared ones.
+type OptionSpec struct {
+	// Required options must be set to a non-empty value
+	Required []string
+
+	// Optional options are read when set
+	Optional []string
+
+	// AnyOptions accepts options beyond Required and Optional, for
+	// providers whose options are not declared
+	AnyOptions bool
+
+	// Credential is the option holding the secret a CredentialProvider
+	// can supply in its place, usually "api_key"; empty if the provider
+	// takes no such secret
+	Credential string
+
+	// CredentialHeader is the request header carrying the credential,
+	// with CredentialPrefix before it, e.g. "Authorization" and "Bearer ".
+	// Empty for providers that sign requests or exchange the credential
+	// for a token, whose credential can't be replaced per request.
+	CredentialHeader string
+	CredentialPrefix string
+
+	// Check validates the options after the required ones were found, if
+	// set
+	Check func(opts map[string]interface{}) error
+}
+
+// Options returns s
+func (s OptionSpec) Options() OptionSpec {
+	return s
+}
+
+// ValidateOptions checks that the required options are set and that the
+// others are declared, then runs Check. OptHTTPClient is an option of
+// every provider.
+func (s OptionSpec) ValidateOptions(opts map[string]interface{}) error {
+	for _, key := range s.Required {
+		if !HasOption(opts, key) {
+			return &OptionError{Key: key, Message: "is required"}
+		}
+	}
+	if !s.AnyOptions {
+		keys := make([]string, 0, len(opts))
+		for key := range opts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if key != OptHTTPClient && !slices.Contains(s.Required, key) && !slices.Contains(s.Optional, key) {
+				return &OptionError{Key: key, Message: "is not an option of the provider"}
+			}
+		}
+	}
+	if s.Check != nil {
+		return s.Check(opts)
+	}
+	return nil
+}
+
+// HasOption reports whether opts holds a non-empty value at key
+func HasOption(opts map[string]interface{}, key string) bool {
+	switch v := opts[key].(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+// OptionError reports an invalid provider option
+type OptionError struct {
+	Key     string
+	Message string
+}
+
+// Error returns the option key followed by the message
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("%s %s", e.Key, e.Message)
+}
+
+var (
+	validators   = make(map[string]OptionValidator)
+	validatorsMu sync.RWMutex
+)
+
+// RegisterOptions registers the option validator of a provider, usually
+// next to its factory
+func RegisterOptions(name string, validator OptionValidator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = validator
+}
+
+// Validator returns the option validator registered for a provider
+func Validator(name string) (OptionValidator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+	validator, ok := validators[name]
+	return validator, ok
+}
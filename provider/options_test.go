@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOptionSpec_ValidateOptions(t *testing.T) {
+	spec := OptionSpec{
+		Required: []string{"api_key", "endpoint"},
+		Optional: []string{"region"},
+		Check: func(opts map[string]interface{}) error {
+			if opts["endpoint"] == "http://insecure" {
+				return &OptionError{Key: "endpoint", Message: "must use https"}
+			}
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		opts map[string]interface{}
+		want string
+	}{
+		{"missing", map[string]interface{}{"endpoint": "https://x"}, "api_key"},
+		{"empty", map[string]interface{}{"api_key": "", "endpoint": "https://x"}, "api_key"},
+		{"check", map[string]interface{}{"api_key": "k", "endpoint": "http://insecure"}, "endpoint"},
+		{"unknown", map[string]interface{}{"api_key": "k", "endpoint": "https://x", "regoin": "eu"}, "regoin"},
+		{"valid", map[string]interface{}{"api_key": "k", "endpoint": "https://x", "region": "eu", OptHTTPClient: nil}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := spec.ValidateOptions(tt.opts)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ValidateOptions() error = %v", err)
+				}
+				return
+			}
+			var optErr *OptionError
+			if !errors.As(err, &optErr) || optErr.Key != tt.want {
+				t.Errorf("ValidateOptions() error = %v, want one for %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionSpec_AnyOptions(t *testing.T) {
+	spec := OptionSpec{Required: []string{"api_key"}, AnyOptions: true}
+	if err := spec.ValidateOptions(map[string]interface{}{"api_key": "k", "organization": "org"}); err != nil {
+		t.Errorf("ValidateOptions() error = %v, want undeclared options accepted", err)
+	}
+}
+
+func TestRegisterOptions(t *testing.T) {
+	RegisterOptions("test-options", OptionSpec{Required: []string{"token"}})
+
+	validator, ok := Validator("test-options")
+	if !ok {
+		t.Fatal("Expected test-options validator to be registered")
+	}
+	if got := validator.Options().Required; len(got) != 1 || got[0] != "token" {
+		t.Errorf("Required = %v", got)
+	}
+	if _, ok := Validator("nonexistent"); ok {
+		t.Error("Expected no validator for nonexistent provider")
+	}
+}
@@ -30,14 +30,19 @@ type Provider interface {
 
 // Features represents the features supported by a provider
 type Features struct {
-	Streaming     bool
-	ToolCalling   bool
-	Vision        bool
-	JSONMode      bool
-	ReasoningMode bool // Claude thinking
-	CacheControl  bool // Prompt caching
-	MultiModal    bool
-	Embedding     bool
+	Streaming         bool
+	ToolCalling       bool
+	ToolChoice        bool // none/required/specific-function tool choice
+	ParallelToolCalls bool // parallel tool calls can be disabled
+	MultipleChoices   bool // n > 1 alternative completions per request
+	Logprobs          bool // output token log probabilities
+	Seed              bool // seeded sampling
+	Vision            bool
+	JSONMode          bool
+	ReasoningMode     bool // Claude thinking
+	CacheControl      bool // Prompt caching
+	MultiModal        bool
+	Embedding         bool
 }
 
 // Model represents a model supported by a provider
@@ -23,8 +23,18 @@ type TongyiProvider struct {
 	*openai.OpenAIProvider
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("tongyi", NewTongyiProvider)
+	provider.RegisterOptions("tongyi", options)
 }
 
 // NewTongyiProvider creates a new Tongyi provider
@@ -41,8 +51,9 @@ func NewTongyiProvider(opts map[string]interface{}) (provider.Provider, error) {
 
 	// Tongyi compatible mode uses OpenAI API format
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -105,10 +116,12 @@ func (p *TongyiProvider) SupportedModels() []provider.Model {
 // SupportedFeatures returns the features supported by Tongyi
 func (p *TongyiProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:   true,
-		ToolCalling: true,
-		Vision:      true, // qwen-vl models support vision
-		JSONMode:    true,
+		Streaming:         true,
+		ToolCalling:       true,
+		ToolChoice:        true,
+		ParallelToolCalls: true,
+		Vision:            true, // qwen-vl models support vision
+		JSONMode:          true,
 		// SystemPrompt not needed
 	}
 }
@@ -20,8 +20,17 @@ type VLLMProvider struct {
 	*openai.OpenAIProvider // Embed OpenAI provider for API compatibility
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Optional:         []string{"base_url", "api_key"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("vllm", NewVLLMProvider)
+	provider.RegisterOptions("vllm", options)
 }
 
 // NewVLLMProvider creates a new vLLM provider
@@ -39,8 +48,9 @@ func NewVLLMProvider(opts map[string]interface{}) (provider.Provider, error) {
 
 	// Create OpenAI-compatible provider
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -76,10 +86,14 @@ func (p *VLLMProvider) SupportedModels() []provider.Model {
 // SupportedFeatures returns the features supported by vLLM
 func (p *VLLMProvider) SupportedFeatures() provider.Features {
 	return provider.Features{
-		Streaming:   true,
-		ToolCalling: true,  // vLLM supports function calling
-		Vision:      false, // Depends on deployed model
-		JSONMode:    true,
+		Streaming:       true,
+		ToolCalling:     true, // vLLM supports function calling
+		ToolChoice:      true, // Named and required choices via guided decoding
+		MultipleChoices: true, // Parallel sampling with n
+		Logprobs:        true,
+		Seed:            true,
+		Vision:          false, // Depends on deployed model
+		JSONMode:        true,
 		// SystemPrompt not needed
 	}
 }
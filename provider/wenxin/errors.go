@@ -0,0 +1,89 @@
+package wenxin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// errorStatus maps Wenxin error codes to the matching HTTP status
+var errorStatus = map[int]int{
+	1:      http.StatusInternalServerError, // Unknown error
+	2:      http.StatusServiceUnavailable,  // Service temporarily unavailable
+	4:      http.StatusTooManyRequests,     // Cluster over capacity
+	6:      http.StatusForbidden,           // No permission for the API
+	13:     http.StatusUnauthorized,        // Invalid token
+	14:     http.StatusUnauthorized,        // IAM certification failed
+	15:     http.StatusForbidden,           // App missing or creation failed
+	17:     http.StatusTooManyRequests,     // Daily request limit reached
+	18:     http.StatusTooManyRequests,     // QPS limit reached
+	19:     http.StatusTooManyRequests,     // Total request limit reached
+	100:    http.StatusUnauthorized,        // Invalid client
+	110:    http.StatusUnauthorized,        // Access token invalid
+	111:    http.StatusUnauthorized,        // Access token expired
+	336000: http.StatusInternalServerError, // Service internal error
+	336001: http.StatusBadRequest,          // Invalid argument
+	336002: http.StatusBadRequest,          // Invalid JSON
+	336003: http.StatusBadRequest,          // Invalid parameter
+	336006: http.StatusBadRequest,          // Messages violate the alternation rules
+	336007: http.StatusBadRequest,          // Question too long
+	336100: http.StatusServiceUnavailable,  // Service busy
+	336103: http.StatusBadRequest,          // Messages too long
+	336501: http.StatusTooManyRequests,     // RPM limit reached
+	336502: http.StatusTooManyRequests,     // TPM limit reached
+}
+
+// errorCodes name the Wenxin error codes that ClassifyError cannot tell
+// from their status alone
+var errorCodes = map[int]string{
+	17:     "insufficient_quota",
+	19:     "insufficient_quota",
+	336007: "context_length_exceeded",
+	336103: "context_length_exceeded",
+}
+
+// convertError converts a failed HTTP response to an llmx error
+func (p *WenxinProvider) convertError(header http.Header, body []byte, statusCode int) error {
+	info := llmx.ErrorInfo{
+		Provider:   "wenxin",
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf("HTTP %d", statusCode),
+		RequestID:  llmx.RequestIDFromHeader(header),
+		RetryAfter: llmx.ParseRetryAfter(header),
+		Body:       body,
+	}
+	var errResp map[string]interface{}
+	if json.Unmarshal(body, &errResp) == nil {
+		apiErrorInfo(errResp, &info)
+	}
+	return llmx.ClassifyError(info)
+}
+
+// convertAPIError converts an error reported in a response body, with an
+// HTTP 200 status, to an llmx error
+func (p *WenxinProvider) convertAPIError(resp map[string]interface{}) error {
+	info := llmx.ErrorInfo{Provider: "wenxin"}
+	info.Body, _ = json.Marshal(resp)
+	apiErrorInfo(resp, &info)
+	return llmx.ClassifyError(info)
+}
+
+// apiErrorInfo fills info from a Wenxin error body
+func apiErrorInfo(resp map[string]interface{}, info *llmx.ErrorInfo) {
+	code, ok := resp["error_code"].(float64)
+	if !ok {
+		return
+	}
+	errCode := int(code)
+	msg, _ := resp["error_msg"].(string)
+
+	info.Message = fmt.Sprintf("API error %d: %s", errCode, msg)
+	info.Code = errorCodes[errCode]
+	if status, ok := errorStatus[errCode]; ok {
+		info.StatusCode = status
+	} else if info.StatusCode < 400 {
+		info.StatusCode = 0
+	}
+}
@@ -0,0 +1,42 @@
+package wenxin
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+)
+
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+	}{
+		{"qps limit", 200, `{"error_code":18,"error_msg":"Open api qps request limit reached"}`, llmx.ErrRateLimit},
+		{"daily limit", 200, `{"error_code":17,"error_msg":"Open api daily request limit reached"}`, llmx.ErrQuotaExceeded},
+		{"token expired", 200, `{"error_code":111,"error_msg":"Access token expired"}`, llmx.ErrAuthentication},
+		{"too long", 200, `{"error_code":336103,"error_msg":"the length of messages must be less than 20000"}`, llmx.ErrContextLengthExceeded},
+		{"invalid argument", 200, `{"error_code":336003,"error_msg":"the role of message with odd index in the messages must be assistant"}`, llmx.ErrInvalidRequest},
+		{"busy", 200, `{"error_code":336100,"error_msg":"try again later"}`, llmx.ErrOverloaded},
+		{"unknown code", 200, `{"error_code":999999,"error_msg":"unexpected"}`, llmx.ErrInternal},
+		{"http error", 502, `<html>Bad Gateway</html>`, llmx.ErrProvider},
+	}
+
+	p := &WenxinProvider{}
+	header := http.Header{"X-Bce-Request-Id": {"bce-req-1"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.convertError(header, []byte(tt.body), tt.status)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("expected %v, got %T: %v", tt.sentinel, err, err)
+			}
+			var base *llmx.BaseError
+			if !errors.As(err, &base) || base.RequestID != "bce-req-1" || string(base.RawBody) != tt.body {
+				t.Errorf("expected request ID and body to be kept, got %+v", base)
+			}
+		})
+	}
+}
@@ -9,11 +9,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"sync"
 	"time"
 
 	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/auth"
 	"github.com/llmx-ai/llmx/core"
 	"github.com/llmx-ai/llmx/provider"
 )
@@ -27,16 +26,22 @@ const (
 
 // WenxinProvider implements the Provider interface for Baidu Wenxin
 type WenxinProvider struct {
-	apiKey      string
-	secretKey   string
-	accessToken string
-	tokenExpiry time.Time
-	tokenMutex  sync.RWMutex
-	httpClient  *http.Client
+	// tokens exchanges the key pair for access tokens
+	tokens *auth.ClientCredentials
+	// httpClient adds the access token to every request
+	httpClient *http.Client
+}
+
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required: []string{"api_key", "secret_key"},
+	// The key pair is exchanged for an access token
+	Credential: "api_key",
 }
 
 func init() {
 	provider.Register("wenxin", NewWenxinProvider)
+	provider.RegisterOptions("wenxin", options)
 }
 
 // NewWenxinProvider creates a new Wenxin provider
@@ -51,10 +56,21 @@ func NewWenxinProvider(opts map[string]interface{}) (provider.Provider, error) {
 		return nil, fmt.Errorf("wenxin: secret_key is required")
 	}
 
+	httpClient := provider.HTTPClient(opts)
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	tokens := &auth.ClientCredentials{
+		TokenURL:     tokenURL,
+		ClientID:     apiKey,
+		ClientSecret: secretKey,
+		AuthStyle:    auth.AuthInQuery,
+		HTTPClient:   httpClient,
+	}
 	return &WenxinProvider{
-		apiKey:     apiKey,
-		secretKey:  secretKey,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		tokens:     tokens,
+		httpClient: auth.NewClient(httpClient, auth.QueryParam("access_token", tokens)),
 	}, nil
 }
 
@@ -118,15 +134,8 @@ func (p *WenxinProvider) Chat(ctx context.Context, req interface{}) (interface{}
 		return nil, fmt.Errorf("wenxin: invalid request type")
 	}
 
-	// Get access token
-	token, err := p.getAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// Build endpoint URL
+	// Build endpoint URL, the access token is added by the HTTP client
 	endpoint := p.getEndpoint(chatReq.Model)
-	fullURL := fmt.Sprintf("%s?access_token=%s", endpoint, token)
 
 	// Convert request
 	wenxinReq := p.convertRequest(chatReq)
@@ -139,7 +148,7 @@ func (p *WenxinProvider) Chat(ctx context.Context, req interface{}) (interface{}
 	}
 
 	// Send request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(requestBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("wenxin: failed to create request: %w", err)
 	}
@@ -147,7 +156,7 @@ func (p *WenxinProvider) Chat(ctx context.Context, req interface{}) (interface{}
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("wenxin: request failed: %w", err)
+		return nil, llmx.ClassifyError(llmx.ErrorInfo{Provider: "wenxin", Message: "request failed", Cause: err})
 	}
 	defer resp.Body.Close()
 
@@ -159,7 +168,7 @@ func (p *WenxinProvider) Chat(ctx context.Context, req interface{}) (interface{}
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		return nil, p.convertError(body, resp.StatusCode)
+		return nil, p.convertError(resp.Header, body, resp.StatusCode)
 	}
 
 	// Parse response
@@ -174,7 +183,7 @@ func (p *WenxinProvider) Chat(ctx context.Context, req interface{}) (interface{}
 	}
 
 	// Convert to llmx response
-	return p.convertResponse(wenxinResp, chatReq.Model), nil
+	return p.convertResponse(wenxinResp, chatReq.Model, resp.Header), nil
 }
 
 // StreamChat sends a streaming chat request to Wenxin
@@ -184,15 +193,8 @@ func (p *WenxinProvider) StreamChat(ctx context.Context, req interface{}) (inter
 		return nil, fmt.Errorf("wenxin: invalid request type")
 	}
 
-	// Get access token
-	token, err := p.getAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// Build endpoint URL
+	// Build endpoint URL, the access token is added by the HTTP client
 	endpoint := p.getEndpoint(chatReq.Model)
-	fullURL := fmt.Sprintf("%s?access_token=%s", endpoint, token)
 
 	// Convert request
 	wenxinReq := p.convertRequest(chatReq)
@@ -205,7 +207,7 @@ func (p *WenxinProvider) StreamChat(ctx context.Context, req interface{}) (inter
 	}
 
 	// Send request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fullURL, bytes.NewReader(requestBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("wenxin: failed to create request: %w", err)
 	}
@@ -213,11 +215,20 @@ func (p *WenxinProvider) StreamChat(ctx context.Context, req interface{}) (inter
 
 	resp, err := p.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("wenxin: request failed: %w", err)
+		return nil, llmx.ClassifyError(llmx.ErrorInfo{Provider: "wenxin", Message: "request failed", Cause: err})
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, p.convertError(resp.Header, body, resp.StatusCode)
 	}
 
 	// Create llmx stream
 	chatStream := llmx.NewChatStream(ctx)
+	chatStream.UpdateMetadata(func(m *llmx.ResponseMetadata) {
+		*m = llmx.MetadataFromHeader(resp.Header)
+	})
 
 	// Start goroutine to handle streaming
 	go p.handleStream(ctx, resp, chatStream)
@@ -225,85 +236,15 @@ func (p *WenxinProvider) StreamChat(ctx context.Context, req interface{}) (inter
 	return chatStream, nil
 }
 
-// getAccessToken retrieves or refreshes the access token
-func (p *WenxinProvider) getAccessToken(ctx context.Context) (string, error) {
-	// Check if we have a valid cached token
-	p.tokenMutex.RLock()
-	if p.accessToken != "" && time.Now().Before(p.tokenExpiry) {
-		token := p.accessToken
-		p.tokenMutex.RUnlock()
-		return token, nil
-	}
-	p.tokenMutex.RUnlock()
-
-	// Need to refresh token
-	p.tokenMutex.Lock()
-	defer p.tokenMutex.Unlock()
-
-	// Double-check after acquiring write lock
-	if p.accessToken != "" && time.Now().Before(p.tokenExpiry) {
-		return p.accessToken, nil
-	}
-
-	// Request new token
-	params := url.Values{}
-	params.Set("grant_type", "client_credentials")
-	params.Set("client_id", p.apiKey)
-	params.Set("client_secret", p.secretKey)
-
-	fullURL := fmt.Sprintf("%s?%s", tokenURL, params.Encode())
-
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("wenxin: failed to create token request: %w", err)
-	}
-
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("wenxin: token request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("wenxin: failed to read token response: %w", err)
-	}
-
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int    `json:"expires_in"`
-		Error       string `json:"error"`
-		ErrorDesc   string `json:"error_description"`
-	}
-
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return "", fmt.Errorf("wenxin: failed to unmarshal token response: %w", err)
-	}
-
-	if tokenResp.Error != "" {
-		return "", fmt.Errorf("wenxin: token error: %s - %s", tokenResp.Error, tokenResp.ErrorDesc)
-	}
-
-	if tokenResp.AccessToken == "" {
-		return "", fmt.Errorf("wenxin: empty access token received")
-	}
-
-	// Cache token (expire 5 minutes early for safety)
-	p.accessToken = tokenResp.AccessToken
-	p.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn-300) * time.Second)
-
-	return p.accessToken, nil
-}
-
 // getEndpoint returns the API endpoint for a given model
 func (p *WenxinProvider) getEndpoint(model string) string {
 	// Map model IDs to endpoints
 	endpoints := map[string]string{
-		"ernie-4.0-8k":    baseURL + "/completions_pro",
-		"ernie-3.5-8k":    baseURL + "/completions",
-		"ernie-speed-8k":  baseURL + "/ernie_speed",
-		"ernie-lite-8k":   baseURL + "/eb-instant",
-		"ernie-tiny-8k":   baseURL + "/ernie-tiny-8k",
+		"ernie-4.0-8k":   baseURL + "/completions_pro",
+		"ernie-3.5-8k":   baseURL + "/completions",
+		"ernie-speed-8k": baseURL + "/ernie_speed",
+		"ernie-lite-8k":  baseURL + "/eb-instant",
+		"ernie-tiny-8k":  baseURL + "/ernie-tiny-8k",
 	}
 
 	if endpoint, ok := endpoints[model]; ok {
@@ -354,16 +295,23 @@ func (p *WenxinProvider) convertRequest(req *llmx.ChatRequest) map[string]interf
 }
 
 // convertResponse converts Wenxin response to llmx format
-func (p *WenxinProvider) convertResponse(resp map[string]interface{}, model string) *llmx.ChatResponse {
+func (p *WenxinProvider) convertResponse(resp map[string]interface{}, model string, header http.Header) *llmx.ChatResponse {
 	llmxResp := &llmx.ChatResponse{
-		Model:        model,
-		FinishReason: "stop",
+		Model:    model,
+		Metadata: llmx.MetadataFromHeader(header),
+	}
+	if id, ok := resp["id"].(string); ok {
+		llmxResp.ID = id
 	}
 
 	// Extract result
 	if result, ok := resp["result"].(string); ok {
 		llmxResp.Content = result
 	}
+	if reason, ok := resp["finish_reason"].(string); ok {
+		llmxResp.RawFinishReason = reason
+		llmxResp.FinishReason = llmx.NormalizeFinishReason(reason)
+	}
 
 	// Set usage if available
 	if usage, ok := resp["usage"].(map[string]interface{}); ok {
@@ -424,9 +372,10 @@ func (p *WenxinProvider) handleStream(ctx context.Context, resp *http.Response,
 
 			// Check if finished
 			if isEnd, ok := chunk["is_end"].(bool); ok && isEnd {
+				finishReason, _ := chunk["finish_reason"].(string)
 				chatStream.SendEvent(core.StreamEvent{
 					Type: core.EventTypeFinish,
-					Data: "stop",
+					Data: finishReason,
 				})
 				return
 			}
@@ -466,47 +415,3 @@ func convertTools(tools []llmx.Tool) []map[string]interface{} {
 	}
 	return wenxinTools
 }
-
-func (p *WenxinProvider) convertError(body []byte, statusCode int) error {
-	var errResp map[string]interface{}
-	json.Unmarshal(body, &errResp)
-
-	errMsg := fmt.Sprintf("wenxin: HTTP %d", statusCode)
-	if msg, ok := errResp["error_msg"].(string); ok {
-		errMsg = msg
-	}
-
-	if statusCode == 429 {
-		return llmx.NewRateLimitError(errMsg, 0)
-	} else if statusCode == 401 || statusCode == 403 {
-		return llmx.NewAuthenticationError(errMsg)
-	} else if statusCode == 404 {
-		return llmx.NewNotFoundError(errMsg, "model")
-	} else if statusCode >= 400 && statusCode < 500 {
-		return llmx.NewInvalidRequestError(errMsg, nil)
-	}
-
-	return llmx.NewInternalError(errMsg, nil)
-}
-
-func (p *WenxinProvider) convertAPIError(resp map[string]interface{}) error {
-	errCode := int(resp["error_code"].(float64))
-	errMsg := ""
-	if msg, ok := resp["error_msg"].(string); ok {
-		errMsg = msg
-	}
-
-	fullMsg := fmt.Sprintf("wenxin API error %d: %s", errCode, errMsg)
-
-	// Map error codes to llmx error types
-	switch errCode {
-	case 18, 19: // Rate limit errors
-		return llmx.NewRateLimitError(fullMsg, 0)
-	case 110, 111: // Auth errors
-		return llmx.NewAuthenticationError(fullMsg)
-	case 1, 2, 3, 4, 6: // Invalid request
-		return llmx.NewInvalidRequestError(fullMsg, nil)
-	default:
-		return llmx.NewInternalError(fullMsg, nil)
-	}
-}
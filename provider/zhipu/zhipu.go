@@ -23,8 +23,18 @@ type ZhipuProvider struct {
 	*openai.OpenAIProvider
 }
 
+// options declares the options of the provider, see provider.OptionSpec
+var options = provider.OptionSpec{
+	Required:         []string{"api_key"},
+	Optional:         []string{"base_url"},
+	Credential:       "api_key",
+	CredentialHeader: "Authorization",
+	CredentialPrefix: "Bearer ",
+}
+
 func init() {
 	provider.Register("zhipu", NewZhipuProvider)
+	provider.RegisterOptions("zhipu", options)
 }
 
 // NewZhipuProvider creates a new Zhipu provider
@@ -41,8 +51,9 @@ func NewZhipuProvider(opts map[string]interface{}) (provider.Provider, error) {
 
 	// Zhipu API is similar to OpenAI
 	openaiOpts := map[string]interface{}{
-		"api_key":  apiKey,
-		"base_url": baseURL,
+		"api_key":     apiKey,
+		"base_url":    baseURL,
+		"http_client": opts["http_client"],
 	}
 
 	openaiProvider, err := openai.NewOpenAIProvider(openaiOpts)
@@ -0,0 +1,128 @@
+package llmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ProviderRequestOptions is implemented by typed, provider-specific request
+// options such as openai.RequestOptions. Options are stored in
+// ChatRequest.ProviderOptions under the name returned by ProviderName, so a
+// request can carry options for several providers at once.
+type ProviderRequestOptions interface {
+	ProviderName() string
+}
+
+// ProviderOptionsDecoder decodes the JSON options of one provider
+type ProviderOptionsDecoder func(data []byte) (ProviderRequestOptions, error)
+
+var providerOptions = struct {
+	sync.RWMutex
+	decoders map[string]ProviderOptionsDecoder
+}{decoders: make(map[string]ProviderOptionsDecoder)}
+
+// RegisterProviderOptions registers T as the type of the options stored
+// under T's ProviderName, so that requests carrying them can be
+// unmarshalled. Provider packages register their options when imported.
+func RegisterProviderOptions[T ProviderRequestOptions]() {
+	var zero T
+	providerOptions.Lock()
+	defer providerOptions.Unlock()
+	providerOptions.decoders[zero.ProviderName()] = func(data []byte) (ProviderRequestOptions, error) {
+		var opts T
+		if err := json.Unmarshal(data, &opts); err != nil {
+			return nil, err
+		}
+		return opts, nil
+	}
+}
+
+// unmarshalProviderOptions decodes the options of each provider with the
+// type registered for it
+func unmarshalProviderOptions(raw map[string]json.RawMessage) (map[string]interface{}, error) {
+	options := make(map[string]interface{}, len(raw))
+	for name, data := range raw {
+		providerOptions.RLock()
+		decode, ok := providerOptions.decoders[name]
+		providerOptions.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("llmx: unknown provider options %q: import the provider package to register them", name)
+		}
+
+		opts, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("llmx: invalid %s options: %w", name, err)
+		}
+		options[name] = opts
+	}
+	return options, nil
+}
+
+// SetProviderOptions attaches typed provider options to the request,
+// replacing any options previously set for the same provider
+func (r *ChatRequest) SetProviderOptions(opts ...ProviderRequestOptions) {
+	if r.ProviderOptions == nil {
+		r.ProviderOptions = make(map[string]interface{}, len(opts))
+	}
+	for _, o := range opts {
+		r.ProviderOptions[o.ProviderName()] = o
+	}
+}
+
+// ProviderOptionsFor returns the options of type T attached to the request.
+// Both T and *T values are accepted.
+func ProviderOptionsFor[T ProviderRequestOptions](req *ChatRequest) (T, bool) {
+	var zero T
+	if req == nil {
+		return zero, false
+	}
+
+	switch v := req.ProviderOptions[zero.ProviderName()].(type) {
+	case T:
+		return v, true
+	case *T:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return zero, false
+}
+
+// ValidateProviderOptions checks that every entry holds typed options for the
+// provider named by its key. Options that implement Validate() error are
+// validated as well.
+func ValidateProviderOptions(options map[string]interface{}) error {
+	for key, value := range options {
+		opts, ok := value.(ProviderRequestOptions)
+		if !ok || isNilPointer(value) {
+			return NewInvalidRequestError(
+				fmt.Sprintf("unknown provider option %q: use typed options such as openai.RequestOptions", key),
+				map[string]interface{}{"key": key},
+			)
+		}
+
+		if name := opts.ProviderName(); name != key {
+			return NewInvalidRequestError(
+				fmt.Sprintf("provider option %q holds options for provider %q", key, name),
+				map[string]interface{}{"key": key},
+			)
+		}
+
+		if v, ok := value.(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return NewInvalidRequestError(
+					fmt.Sprintf("invalid %s options: %v", key, err),
+					map[string]interface{}{"key": key},
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func isNilPointer(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
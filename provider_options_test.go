@@ -0,0 +1,93 @@
+package llmx
+
+import "testing"
+
+type testProviderOptions struct {
+	Flag string
+}
+
+func (o testProviderOptions) ProviderName() string { return "mock" }
+
+func (o testProviderOptions) Validate() error {
+	if o.Flag == "bad" {
+		return NewInvalidRequestError("bad flag", nil)
+	}
+	return nil
+}
+
+func TestProviderOptionsFor(t *testing.T) {
+	t.Run("value", func(t *testing.T) {
+		req := &ChatRequest{}
+		req.SetProviderOptions(testProviderOptions{Flag: "on"})
+
+		opts, ok := ProviderOptionsFor[testProviderOptions](req)
+		if !ok {
+			t.Fatal("expected options to be found")
+		}
+		if opts.Flag != "on" {
+			t.Errorf("expected flag 'on', got %s", opts.Flag)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		req := &ChatRequest{}
+		req.SetProviderOptions(&testProviderOptions{Flag: "ptr"})
+
+		opts, ok := ProviderOptionsFor[testProviderOptions](req)
+		if !ok || opts.Flag != "ptr" {
+			t.Errorf("expected flag 'ptr', got %+v (found %v)", opts, ok)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, ok := ProviderOptionsFor[testProviderOptions](&ChatRequest{}); ok {
+			t.Error("expected no options")
+		}
+	})
+}
+
+func TestValidateProviderOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "nil",
+			options: nil,
+		},
+		{
+			name:    "typed options",
+			options: map[string]interface{}{"mock": testProviderOptions{}},
+		},
+		{
+			name:    "unknown key",
+			options: map[string]interface{}{"response_format": map[string]string{"type": "json_object"}},
+			wantErr: true,
+		},
+		{
+			name:    "mismatched key",
+			options: map[string]interface{}{"openai": testProviderOptions{}},
+			wantErr: true,
+		},
+		{
+			name:    "nil pointer",
+			options: map[string]interface{}{"mock": (*testProviderOptions)(nil)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid options",
+			options: map[string]interface{}{"mock": testProviderOptions{Flag: "bad"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProviderOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProviderOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// casAttempts is how many times an update is retried under contention
+const casAttempts = 16
+
+// Limit is a GCRA rate limit: Rate requests per Period on average, in
+// bursts of up to Burst
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// PerSecond returns a limit of rate requests a second in bursts of burst.
+// It panics if rate is not positive.
+func PerSecond(rate float64, burst int) Limit {
+	if !(rate > 0) {
+		panic(fmt.Sprintf("store: non-positive rate %v", rate))
+	}
+	return Limit{Rate: 1, Period: time.Duration(float64(time.Second) / rate), Burst: burst}
+}
+
+// Interval is the time a request takes of the limit
+func (l Limit) Interval() time.Duration {
+	return l.Period / time.Duration(max(l.Rate, 1))
+}
+
+// GCRAResult is the outcome of a GCRA request
+type GCRAResult struct {
+	// Allowed reports whether the request may proceed
+	Allowed bool
+	// RetryAfter is how long a refused request must wait
+	RetryAfter time.Duration
+	// Remaining is how many more requests fit now
+	Remaining int
+}
+
+// GCRA takes cost requests under limit from the state of key in s, with
+// the generic cell rate algorithm: key holds the theoretical arrival time
+// of the next request, which each request pushes back by its interval, and
+// a request is allowed while that time is less than a burst ahead. Replicas
+// sharing s share the limit, provided their clocks agree.
+func GCRA(ctx context.Context, s StateStore, key string, limit Limit, cost int, now time.Time) (GCRAResult, error) {
+	interval := limit.Interval()
+	tolerance := interval * time.Duration(max(limit.Burst, 1))
+
+	for attempt := 0; attempt < casAttempts; attempt++ {
+		stored, ok, err := s.Get(ctx, key)
+		if err != nil {
+			return GCRAResult{}, err
+		}
+
+		tat := now
+		if ok {
+			if nanos, err := strconv.ParseInt(string(stored), 10, 64); err == nil {
+				tat = time.Unix(0, nanos)
+			}
+			if tat.Before(now) {
+				tat = now
+			}
+		}
+
+		newTat := tat.Add(interval * time.Duration(cost))
+		if allowAt := newTat.Add(-tolerance); now.Before(allowAt) {
+			return GCRAResult{
+				RetryAfter: allowAt.Sub(now),
+				Remaining:  max(int((tolerance-tat.Sub(now))/interval), 0),
+			}, nil
+		}
+
+		var old []byte
+		if ok {
+			old = stored
+		}
+		value := []byte(strconv.FormatInt(newTat.UnixNano(), 10))
+		swapped, err := s.CompareAndSwap(ctx, key, old, value, newTat.Sub(now))
+		if err != nil {
+			return GCRAResult{}, err
+		}
+		if swapped {
+			return GCRAResult{
+				Allowed:   true,
+				Remaining: max(int((tolerance-newTat.Sub(now))/interval), 0),
+			}, nil
+		}
+	}
+	return GCRAResult{}, ErrConflict
+}
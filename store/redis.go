@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx/internal/resp"
+)
+
+// RedisOptions configures a RedisStore
+type RedisOptions struct {
+	// Addr is the host:port of the server
+	Addr string
+	// Password authenticates with AUTH, as Username if set
+	Username string
+	Password string
+	// DB is the database selected with SELECT
+	DB int
+
+	// DialTimeout limits connecting, 5s if zero
+	DialTimeout time.Duration
+	// Timeout limits each command without a context deadline, 1s if zero
+	Timeout time.Duration
+	// PoolSize is the number of idle connections kept, 10 if zero
+	PoolSize int
+}
+
+// RedisStore is a StateStore in a server speaking the Redis protocol
+// (RESP). CompareAndSwap uses WATCH and MULTI, so it needs no scripting.
+type RedisStore struct {
+	opts RedisOptions
+
+	mu     sync.Mutex
+	idle   []*redisConn
+	closed bool
+}
+
+// NewRedisStore creates a store on the server of opts. It connects on
+// first use.
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	return &RedisStore{opts: opts}
+}
+
+// RedisError is an error reply of the server
+type RedisError = resp.Error
+
+// Get returns the value of key
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	var ok bool
+	err := r.with(ctx, func(conn *redisConn) error {
+		var err error
+		value, ok, err = conn.get(key)
+		return err
+	})
+	return value, ok, err
+}
+
+// Set sets the value of key
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.with(ctx, func(conn *redisConn) error {
+		_, err := conn.do(setArgs(key, value, ttl)...)
+		return err
+	})
+}
+
+// CompareAndSwap sets the value of key if it is still old, watching key so
+// that the write is dropped if another client changes it first
+func (r *RedisStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	var swapped bool
+	err := r.with(ctx, func(conn *redisConn) error {
+		if _, err := conn.do("WATCH", key); err != nil {
+			return err
+		}
+		current, ok, err := conn.get(key)
+		if err != nil {
+			return err
+		}
+		if ok != (old != nil) || string(current) != string(old) {
+			_, err := conn.do("UNWATCH")
+			return err
+		}
+
+		if _, err := conn.do("MULTI"); err != nil {
+			return err
+		}
+		if _, err := conn.do(setArgs(key, value, ttl)...); err != nil {
+			conn.do("DISCARD")
+			return err
+		}
+		reply, err := conn.do("EXEC")
+		if err != nil {
+			return err
+		}
+		// EXEC replies nil when a watched key changed
+		swapped = reply != nil
+		return nil
+	})
+	return swapped, err
+}
+
+// Ping checks that the server is reachable
+func (r *RedisStore) Ping(ctx context.Context) error {
+	return r.with(ctx, func(conn *redisConn) error {
+		_, err := conn.do("PING")
+		return err
+	})
+}
+
+// Close closes the idle connections; connections in use close when they
+// are returned
+func (r *RedisStore) Close() error {
+	r.mu.Lock()
+	idle := r.idle
+	r.idle = nil
+	r.closed = true
+	r.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.Close()
+	}
+	return nil
+}
+
+// with runs fn on a pooled connection within the deadline of ctx, or the
+// store's timeout. Connections that failed are closed rather than reused.
+func (r *RedisStore) with(ctx context.Context, fn func(conn *redisConn) error) error {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.opts.Timeout)
+	}
+	conn.SetDeadline(deadline)
+
+	// A failed connection may be mid-transaction, so it is not reused
+	if err := fn(conn); err != nil {
+		conn.Close()
+		return err
+	}
+	r.release(conn)
+	return nil
+}
+
+// conn returns an idle connection, or dials a new one
+func (r *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errors.New("redis: store closed")
+	}
+	if n := len(r.idle); n > 0 {
+		conn := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return conn, nil
+	}
+	r.mu.Unlock()
+
+	dialer := net.Dialer{Timeout: r.opts.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", r.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn), w: bufio.NewWriter(netConn)}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.opts.Timeout)
+	}
+	conn.SetDeadline(deadline)
+	if err := r.handshake(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// handshake authenticates and selects the database
+func (r *RedisStore) handshake(conn *redisConn) error {
+	if r.opts.Password != "" {
+		args := []string{"AUTH", r.opts.Password}
+		if r.opts.Username != "" {
+			args = []string{"AUTH", r.opts.Username, r.opts.Password}
+		}
+		if _, err := conn.do(args...); err != nil {
+			return err
+		}
+	}
+	if r.opts.DB != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(r.opts.DB)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release returns conn to the pool, or closes it if the pool is full
+func (r *RedisStore) release(conn *redisConn) {
+	r.mu.Lock()
+	if r.closed || len(r.idle) >= r.opts.PoolSize {
+		r.mu.Unlock()
+		conn.Close()
+		return
+	}
+	r.idle = append(r.idle, conn)
+	r.mu.Unlock()
+}
+
+// setArgs returns the SET command of key, expiring after ttl if positive
+func setArgs(key string, value []byte, ttl time.Duration) []string {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	}
+	return args
+}
+
+// redisConn is a connection speaking RESP
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// get returns the value of key
+func (c *redisConn) get(key string) ([]byte, bool, error) {
+	reply, err := c.do("GET", key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+// do sends a command and reads its reply, see resp.Read
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	if err := resp.WriteCommand(c.w, args...); err != nil {
+		return nil, err
+	}
+	return resp.Read(c.r)
+}
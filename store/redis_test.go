@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/store/redistest"
+)
+
+func newTestRedis(t *testing.T) (*RedisStore, *redistest.Server) {
+	t.Helper()
+	server := redistest.NewServer()
+	t.Cleanup(server.Close)
+	r := NewRedisStore(RedisOptions{Addr: server.Addr()})
+	t.Cleanup(func() { r.Close() })
+	return r, server
+}
+
+func TestRedisStore(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newTestRedis(t)
+
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if _, ok, err := r.Get(ctx, "k"); ok || err != nil {
+		t.Fatalf("Get() = %v, %v for a missing key", ok, err)
+	}
+	if swapped, err := r.CompareAndSwap(ctx, "k", nil, []byte("1"), 0); !swapped || err != nil {
+		t.Fatalf("CompareAndSwap() = %v, %v, want a new key", swapped, err)
+	}
+	if swapped, _ := r.CompareAndSwap(ctx, "k", []byte("0"), []byte("2"), 0); swapped {
+		t.Error("CompareAndSwap() swapped a stale value")
+	}
+	if swapped, _ := r.CompareAndSwap(ctx, "k", []byte("1"), []byte("2"), 50*time.Millisecond); !swapped {
+		t.Error("CompareAndSwap() didn't swap a matching value")
+	}
+	if value, ok, _ := r.Get(ctx, "k"); !ok || string(value) != "2" {
+		t.Errorf("Get() = %q, %v, want 2", value, ok)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, ok, _ := r.Get(ctx, "k"); ok {
+		t.Error("Get() found an expired key")
+	}
+
+	if err := r.Set(ctx, "empty", nil, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if value, ok, _ := r.Get(ctx, "empty"); !ok || len(value) != 0 {
+		t.Errorf("Get() = %q, %v, want an empty value", value, ok)
+	}
+}
+
+func TestRedisStore_GCRAConcurrent(t *testing.T) {
+	r, _ := newTestRedis(t)
+	limit := Limit{Rate: 1, Period: time.Hour, Burst: 20}
+	now := time.Now()
+
+	// Concurrent clients, as from several replicas, share the burst
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := GCRA(context.Background(), r, "k", limit, 1, now)
+			if err != nil && !errors.Is(err, ErrConflict) {
+				t.Errorf("GCRA() error = %v", err)
+			}
+			if result.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got == 0 || got > 20 {
+		t.Errorf("%d requests allowed, want up to the burst of 20", got)
+	}
+}
+
+func TestRedisStore_Auth(t *testing.T) {
+	ctx := context.Background()
+	server := redistest.NewServer()
+	server.RequirePassword("secret")
+	defer server.Close()
+
+	r := NewRedisStore(RedisOptions{Addr: server.Addr(), Password: "wrong"})
+	defer r.Close()
+	var redisErr RedisError
+	if err := r.Ping(ctx); !errors.As(err, &redisErr) {
+		t.Errorf("Ping() error = %v, want a WRONGPASS reply", err)
+	}
+
+	r = NewRedisStore(RedisOptions{Addr: server.Addr(), Password: "secret", DB: 2})
+	defer r.Close()
+	if err := r.Ping(ctx); err != nil {
+		t.Errorf("Ping() error = %v", err)
+	}
+}
+
+func TestRedisStore_Unreachable(t *testing.T) {
+	r, server := newTestRedis(t)
+	ctx := context.Background()
+	if err := r.Set(ctx, "k", []byte("1"), 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	server.Close()
+	if _, _, err := r.Get(ctx, "k"); !IsUnavailable(err) {
+		t.Errorf("Get() error = %v with the server down, want one IsUnavailable reports", err)
+	}
+	for _, err := range []error{ErrConflict, RedisError("ERR wrong number of arguments"), context.Canceled} {
+		if IsUnavailable(err) {
+			t.Errorf("IsUnavailable(%v) = true", err)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+// Package redistest provides an in-process server speaking the Redis
+// protocol, for testing code using store.RedisStore without Redis. It
+// supports the commands RedisStore sends: PING, AUTH, SELECT, GET, SET
+// with EX, PX and NX, DEL, WATCH, UNWATCH, MULTI, EXEC and DISCARD.
+//
+//	server := redistest.NewServer()
+//	defer server.Close()
+//	shared := store.NewRedisStore(store.RedisOptions{Addr: server.Addr()})
+package redistest
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx/internal/resp"
+)
+
+// Server is a Redis protocol server keeping its data in memory
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	password string
+	data     map[string]entry
+	versions map[string]uint64
+	conns    map[net.Conn]struct{}
+	commands int
+	wg       sync.WaitGroup
+}
+
+// entry is a value and the time it expires, zero if it does not
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewServer starts a server on a local port. It panics if it cannot
+// listen, like httptest.NewServer.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("redistest: failed to listen: " + err.Error())
+	}
+	s := &Server{
+		listener: listener,
+		data:     make(map[string]entry),
+		versions: make(map[string]uint64),
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr returns the host:port of the server
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// RequirePassword makes clients connecting from now on authenticate with
+// password before other commands, like requirepass
+func (s *Server) RequirePassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// Commands returns the number of commands the server has run
+func (s *Server) Commands() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands
+}
+
+// Close stops the server and closes its connections
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// session is the state of a connection
+type session struct {
+	authenticated bool
+	watched       map[string]uint64
+	queued        [][]string // commands queued after MULTI
+	inMulti       bool
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	s.mu.Lock()
+	sess := &session{authenticated: s.password == ""}
+	s.mu.Unlock()
+	for {
+		request, err := resp.Read(r)
+		if err != nil {
+			return
+		}
+		args, err := commandArgs(request)
+		if err != nil {
+			resp.Write(w, resp.Error("ERR "+err.Error()))
+		} else {
+			resp.Write(w, s.run(sess, args))
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// commandArgs returns the arguments of a command sent as an array of bulk
+// strings
+func commandArgs(request interface{}) ([]string, error) {
+	items, ok := request.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("expected a command array")
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		data, ok := item.([]byte)
+		if !ok {
+			return nil, errors.New("expected bulk string arguments")
+		}
+		args[i] = string(data)
+	}
+	return args, nil
+}
+
+// run runs a command of sess and returns its reply
+func (s *Server) run(sess *session, args []string) interface{} {
+	name := strings.ToUpper(args[0])
+	if !sess.authenticated && name != "AUTH" {
+		return resp.Error("NOAUTH Authentication required.")
+	}
+
+	switch name {
+	case "MULTI":
+		if sess.inMulti {
+			return resp.Error("ERR MULTI calls can not be nested")
+		}
+		sess.inMulti = true
+		return "OK"
+	case "EXEC":
+		if !sess.inMulti {
+			return resp.Error("ERR EXEC without MULTI")
+		}
+		return s.exec(sess)
+	case "DISCARD":
+		if !sess.inMulti {
+			return resp.Error("ERR DISCARD without MULTI")
+		}
+		sess.inMulti, sess.queued, sess.watched = false, nil, nil
+		return "OK"
+	}
+	if sess.inMulti {
+		if name == "WATCH" {
+			return resp.Error("ERR WATCH inside MULTI is not allowed")
+		}
+		sess.queued = append(sess.queued, args)
+		return "QUEUED"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.command(sess, name, args[1:])
+}
+
+// exec runs the queued commands of sess atomically, unless a watched key
+// changed
+func (s *Server) exec(sess *session) interface{} {
+	queued, watched := sess.queued, sess.watched
+	sess.inMulti, sess.queued, sess.watched = false, nil, nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, version := range watched {
+		s.expire(key)
+		if s.versions[key] != version {
+			return []interface{}(nil)
+		}
+	}
+	replies := make([]interface{}, 0, len(queued))
+	for _, args := range queued {
+		replies = append(replies, s.command(sess, strings.ToUpper(args[0]), args[1:]))
+	}
+	return replies
+}
+
+// command runs a command, queued or not. s.mu must be held.
+func (s *Server) command(sess *session, name string, args []string) interface{} {
+	s.commands++
+
+	switch name {
+	case "PING":
+		if len(args) > 0 {
+			return []byte(args[0])
+		}
+		return "PONG"
+
+	case "AUTH":
+		if len(args) < 1 || len(args) > 2 {
+			return wrongArgs(name)
+		}
+		if s.password == "" {
+			return resp.Error("ERR AUTH <password> called without any password configured for the default user.")
+		}
+		if args[len(args)-1] != s.password {
+			return resp.Error("WRONGPASS invalid username-password pair or user is disabled.")
+		}
+		sess.authenticated = true
+		return "OK"
+
+	case "SELECT":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			return resp.Error("ERR value is not an integer or out of range")
+		}
+		return "OK"
+
+	case "GET":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		s.expire(args[0])
+		if e, ok := s.data[args[0]]; ok {
+			return e.value
+		}
+		return nil
+
+	case "SET":
+		return s.set(args)
+
+	case "DEL":
+		if len(args) < 1 {
+			return wrongArgs(name)
+		}
+		var deleted int64
+		for _, key := range args {
+			s.expire(key)
+			if _, ok := s.data[key]; ok {
+				delete(s.data, key)
+				s.versions[key]++
+				deleted++
+			}
+		}
+		return deleted
+
+	case "WATCH":
+		if len(args) < 1 {
+			return wrongArgs(name)
+		}
+		if sess.watched == nil {
+			sess.watched = make(map[string]uint64)
+		}
+		for _, key := range args {
+			s.expire(key)
+			sess.watched[key] = s.versions[key]
+		}
+		return "OK"
+
+	case "UNWATCH":
+		sess.watched = nil
+		return "OK"
+
+	default:
+		return resp.Error("ERR unknown command '" + strings.ToLower(name) + "'")
+	}
+}
+
+// set runs SET key value [EX seconds | PX milliseconds] [NX]. s.mu must be
+// held.
+func (s *Server) set(args []string) interface{} {
+	if len(args) < 2 {
+		return wrongArgs("SET")
+	}
+	key := args[0]
+	e := entry{value: []byte(args[1])}
+	nx := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return resp.Error("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return resp.Error("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Millisecond
+			if strings.ToUpper(args[i]) == "EX" {
+				unit = time.Second
+			}
+			e.expires = time.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return resp.Error("ERR syntax error")
+		}
+	}
+
+	s.expire(key)
+	if _, exists := s.data[key]; nx && exists {
+		return nil
+	}
+	s.data[key] = e
+	s.versions[key]++
+	return "OK"
+}
+
+// expire deletes key if it expired, which counts as a change for WATCH.
+// s.mu must be held.
+func (s *Server) expire(key string) {
+	if e, ok := s.data[key]; ok && !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		delete(s.data, key)
+		s.versions[key]++
+	}
+}
+
+func wrongArgs(name string) interface{} {
+	return resp.Error("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+}
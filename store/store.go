@@ -0,0 +1,151 @@
+// Package store holds state shared by the replicas of a service, so that
+// rate limits and circuit breakers apply to all of them together rather
+// than to each one: GCRA rate limits and the open state of breakers.
+//
+// A StateStore is a key-value store with compare-and-swap. MemoryStore
+// keeps state in the process, for a single replica or for tests;
+// RedisStore keeps it in Redis or any server speaking its protocol, such as
+// Valkey, KeyDB or Dragonfly:
+//
+//	shared := store.NewRedisStore(store.RedisOptions{Addr: "redis:6379"})
+//	limiter := middleware.NewGCRALimiter(shared, 10, 20)
+//
+// Callers choose with a FailurePolicy what happens to requests while the
+// store is unreachable.
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// StateStore is a key-value store shared by replicas. Values expire after
+// their TTL, if positive.
+type StateStore interface {
+	// Get returns the value of key, and false if it is missing
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set sets the value of key
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// CompareAndSwap sets the value of key if it is still old, nil for a
+	// missing key, and reports whether it did
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error)
+}
+
+// FailurePolicy decides what happens to a request when the store is
+// unreachable, see IsUnavailable. It does not cover other errors, such as
+// error replies or ErrConflict, which a store that answers would repeat.
+type FailurePolicy int
+
+const (
+	// FailOpen lets requests through, limited only by the replica's own
+	// state
+	FailOpen FailurePolicy = iota
+	// FailClosed refuses requests until the store is back
+	FailClosed
+)
+
+// String returns the name of the policy
+func (p FailurePolicy) String() string {
+	switch p {
+	case FailOpen:
+		return "fail-open"
+	case FailClosed:
+		return "fail-closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrConflict is returned when a value kept changing under an update
+// retried too many times
+var ErrConflict = errors.New("store: too many concurrent updates")
+
+// IsUnavailable reports whether err, returned by a StateStore, means the
+// store could not be reached or did not answer in time: network errors and
+// connections closed mid-reply. Errors of the caller's context, error
+// replies and ErrConflict are not.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// MemoryStore is a StateStore in the memory of the process
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// memoryEntry is a value and the time it expires, zero if it does not
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value of key
+func (m *MemoryStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.get(key)
+	return value, ok, nil
+}
+
+// Set sets the value of key
+func (m *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(key, value, ttl)
+	return nil
+}
+
+// CompareAndSwap sets the value of key if it is still old
+func (m *MemoryStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.get(key)
+	if ok != (old != nil) || string(current) != string(old) {
+		return false, nil
+	}
+	m.set(key, value, ttl)
+	return true, nil
+}
+
+// get returns the value of key unless it expired. m.mu must be held.
+func (m *MemoryStore) get(key string) ([]byte, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expires.IsZero() && !m.now().Before(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set sets the value of key. m.mu must be held. The copy of value is
+// never nil, which would read as a missing key in CompareAndSwap.
+func (m *MemoryStore) set(key string, value []byte, ttl time.Duration) {
+	entry := memoryEntry{value: make([]byte, len(value))}
+	copy(entry.value, value)
+	if ttl > 0 {
+		entry.expires = m.now().Add(ttl)
+	}
+	m.entries[key] = entry
+}
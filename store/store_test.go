@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	if _, ok, _ := m.Get(ctx, "k"); ok {
+		t.Fatal("Get() found a missing key")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "k", []byte("x"), []byte("1"), 0); swapped {
+		t.Error("CompareAndSwap() swapped a missing key from a value")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "k", nil, []byte("1"), time.Second); !swapped {
+		t.Error("CompareAndSwap() didn't create a missing key")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "k", nil, []byte("2"), time.Second); swapped {
+		t.Error("CompareAndSwap() replaced an existing key expected missing")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "k", []byte("1"), []byte("2"), time.Second); !swapped {
+		t.Error("CompareAndSwap() didn't swap a matching value")
+	}
+	if value, ok, _ := m.Get(ctx, "k"); !ok || string(value) != "2" {
+		t.Errorf("Get() = %q, %v, want 2", value, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok, _ := m.Get(ctx, "k"); ok {
+		t.Error("Get() found an expired key")
+	}
+
+	// Empty values are values, not missing keys
+	m.Set(ctx, "empty", nil, 0)
+	if value, ok, _ := m.Get(ctx, "empty"); !ok || value == nil {
+		t.Errorf("Get() = %v, %v, want an empty value", value, ok)
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "empty", []byte{}, []byte("1"), 0); !swapped {
+		t.Error("CompareAndSwap() didn't swap a matching empty value")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "empty", []byte("1"), []byte{}, 0); !swapped {
+		t.Error("CompareAndSwap() didn't swap to an empty value")
+	}
+	if swapped, _ := m.CompareAndSwap(ctx, "empty", nil, []byte("2"), 0); swapped {
+		t.Error("CompareAndSwap() replaced an empty value expected missing")
+	}
+}
+
+func TestGCRA(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := PerSecond(10, 3)
+
+	// A burst of 3, then one every 100ms
+	for i := 0; i < 3; i++ {
+		result, err := GCRA(ctx, s, "k", limit, 1, now)
+		if err != nil || !result.Allowed {
+			t.Fatalf("request %d: GCRA() = %+v, %v, want allowed", i, result, err)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("request %d: Remaining = %d, want %d", i, result.Remaining, 2-i)
+		}
+	}
+	result, _ := GCRA(ctx, s, "k", limit, 1, now)
+	if result.Allowed || result.RetryAfter != 100*time.Millisecond {
+		t.Errorf("GCRA() = %+v, want refused for 100ms", result)
+	}
+
+	now = now.Add(100 * time.Millisecond)
+	if result, _ := GCRA(ctx, s, "k", limit, 1, now); !result.Allowed {
+		t.Errorf("GCRA() = %+v after 100ms, want allowed", result)
+	}
+
+	// Keys are limited separately, and costs count as several requests
+	if result, _ := GCRA(ctx, s, "other", limit, 3, now); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("GCRA() = %+v for a cost of 3, want allowed with none remaining", result)
+	}
+}
+
+func TestPerSecond(t *testing.T) {
+	if got := PerSecond(4, 2); got.Interval() != 250*time.Millisecond || got.Burst != 2 {
+		t.Errorf("PerSecond(4, 2) = %+v", got)
+	}
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("PerSecond(%v, 1) didn't panic", rate)
+				}
+			}()
+			PerSecond(rate, 1)
+		}()
+	}
+}
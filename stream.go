@@ -2,7 +2,9 @@ package llmx
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/llmx-ai/llmx/core"
 )
@@ -18,6 +20,9 @@ type ChatStream struct {
 
 	// Accumulated response
 	accumulated *ChatResponse
+	start       time.Time
+	toolCalls   int
+	err         error // last error sent
 }
 
 // NewChatStream creates a new chat stream
@@ -30,6 +35,7 @@ func NewChatStream(ctx context.Context) *ChatStream {
 		accumulated: &ChatResponse{
 			Content: "",
 		},
+		start: time.Now(),
 	}
 }
 
@@ -52,17 +58,78 @@ func (s *ChatStream) SendEvent(event core.StreamEvent) {
 	case <-s.done:
 		return
 	case s.events <- event:
-		// Accumulate text deltas
-		if event.Type == core.EventTypeTextDelta {
+		// Accumulate text deltas, tool calls, citations, usage and the
+		// finish reason
+		switch event.Type {
+		case core.EventTypeTextDelta, core.EventTypeToolCall, core.EventTypeToolCallDelta,
+			core.EventTypeReasoning, core.EventTypeReasoningDelta:
+			if s.accumulated.Metadata.TimeToFirstToken == 0 {
+				s.accumulated.Metadata.TimeToFirstToken = time.Since(s.start)
+			}
+		}
+		switch event.Type {
+		case core.EventTypeTextDelta:
 			if text, ok := event.Data.(string); ok {
 				s.accumulated.Content += text
 			}
+		case core.EventTypeToolCall:
+			s.toolCalls++
+			if call, ok := ToolCallFromEvent(event.Data); ok {
+				s.accumulated.ToolCalls = append(s.accumulated.ToolCalls, call)
+			}
+		case core.EventTypeCitation:
+			if citations, ok := event.Data.([]Citation); ok {
+				s.accumulated.Citations = append(s.accumulated.Citations, citations...)
+			}
+		case core.EventTypeUsage:
+			if usage, ok := event.Data.(Usage); ok {
+				s.accumulated.Usage = usage
+			}
+		case core.EventTypeError:
+			if err, ok := event.Data.(error); ok {
+				s.err = err
+			}
+		case core.EventTypeFinish:
+			if reason, ok := event.Data.(string); ok && reason != "" {
+				s.accumulated.RawFinishReason = reason
+				s.accumulated.FinishReason = NormalizeFinishReason(reason)
+			}
+			s.finish()
 		}
 	case <-s.ctx.Done():
 		return
 	}
 }
 
+// ToolCallFromEvent decodes the data of a tool call event, a map with the
+// call's "id", "name" and "args"
+func ToolCallFromEvent(data interface{}) (ToolCall, bool) {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return ToolCall{}, false
+	}
+
+	call := ToolCall{}
+	call.ID, _ = fields["id"].(string)
+	call.Name, _ = fields["name"].(string)
+	switch args := fields["args"].(type) {
+	case string:
+		call.Arguments = json.RawMessage(args)
+	case json.RawMessage:
+		call.Arguments = args
+	case []byte:
+		call.Arguments = args
+	case nil:
+	default:
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			return ToolCall{}, false
+		}
+		call.Arguments = encoded
+	}
+	return call, true
+}
+
 // SendError sends an error to the stream
 func (s *ChatStream) SendError(err error) {
 	s.mu.Lock()
@@ -72,15 +139,62 @@ func (s *ChatStream) SendError(err error) {
 	case <-s.done:
 		return
 	case s.errors <- err:
+		s.err = err
 	case <-s.ctx.Done():
 		return
 	}
 }
 
+// UpdateMetadata changes the metadata of the accumulated response.
+// Providers use it to report the request ID and rate limits of a stream.
+func (s *ChatStream) UpdateMetadata(update func(*ResponseMetadata)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.accumulated.Metadata)
+}
+
+// started records the provider and when the client sent the request, which
+// is before the provider created the stream
+func (s *ChatStream) started(providerName string, start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accumulated.Metadata.Provider == "" {
+		s.accumulated.Metadata.Provider = providerName
+	}
+	if start.Before(s.start) {
+		if ttft := s.accumulated.Metadata.TimeToFirstToken; ttft > 0 {
+			s.accumulated.Metadata.TimeToFirstToken = ttft + s.start.Sub(start)
+		}
+		if latency := s.accumulated.Metadata.Latency; latency > 0 {
+			s.accumulated.Metadata.Latency = latency + s.start.Sub(start)
+		}
+		s.start = start
+	}
+}
+
+// finish records the latency, completes the finish reason and sets the
+// stream's single choice; s.mu must be held
+func (s *ChatStream) finish() {
+	if s.accumulated.Metadata.Latency == 0 {
+		s.accumulated.Metadata.Latency = time.Since(s.start)
+	}
+	s.accumulated.FinishReason = inferFinishReason(s.accumulated.FinishReason, s.toolCalls > 0)
+	s.accumulated.Choices = []Choice{{
+		Content:         s.accumulated.Content,
+		ToolCalls:       s.accumulated.ToolCalls,
+		FinishReason:    s.accumulated.FinishReason,
+		RawFinishReason: s.accumulated.RawFinishReason,
+	}}
+}
+
 // Close closes the stream
 func (s *ChatStream) Close() error {
 	s.once.Do(func() {
+		// Closing done first releases a SendEvent blocked on a full buffer
 		close(s.done)
+		s.mu.Lock()
+		s.finish()
+		s.mu.Unlock()
 		close(s.events)
 		close(s.errors)
 	})
@@ -89,18 +203,24 @@ func (s *ChatStream) Close() error {
 
 // Accumulate waits for the stream to complete and returns the full response
 func (s *ChatStream) Accumulate() (*ChatResponse, error) {
+	errs := s.errors
 	for {
 		select {
 		case event, ok := <-s.events:
 			if !ok {
-				return s.accumulated, nil
+				return s.GetAccumulated(), nil
 			}
 			if event.Type == core.EventTypeError {
 				if err, ok := event.Data.(error); ok {
 					return nil, err
 				}
 			}
-		case err := <-s.errors:
+		case err, ok := <-errs:
+			if !ok {
+				// Closed with the events; drain the remaining events
+				errs = nil
+				continue
+			}
 			return nil, err
 		case <-s.ctx.Done():
 			return nil, s.ctx.Err()
@@ -108,6 +228,17 @@ func (s *ChatStream) Accumulate() (*ChatResponse, error) {
 	}
 }
 
+// result returns the accumulated response and the last error sent, for a
+// stream that has been closed
+func (s *ChatStream) result() (*ChatResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.accumulated, nil
+}
+
 // GetAccumulated returns the accumulated response so far
 func (s *ChatStream) GetAccumulated() *ChatResponse {
 	s.mu.Lock()
@@ -28,7 +28,7 @@ func (o *Output) Generate(
 	// Build system message with JSON instruction
 	systemMsg := "You must respond with valid JSON that matches the provided schema. Do not include any text outside the JSON object."
 
-	// Create request with JSON mode
+	// Create request; JSON output is requested through the system prompt
 	req := &llmx.ChatRequest{
 		Messages: []llmx.Message{
 			{
@@ -44,11 +44,6 @@ func (o *Output) Generate(
 				},
 			},
 		},
-		ProviderOptions: map[string]interface{}{
-			"response_format": map[string]string{
-				"type": "json_object",
-			},
-		},
 	}
 
 	// Execute request
@@ -4,14 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/llmx-ai/llmx"
 )
 
 // Executor executes tools and manages tool calling loops
 type Executor struct {
-	registry *Registry
-	maxDepth int
+	registry         *Registry
+	maxDepth         int
+	finalAnswerAfter int
 }
 
 // NewExecutor creates a new tool executor
@@ -28,6 +30,14 @@ func (e *Executor) WithMaxDepth(depth int) *Executor {
 	return e
 }
 
+// WithFinalAnswerAfter forbids further tool calls once the given number of
+// tool turns has run, so the model must answer with the results it has.
+// The provider must support tool choice "none".
+func (e *Executor) WithFinalAnswerAfter(turns int) *Executor {
+	e.finalAnswerAfter = turns
+	return e
+}
+
 // ExecuteLoop automatically executes tools until no more tool calls are needed
 func (e *Executor) ExecuteLoop(
 	ctx context.Context,
@@ -46,13 +56,17 @@ func (e *Executor) ExecuteLoop(
 	for depth < e.maxDepth {
 		// Create request with current messages
 		currentReq := &llmx.ChatRequest{
-			Model:       req.Model,
-			Messages:    messages,
-			Tools:       req.Tools,
-			Temperature: req.Temperature,
-			MaxTokens:   req.MaxTokens,
-			TopP:        req.TopP,
-			Stop:        req.Stop,
+			Model:             req.Model,
+			Messages:          messages,
+			Tools:             req.Tools,
+			ToolChoice:        e.toolChoice(req.ToolChoice, depth),
+			ParallelToolCalls: req.ParallelToolCalls,
+			Temperature:       req.Temperature,
+			MaxTokens:         req.MaxTokens,
+			TopP:              req.TopP,
+			TopK:              req.TopK,
+			Stop:              req.Stop,
+			ProviderOptions:   req.ProviderOptions,
 		}
 
 		// Call AI
@@ -111,6 +125,21 @@ func (e *Executor) ExecuteLoop(
 	return nil, fmt.Errorf("max tool call depth reached: %d", e.maxDepth)
 }
 
+// toolChoice returns the tool choice for the turn after depth tool turns.
+// A forced choice only applies to the first turn; repeating it would make
+// the model call tools forever.
+func (e *Executor) toolChoice(choice *llmx.ToolChoice, depth int) *llmx.ToolChoice {
+	if e.finalAnswerAfter > 0 && depth >= e.finalAnswerAfter {
+		return &llmx.ToolChoice{Mode: llmx.ToolChoiceNone}
+	}
+
+	if depth > 0 && choice != nil && (choice.Mode == llmx.ToolChoiceRequired || choice.Mode == llmx.ToolChoiceFunction) {
+		return &llmx.ToolChoice{Mode: llmx.ToolChoiceAuto}
+	}
+
+	return choice
+}
+
 // ExecuteSingle executes a single tool call
 func (e *Executor) ExecuteSingle(
 	ctx context.Context,
@@ -146,14 +175,83 @@ func ValidateToolArguments(schema *llmx.Schema, args json.RawMessage) error {
 		return fmt.Errorf("invalid JSON: %w", err)
 	}
 
+	return validateObject(argMap, schema, "")
+}
+
+// validateObject checks required fields and known properties of an object
+func validateObject(obj map[string]interface{}, schema *llmx.Schema, path string) error {
 	// Check required fields
 	for _, required := range schema.Required {
-		if _, ok := argMap[required]; !ok {
-			return fmt.Errorf("missing required field: %s", required)
+		if _, ok := obj[required]; !ok {
+			return fmt.Errorf("missing required field: %s", path+required)
+		}
+	}
+
+	for key, value := range obj {
+		propSchema, ok := schema.Properties[key]
+		if !ok || value == nil {
+			continue
+		}
+		if err := validateValue(value, propSchema, path+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateValue checks a decoded JSON value against a schema's type and enum
+func validateValue(value interface{}, schema *llmx.Schema, path string) error {
+	switch schema.Type {
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("field %s: expected string, got %T", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("field %s: expected number, got %T", path, value)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("field %s: expected integer, got %v", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("field %s: expected boolean, got %T", path, value)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("field %s: expected array, got %T", path, value)
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				if item == nil {
+					continue
+				}
+				if err := validateValue(item, schema.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field %s: expected object, got %T", path, value)
+		}
+		if err := validateObject(obj, schema, path+"."); err != nil {
+			return err
 		}
 	}
 
-	// TODO: Add more detailed validation (type checking, etc.)
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("field %s: value %v is not one of %v", path, value, schema.Enum)
+	}
 
 	return nil
 }
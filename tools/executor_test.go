@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/provider"
+)
+
+// loopProvider calls the "echo" tool on every turn unless tool calls are
+// forbidden, and records the requests it receives
+type loopProvider struct {
+	requests []*llmx.ChatRequest
+}
+
+var testLoopProvider = &loopProvider{}
+
+func init() {
+	provider.Register("tools-loop", func(opts map[string]interface{}) (provider.Provider, error) {
+		return testLoopProvider, nil
+	})
+}
+
+func (p *loopProvider) Name() string { return "tools-loop" }
+
+func (p *loopProvider) Chat(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	req := reqInterface.(*llmx.ChatRequest)
+	p.requests = append(p.requests, req)
+
+	if req.ToolChoice != nil && req.ToolChoice.Mode == llmx.ToolChoiceNone {
+		return &llmx.ChatResponse{Content: "done"}, nil
+	}
+	return &llmx.ChatResponse{
+		ToolCalls: []llmx.ToolCall{{ID: "call_1", Name: "echo", Arguments: json.RawMessage(`{}`)}},
+	}, nil
+}
+
+func (p *loopProvider) StreamChat(ctx context.Context, req interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (p *loopProvider) SupportedFeatures() provider.Features {
+	return provider.Features{ToolCalling: true, ToolChoice: true}
+}
+
+func (p *loopProvider) SupportedModels() []provider.Model { return nil }
+
+func TestExecutor_ToolChoice(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(llmx.Tool{
+		Name: "echo",
+		Execute: func(ctx context.Context, args json.RawMessage) (*llmx.ToolResult, error) {
+			return &llmx.ToolResult{Output: "ok"}, nil
+		},
+	})
+
+	client, err := llmx.NewClient(llmx.WithProvider("tools-loop", map[string]interface{}{"api_key": "test-key"}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	testLoopProvider.requests = nil
+	resp, err := NewExecutor(registry).WithFinalAnswerAfter(2).ExecuteLoop(context.Background(), client, &llmx.ChatRequest{
+		Model:      "test-model",
+		Messages:   []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: "hi"}}}},
+		Tools:      registry.List(),
+		ToolChoice: llmx.ForceTool("echo"),
+	})
+	if err != nil {
+		t.Fatalf("ExecuteLoop() error = %v", err)
+	}
+	if resp.Content != "done" {
+		t.Errorf("expected final answer 'done', got %q", resp.Content)
+	}
+
+	want := []llmx.ToolChoiceMode{llmx.ToolChoiceFunction, llmx.ToolChoiceAuto, llmx.ToolChoiceNone}
+	if len(testLoopProvider.requests) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(testLoopProvider.requests))
+	}
+	for i, mode := range want {
+		if got := testLoopProvider.requests[i].ToolChoice.Mode; got != mode {
+			t.Errorf("request %d: expected tool choice %q, got %q", i, mode, got)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Client is a connection to one MCP server. It connects lazily, and
+// reconnects and re-initializes on the next call after the server exits
+// or the session expires.
+type Client struct {
+	transport Transport
+	info      Implementation
+	prefix    string
+
+	// connectMu serializes connection attempts
+	connectMu sync.Mutex
+
+	mu          sync.Mutex
+	conn        Conn
+	initResult  *InitializeResult
+	nextID      int64
+	pending     map[string]*pendingCall
+	connections int
+	closed      bool
+	listeners   []func()
+
+	// Tools registered by RegisterTools
+	registered *registration
+}
+
+// pendingCall is a request waiting for its response
+type pendingCall struct {
+	conn Conn
+	ch   chan *Message
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithClientInfo sets the name and version reported to the server
+func WithClientInfo(name, version string) ClientOption {
+	return func(c *Client) {
+		c.info = Implementation{Name: name, Version: version}
+	}
+}
+
+// WithToolPrefix prefixes the names of registered tools, to keep tools
+// from different servers apart
+func WithToolPrefix(prefix string) ClientOption {
+	return func(c *Client) {
+		c.prefix = prefix
+	}
+}
+
+// NewClient creates a client for the server reached through transport
+func NewClient(transport Transport, opts ...ClientOption) *Client {
+	c := &Client{
+		transport: transport,
+		info:      Implementation{Name: "llmx", Version: "1.0.0"},
+		pending:   make(map[string]*pendingCall),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect opens the connection and performs the initialize handshake if
+// the client is not already connected
+func (c *Client) Connect(ctx context.Context) error {
+	_, err := c.connection(ctx)
+	return err
+}
+
+// ServerInfo returns the result of the last initialize handshake, or nil
+// before the first connection
+func (c *Client) ServerInfo() *InitializeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.initResult
+}
+
+// OnToolsChanged registers fn to run when the server reports a changed
+// tool list or is restarted. fn runs on its own goroutine.
+func (c *Client) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+// ListTools returns all tools the server exposes
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var tools []ToolInfo
+	params := ListToolsParams{}
+	for {
+		var result ListToolsResult
+		if err := c.call(ctx, MethodToolsList, params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		params.Cursor = result.NextCursor
+	}
+}
+
+// CallTool invokes a tool on the server. A tool that fails reports it
+// through CallToolResult.IsError; the error is for protocol failures.
+func (c *Client) CallTool(ctx context.Context, name string, args json.RawMessage) (*CallToolResult, error) {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	var result CallToolResult
+	if err := c.call(ctx, MethodToolsCall, CallToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close closes the connection. The client cannot be used afterwards.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+// call sends a request and decodes its result. If the session has expired
+// the request was not processed, so it is retried once on a new session.
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	err := c.callOnce(ctx, method, params, result)
+	if errors.Is(err, ErrSessionExpired) {
+		err = c.callOnce(ctx, method, params, result)
+	}
+	return err
+}
+
+func (c *Client) callOnce(ctx context.Context, method string, params, result interface{}) error {
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.roundTrip(ctx, conn, method, params)
+	if errors.Is(err, ErrSessionExpired) {
+		c.dropConnection(conn)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("mcp: decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// roundTrip sends a request on conn and waits for its response
+func (c *Client) roundTrip(ctx context.Context, conn Conn, method string, params interface{}) (*Message, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: encode %s params: %w", method, err)
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	id := strconv.FormatInt(c.nextID, 10)
+	ch := make(chan *Message, 1)
+	c.pending[id] = &pendingCall{conn: conn, ch: ch}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg := &Message{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(id),
+		Method:  method,
+		Params:  data,
+	}
+	if err := conn.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrConnectionClosed
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp, nil
+	case <-ctx.Done():
+		// Tell the server to stop; the result would be discarded anyway
+		params, _ := json.Marshal(CancelledParams{RequestID: msg.ID, Reason: ctx.Err().Error()})
+		conn.Send(context.Background(), &Message{JSONRPC: "2.0", Method: MethodCancelled, Params: params})
+		return nil, ctx.Err()
+	}
+}
+
+// notify sends a notification on conn
+func (c *Client) notify(ctx context.Context, conn Conn, method string) error {
+	return conn.Send(ctx, &Message{JSONRPC: "2.0", Method: method})
+}
+
+// connection returns the open connection, connecting and initializing a
+// new one if there is none
+func (c *Client) connection(ctx context.Context) (Conn, error) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("mcp: client is closed")
+	}
+	if c.conn != nil {
+		conn := c.conn
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	conn, err := c.transport.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	go c.readLoop(conn)
+
+	var result InitializeResult
+	resp, err := c.roundTrip(ctx, conn, MethodInitialize, InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      c.info,
+	})
+	if err == nil {
+		err = json.Unmarshal(resp.Result, &result)
+	}
+	if err == nil {
+		err = c.notify(ctx, conn, MethodInitialized)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mcp: initialize: %w", err)
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.initResult = &result
+	c.connections++
+	reconnected := c.connections > 1
+	c.mu.Unlock()
+
+	// A restarted server may expose a different set of tools
+	if reconnected {
+		c.toolsChanged()
+	}
+
+	return conn, nil
+}
+
+// readLoop dispatches messages from conn until it ends
+func (c *Client) readLoop(conn Conn) {
+	for {
+		msg, err := conn.Receive()
+		if err != nil {
+			c.dropConnection(conn)
+			return
+		}
+
+		switch {
+		case msg.IsResponse():
+			c.mu.Lock()
+			call, ok := c.pending[string(msg.ID)]
+			if ok && call.conn == conn {
+				delete(c.pending, string(msg.ID))
+			} else {
+				ok = false
+			}
+			c.mu.Unlock()
+			if ok {
+				call.ch <- msg
+			}
+
+		case msg.IsNotification():
+			if msg.Method == MethodToolsListChanged {
+				c.toolsChanged()
+			}
+
+		case msg.IsRequest():
+			go c.handleRequest(conn, msg)
+		}
+	}
+}
+
+// handleRequest answers requests from the server. Only ping is supported.
+func (c *Client) handleRequest(conn Conn, msg *Message) {
+	resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == MethodPing {
+		resp.Result = json.RawMessage("{}")
+	} else {
+		resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	conn.Send(context.Background(), resp)
+}
+
+// dropConnection forgets conn and fails the calls waiting on it, so the
+// next call reconnects
+func (c *Client) dropConnection(conn Conn) {
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
+	var dropped []chan *Message
+	for id, call := range c.pending {
+		if call.conn == conn {
+			dropped = append(dropped, call.ch)
+			delete(c.pending, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, ch := range dropped {
+		close(ch)
+	}
+	conn.Close()
+}
+
+// toolsChanged runs the OnToolsChanged listeners
+func (c *Client) toolsChanged() {
+	c.mu.Lock()
+	listeners := append([]func(){}, c.listeners...)
+	c.mu.Unlock()
+
+	for _, fn := range listeners {
+		go fn()
+	}
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sessionHeader carries the session ID assigned by a streamable HTTP server
+const sessionHeader = "Mcp-Session-Id"
+
+// HTTPTransport connects to an MCP server over streamable HTTP. Each
+// message is POSTed to URL; the server answers with JSON or an SSE stream.
+type HTTPTransport struct {
+	URL string
+
+	// Header is added to every request, e.g. for authorization
+	Header http.Header
+
+	// HTTPClient is used for requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// NewHTTPTransport returns a streamable HTTP transport for url
+func NewHTTPTransport(url string) *HTTPTransport {
+	return &HTTPTransport{URL: url}
+}
+
+// Connect returns a connection; no request is made until the first Send
+func (t *HTTPTransport) Connect(ctx context.Context) (Conn, error) {
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	listenCtx, cancel := context.WithCancel(context.Background())
+	return &httpConn{
+		transport: t,
+		client:    client,
+		incoming:  make(chan *Message, 64),
+		done:      make(chan struct{}),
+		listenCtx: listenCtx,
+		cancel:    cancel,
+	}, nil
+}
+
+type httpConn struct {
+	transport *HTTPTransport
+	client    *http.Client
+
+	incoming chan *Message
+	done     chan struct{}
+
+	listenCtx context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	sessionID string
+	listening bool
+
+	closeOnce sync.Once
+}
+
+func (c *httpConn) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: encode message: %w", err)
+	}
+
+	// The response may be an SSE stream that outlives ctx, so the request
+	// is bound to the connection and only cancelled by ctx until headers
+	// arrive
+	reqCtx, cancelReq := context.WithCancel(c.listenCtx)
+	stop := context.AfterFunc(ctx, cancelReq)
+
+	req, err := c.newRequest(reqCtx, http.MethodPost, bytes.NewReader(body))
+	if err != nil {
+		cancelReq()
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := c.client.Do(req)
+	stop()
+	if err != nil {
+		cancelReq()
+		return fmt.Errorf("mcp: post: %w", err)
+	}
+
+	c.mu.Lock()
+	hadSession := c.sessionID != ""
+	c.mu.Unlock()
+
+	if resp.StatusCode == http.StatusNotFound && hadSession {
+		resp.Body.Close()
+		cancelReq()
+		return ErrSessionExpired
+	}
+	if resp.StatusCode >= 300 {
+		defer cancelReq()
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("mcp: server returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		c.mu.Lock()
+		c.sessionID = id
+		c.mu.Unlock()
+	}
+
+	if msg.Method == MethodInitialized {
+		c.startListening()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case resp.StatusCode == http.StatusAccepted || resp.ContentLength == 0:
+		resp.Body.Close()
+		cancelReq()
+	case mediaType == "text/event-stream":
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer cancelReq()
+			defer resp.Body.Close()
+			c.readEvents(resp.Body)
+		}()
+	default:
+		defer cancelReq()
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("mcp: read response: %w", err)
+		}
+		if err := c.deliverJSON(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *httpConn) Receive() (*Message, error) {
+	select {
+	case msg := <-c.incoming:
+		return msg, nil
+	case <-c.done:
+		return nil, io.EOF
+	}
+}
+
+// Close ends the session on the server and stops background streams
+func (c *httpConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		sessionID := c.sessionID
+		c.mu.Unlock()
+
+		if sessionID != "" {
+			if req, err := c.newRequest(context.Background(), http.MethodDelete, nil); err == nil {
+				if resp, err := c.client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}
+		}
+
+		c.cancel()
+		close(c.done)
+		c.wg.Wait()
+	})
+	return nil
+}
+
+func (c *httpConn) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.transport.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: build request: %w", err)
+	}
+	for key, values := range c.transport.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	c.mu.Lock()
+	if c.sessionID != "" {
+		req.Header.Set(sessionHeader, c.sessionID)
+	}
+	c.mu.Unlock()
+
+	return req, nil
+}
+
+// startListening opens the optional GET stream that carries server-initiated
+// messages such as tool list changes. Servers without one answer 405.
+func (c *httpConn) startListening() {
+	c.mu.Lock()
+	if c.listening {
+		c.mu.Unlock()
+		return
+	}
+	c.listening = true
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		req, err := c.newRequest(c.listenCtx, http.MethodGet, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return
+		}
+		c.readEvents(resp.Body)
+	}()
+}
+
+// readEvents delivers the messages in an SSE stream
+func (c *httpConn) readEvents(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				c.deliverJSON([]byte(strings.Join(data, "\n")))
+				data = data[:0]
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if len(data) > 0 {
+		c.deliverJSON([]byte(strings.Join(data, "\n")))
+	}
+}
+
+// deliverJSON queues a message or batch of messages for Receive
+func (c *httpConn) deliverJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var msgs []*Message
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return fmt.Errorf("mcp: decode response: %w", err)
+		}
+	} else {
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("mcp: decode response: %w", err)
+		}
+		msgs = append(msgs, &msg)
+	}
+
+	for _, msg := range msgs {
+		select {
+		case c.incoming <- msg:
+		case <-c.done:
+			return ErrConnectionClosed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/tools"
+)
+
+// The test binary doubles as a fake MCP server: with MCP_FAKE_SERVER set
+// it serves stdio instead of running tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_FAKE_SERVER") == "1" {
+		runStdioServer(newFakeServer(os.Getenv("MCP_FAKE_EXIT_AFTER_CALL") == "1"))
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeServer implements a calculator MCP server. Calling "grow" adds an
+// "extra" tool and announces the change.
+type fakeServer struct {
+	mu            sync.Mutex
+	grown         bool
+	exitAfterCall bool
+}
+
+func newFakeServer(exitAfterCall bool) *fakeServer {
+	return &fakeServer{exitAfterCall: exitAfterCall}
+}
+
+// handle returns the response to msg, if any, and notifications to send
+func (s *fakeServer) handle(msg *Message) (*Message, []*Message) {
+	if msg.IsNotification() {
+		return nil, nil
+	}
+
+	reply := func(result interface{}) *Message {
+		data, _ := json.Marshal(result)
+		return &Message{JSONRPC: "2.0", ID: msg.ID, Result: data}
+	}
+
+	switch msg.Method {
+	case MethodInitialize:
+		return reply(InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    ServerCapabilities{Tools: &ToolsCapability{ListChanged: true}},
+			ServerInfo:      Implementation{Name: "fake", Version: "0.1.0"},
+		}), nil
+
+	case MethodToolsList:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		list := []ToolInfo{
+			{
+				Name:        "add",
+				Description: "Add two numbers",
+				InputSchema: json.RawMessage(`{"type":"object","properties":{"a":{"type":"number"},"b":{"type":"number"}},"required":["a","b"]}`),
+			},
+			{Name: "grow", InputSchema: json.RawMessage(`{"type":"object"}`)},
+		}
+		if s.grown {
+			list = append(list, ToolInfo{Name: "extra", InputSchema: json.RawMessage(`{"type":"object"}`)})
+		}
+		return reply(ListToolsResult{Tools: list}), nil
+
+	case MethodToolsCall:
+		var params CallToolParams
+		json.Unmarshal(msg.Params, &params)
+
+		switch params.Name {
+		case "add":
+			var args struct{ A, B float64 }
+			json.Unmarshal(params.Arguments, &args)
+			out, _ := json.Marshal(args.A + args.B)
+			return reply(CallToolResult{Content: []Content{TextContent(string(out))}}), nil
+		case "grow":
+			s.mu.Lock()
+			s.grown = true
+			s.mu.Unlock()
+			return reply(CallToolResult{Content: []Content{TextContent("grown")}}),
+				[]*Message{{JSONRPC: "2.0", Method: MethodToolsListChanged}}
+		default:
+			return reply(CallToolResult{Content: []Content{TextContent("unknown tool")}, IsError: true}), nil
+		}
+
+	default:
+		return &Message{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: CodeMethodNotFound, Message: "not found"}}, nil
+	}
+}
+
+func runStdioServer(s *fakeServer) {
+	scanner := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		resp, notifications := s.handle(&msg)
+		if resp != nil {
+			enc.Encode(resp)
+		}
+		for _, n := range notifications {
+			enc.Encode(n)
+		}
+		if s.exitAfterCall && msg.Method == MethodToolsCall {
+			return
+		}
+	}
+}
+
+func fakeStdioTransport(env ...string) *StdioTransport {
+	t := NewStdioTransport(os.Args[0], "-test.run=^$")
+	t.Env = append([]string{"MCP_FAKE_SERVER=1"}, env...)
+	return t
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_Stdio(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(fakeStdioTransport(), WithToolPrefix("calc_"))
+	defer client.Close()
+
+	registry := tools.NewRegistry()
+	if err := client.RegisterTools(ctx, registry); err != nil {
+		t.Fatalf("RegisterTools() error = %v", err)
+	}
+
+	if info := client.ServerInfo(); info == nil || info.ServerInfo.Name != "fake" {
+		t.Errorf("unexpected server info %+v", info)
+	}
+
+	add, ok := registry.Get("calc_add")
+	if !ok {
+		t.Fatal("expected calc_add to be registered")
+	}
+	if add.Parameters.Type != "object" || add.Parameters.Properties["a"].Type != "number" {
+		t.Errorf("unexpected schema %+v", add.Parameters)
+	}
+
+	result, err := add.Execute(ctx, json.RawMessage(`{"a":2,"b":3}`))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Output != "5" || result.IsError {
+		t.Errorf("expected output 5, got %+v", result)
+	}
+
+	t.Run("list changed", func(t *testing.T) {
+		grow, _ := registry.Get("calc_grow")
+		if _, err := grow.Execute(ctx, nil); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		waitFor(t, "calc_extra", func() bool { return registry.Has("calc_extra") })
+	})
+}
+
+func TestClient_StdioRestart(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(fakeStdioTransport("MCP_FAKE_EXIT_AFTER_CALL=1"))
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		result, err := client.CallTool(ctx, "add", json.RawMessage(`{"a":1,"b":1}`))
+		if err != nil {
+			t.Fatalf("call %d: CallTool() error = %v", i, err)
+		}
+		if len(result.Content) != 1 || result.Content[0].Text != "2" {
+			t.Errorf("call %d: unexpected result %+v", i, result)
+		}
+
+		// The server exits after each call; wait for the client to notice
+		waitFor(t, "disconnect", func() bool {
+			client.mu.Lock()
+			defer client.mu.Unlock()
+			return client.conn == nil
+		})
+	}
+
+	if client.connections != 2 {
+		t.Errorf("expected 2 connections, got %d", client.connections)
+	}
+}
+
+func TestClient_HTTP(t *testing.T) {
+	server := newFakeServer(false)
+
+	var mu sync.Mutex
+	sessions := 0
+	session := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		if msg.Method == MethodInitialize {
+			sessions++
+			session = "session-" + string(rune('0'+sessions))
+			w.Header().Set(sessionHeader, session)
+		} else if r.Header.Get(sessionHeader) != session {
+			mu.Unlock()
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Unlock()
+
+		resp, _ := server.handle(&msg)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer ts.Close()
+
+	transport := NewHTTPTransport(ts.URL)
+	transport.Header = http.Header{"Authorization": {"Bearer token"}}
+	client := NewClient(transport)
+	defer client.Close()
+
+	ctx := context.Background()
+	list, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(list))
+	}
+
+	// Expire the session; the client re-initializes and retries
+	mu.Lock()
+	session = "expired"
+	mu.Unlock()
+
+	result, err := client.CallTool(ctx, "add", json.RawMessage(`{"a":2,"b":2}`))
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if result.Content[0].Text != "4" {
+		t.Errorf("expected 4, got %+v", result)
+	}
+	if sessions != 2 {
+		t.Errorf("expected 2 sessions, got %d", sessions)
+	}
+
+	_, err = client.CallTool(ctx, "missing", nil)
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+
+	var rpcErr *RPCError
+	if err := client.call(ctx, "resources/list", struct{}{}, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Errorf("expected method not found, got %v", err)
+	}
+}
+
+func TestConvertSchema(t *testing.T) {
+	schema, err := ConvertSchema(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "description": "Search query"},
+			"limit": {"type": ["integer", "null"]},
+			"filter": {"$ref": "#/$defs/filter"},
+			"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}},
+			"mode": {"anyOf": [{"type": "null"}, {"const": "fast"}]}
+		},
+		"required": ["query"],
+		"$defs": {
+			"filter": {"type": "object", "properties": {"field": {"type": "string"}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ConvertSchema() error = %v", err)
+	}
+
+	if schema.Properties["query"].Description != "Search query" {
+		t.Errorf("expected query description, got %+v", schema.Properties["query"])
+	}
+	if schema.Properties["limit"].Type != "integer" {
+		t.Errorf("expected integer limit, got %s", schema.Properties["limit"].Type)
+	}
+	if filter := schema.Properties["filter"]; filter.Type != "object" || filter.Properties["field"] == nil {
+		t.Errorf("expected resolved filter ref, got %+v", filter)
+	}
+	if tags := schema.Properties["tags"]; tags.Items == nil || len(tags.Items.Enum) != 2 {
+		t.Errorf("expected tag enum, got %+v", tags)
+	}
+	if mode := schema.Properties["mode"]; len(mode.Enum) != 1 || mode.Enum[0] != "fast" {
+		t.Errorf("expected const mode, got %+v", mode)
+	}
+
+	empty, err := ConvertSchema(nil)
+	if err != nil || empty.Type != "object" {
+		t.Errorf("expected empty object schema, got %+v, %v", empty, err)
+	}
+}
+
+func TestConvertSchema_UntypedArguments(t *testing.T) {
+	// Untyped properties and unions of several types accept any value, so
+	// the arguments of valid calls must pass validation
+	schema, err := ConvertSchema(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"v": {"description": "Any value"},
+			"id": {"anyOf": [{"type": "string"}, {"type": "integer"}]},
+			"n": {"type": ["string", "number", "null"]},
+			"count": {"oneOf": [{"type": "integer"}, {"type": "null"}]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ConvertSchema() error = %v", err)
+	}
+	for _, name := range []string{"v", "id", "n"} {
+		if typ := schema.Properties[name].Type; typ != "" {
+			t.Errorf("property %s has type %q, want none", name, typ)
+		}
+	}
+	if typ := schema.Properties["count"].Type; typ != "integer" {
+		t.Errorf("property count has type %q, want integer", typ)
+	}
+
+	args := json.RawMessage(`{"v": 3, "id": 42, "n": 1.5, "count": 2}`)
+	if err := tools.ValidateToolArguments(schema, args); err != nil {
+		t.Errorf("ValidateToolArguments() error = %v", err)
+	}
+	if err := tools.ValidateToolArguments(schema, json.RawMessage(`{"count": "two"}`)); err == nil {
+		t.Error("expected a typed union to reject a string")
+	}
+}
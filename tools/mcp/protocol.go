@@ -0,0 +1,175 @@
+// Package mcp connects llmx tools to the Model Context Protocol.
+//
+// A Client launches an MCP server over stdio or connects to one over
+// streamable HTTP, lists its tools and registers them in a tools.Registry
+// as llmx.Tools whose Execute forwards to the server. A Server does the
+// reverse, exposing a tools.Registry to other MCP clients.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision this package speaks
+const ProtocolVersion = "2025-03-26"
+
+// MCP method names
+const (
+	MethodInitialize       = "initialize"
+	MethodInitialized      = "notifications/initialized"
+	MethodPing             = "ping"
+	MethodToolsList        = "tools/list"
+	MethodToolsCall        = "tools/call"
+	MethodToolsListChanged = "notifications/tools/list_changed"
+	MethodCancelled        = "notifications/cancelled"
+	MethodProgress         = "notifications/progress"
+)
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is a JSON-RPC 2.0 request, notification or response. Requests
+// carry an ID and a Method, notifications only a Method, and responses an
+// ID with either Result or Error.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// IsRequest reports whether the message is a request
+func (m *Message) IsRequest() bool { return m.Method != "" && len(m.ID) > 0 }
+
+// IsNotification reports whether the message is a notification
+func (m *Message) IsNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+
+// IsResponse reports whether the message is a response
+func (m *Message) IsResponse() bool { return m.Method == "" && len(m.ID) > 0 }
+
+// RPCError is a JSON-RPC error object
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp: %s (code %d)", e.Message, e.Code)
+}
+
+// Implementation identifies an MCP client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeParams are sent by the client to open a session
+type InitializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+}
+
+// ServerCapabilities lists the features a server offers
+type ServerCapabilities struct {
+	Tools *ToolsCapability `json:"tools,omitempty"`
+}
+
+// ToolsCapability describes a server's tool support
+type ToolsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// InitializeResult is the server's answer to initialize
+type InitializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ServerCapabilities `json:"capabilities"`
+	ServerInfo      Implementation     `json:"serverInfo"`
+	Instructions    string             `json:"instructions,omitempty"`
+}
+
+// ToolInfo describes a tool exposed by a server
+type ToolInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// ListToolsParams request a page of tools
+type ListToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListToolsResult is one page of a server's tools
+type ListToolsResult struct {
+	Tools      []ToolInfo `json:"tools"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// RequestMeta is the _meta object of a request
+type RequestMeta struct {
+	// ProgressToken asks the server for progress notifications
+	ProgressToken json.RawMessage `json:"progressToken,omitempty"`
+}
+
+// CallToolParams invoke a tool
+type CallToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Meta      *RequestMeta    `json:"_meta,omitempty"`
+}
+
+// CallToolResult is the outcome of a tool call
+type CallToolResult struct {
+	Content           []Content              `json:"content"`
+	StructuredContent json.RawMessage        `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
+	Meta              map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// CancelledParams tell the receiver to stop working on a request
+type CancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// ProgressParams report progress on a request that carried a progress token
+type ProgressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// Content is a block of tool output. Type is "text", "image", "audio" or
+// "resource".
+type Content struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	Data     string    `json:"data,omitempty"`
+	MimeType string    `json:"mimeType,omitempty"`
+	Resource *Resource `json:"resource,omitempty"`
+}
+
+// Resource is an embedded resource in tool output
+type Resource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// TextContent returns a text content block
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
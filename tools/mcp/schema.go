@@ -0,0 +1,186 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// maxRefDepth bounds $ref resolution so recursive schemas terminate
+const maxRefDepth = 8
+
+// jsonSchema is the subset of JSON Schema that maps onto llmx.Schema
+type jsonSchema struct {
+	Type        json.RawMessage        `json:"type"`
+	Description string                 `json:"description"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+	Enum        []interface{}          `json:"enum"`
+	Const       interface{}            `json:"const"`
+	AnyOf       []*jsonSchema          `json:"anyOf"`
+	OneOf       []*jsonSchema          `json:"oneOf"`
+	AllOf       []*jsonSchema          `json:"allOf"`
+	Ref         string                 `json:"$ref"`
+	Defs        map[string]*jsonSchema `json:"$defs"`
+	Definitions map[string]*jsonSchema `json:"definitions"`
+}
+
+// ConvertSchema converts a tool's JSON Schema to an llmx.Schema. Local
+// $refs are inlined, anyOf/oneOf unions whose non-null branches share a type
+// take the first of them and allOf branches are merged; keywords
+// llmx.Schema cannot express are dropped. Untyped values and unions of
+// several types convert to schemas without a type, which accept any value.
+// An empty schema converts to an object with no properties.
+func ConvertSchema(raw json.RawMessage) (*llmx.Schema, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return &llmx.Schema{Type: "object", Properties: map[string]*llmx.Schema{}}, nil
+	}
+
+	var root jsonSchema
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+
+	defs := make(map[string]*jsonSchema)
+	for name, def := range root.Definitions {
+		defs["#/definitions/"+name] = def
+	}
+	for name, def := range root.Defs {
+		defs["#/$defs/"+name] = def
+	}
+
+	schema := convertSchema(&root, defs, 0)
+	if schema.Type == "" {
+		schema.Type = "object"
+	}
+	if schema.Type == "object" && schema.Properties == nil {
+		schema.Properties = map[string]*llmx.Schema{}
+	}
+	return schema, nil
+}
+
+func convertSchema(s *jsonSchema, defs map[string]*jsonSchema, depth int) *llmx.Schema {
+	if s == nil {
+		return &llmx.Schema{}
+	}
+
+	if s.Ref != "" {
+		if def, ok := defs[s.Ref]; ok && depth < maxRefDepth {
+			schema := convertSchema(def, defs, depth+1)
+			if s.Description != "" {
+				schema.Description = s.Description
+			}
+			return schema
+		}
+		return &llmx.Schema{Type: "object", Description: s.Description}
+	}
+
+	for _, branches := range [][]*jsonSchema{s.AnyOf, s.OneOf} {
+		if schema := convertUnion(branches, defs, depth); schema != nil {
+			if s.Description != "" {
+				schema.Description = s.Description
+			}
+			return schema
+		}
+	}
+
+	schema := &llmx.Schema{
+		Type:        schemaType(s.Type),
+		Description: s.Description,
+		Required:    s.Required,
+		Enum:        s.Enum,
+	}
+	if s.Const != nil {
+		schema.Enum = []interface{}{s.Const}
+	}
+
+	if len(s.Properties) > 0 {
+		schema.Properties = make(map[string]*llmx.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			schema.Properties[name] = convertSchema(prop, defs, depth)
+		}
+	}
+	if s.Items != nil {
+		schema.Items = convertSchema(s.Items, defs, depth)
+	}
+
+	for _, part := range s.AllOf {
+		merged := convertSchema(part, defs, depth)
+		if schema.Type == "" {
+			schema.Type = merged.Type
+		}
+		for name, prop := range merged.Properties {
+			if schema.Properties == nil {
+				schema.Properties = make(map[string]*llmx.Schema)
+			}
+			schema.Properties[name] = prop
+		}
+		schema.Required = append(schema.Required, merged.Required...)
+	}
+
+	if schema.Type == "" {
+		switch {
+		case schema.Properties != nil:
+			schema.Type = "object"
+		case schema.Items != nil:
+			schema.Type = "array"
+		}
+	}
+
+	return schema
+}
+
+// convertUnion converts the branches of an anyOf or oneOf, ignoring null
+// ones. Branches of one type convert to the first of them; branches of
+// several types to an untyped schema. It returns nil without branches.
+func convertUnion(branches []*jsonSchema, defs map[string]*jsonSchema, depth int) *llmx.Schema {
+	var first *llmx.Schema
+	for _, branch := range branches {
+		if branch == nil || schemaType(branch.Type) == "null" {
+			continue
+		}
+		schema := convertSchema(branch, defs, depth)
+		if first == nil {
+			first = schema
+		} else if schema.Type != first.Type {
+			return &llmx.Schema{}
+		}
+	}
+	return first
+}
+
+// schemaType reads a type keyword, which is a string or an array of
+// strings. An array with a single non-null type yields that type, one with
+// several yields none.
+func schemaType(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err == nil {
+		found := ""
+		for _, t := range multiple {
+			if strings.EqualFold(t, "null") {
+				continue
+			}
+			if found != "" && found != t {
+				return ""
+			}
+			found = t
+		}
+		if found == "" && len(multiple) > 0 {
+			return multiple[0]
+		}
+		return found
+	}
+	return ""
+}
@@ -0,0 +1,443 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/tools"
+)
+
+// supportedVersions are the protocol revisions a Server accepts from clients
+var supportedVersions = map[string]bool{
+	"2024-11-05":    true,
+	ProtocolVersion: true,
+	"2025-06-18":    true,
+}
+
+// Server exposes the tools of a tools.Registry over MCP, on stdio with
+// ServeStdio or Serve and on streamable HTTP as an http.Handler
+type Server struct {
+	registry     *tools.Registry
+	info         Implementation
+	instructions string
+
+	// sessionTimeout is how long an idle HTTP session lives
+	sessionTimeout time.Duration
+	// allowedOrigins are the browser origins allowed on HTTP, nil for
+	// loopback ones only
+	allowedOrigins map[string]bool
+	now            func() time.Time
+
+	mu        sync.Mutex
+	sessions  map[*session]bool
+	byID      map[string]*session
+	lastSweep time.Time
+}
+
+// ServerOption configures a Server
+type ServerOption func(*Server)
+
+// WithServerInfo sets the name and version reported to clients
+func WithServerInfo(name, version string) ServerOption {
+	return func(s *Server) {
+		s.info = Implementation{Name: name, Version: version}
+	}
+}
+
+// WithInstructions sets usage hints returned from initialize
+func WithInstructions(instructions string) ServerOption {
+	return func(s *Server) {
+		s.instructions = instructions
+	}
+}
+
+// WithSessionTimeout sets how long an HTTP session may stay idle, with no
+// request running and no GET stream open, before it is dropped; 30 minutes
+// by default. Clients of dropped sessions get 404 and initialize again.
+func WithSessionTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.sessionTimeout = d
+	}
+}
+
+// WithAllowedOrigins sets the browser origins, e.g.
+// "https://app.example.com", allowed to reach the HTTP transport. Requests
+// with another Origin header are refused to prevent DNS rebinding; by
+// default only loopback origins are allowed. Requests without an Origin
+// header, from clients other than browsers, are always allowed.
+func WithAllowedOrigins(origins ...string) ServerOption {
+	return func(s *Server) {
+		s.allowedOrigins = make(map[string]bool, len(origins))
+		for _, origin := range origins {
+			s.allowedOrigins[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+}
+
+// NewServer creates a server for the tools in registry. Tools added to or
+// removed from the registry later are picked up by the next tools/list;
+// call NotifyToolsChanged to tell connected clients.
+func NewServer(registry *tools.Registry, opts ...ServerOption) *Server {
+	s := &Server{
+		registry:       registry,
+		info:           Implementation{Name: "llmx", Version: "1.0.0"},
+		sessionTimeout: 30 * time.Minute,
+		now:            time.Now,
+		sessions:       make(map[*session]bool),
+		byID:           make(map[string]*session),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NotifyToolsChanged sends a tool list change notification to every
+// connected client
+func (s *Server) NotifyToolsChanged() {
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.notify(&Message{JSONRPC: "2.0", Method: MethodToolsListChanged})
+	}
+}
+
+// ServeStdio serves one client on the process's stdin and stdout
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.Serve(ctx, os.Stdin, os.Stdout)
+}
+
+// Serve serves one client exchanging newline-delimited JSON-RPC messages
+// on r and w. It returns once r is exhausted and in-flight calls have
+// finished, or when ctx is cancelled, after cancelling in-flight calls.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(w)
+	write := func(msg *Message) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return enc.Encode(msg)
+	}
+
+	sess := newSession("", write)
+	s.addSession(sess)
+	defer s.removeSession(sess)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			wg.Wait()
+			return err
+		case line := <-lines:
+			if len(line) == 0 {
+				continue
+			}
+
+			var msg Message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				write(&Message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: CodeParseError, Message: err.Error()}})
+				continue
+			}
+			if !msg.IsRequest() {
+				s.handleNotification(sess, &msg)
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.handleRequest(ctx, sess, &msg, write); resp != nil {
+					write(resp)
+				}
+			}()
+		}
+	}
+}
+
+// session is one connected client
+type session struct {
+	id string
+
+	mu sync.Mutex
+
+	// send delivers server-initiated messages; it is nil for HTTP
+	// sessions without an open GET stream
+	send func(*Message) error
+
+	// inflight cancels running requests by ID
+	inflight map[string]context.CancelFunc
+
+	// lastSeen is when the client last made an HTTP request
+	lastSeen time.Time
+}
+
+func newSession(id string, send func(*Message) error) *session {
+	return &session{id: id, send: send, inflight: make(map[string]context.CancelFunc)}
+}
+
+func (sess *session) notify(msg *Message) {
+	sess.mu.Lock()
+	send := sess.send
+	sess.mu.Unlock()
+	if send != nil {
+		send(msg)
+	}
+}
+
+func (s *Server) addSession(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sess] = true
+	if sess.id != "" {
+		s.byID[sess.id] = sess
+	}
+}
+
+func (s *Server) removeSession(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sess)
+	delete(s.byID, sess.id)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	for _, cancel := range sess.inflight {
+		cancel()
+	}
+}
+
+// handleNotification processes a client notification
+func (s *Server) handleNotification(sess *session, msg *Message) {
+	if msg.Method != MethodCancelled {
+		return
+	}
+
+	var params CancelledParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+
+	sess.mu.Lock()
+	cancel, ok := sess.inflight[string(params.RequestID)]
+	sess.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// handleRequest answers a client request. Messages written with out, such
+// as progress notifications, are delivered before the response. It returns
+// nil if the request was cancelled, since cancelled requests get no reply.
+func (s *Server) handleRequest(ctx context.Context, sess *session, msg *Message, out func(*Message) error) *Message {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	id := string(msg.ID)
+	sess.mu.Lock()
+	sess.inflight[id] = cancel
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.inflight, id)
+		sess.mu.Unlock()
+	}()
+
+	result, err := s.dispatch(ctx, msg, out)
+	if ctx.Err() != nil && msg.Method == MethodToolsCall {
+		return nil
+	}
+
+	resp := &Message{JSONRPC: "2.0", ID: msg.ID}
+	if err != nil {
+		rpcErr, ok := err.(*RPCError)
+		if !ok {
+			rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, msg *Message, out func(*Message) error) (interface{}, error) {
+	switch msg.Method {
+	case MethodInitialize:
+		var params InitializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		return InitializeResult{
+			ProtocolVersion: version,
+			Capabilities:    ServerCapabilities{Tools: &ToolsCapability{ListChanged: true}},
+			ServerInfo:      s.info,
+			Instructions:    s.instructions,
+		}, nil
+
+	case MethodPing:
+		return struct{}{}, nil
+
+	case MethodToolsList:
+		return s.listTools()
+
+	case MethodToolsCall:
+		var params CallToolParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return s.callTool(ctx, params, out)
+
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+// listTools describes the registry's tools, sorted by name
+func (s *Server) listTools() (ListToolsResult, error) {
+	list := s.registry.List()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	result := ListToolsResult{Tools: make([]ToolInfo, 0, len(list))}
+	for _, tool := range list {
+		schema := json.RawMessage(`{"type":"object","properties":{}}`)
+		if tool.Parameters != nil {
+			data, err := json.Marshal(tool.Parameters)
+			if err != nil {
+				return ListToolsResult{}, fmt.Errorf("tool %s: %w", tool.Name, err)
+			}
+			schema = data
+		}
+		result.Tools = append(result.Tools, ToolInfo{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return result, nil
+}
+
+// callTool runs a tool. Failures of the tool itself, including invalid
+// arguments, are reported in the result so the model can see them; an
+// unknown tool is a protocol error.
+func (s *Server) callTool(ctx context.Context, params CallToolParams, out func(*Message) error) (*CallToolResult, error) {
+	tool, ok := s.registry.Get(params.Name)
+	if !ok {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+
+	if params.Meta != nil && len(params.Meta.ProgressToken) > 0 {
+		ctx = context.WithValue(ctx, progressKey{}, &progressReporter{token: params.Meta.ProgressToken, out: out})
+	}
+
+	args := params.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	if tool.Parameters != nil {
+		if err := tools.ValidateToolArguments(tool.Parameters, args); err != nil {
+			return errorToolResult(fmt.Sprintf("invalid arguments: %v", err)), nil
+		}
+	}
+
+	result, err := tool.Execute(ctx, args)
+	if err != nil {
+		return errorToolResult(err.Error()), nil
+	}
+	if result == nil {
+		return &CallToolResult{Content: []Content{}}, nil
+	}
+	return toolResultContent(result), nil
+}
+
+func errorToolResult(msg string) *CallToolResult {
+	return &CallToolResult{Content: []Content{TextContent(msg)}, IsError: true}
+}
+
+// toolResultContent converts an llmx.ToolResult to MCP tool output.
+// Metadata is returned in the result's _meta.
+func toolResultContent(result *llmx.ToolResult) *CallToolResult {
+	return &CallToolResult{
+		Content: []Content{TextContent(result.Output)},
+		IsError: result.IsError,
+		Meta:    result.Metadata,
+	}
+}
+
+type progressKey struct{}
+
+// progressReporter sends progress notifications for one request
+type progressReporter struct {
+	token json.RawMessage
+	out   func(*Message) error
+}
+
+// ReportProgress sends a progress notification for the tool call running
+// in ctx. It does nothing unless the call was made by an MCP client that
+// asked for progress. total may be 0 when unknown.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	reporter, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+
+	params, err := json.Marshal(ProgressParams{
+		ProgressToken: reporter.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	})
+	if err != nil {
+		return
+	}
+	reporter.out(&Message{JSONRPC: "2.0", Method: MethodProgress, Params: params})
+}
@@ -0,0 +1,317 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxRequestBody bounds the size of a POSTed message
+const maxRequestBody = 4 << 20
+
+// ServeHTTP implements the streamable HTTP transport. Clients POST
+// messages; requests are answered with an SSE stream when the client
+// accepts one, so progress notifications can precede the response, and
+// with JSON otherwise. A GET stream receives tool list changes and DELETE
+// ends the session; idle sessions expire, see WithSessionTimeout. Requests
+// from disallowed browser origins are refused, see WithAllowedOrigins.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	s.expireSessions()
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleGet(w, r)
+	case http.MethodDelete:
+		sess, ok := s.httpSession(w, r)
+		if !ok {
+			return
+		}
+		s.removeSession(sess)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	msgs, err := decodeMessages(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &Message{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   &RPCError{Code: CodeParseError, Message: err.Error()},
+		})
+		return
+	}
+
+	var sess *session
+	if len(msgs) == 1 && msgs[0].Method == MethodInitialize {
+		sess = newSession(newSessionID(), nil)
+		sess.lastSeen = s.now()
+		s.addSession(sess)
+		w.Header().Set(sessionHeader, sess.id)
+	} else {
+		var ok bool
+		if sess, ok = s.httpSession(w, r); !ok {
+			return
+		}
+	}
+	// Idle time counts from the end of the request
+	defer s.touch(sess)
+
+	var requests []*Message
+	for _, msg := range msgs {
+		if msg.IsRequest() {
+			requests = append(requests, msg)
+		} else {
+			s.handleNotification(sess, msg)
+		}
+	}
+	if len(requests) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		events := newEventWriter(w)
+		defer events.close()
+		for _, msg := range requests {
+			if resp := s.handleRequest(r.Context(), sess, msg, events.write); resp != nil {
+				events.write(resp)
+			}
+		}
+		return
+	}
+
+	var responses []*Message
+	discard := func(*Message) error { return nil }
+	for _, msg := range requests {
+		if resp := s.handleRequest(r.Context(), sess, msg, discard); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	switch {
+	case len(responses) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case len(msgs) == 1:
+		writeJSON(w, http.StatusOK, responses[0])
+	default:
+		writeJSON(w, http.StatusOK, responses)
+	}
+}
+
+// handleGet opens the stream for server-initiated messages
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "text/event-stream required", http.StatusNotAcceptable)
+		return
+	}
+
+	sess, ok := s.httpSession(w, r)
+	if !ok {
+		return
+	}
+
+	sess.mu.Lock()
+	if sess.send != nil {
+		sess.mu.Unlock()
+		http.Error(w, "stream already open", http.StatusConflict)
+		return
+	}
+	events := newEventWriter(w)
+	sess.send = events.write
+	sess.mu.Unlock()
+
+	<-r.Context().Done()
+
+	sess.mu.Lock()
+	sess.send = nil
+	sess.lastSeen = s.now()
+	sess.mu.Unlock()
+	// A notification may hold events.write already; it must not write
+	// once the handler returns
+	events.close()
+}
+
+// httpSession returns the session named by the request, writing an error
+// response if there is none
+func (s *Server) httpSession(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	sess, ok := s.byID[id]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return nil, false
+	}
+
+	s.touch(sess)
+	return sess, true
+}
+
+// touch records that the client of sess was just active
+func (s *Server) touch(sess *session) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.lastSeen = s.now()
+}
+
+// expireSessions drops the HTTP sessions idle for longer than the session
+// timeout. It scans the sessions at most every quarter of the timeout.
+func (s *Server) expireSessions() {
+	if s.sessionTimeout <= 0 {
+		return
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < s.sessionTimeout/4 {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	var expired []*session
+	for _, sess := range s.byID {
+		sess.mu.Lock()
+		idle := sess.send == nil && len(sess.inflight) == 0 && now.Sub(sess.lastSeen) > s.sessionTimeout
+		sess.mu.Unlock()
+		if idle {
+			expired = append(expired, sess)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sess := range expired {
+		s.removeSession(sess)
+	}
+}
+
+// allowedOrigin reports whether a request with the Origin header origin
+// may reach the server
+func (s *Server) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	if s.allowedOrigins != nil {
+		return s.allowedOrigins[origin]
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// eventWriter writes messages as server-sent events until closed
+type eventWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	started bool
+	closed  bool
+}
+
+func newEventWriter(w http.ResponseWriter) *eventWriter {
+	return &eventWriter{w: w}
+}
+
+func (e *eventWriter) write(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrConnectionClosed
+	}
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.started = true
+	}
+
+	if _, err := fmt.Fprintf(e.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// close stops further writes, waiting for one in progress; the handler
+// owning the ResponseWriter calls it before returning
+func (e *eventWriter) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+}
+
+func decodeMessages(body []byte) ([]*Message, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	if body[0] == '[' {
+		var msgs []*Message
+		if err := json.Unmarshal(body, &msgs); err != nil {
+			return nil, err
+		}
+		return msgs, nil
+	}
+
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	return []*Message{&msg}, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/tools"
+	"github.com/llmx-ai/llmx/tools/builtin"
+)
+
+func newTestRegistry(t *testing.T) *tools.Registry {
+	t.Helper()
+	registry := tools.NewRegistry()
+	registry.Register(builtin.CalculatorTool())
+	registry.Register(tools.NewTypedTool("fail", "Always fails",
+		func(ctx context.Context, in struct{}) (string, error) {
+			return "", errors.New("boom")
+		}))
+	return registry
+}
+
+func TestServer_HTTP(t *testing.T) {
+	registry := newTestRegistry(t)
+	server := NewServer(registry, WithServerInfo("test-server", "0.0.1"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ctx := context.Background()
+	client := NewClient(NewHTTPTransport(ts.URL))
+	defer client.Close()
+
+	local := tools.NewRegistry()
+	if err := client.RegisterTools(ctx, local); err != nil {
+		t.Fatalf("RegisterTools() error = %v", err)
+	}
+	if info := client.ServerInfo(); info.ServerInfo.Name != "test-server" {
+		t.Errorf("unexpected server info %+v", info.ServerInfo)
+	}
+
+	calc, ok := local.Get("calculator")
+	if !ok {
+		t.Fatal("expected calculator to be registered")
+	}
+	if calc.Parameters.Properties["expression"] == nil {
+		t.Errorf("expected expression parameter, got %+v", calc.Parameters)
+	}
+
+	result, err := calc.Execute(ctx, json.RawMessage(`{"expression":"2 + 3"}`))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.IsError || result.Output != "2 + 3 = 5" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if result.Metadata["expression"] != "2 + 3" {
+		t.Errorf("expected metadata to round-trip, got %v", result.Metadata)
+	}
+
+	t.Run("tool error", func(t *testing.T) {
+		fail, _ := local.Get("fail")
+		result, err := fail.Execute(ctx, nil)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if !result.IsError || result.Output != "boom" {
+			t.Errorf("expected error result 'boom', got %+v", result)
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		result, err := calc.Execute(ctx, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if !result.IsError {
+			t.Errorf("expected error result, got %+v", result)
+		}
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		var rpcErr *RPCError
+		if _, err := client.CallTool(ctx, "missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+			t.Errorf("expected invalid params error, got %v", err)
+		}
+	})
+
+	t.Run("list changed", func(t *testing.T) {
+		registry.Register(builtin.DateTimeTool())
+		// The GET stream opens asynchronously, so notify until it lands
+		waitFor(t, "datetime", func() bool {
+			server.NotifyToolsChanged()
+			return local.Has("get_datetime")
+		})
+	})
+}
+
+func TestServer_StdioProgressAndCancel(t *testing.T) {
+	started := make(chan struct{})
+	registry := tools.NewRegistry()
+	registry.Register(llmx.Tool{
+		Name: "slow",
+		Execute: func(ctx context.Context, args json.RawMessage) (*llmx.ToolResult, error) {
+			ReportProgress(ctx, 1, 2, "halfway")
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(registry).Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+
+	enc := json.NewEncoder(inW)
+	out := bufio.NewScanner(outR)
+	next := func() *Message {
+		t.Helper()
+		if !out.Scan() {
+			t.Fatalf("server output ended: %v", out.Err())
+		}
+		var msg Message
+		if err := json.Unmarshal(out.Bytes(), &msg); err != nil {
+			t.Fatalf("invalid server output %q: %v", out.Bytes(), err)
+		}
+		return &msg
+	}
+
+	enc.Encode(Message{JSONRPC: "2.0", ID: json.RawMessage("1"), Method: MethodInitialize,
+		Params: json.RawMessage(`{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}`)})
+	var init InitializeResult
+	json.Unmarshal(next().Result, &init)
+	if init.ProtocolVersion != "2024-11-05" {
+		t.Errorf("expected negotiated version 2024-11-05, got %s", init.ProtocolVersion)
+	}
+
+	enc.Encode(Message{JSONRPC: "2.0", ID: json.RawMessage("2"), Method: MethodToolsCall,
+		Params: json.RawMessage(`{"name":"slow","_meta":{"progressToken":"tok"}}`)})
+
+	progress := next()
+	var params ProgressParams
+	json.Unmarshal(progress.Params, &params)
+	if progress.Method != MethodProgress || string(params.ProgressToken) != `"tok"` || params.Progress != 1 || params.Total != 2 {
+		t.Errorf("unexpected progress notification %s %s", progress.Method, progress.Params)
+	}
+
+	<-started
+	enc.Encode(Message{JSONRPC: "2.0", Method: MethodCancelled, Params: json.RawMessage(`{"requestId":2}`)})
+	enc.Encode(Message{JSONRPC: "2.0", ID: json.RawMessage("3"), Method: MethodPing})
+
+	// The cancelled call gets no response, so the ping answer comes next
+	if resp := next(); string(resp.ID) != "3" || resp.Error != nil {
+		t.Errorf("expected ping response, got %+v", resp)
+	}
+
+	inW.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+// postInitialize sends initialize with the Origin header origin and returns
+// the response
+func postInitialize(t *testing.T, url, origin string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestServer_HTTPSessionExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	server := NewServer(newTestRegistry(t), WithSessionTimeout(time.Minute))
+	server.now = func() time.Time { return now }
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	idle := postInitialize(t, ts.URL, "").Header.Get(sessionHeader)
+	if idle == "" {
+		t.Fatal("expected a session ID")
+	}
+
+	now = now.Add(2 * time.Minute)
+	active := postInitialize(t, ts.URL, "").Header.Get(sessionHeader)
+
+	server.mu.Lock()
+	_, idleKept := server.byID[idle]
+	_, activeKept := server.byID[active]
+	sessions := len(server.sessions)
+	server.mu.Unlock()
+	if idleKept || !activeKept || sessions != 1 {
+		t.Errorf("idle kept = %v, active kept = %v, sessions = %d; want only the active session", idleKept, activeKept, sessions)
+	}
+}
+
+func TestServer_HTTPOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []ServerOption
+		origin string
+		want   int
+	}{
+		{"no origin", nil, "", http.StatusOK},
+		{"localhost", nil, "http://localhost:3000", http.StatusOK},
+		{"loopback IP", nil, "http://127.0.0.1:8080", http.StatusOK},
+		{"rebound domain", nil, "http://attacker.example", http.StatusForbidden},
+		{"allowed", []ServerOption{WithAllowedOrigins("https://app.example.com/")}, "https://app.example.com", http.StatusOK},
+		{"not allowed", []ServerOption{WithAllowedOrigins("https://app.example.com")}, "http://localhost:3000", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(NewServer(newTestRegistry(t), tt.opts...))
+			defer ts.Close()
+
+			if resp := postInitialize(t, ts.URL, tt.origin); resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventWriter_Closed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	events := newEventWriter(rec)
+	events.close()
+
+	if err := events.write(&Message{JSONRPC: "2.0", Method: MethodToolsListChanged}); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("write() error = %v, want ErrConnectionClosed", err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("wrote %q after close", rec.Body.String())
+	}
+}
@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/tools"
+)
+
+// syncTimeout bounds the tools/list call made when the tool list changes
+const syncTimeout = 30 * time.Second
+
+// registration tracks the tools a client added to a registry
+type registration struct {
+	mu       sync.Mutex
+	registry *tools.Registry
+	names    map[string]bool
+	lastErr  error
+}
+
+// RegisterTools lists the server's tools and registers them in registry.
+// The registry is kept in sync afterwards: when the server reports a
+// changed tool list or restarts, tools are added, updated and removed to
+// match. A client registers into at most one registry.
+func (c *Client) RegisterTools(ctx context.Context, registry *tools.Registry) error {
+	c.mu.Lock()
+	if c.registered != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("mcp: tools are already registered")
+	}
+	reg := &registration{registry: registry, names: make(map[string]bool)}
+	c.registered = reg
+	c.mu.Unlock()
+
+	if err := c.syncTools(ctx, reg); err != nil {
+		c.mu.Lock()
+		c.registered = nil
+		c.mu.Unlock()
+		return err
+	}
+
+	c.OnToolsChanged(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		defer cancel()
+		c.syncTools(ctx, reg)
+	})
+	return nil
+}
+
+// SyncError returns the error from the last background tool list sync,
+// or nil if it succeeded
+func (c *Client) SyncError() error {
+	c.mu.Lock()
+	reg := c.registered
+	c.mu.Unlock()
+
+	if reg == nil {
+		return nil
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.lastErr
+}
+
+// syncTools makes the registry match the server's current tool list
+func (c *Client) syncTools(ctx context.Context, reg *registration) error {
+	infos, err := c.ListTools(ctx)
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.lastErr = err
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		tool, err := c.Tool(info)
+		if err != nil {
+			reg.lastErr = err
+			return err
+		}
+
+		if reg.names[tool.Name] {
+			reg.registry.Remove(tool.Name)
+		} else if reg.registry.Has(tool.Name) {
+			reg.lastErr = fmt.Errorf("mcp: tool %q conflicts with a registered tool", tool.Name)
+			return reg.lastErr
+		}
+		if err := reg.registry.Register(tool); err != nil {
+			reg.lastErr = err
+			return err
+		}
+		current[tool.Name] = true
+	}
+
+	for name := range reg.names {
+		if !current[name] {
+			reg.registry.Remove(name)
+		}
+	}
+	reg.names = current
+
+	return nil
+}
+
+// Tool converts a server tool to an llmx.Tool whose Execute calls it
+func (c *Client) Tool(info ToolInfo) (llmx.Tool, error) {
+	schema, err := ConvertSchema(info.InputSchema)
+	if err != nil {
+		return llmx.Tool{}, fmt.Errorf("mcp: tool %q: %w", info.Name, err)
+	}
+
+	name := info.Name
+	return llmx.Tool{
+		Name:        c.prefix + name,
+		Description: info.Description,
+		Parameters:  schema,
+		Execute: func(ctx context.Context, args json.RawMessage) (*llmx.ToolResult, error) {
+			result, err := c.CallTool(ctx, name, args)
+			if err != nil {
+				return nil, err
+			}
+			return convertResult(result), nil
+		},
+	}, nil
+}
+
+// convertResult flattens tool output into an llmx.ToolResult. Text blocks
+// are joined; other content is summarized, since tool results are text.
+func convertResult(result *CallToolResult) *llmx.ToolResult {
+	var parts []string
+	for _, content := range result.Content {
+		switch content.Type {
+		case "text":
+			parts = append(parts, content.Text)
+		case "resource":
+			if content.Resource == nil {
+				continue
+			}
+			if content.Resource.Text != "" {
+				parts = append(parts, content.Resource.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s]", content.Resource.URI))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[%s: %s]", content.Type, content.MimeType))
+		}
+	}
+
+	output := strings.Join(parts, "\n")
+	if output == "" && len(result.StructuredContent) > 0 {
+		output = string(result.StructuredContent)
+	}
+
+	toolResult := &llmx.ToolResult{
+		Output:  output,
+		IsError: result.IsError,
+	}
+	if len(result.Meta) > 0 || len(result.StructuredContent) > 0 {
+		toolResult.Metadata = make(map[string]interface{}, len(result.Meta)+1)
+		for k, v := range result.Meta {
+			toolResult.Metadata[k] = v
+		}
+		if len(result.StructuredContent) > 0 {
+			toolResult.Metadata["structured_content"] = result.StructuredContent
+		}
+	}
+	return toolResult
+}
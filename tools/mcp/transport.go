@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrConnectionClosed is returned for calls that were in flight when the
+// connection to the server ended
+var ErrConnectionClosed = errors.New("mcp: connection closed")
+
+// ErrSessionExpired is returned by a Conn when the server no longer knows
+// the session; the client reconnects and retries
+var ErrSessionExpired = errors.New("mcp: session expired")
+
+// Transport opens connections to an MCP server
+type Transport interface {
+	Connect(ctx context.Context) (Conn, error)
+}
+
+// Conn is an open connection to an MCP server. Receive is called from a
+// single goroutine and returns an error once the connection has ended.
+type Conn interface {
+	Send(ctx context.Context, msg *Message) error
+	Receive() (*Message, error)
+	Close() error
+}
+
+// StdioTransport launches an MCP server as a subprocess and exchanges
+// newline-delimited JSON-RPC messages over its stdin and stdout
+type StdioTransport struct {
+	Command string
+	Args    []string
+	Env     []string // Added to the current environment
+	Dir     string
+
+	// Stderr receives the server's log output; nil discards it
+	Stderr io.Writer
+}
+
+// NewStdioTransport returns a transport that runs command with args
+func NewStdioTransport(command string, args ...string) *StdioTransport {
+	return &StdioTransport{Command: command, Args: args}
+}
+
+// Connect starts the server process. The process outlives ctx; it is
+// stopped by closing the connection.
+func (t *StdioTransport) Connect(ctx context.Context) (Conn, error) {
+	cmd := exec.Command(t.Command, t.Args...)
+	cmd.Dir = t.Dir
+	if len(t.Env) > 0 {
+		cmd.Env = append(os.Environ(), t.Env...)
+	}
+	cmd.Stderr = t.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: stdin pipe: %w", err)
+	}
+	// Wait closes the pipes of StdoutPipe as soon as the process exits,
+	// which would drop the messages it wrote last, so stdout is a pipe the
+	// connection owns
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: stdout pipe: %w", err)
+	}
+	cmd.Stdout = stdoutWriter
+
+	err = cmd.Start()
+	stdoutWriter.Close()
+	if err != nil {
+		stdin.Close()
+		stdout.Close()
+		return nil, fmt.Errorf("mcp: start %s: %w", t.Command, err)
+	}
+
+	conn := &stdioConn{
+		cmd:     cmd,
+		stdin:   stdin,
+		stdout:  stdout,
+		scanner: bufio.NewScanner(stdout),
+		exited:  make(chan struct{}),
+	}
+	conn.scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	go func() {
+		conn.waitErr = cmd.Wait()
+		close(conn.exited)
+	}()
+
+	return conn, nil
+}
+
+type stdioConn struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  io.Closer
+	scanner *bufio.Scanner
+
+	writeMu sync.Mutex
+
+	exited  chan struct{}
+	waitErr error
+
+	closeOnce sync.Once
+}
+
+func (c *stdioConn) Send(ctx context.Context, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("mcp: encode message: %w", err)
+	}
+	data = append(data, '\n')
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := c.stdin.Write(data); err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	return nil
+}
+
+func (c *stdioConn) Receive() (*Message, error) {
+	for c.scanner.Scan() {
+		line := c.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			// Servers must only write messages to stdout; skip stray output
+			continue
+		}
+		return &msg, nil
+	}
+
+	if err := c.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Close closes the server's stdin and waits briefly for it to exit before
+// killing it
+func (c *stdioConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+
+		select {
+		case <-c.exited:
+		case <-time.After(2 * time.Second):
+			c.cmd.Process.Kill()
+			<-c.exited
+		}
+		c.stdout.Close()
+	})
+	return nil
+}
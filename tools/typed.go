@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/llmx-ai/llmx"
+)
+
+// NewTypedTool creates a tool from a typed Go function. The parameters
+// schema is derived from In, which must be a struct; arguments are decoded
+// into In and validated before fn runs, and the returned Out is marshaled
+// to JSON (strings are used as-is). Decoding, validation and fn errors
+// become IsError results so the model can see what went wrong.
+//
+// Struct fields use their json tags for names; fields without omitempty
+// and not of pointer type are required. A description tag documents a
+// field and an enum tag lists its allowed values, separated by commas:
+//
+//	type WeatherInput struct {
+//		City string `json:"city" description:"City name"`
+//		Unit string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+//	}
+//
+// NewTypedTool panics if In is not a struct type.
+func NewTypedTool[In, Out any](
+	name, description string,
+	fn func(ctx context.Context, in In) (Out, error),
+) llmx.Tool {
+	t := reflect.TypeOf((*In)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tools: NewTypedTool %s: input type %s is not a struct", name, t))
+	}
+
+	schema := SchemaFor(t)
+
+	return llmx.Tool{
+		Name:        name,
+		Description: description,
+		Parameters:  schema,
+		Execute: func(ctx context.Context, args json.RawMessage) (*llmx.ToolResult, error) {
+			if len(args) == 0 {
+				args = json.RawMessage("{}")
+			}
+
+			if err := ValidateToolArguments(schema, args); err != nil {
+				return errorResult(fmt.Sprintf("Invalid arguments: %v", err)), nil
+			}
+
+			var in In
+			if err := json.Unmarshal(args, &in); err != nil {
+				return errorResult(fmt.Sprintf("Invalid arguments: %v", err)), nil
+			}
+
+			out, err := fn(ctx, in)
+			if err != nil {
+				return errorResult(err.Error()), nil
+			}
+
+			if s, ok := any(out).(string); ok {
+				return &llmx.ToolResult{Output: s}, nil
+			}
+
+			data, err := json.Marshal(out)
+			if err != nil {
+				return errorResult(fmt.Sprintf("Invalid result: %v", err)), nil
+			}
+			return &llmx.ToolResult{Output: string(data)}, nil
+		},
+	}
+}
+
+// errorResult returns a tool result reporting a failure to the model
+func errorResult(msg string) *llmx.ToolResult {
+	return &llmx.ToolResult{Output: msg, IsError: true}
+}
+
+// SchemaFor derives a JSON schema from a Go type using the same rules as
+// NewTypedTool
+func SchemaFor(t reflect.Type) *llmx.Schema {
+	return schemaForType(t, map[reflect.Type]bool{})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType derives a schema for t. seen guards against recursive types,
+// which are described as plain objects on the second visit.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) *llmx.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &llmx.Schema{Type: "string", Description: "RFC 3339 timestamp"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &llmx.Schema{Type: "string"}
+	case reflect.Bool:
+		return &llmx.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &llmx.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &llmx.Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &llmx.Schema{Type: "array", Items: schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return &llmx.Schema{Type: "object"}
+	case reflect.Struct:
+		if seen[t] {
+			return &llmx.Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		schema := &llmx.Schema{
+			Type:       "object",
+			Properties: make(map[string]*llmx.Schema),
+		}
+		addStructFields(schema, t, seen)
+		return schema
+	default:
+		return &llmx.Schema{Type: "string"}
+	}
+}
+
+// addStructFields adds the exported fields of t to an object schema.
+// Embedded structs without a json name are flattened, as encoding/json does.
+func addStructFields(schema *llmx.Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addStructFields(schema, fieldType, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := schemaForType(field.Type, seen)
+		if desc := field.Tag.Get("description"); desc != "" {
+			fieldSchema.Description = desc
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, v := range strings.Split(enum, ",") {
+				fieldSchema.Enum = append(fieldSchema.Enum, strings.TrimSpace(v))
+			}
+		}
+		schema.Properties[name] = fieldSchema
+
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type weatherInput struct {
+	City  string   `json:"city" description:"City name"`
+	Unit  string   `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Days  int      `json:"days,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Debug *bool    `json:"debug"`
+}
+
+type weatherOutput struct {
+	City string  `json:"city"`
+	Temp float64 `json:"temp"`
+}
+
+func TestNewTypedTool_Schema(t *testing.T) {
+	tool := NewTypedTool("get_weather", "Get the weather",
+		func(ctx context.Context, in weatherInput) (weatherOutput, error) {
+			return weatherOutput{}, nil
+		})
+
+	schema := tool.Parameters
+	if schema.Type != "object" {
+		t.Fatalf("expected object schema, got %s", schema.Type)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"city"}) {
+		t.Errorf("expected required [city], got %v", schema.Required)
+	}
+	if got := schema.Properties["city"].Description; got != "City name" {
+		t.Errorf("expected city description, got %q", got)
+	}
+	if got := schema.Properties["unit"].Enum; !reflect.DeepEqual(got, []interface{}{"celsius", "fahrenheit"}) {
+		t.Errorf("expected unit enum, got %v", got)
+	}
+	if got := schema.Properties["days"].Type; got != "integer" {
+		t.Errorf("expected integer days, got %s", got)
+	}
+	if got := schema.Properties["tags"]; got.Type != "array" || got.Items.Type != "string" {
+		t.Errorf("expected string array tags, got %+v", got)
+	}
+}
+
+func TestNewTypedTool_Execute(t *testing.T) {
+	tool := NewTypedTool("get_weather", "Get the weather",
+		func(ctx context.Context, in weatherInput) (weatherOutput, error) {
+			if in.City == "Atlantis" {
+				return weatherOutput{}, errors.New("city not found")
+			}
+			return weatherOutput{City: in.City, Temp: 21.5}, nil
+		})
+
+	tests := []struct {
+		name      string
+		args      string
+		want      string
+		wantError bool
+	}{
+		{name: "valid", args: `{"city":"Paris","unit":"celsius"}`, want: `{"city":"Paris","temp":21.5}`},
+		{name: "missing required", args: `{"unit":"celsius"}`, wantError: true},
+		{name: "wrong type", args: `{"city":42}`, wantError: true},
+		{name: "not in enum", args: `{"city":"Paris","unit":"kelvin"}`, wantError: true},
+		{name: "fractional integer", args: `{"city":"Paris","days":1.5}`, wantError: true},
+		{name: "function error", args: `{"city":"Atlantis"}`, want: "city not found", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), json.RawMessage(tt.args))
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v (output %q)", result.IsError, tt.wantError, result.Output)
+			}
+			if tt.want != "" && result.Output != tt.want {
+				t.Errorf("Output = %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTypedTool_StringOutput(t *testing.T) {
+	tool := NewTypedTool("echo", "Echo the input",
+		func(ctx context.Context, in struct {
+			Text string `json:"text"`
+		}) (string, error) {
+			return in.Text, nil
+		})
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{"text":"hello"}`))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Output != "hello" {
+		t.Errorf("expected output 'hello', got %q", result.Output)
+	}
+}
+
+func TestNewTypedTool_NonStructPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-struct input")
+		}
+	}()
+	NewTypedTool("bad", "", func(ctx context.Context, in string) (string, error) { return in, nil })
+}
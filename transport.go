@@ -0,0 +1,136 @@
+package llmx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/llmx-ai/llmx/auth"
+	"github.com/llmx-ai/llmx/provider"
+)
+
+// providerOptions returns the provider options with the configured HTTP
+// client added under provider.OptHTTPClient, unless one is already set.
+// With a CredentialProvider, the credential option holds its current
+// credential, and the HTTP client sets the credential header of every
+// request from it. An Authenticator replaces the provider's own
+// authentication of every request.
+func (c *Config) providerOptions() (map[string]interface{}, error) {
+	opts := make(map[string]interface{}, len(c.ProviderOptions)+1)
+	for k, v := range c.ProviderOptions {
+		opts[k] = v
+	}
+
+	spec := c.optionValidator().Options()
+	if c.Credentials != nil && spec.Credential != "" {
+		credential, err := c.Credentials.Credential(context.Background())
+		if err != nil {
+			return nil, NewAuthenticationError(err.Error())
+		}
+		opts[spec.Credential] = credential
+	} else if c.Authenticator != nil && spec.Credential != "" && !provider.HasOption(opts, spec.Credential) {
+		opts[spec.Credential] = authenticatorCredential
+	}
+	if provider.HTTPClient(opts) != nil {
+		return opts, nil
+	}
+
+	client, err := c.buildHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	if authenticator := c.authenticator(spec); authenticator != nil {
+		client.Transport = &auth.Transport{Base: client.Transport, Authenticator: authenticator}
+	}
+	opts[provider.OptHTTPClient] = client
+	return opts, nil
+}
+
+// authenticator returns the authenticator of the configured client: the
+// Authenticator, or one setting the credential header of the provider from
+// the CredentialProvider; nil if neither applies
+func (c *Config) authenticator(spec provider.OptionSpec) auth.Authenticator {
+	switch {
+	case c.Authenticator != nil:
+		return c.Authenticator
+	case c.Credentials != nil && spec.Credential != "" && spec.CredentialHeader != "":
+		return auth.Header(spec.CredentialHeader, spec.CredentialPrefix, c.Credentials)
+	}
+	return nil
+}
+
+// buildHTTPClient returns a copy of the configured HTTP client with the
+// proxy, TLS settings and extra headers applied
+func (c *Config) buildHTTPClient() (*http.Client, error) {
+	client := &http.Client{}
+	if c.HTTPClient != nil {
+		*client = *c.HTTPClient
+	}
+	if c.Timeout > 0 {
+		client.Timeout = c.Timeout
+	}
+
+	if c.ProxyURL != "" || c.TLSConfig != nil {
+		var transport *http.Transport
+		switch base := client.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = base.Clone()
+		default:
+			return nil, NewInvalidRequestError(
+				fmt.Sprintf("proxy and TLS settings need an *http.Transport, got %T", base), nil)
+		}
+
+		if c.ProxyURL != "" {
+			proxyURL, err := url.Parse(c.ProxyURL)
+			if err != nil {
+				return nil, NewInvalidRequestError("invalid proxy URL", map[string]interface{}{
+					"proxy": c.ProxyURL,
+				})
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+		if c.TLSConfig != nil {
+			transport.TLSClientConfig = c.TLSConfig.Clone()
+		}
+		client.Transport = transport
+	}
+
+	if len(c.Headers) > 0 {
+		base := client.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		client.Transport = &headerTransport{base: base, headers: c.Headers.Clone()}
+	}
+	return client, nil
+}
+
+// headerTransport adds extra headers to every request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// RoundTrip sends req with the extra headers, which replace any the
+// provider set under the same names
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header.Del(name)
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the wrapped
+// transport
+func (t *headerTransport) CloseIdleConnections() {
+	if closer, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,150 @@
+package llmx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/llmx-ai/llmx/provider"
+)
+
+// probeProvider keeps the options it was created with
+type probeProvider struct {
+	mockProvider
+	opts map[string]interface{}
+}
+
+func init() {
+	provider.Register("transport-probe", func(opts map[string]interface{}) (provider.Provider, error) {
+		return &probeProvider{opts: opts}, nil
+	})
+}
+
+// providerHTTPClient creates a client and returns the HTTP client its
+// provider was given
+func providerHTTPClient(t *testing.T, opts ...Option) *http.Client {
+	t.Helper()
+	opts = append([]Option{WithProvider("transport-probe", map[string]interface{}{"api_key": "test-key"})}, opts...)
+	client, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	httpClient := provider.HTTPClient(client.provider.(*probeProvider).opts)
+	if httpClient == nil {
+		t.Fatal("provider was not given an HTTP client")
+	}
+	return httpClient
+}
+
+func TestNewClient_HTTPClient(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		httpClient := providerHTTPClient(t, WithTimeout(5*time.Second))
+		if httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected timeout 5s, got %v", httpClient.Timeout)
+		}
+	})
+
+	t.Run("custom client", func(t *testing.T) {
+		transport := &http.Transport{}
+		httpClient := providerHTTPClient(t, WithHTTPClient(&http.Client{Transport: transport}))
+		if httpClient.Transport != transport {
+			t.Error("expected the configured transport to be used")
+		}
+	})
+
+	t.Run("explicit provider option wins", func(t *testing.T) {
+		explicit := &http.Client{}
+		client, err := NewClient(
+			WithProvider("transport-probe", map[string]interface{}{"api_key": "test-key", "http_client": explicit}),
+			WithHeader("X-Team", "a"),
+		)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		if provider.HTTPClient(client.provider.(*probeProvider).opts) != explicit {
+			t.Error("expected the http_client provider option to be kept")
+		}
+	})
+
+	t.Run("custom transport with proxy", func(t *testing.T) {
+		_, err := NewClient(
+			WithProvider("transport-probe", map[string]interface{}{"api_key": "test-key"}),
+			WithHTTPClient(&http.Client{Transport: roundTripFunc(nil)}),
+			WithProxy("http://proxy.internal:3128"),
+		)
+		if err == nil {
+			t.Error("expected an error for a proxy on a custom RoundTripper")
+		}
+	})
+
+	t.Run("invalid proxy", func(t *testing.T) {
+		_, err := NewClient(
+			WithProvider("transport-probe", map[string]interface{}{"api_key": "test-key"}),
+			WithProxy("ftp://proxy.internal"),
+		)
+		if err == nil {
+			t.Error("expected an error for an ftp proxy")
+		}
+	})
+}
+
+func TestNewClient_HeadersAndProxy(t *testing.T) {
+	var gotURL, gotTeam, gotAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotTeam = r.Header.Get("X-Team")
+		gotAuth = r.Header.Get("Authorization")
+		io.WriteString(w, "ok")
+	}))
+	defer proxy.Close()
+
+	httpClient := providerHTTPClient(t, WithProxy(proxy.URL), WithHeader("X-Team", "search"))
+
+	req, _ := http.NewRequest("GET", "http://api.provider.test/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotURL != "http://api.provider.test/v1/models" {
+		t.Errorf("expected the request to go through the proxy, got %q", gotURL)
+	}
+	if gotTeam != "search" || gotAuth != "Bearer key" {
+		t.Errorf("expected extra and provider headers, got X-Team=%q Authorization=%q", gotTeam, gotAuth)
+	}
+	if req.Header.Get("X-Team") != "" {
+		t.Error("the caller's request must not be modified")
+	}
+}
+
+func TestNewClient_TLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	if _, err := providerHTTPClient(t).Get(ts.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be rejected by default")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	httpClient := providerHTTPClient(t, WithTLSConfig(&tls.Config{RootCAs: pool}))
+	resp, err := httpClient.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("expected the configured CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
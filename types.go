@@ -88,7 +88,28 @@ type ChatRequest struct {
 	// Tools
 	Tools []Tool `json:"tools,omitempty"`
 
-	// Provider-specific options
+	// ToolChoice controls whether and which tools the model may call;
+	// nil leaves the provider default (usually auto)
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// ParallelToolCalls allows or forbids several tool calls in one turn;
+	// nil leaves the provider default
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// N asks for several alternative completions, returned in
+	// ChatResponse.Choices; nil means one
+	N *int `json:"n,omitempty"`
+
+	// Logprobs asks for the log probability of each output token, and
+	// TopLogprobs for that many of the most likely tokens at each position
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs *int `json:"top_logprobs,omitempty"`
+
+	// Seed makes sampling repeatable on a best-effort basis
+	Seed *int `json:"seed,omitempty"`
+
+	// Provider-specific options keyed by provider name. Values must implement
+	// ProviderRequestOptions; attach them with SetProviderOptions.
 	ProviderOptions map[string]interface{} `json:"provider_options,omitempty"`
 }
 
@@ -99,15 +120,103 @@ type ChatResponse struct {
 	Content   string     `json:"content"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 
+	// Citations link spans of Content to grounding documents (RAG providers)
+	Citations []Citation `json:"citations,omitempty"`
+
+	// Choices holds every alternative completion, at least one. Content,
+	// ToolCalls and FinishReason are those of the first.
+	Choices []Choice `json:"choices,omitempty"`
+
 	// Metadata
-	Usage        Usage     `json:"usage"`
-	FinishReason string    `json:"finish_reason"`
-	CreatedAt    time.Time `json:"created_at"`
+	Usage        Usage        `json:"usage"`
+	FinishReason FinishReason `json:"finish_reason"`
+	CreatedAt    time.Time    `json:"created_at"`
+
+	// RawFinishReason is the finish reason as the provider reported it
+	RawFinishReason string `json:"raw_finish_reason,omitempty"`
+
+	// Metadata describes how the provider served the request
+	Metadata ResponseMetadata `json:"metadata"`
 
 	// Raw response for debugging
 	Raw interface{} `json:"raw,omitempty"`
 }
 
+// Choice is one of the alternative completions of a response
+type Choice struct {
+	Index           int            `json:"index"`
+	Content         string         `json:"content"`
+	ToolCalls       []ToolCall     `json:"tool_calls,omitempty"`
+	FinishReason    FinishReason   `json:"finish_reason"`
+	RawFinishReason string         `json:"raw_finish_reason,omitempty"`
+	Logprobs        []TokenLogprob `json:"logprobs,omitempty"`
+}
+
+// TokenLogprob is the log probability of an output token
+type TokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []byte  `json:"bytes,omitempty"`
+
+	// TopLogprobs are the most likely tokens at this position
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is one of the most likely tokens at an output position
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []byte  `json:"bytes,omitempty"`
+}
+
+// FinishReason is why the model stopped generating, normalized across
+// providers
+type FinishReason string
+
+const (
+	FinishReasonStop          FinishReason = "stop"           // natural end or stop sequence
+	FinishReasonLength        FinishReason = "length"         // output token or context limit reached
+	FinishReasonToolCalls     FinishReason = "tool_calls"     // the model called tools
+	FinishReasonContentFilter FinishReason = "content_filter" // output blocked by a safety filter
+	FinishReasonError         FinishReason = "error"          // generation failed at the provider
+)
+
+// ResponseMetadata describes how the provider served a request
+type ResponseMetadata struct {
+	Provider  string `json:"provider"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Latency is the time from sending the request to the complete
+	// response; TimeToFirstToken is the time to the first streamed output
+	Latency          time.Duration `json:"latency"`
+	TimeToFirstToken time.Duration `json:"time_to_first_token,omitempty"`
+
+	// SystemFingerprint identifies the backend configuration (OpenAI)
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// RateLimit is the rate limit state the provider reported, if any
+	RateLimit *RateLimitInfo `json:"rate_limit,omitempty"`
+}
+
+// RateLimitInfo is a provider's rate limit state after a request. Counts
+// the provider did not report are -1.
+type RateLimitInfo struct {
+	LimitRequests     int           `json:"limit_requests"`
+	LimitTokens       int           `json:"limit_tokens"`
+	RemainingRequests int           `json:"remaining_requests"`
+	RemainingTokens   int           `json:"remaining_tokens"`
+	ResetRequests     time.Duration `json:"reset_requests,omitempty"`
+	ResetTokens       time.Duration `json:"reset_tokens,omitempty"`
+}
+
+// Citation links a span of generated text to the documents that support it
+type Citation struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	DocumentIDs []string `json:"document_ids,omitempty"`
+}
+
 // Usage represents token usage information
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
@@ -117,10 +226,86 @@ type Usage struct {
 
 // Tool represents a function that can be called by the AI
 type Tool struct {
-	Name        string
-	Description string
-	Parameters  *Schema
-	Execute     ToolExecuteFunc
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  *Schema         `json:"parameters,omitempty"`
+	Execute     ToolExecuteFunc `json:"-"`
+}
+
+// ToolChoiceMode selects how the model may use the request's tools
+type ToolChoiceMode string
+
+const (
+	ToolChoiceAuto     ToolChoiceMode = "auto"     // the model decides
+	ToolChoiceNone     ToolChoiceMode = "none"     // no tool calls
+	ToolChoiceRequired ToolChoiceMode = "required" // at least one tool call
+	ToolChoiceFunction ToolChoiceMode = "function" // call the tool named by ToolChoice.Name
+)
+
+// ToolChoice controls tool calling for a request
+type ToolChoice struct {
+	Mode ToolChoiceMode `json:"mode"`
+	Name string         `json:"name,omitempty"`
+}
+
+// ForceTool returns a ToolChoice that requires a call to the named tool
+func ForceTool(name string) *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceFunction, Name: name}
+}
+
+// ValidateToolChoice checks the request's tool choice against its tools
+func ValidateToolChoice(req *ChatRequest) error {
+	choice := req.ToolChoice
+	if choice == nil {
+		return nil
+	}
+
+	switch choice.Mode {
+	case ToolChoiceAuto, ToolChoiceNone:
+		return nil
+	case ToolChoiceRequired:
+		if len(req.Tools) == 0 {
+			return NewInvalidRequestError("tool choice \"required\" needs at least one tool", nil)
+		}
+		return nil
+	case ToolChoiceFunction:
+		if choice.Name == "" {
+			return NewInvalidRequestError("tool choice \"function\" needs a tool name", nil)
+		}
+		for _, tool := range req.Tools {
+			if tool.Name == choice.Name {
+				return nil
+			}
+		}
+		return NewInvalidRequestError(
+			fmt.Sprintf("tool choice names unknown tool %q", choice.Name),
+			map[string]interface{}{"tool": choice.Name},
+		)
+	}
+
+	return NewInvalidRequestError(fmt.Sprintf("unknown tool choice mode %q", choice.Mode), nil)
+}
+
+// MaxTopLogprobs is the largest TopLogprobs providers accept
+const MaxTopLogprobs = 20
+
+// ValidateSampling checks the request's choice count and logprobs settings
+func ValidateSampling(req *ChatRequest) error {
+	if req.N != nil && *req.N < 1 {
+		return NewInvalidRequestError("n must be at least 1", map[string]interface{}{"n": *req.N})
+	}
+	if req.TopLogprobs != nil {
+		if !req.Logprobs {
+			return NewInvalidRequestError("top_logprobs needs logprobs", nil)
+		}
+		if *req.TopLogprobs < 0 || *req.TopLogprobs > MaxTopLogprobs {
+			return NewInvalidRequestError(
+				fmt.Sprintf("top_logprobs must be between 0 and %d", MaxTopLogprobs),
+				map[string]interface{}{"top_logprobs": *req.TopLogprobs},
+			)
+		}
+	}
+	return nil
 }
 
 // ToolExecuteFunc is the function signature for tool execution
@@ -135,7 +320,8 @@ type ToolResult struct {
 
 // Schema represents a JSON Schema for tool parameters
 type Schema struct {
-	Type        string             `json:"type"`
+	// Type is empty for values of any type
+	Type        string             `json:"type,omitempty"`
 	Description string             `json:"description,omitempty"`
 	Properties  map[string]*Schema `json:"properties,omitempty"`
 	Required    []string           `json:"required,omitempty"`
@@ -0,0 +1,86 @@
+package vcr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cassetteVersion is the version of the cassette file format
+const cassetteVersion = 1
+
+// Cassette is the recorded traffic stored in a cassette file
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	used bool
+}
+
+// Request is a recorded HTTP request, with credentials redacted
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded HTTP response. Streamed responses keep the
+// chunks the body was read in, with the time each one took to arrive, and
+// leave Body empty.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Chunks  []Chunk     `json:"chunks,omitempty"`
+
+	// Duration is how long the response headers took to arrive
+	Duration time.Duration `json:"duration"`
+}
+
+// Chunk is a piece of a streamed response body
+type Chunk struct {
+	Data string `json:"data"`
+
+	// Delay is the time since the previous chunk, or since the response
+	// headers for the first one
+	Delay time.Duration `json:"delay"`
+}
+
+// LoadCassette reads the cassette file at path
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("vcr: invalid cassette %s: %w", path, err)
+	}
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("vcr: cassette %s has unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, creating its directory if needed
+func (c *Cassette) Save(path string) error {
+	c.Version = cassetteVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
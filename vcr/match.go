@@ -0,0 +1,105 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Matcher reports whether a recorded request answers an incoming one.
+// Both are redacted before matching.
+type Matcher func(req, recorded *Request) bool
+
+// DefaultMatcher matches on method and URL
+var DefaultMatcher = All(MatchMethod(), MatchURL())
+
+// MatchMethod matches requests with the same method
+func MatchMethod() Matcher {
+	return func(req, recorded *Request) bool {
+		return req.Method == recorded.Method
+	}
+}
+
+// MatchURL matches requests with the same URL, ignoring the order of
+// query parameters
+func MatchURL() Matcher {
+	return func(req, recorded *Request) bool {
+		a, errA := url.Parse(req.URL)
+		b, errB := url.Parse(recorded.URL)
+		if errA != nil || errB != nil {
+			return req.URL == recorded.URL
+		}
+		return a.Scheme == b.Scheme && a.Host == b.Host && a.Path == b.Path &&
+			a.Query().Encode() == b.Query().Encode()
+	}
+}
+
+// MatchPath matches requests with the same URL path, whatever the host,
+// which lets cassettes recorded against one server replay against another
+func MatchPath() Matcher {
+	return func(req, recorded *Request) bool {
+		a, errA := url.Parse(req.URL)
+		b, errB := url.Parse(recorded.URL)
+		if errA != nil || errB != nil {
+			return false
+		}
+		return a.Path == b.Path
+	}
+}
+
+// MatchBody matches requests with the same body. JSON bodies are compared
+// by value, so formatting and key order do not matter.
+func MatchBody() Matcher {
+	return func(req, recorded *Request) bool {
+		if req.Body == recorded.Body {
+			return true
+		}
+		a, okA := normalizeJSON(req.Body)
+		b, okB := normalizeJSON(recorded.Body)
+		return okA && okB && a == b
+	}
+}
+
+// MatchHeaders matches requests with the same values for the named
+// headers
+func MatchHeaders(names ...string) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, name := range names {
+			name = http.CanonicalHeaderKey(name)
+			if strings.Join(req.Headers[name], ",") != strings.Join(recorded.Headers[name], ",") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// All matches requests matched by every matcher
+func All(matchers ...Matcher) Matcher {
+	return func(req, recorded *Request) bool {
+		for _, m := range matchers {
+			if !m(req, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// normalizeJSON re-encodes a JSON document with sorted keys and no
+// insignificant whitespace
+func normalizeJSON(s string) (string, bool) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
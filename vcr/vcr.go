@@ -0,0 +1,510 @@
+// Package vcr records the HTTP traffic of providers into cassette files
+// and replays it, so tests can exercise real provider code paths without
+// network access or API keys.
+//
+// A Recorder is an http.RoundTripper. Inject its client into a provider
+// and run the test once against the live API to record, then replay the
+// cassette on every later run:
+//
+//	rec, err := vcr.New("testdata/chat.json")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	client, _ := llmx.NewClient(
+//		llmx.WithOpenAI(os.Getenv("OPENAI_API_KEY")),
+//		llmx.WithHTTPClient(rec.Client()),
+//	)
+//
+// Streamed (SSE) responses are recorded chunk by chunk with their timing.
+// Credentials in headers, query parameters, form-encoded request bodies
+// and JSON bodies such as OAuth token responses are redacted before
+// anything is stored.
+package vcr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode selects whether a Recorder records or replays
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if it exists and records it otherwise
+	ModeAuto Mode = iota
+	// ModeRecord sends requests upstream and records them, replacing the
+	// cassette when the recorder stops
+	ModeRecord
+	// ModeReplay answers requests from the cassette only
+	ModeReplay
+)
+
+// String returns the mode name
+func (m Mode) String() string {
+	switch m {
+	case ModeAuto:
+		return "auto"
+	case ModeRecord:
+		return "record"
+	case ModeReplay:
+		return "replay"
+	default:
+		return "Mode(" + strconv.Itoa(int(m)) + ")"
+	}
+}
+
+// Redacted replaces redacted header and query parameter values
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers redacted from recorded requests
+// and responses
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+	"X-Amz-Security-Token",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// DefaultRedactedParams are the query parameters redacted from recorded
+// URLs and form-encoded request bodies
+var DefaultRedactedParams = []string{"key", "api_key", "access_token", "client_id", "client_secret", "refresh_token"}
+
+// DefaultRedactedFields are the top-level fields redacted from recorded
+// JSON bodies, e.g. the tokens of OAuth token responses
+var DefaultRedactedFields = []string{"access_token", "refresh_token", "id_token", "client_secret"}
+
+// ErrNoInteraction is returned when replaying a request the cassette has
+// no unused interaction for
+var ErrNoInteraction = errors.New("vcr: no recorded interaction matches request")
+
+// Recorder is an http.RoundTripper recording to or replaying from a
+// cassette file
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	matcher   Matcher
+	headers   []string
+	params    []string
+	fields    []string
+	filters   []func(*Interaction)
+	timing    float64
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// Option configures a Recorder
+type Option func(*Recorder)
+
+// WithMode sets the recorder mode; the default is ModeAuto
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport sets the transport used to reach the real API when
+// recording; the default is http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithMatcher sets how replayed requests are matched to recorded ones;
+// the default is DefaultMatcher
+func WithMatcher(m Matcher) Option {
+	return func(r *Recorder) {
+		r.matcher = m
+	}
+}
+
+// WithRedactedHeaders redacts more headers, on top of
+// DefaultRedactedHeaders
+func WithRedactedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.headers = append(r.headers, names...)
+	}
+}
+
+// WithRedactedParams redacts more query parameters, on top of
+// DefaultRedactedParams
+func WithRedactedParams(names ...string) Option {
+	return func(r *Recorder) {
+		r.params = append(r.params, names...)
+	}
+}
+
+// WithRedactedFields redacts more top-level JSON body fields, on top of
+// DefaultRedactedFields
+func WithRedactedFields(names ...string) Option {
+	return func(r *Recorder) {
+		r.fields = append(r.fields, names...)
+	}
+}
+
+// WithFilter edits recorded interactions before the cassette is saved,
+// for example to scrub secrets from bodies
+func WithFilter(filter func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.filters = append(r.filters, filter)
+	}
+}
+
+// WithTiming replays the recorded latencies, scaled by scale. By default
+// replay is instant.
+func WithTiming(scale float64) Option {
+	return func(r *Recorder) {
+		r.timing = scale
+	}
+}
+
+// New creates a recorder for the cassette at path
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+		headers:   append([]string(nil), DefaultRedactedHeaders...),
+		params:    append([]string(nil), DefaultRedactedParams...),
+		fields:    append([]string(nil), DefaultRedactedFields...),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+
+	if r.mode == ModeReplay {
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = cassette
+	} else {
+		r.cassette = &Cassette{Version: cassetteVersion}
+	}
+	return r, nil
+}
+
+// Mode returns whether the recorder is recording or replaying
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an *http.Client sending requests through the recorder
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop saves the cassette if the recorder is recording. Streamed
+// responses should be fully read before calling it.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, interaction := range r.cassette.Interactions {
+		for _, filter := range r.filters {
+			filter(interaction)
+		}
+	}
+	return r.cassette.Save(r.path)
+}
+
+// RoundTrip records or replays req
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := r.redactRequest(req, body)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, body, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, recorded Request) (*http.Response, error) {
+	upstream := req.Clone(req.Context())
+	if body != nil {
+		upstream.Body = io.NopCloser(bytes.NewReader(body))
+		upstream.ContentLength = int64(len(body))
+	}
+
+	start := time.Now()
+	resp, err := r.transport.RoundTrip(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: recorded,
+		Response: Response{
+			Status:   resp.StatusCode,
+			Headers:  r.redactHeaders(resp.Header),
+			Duration: time.Since(start),
+		},
+	}
+
+	if isStream(resp.Header) {
+		resp.Body = &chunkRecorder{
+			body:        resp.Body,
+			mu:          &r.mu,
+			interaction: interaction,
+			last:        time.Now(),
+		}
+	} else {
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.Response.Body = r.redactBody(resp.Header, data)
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	var found *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !interaction.used && r.matcher(&recorded, &interaction.Request) {
+			interaction.used = true
+			found = interaction
+			break
+		}
+	}
+	r.mu.Unlock()
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+
+	ctx := req.Context()
+	if err := r.sleep(ctx, found.Response.Duration); err != nil {
+		return nil, err
+	}
+
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", found.Response.Status, http.StatusText(found.Response.Status)),
+		StatusCode: found.Response.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     found.Response.Headers.Clone(),
+		Request:    req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+
+	if len(found.Response.Chunks) > 0 {
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Body = &chunkReader{ctx: ctx, recorder: r, chunks: found.Response.Chunks}
+	} else {
+		resp.Header.Set("Content-Length", strconv.Itoa(len(found.Response.Body)))
+		resp.ContentLength = int64(len(found.Response.Body))
+		resp.Body = io.NopCloser(bytes.NewReader([]byte(found.Response.Body)))
+	}
+	return resp, nil
+}
+
+// sleep waits d scaled by the replay timing
+func (r *Recorder) sleep(ctx context.Context, d time.Duration) error {
+	d = time.Duration(float64(d) * r.timing)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// redactRequest returns the recorded form of req
+func (r *Recorder) redactRequest(req *http.Request, body []byte) Request {
+	return Request{
+		Method:  req.Method,
+		URL:     r.redactURL(req.URL),
+		Headers: r.redactHeaders(req.Header),
+		Body:    r.redactBody(req.Header, body),
+	}
+}
+
+// redactBody returns the recorded form of a body with headers h, with the
+// redacted parameters of a form or fields of a JSON object replaced
+func (r *Recorder) redactBody(h http.Header, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		redacted := false
+		for _, name := range r.params {
+			if form.Has(name) {
+				form.Set(name, Redacted)
+				redacted = true
+			}
+		}
+		if redacted {
+			return form.Encode()
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			return string(body)
+		}
+		redacted := false
+		for _, name := range r.fields {
+			if _, ok := fields[name]; ok {
+				fields[name] = json.RawMessage(`"` + Redacted + `"`)
+				redacted = true
+			}
+		}
+		if redacted {
+			if data, err := json.Marshal(fields); err == nil {
+				return string(data)
+			}
+		}
+	}
+	return string(body)
+}
+
+func (r *Recorder) redactHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range r.headers {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+func (r *Recorder) redactURL(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, name := range r.params {
+		if query.Has(name) {
+			query.Set(name, Redacted)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.String()
+	}
+	clean := *u
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
+// isStream reports whether a response is streamed and should be recorded
+// chunk by chunk
+func isStream(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream", "application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+// chunkRecorder records a streamed body as the client reads it
+type chunkRecorder struct {
+	body        io.ReadCloser
+	mu          *sync.Mutex
+	interaction *Interaction
+	last        time.Time
+}
+
+func (c *chunkRecorder) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	if n > 0 {
+		now := time.Now()
+		c.mu.Lock()
+		c.interaction.Response.Chunks = append(c.interaction.Response.Chunks, Chunk{
+			Data:  string(p[:n]),
+			Delay: now.Sub(c.last),
+		})
+		c.mu.Unlock()
+		c.last = now
+	}
+	return n, err
+}
+
+func (c *chunkRecorder) Close() error {
+	return c.body.Close()
+}
+
+// chunkReader replays a recorded streamed body
+type chunkReader struct {
+	ctx      context.Context
+	recorder *Recorder
+	chunks   []Chunk
+	pending  []byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		if len(c.chunks) == 0 {
+			return 0, io.EOF
+		}
+		chunk := c.chunks[0]
+		c.chunks = c.chunks[1:]
+		if err := c.recorder.sleep(c.ctx, chunk.Delay); err != nil {
+			return 0, err
+		}
+		c.pending = []byte(chunk.Data)
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *chunkReader) Close() error {
+	c.chunks = nil
+	c.pending = nil
+	return nil
+}
@@ -0,0 +1,332 @@
+package vcr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/llmx-ai/llmx"
+	"github.com/llmx-ai/llmx/core"
+	_ "github.com/llmx-ai/llmx/provider/openai"
+	_ "github.com/llmx-ai/llmx/provider/wenxin"
+)
+
+// newUpstream serves OpenAI chat completions, streamed or not
+func newUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-test","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, text := range []string{"one", " two"} {
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"id\":\"chatcmpl-2\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newClient(t *testing.T, rec *Recorder, baseURL string) *llmx.Client {
+	t.Helper()
+	client, err := llmx.NewClient(
+		llmx.WithProvider("openai", map[string]interface{}{
+			"api_key":     "sk-secret",
+			"base_url":    baseURL,
+			"http_client": rec.Client(),
+		}),
+		llmx.WithDefaultModel("gpt-test"),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func userRequest(text string) *llmx.ChatRequest {
+	return &llmx.ChatRequest{
+		Messages: []llmx.Message{{Role: llmx.RoleUser, Content: []llmx.ContentPart{llmx.TextPart{Text: text}}}},
+	}
+}
+
+// exercise sends one chat and one streamed request, returning their text
+func exercise(t *testing.T, client *llmx.Client) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	resp, err := client.Chat(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	stream, err := client.StreamChat(ctx, userRequest("count"))
+	if err != nil {
+		t.Fatalf("StreamChat() error = %v", err)
+	}
+	var streamed string
+	for event := range stream.Events() {
+		if event.Type == core.EventTypeTextDelta {
+			streamed += event.Data.(string)
+		}
+	}
+	if err := <-stream.Errors(); err != nil {
+		t.Fatalf("stream error = %v", err)
+	}
+	return resp.Content, streamed
+}
+
+func TestRecorder_RecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	upstream := newUpstream(t)
+
+	rec, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("expected auto mode to record a missing cassette, got %s", rec.Mode())
+	}
+	content, streamed := exercise(t, newClient(t, rec, upstream.URL))
+	if content != "hello" || streamed != "one two" {
+		t.Fatalf("unexpected upstream replies %q, %q", content, streamed)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-secret") {
+		t.Error("cassette contains the API key")
+	}
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	if len(cassette.Interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(cassette.Interactions))
+	}
+	if got := cassette.Interactions[0].Request.Headers.Get("Authorization"); got != Redacted {
+		t.Errorf("expected redacted Authorization, got %q", got)
+	}
+	if len(cassette.Interactions[1].Response.Chunks) == 0 {
+		t.Error("expected the streamed response to be recorded in chunks")
+	}
+
+	// Replay with the upstream gone
+	upstream.Close()
+	rec, err = New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if rec.Mode() != ModeReplay {
+		t.Fatalf("expected auto mode to replay an existing cassette, got %s", rec.Mode())
+	}
+	client := newClient(t, rec, upstream.URL)
+	content, streamed = exercise(t, client)
+	if content != "hello" || streamed != "one two" {
+		t.Errorf("unexpected replayed replies %q, %q", content, streamed)
+	}
+
+	if _, err := client.Chat(context.Background(), userRequest("again")); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction once the cassette is used up, got %v", err)
+	}
+}
+
+func TestRecorder_Redaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gemini.json")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		fmt.Fprint(w, `{"token":"t-123"}`)
+	}))
+	defer ts.Close()
+
+	rec, _ := New(path, WithMode(ModeRecord),
+		WithRedactedHeaders("X-Org"),
+		WithFilter(func(i *Interaction) {
+			i.Response.Body = strings.ReplaceAll(i.Response.Body, "t-123", Redacted)
+		}))
+	req, _ := http.NewRequest("GET", ts.URL+"/v1/models?key=secret&alt=sse", nil)
+	req.Header.Set("X-Goog-Api-Key", "secret")
+	req.Header.Set("X-Org", "org-1")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, _ := LoadCassette(path)
+	got := cassette.Interactions[0]
+	if strings.Contains(got.Request.URL, "secret") || !strings.Contains(got.Request.URL, "alt=sse") {
+		t.Errorf("query not redacted: %s", got.Request.URL)
+	}
+	for _, h := range []string{"X-Goog-Api-Key", "X-Org"} {
+		if v := got.Request.Headers.Get(h); v != Redacted {
+			t.Errorf("expected %s redacted, got %q", h, v)
+		}
+	}
+	if got.Response.Headers.Get("Set-Cookie") != Redacted || got.Response.Body != `{"token":"REDACTED"}` {
+		t.Errorf("response not redacted: %+v", got.Response)
+	}
+}
+
+// redirect sends every request to the server at base
+type redirect struct {
+	base string
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, err := url.Parse(r.base)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = u.Scheme, u.Host, ""
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRecorder_RedactsTokenExchange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wenxin.json")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth/2.0/token" {
+			fmt.Fprint(w, `{"access_token":"tok-secret","refresh_token":"refresh-secret","expires_in":2592000}`)
+			return
+		}
+		if r.URL.Query().Get("access_token") != "tok-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id":"as-1","result":"hello","usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer ts.Close()
+
+	run := func(rec *Recorder) string {
+		t.Helper()
+		client, err := llmx.NewClient(
+			llmx.WithProvider("wenxin", map[string]interface{}{
+				"api_key":     "ak-secret",
+				"secret_key":  "sk-secret",
+				"http_client": rec.Client(),
+			}),
+			llmx.WithDefaultModel("ernie-3.5-8k"),
+		)
+		if err != nil {
+			t.Fatalf("NewClient() error = %v", err)
+		}
+		resp, err := client.Chat(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+		return resp.Content
+	}
+
+	rec, _ := New(path, WithTransport(redirect{ts.URL}))
+	if got := run(rec); got != "hello" {
+		t.Fatalf("recorded reply %q, want hello", got)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"ak-secret", "sk-secret", "tok-secret", "refresh-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %s:\n%s", secret, data)
+		}
+	}
+
+	rec, err = New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := run(rec); got != "hello" {
+		t.Errorf("replayed reply %q, want hello", got)
+	}
+}
+
+func TestRecorder_RedactsForm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer ts.Close()
+
+	rec, _ := New(path, WithMode(ModeRecord))
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {"id-1"}, "client_secret": {"secret"}}
+	resp, err := rec.Client().PostForm(ts.URL+"/token", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, _ := LoadCassette(path)
+	got, _ := url.ParseQuery(cassette.Interactions[0].Request.Body)
+	if got.Get("client_id") != Redacted || got.Get("client_secret") != Redacted || got.Get("grant_type") != "client_credentials" {
+		t.Errorf("form not redacted: %s", cassette.Interactions[0].Request.Body)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	req := &Request{
+		Method:  "POST",
+		URL:     "https://api.example.com/v1/chat?b=2&a=1",
+		Headers: http.Header{"X-Version": {"1"}},
+		Body:    `{"model": "m", "n": 1}`,
+	}
+
+	tests := []struct {
+		name     string
+		match    Matcher
+		recorded Request
+		want     bool
+	}{
+		{"default", DefaultMatcher, Request{Method: "POST", URL: "https://api.example.com/v1/chat?a=1&b=2"}, true},
+		{"default method", DefaultMatcher, Request{Method: "GET", URL: req.URL}, false},
+		{"default host", DefaultMatcher, Request{Method: "POST", URL: "https://other.example.com/v1/chat?a=1&b=2"}, false},
+		{"path", MatchPath(), Request{URL: "http://127.0.0.1:1234/v1/chat"}, true},
+		{"body json", MatchBody(), Request{Body: `{"n":1,"model":"m"}`}, true},
+		{"body differs", MatchBody(), Request{Body: `{"n":2,"model":"m"}`}, false},
+		{"headers", MatchHeaders("x-version"), Request{Headers: http.Header{"X-Version": {"1"}}}, true},
+		{"headers differ", MatchHeaders("x-version"), Request{}, false},
+		{"all", All(MatchMethod(), MatchPath(), MatchBody()), Request{Method: "POST", URL: "/v1/chat", Body: req.Body}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match(req, &tt.recorded); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}